@@ -0,0 +1,117 @@
+// Command admin manages accounts and checks the audit log. It reads the same
+// config file, environment and flags as the server.
+//
+//	admin [flags] promote EMAIL  give an existing account the admin role
+//	admin [flags] demote EMAIL   take the admin role away from an account
+//	admin [flags] verify-audit   check the audit log's hash chain for tampering
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/shivamp1998/vpn_backend/internal/audit"
+	"github.com/shivamp1998/vpn_backend/internal/config"
+	"github.com/shivamp1998/vpn_backend/internal/model"
+	"github.com/shivamp1998/vpn_backend/internal/repository"
+	"github.com/shivamp1998/vpn_backend/internal/storage"
+)
+
+const usage = "usage: admin [flags] promote|demote EMAIL | verify-audit"
+
+func main() {
+	cfg, err := config.Load("admin", os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		fmt.Fprintln(os.Stderr, usage)
+		return
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var command func(ctx context.Context, repos *repository.Repositories, auditLog *audit.Logger) error
+	switch {
+	case len(cfg.Args) == 2 && cfg.Args[0] == "promote":
+		command = func(ctx context.Context, repos *repository.Repositories, auditLog *audit.Logger) error {
+			return setRole(ctx, repos, auditLog, cfg.Args[1], model.RoleAdmin)
+		}
+	case len(cfg.Args) == 2 && cfg.Args[0] == "demote":
+		command = func(ctx context.Context, repos *repository.Repositories, auditLog *audit.Logger) error {
+			return setRole(ctx, repos, auditLog, cfg.Args[1], "")
+		}
+	case len(cfg.Args) == 1 && cfg.Args[0] == "verify-audit":
+		command = verifyAudit
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	repos, closeStorage, err := storage.Open(cfg.Storage, nil)
+	if err != nil {
+		log.Fatal("Error opening storage: ", err)
+	}
+	defer closeStorage()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	auditLog := audit.NewLogger(repos.Audit, audit.Options{
+		HashChain: cfg.Audit.HashChain,
+	})
+	if err := command(ctx, repos, auditLog); err != nil {
+		log.Fatal(err)
+	}
+	if err := auditLog.Check(ctx); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func verifyAudit(ctx context.Context, repos *repository.Repositories, auditLog *audit.Logger) error {
+	checked, err := auditLog.Verify(ctx)
+	if err != nil {
+		return fmt.Errorf("audit log failed verification after %d events: %v", checked, err)
+	}
+
+	fmt.Printf("%d audit events verified\n", checked)
+	return nil
+}
+
+// setRole changes the role of the account with email. Accounts are never
+// created here: the user registers first and is promoted afterwards.
+func setRole(ctx context.Context, repos *repository.Repositories, auditLog *audit.Logger, email, role string) error {
+	user, err := repos.Users.GetByEmail(ctx, email)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return fmt.Errorf("no account is registered with %s", email)
+	}
+	if err != nil {
+		return err
+	}
+
+	if user.Role == role {
+		fmt.Printf("%s already has role %q\n", email, role)
+		return nil
+	}
+
+	err = repos.Users.SetRole(ctx, user.Id, role)
+	entry := audit.Entry{
+		Action:     model.AuditActionUserSetRole,
+		TargetType: model.AuditTargetUser,
+		TargetId:   user.Id.Hex(),
+		Err:        err,
+	}
+	if err == nil {
+		entry.Reason = fmt.Sprintf("role set to %q from the command line", role)
+	}
+	auditLog.Record(ctx, entry)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s now has role %q; tokens issued before keep the old role until they expire\n", email, role)
+	return nil
+}
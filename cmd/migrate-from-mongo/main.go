@@ -0,0 +1,98 @@
+// Command migrate-from-mongo copies users, servers and WireGuard keys from
+// MongoDB into a SQLite database, for moving a small install onto the
+// embedded backend. It can be run again safely; rows already present in the
+// SQLite database are left alone.
+//
+// It reads the same config file, environment and flags as the server, and
+// copies from storage.mongodb_uri into storage.sqlite_path:
+//
+//	migrate-from-mongo [flags]
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/shivamp1998/vpn_backend/internal/config"
+	"github.com/shivamp1998/vpn_backend/internal/database"
+	"github.com/shivamp1998/vpn_backend/internal/model"
+	"github.com/shivamp1998/vpn_backend/internal/repository"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const usage = "usage: migrate-from-mongo [flags]"
+
+func main() {
+	cfg, err := config.Load("migrate-from-mongo", os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		fmt.Fprintln(os.Stderr, usage)
+		return
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(cfg.Args) != 0 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	// The config only requires these for the backend in use, which may
+	// already be sqlite.
+	if cfg.Storage.MongoURI == "" || cfg.Storage.MongoDatabase == "" || cfg.Storage.SQLitePath == "" {
+		log.Fatal("storage.mongodb_uri, storage.mongodb_database and storage.sqlite_path must all be set")
+	}
+
+	if err := database.Connect(cfg.Storage.MongoURI, cfg.Storage.MongoDatabase); err != nil {
+		log.Fatal("Error in connection to MongoDB: ", err)
+	}
+	defer database.Disconnect()
+
+	db, err := database.OpenSQLite(cfg.Storage.SQLitePath)
+	if err != nil {
+		log.Fatal("Error in opening SQLite database: ", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	if err := database.MigrateSQL(ctx, db, "sqlite"); err != nil {
+		log.Fatal("Error in migrating SQLite schema: ", err)
+	}
+
+	var users []*model.User
+	var servers []*model.Server
+	var keys []*model.WireGuardKeys
+
+	if err := readAll(ctx, "users", bson.M{}, &users); err != nil {
+		log.Fatal("Error in reading users: ", err)
+	}
+	if err := readAll(ctx, "servers", bson.M{}, &servers); err != nil {
+		log.Fatal("Error in reading servers: ", err)
+	}
+	// Revoked peers the relay has yet to delete are gone already.
+	if err := readAll(ctx, "wireguard_keys", bson.M{"revoked_at": bson.M{"$exists": false}}, &keys); err != nil {
+		log.Fatal("Error in reading keys: ", err)
+	}
+
+	counts, err := repository.NewSQLiteImporter(db).Import(ctx, users, servers, keys)
+	if err != nil {
+		log.Fatal("Error in copying data, nothing was written: ", err)
+	}
+
+	log.Printf("Copied %d/%d users, %d/%d servers, %d/%d keys into %s",
+		counts.Users, len(users), counts.Servers, len(servers), counts.Keys, len(keys), cfg.Storage.SQLitePath)
+}
+
+func readAll(ctx context.Context, collection string, filter bson.M, result any) error {
+	cursor, err := database.DB.Collection(collection).Find(ctx, filter)
+	if err != nil {
+		return err
+	}
+	return cursor.All(ctx, result)
+}
@@ -0,0 +1,101 @@
+// Command migrate manages the MongoDB schema. It reads the same config
+// file, environment and flags as the server.
+//
+//	migrate [flags] list     show every migration and whether it has been applied
+//	migrate [flags] dry-run  show the migrations run would apply, without applying them
+//	migrate [flags] run      apply pending migrations in order
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/shivamp1998/vpn_backend/internal/config"
+	"github.com/shivamp1998/vpn_backend/internal/database"
+)
+
+const usage = "usage: migrate [flags] list|dry-run|run"
+
+func main() {
+	cfg, err := config.Load("migrate", os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		fmt.Fprintln(os.Stderr, usage)
+		return
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var command func(ctx context.Context) error
+	switch {
+	case len(cfg.Args) == 1 && cfg.Args[0] == "list":
+		command = list
+	case len(cfg.Args) == 1 && cfg.Args[0] == "dry-run":
+		command = func(ctx context.Context) error { return migrate(ctx, true) }
+	case len(cfg.Args) == 1 && cfg.Args[0] == "run":
+		command = func(ctx context.Context) error { return migrate(ctx, false) }
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	// The SQL backends apply their migrations when they are opened.
+	if cfg.Storage.Backend != "mongo" {
+		log.Fatalf("storage.backend is %s; migrate only manages the mongo backend", cfg.Storage.Backend)
+	}
+
+	if err := database.Connect(cfg.Storage.MongoURI, cfg.Storage.MongoDatabase); err != nil {
+		log.Fatal("Error in connection to database: ", err)
+	}
+	defer database.Disconnect()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	if err := command(ctx); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func list(ctx context.Context) error {
+	statuses, err := database.MongoMigrationStatus(ctx, database.DB)
+	if err != nil {
+		return err
+	}
+
+	for _, status := range statuses {
+		applied := "pending"
+		if status.Applied {
+			applied = "applied " + status.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Printf("%04d  %-32s  %s\n", status.Version, status.Name, applied)
+	}
+	return nil
+}
+
+func migrate(ctx context.Context, dryRun bool) error {
+	statuses, err := database.MigrateMongo(ctx, database.DB, dryRun)
+	if err != nil {
+		return err
+	}
+
+	if len(statuses) == 0 {
+		fmt.Println("Schema is up to date")
+		return nil
+	}
+
+	verb := "Applied"
+	if dryRun {
+		verb = "Would apply"
+	}
+
+	for _, status := range statuses {
+		fmt.Printf("%s %04d_%s: %s\n", verb, status.Version, status.Name, status.Description)
+	}
+	return nil
+}
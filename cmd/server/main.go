@@ -2,74 +2,282 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
-	"log"
+	"log/slog"
 	"net"
+	"net/http"
+	"net/netip"
 	"os"
-	"time"
+	"os/signal"
+	"syscall"
 
-	"github.com/joho/godotenv"
-	"github.com/shivamp1998/vpn_backend/internal/database"
+	"github.com/shivamp1998/vpn_backend/internal/audit"
+	"github.com/shivamp1998/vpn_backend/internal/auth"
+	"github.com/shivamp1998/vpn_backend/internal/config"
+	"github.com/shivamp1998/vpn_backend/internal/events"
+	"github.com/shivamp1998/vpn_backend/internal/health"
+	"github.com/shivamp1998/vpn_backend/internal/lifecycle"
+	"github.com/shivamp1998/vpn_backend/internal/logging"
+	"github.com/shivamp1998/vpn_backend/internal/metrics"
+	"github.com/shivamp1998/vpn_backend/internal/middleware"
+	"github.com/shivamp1998/vpn_backend/internal/repository"
 	server "github.com/shivamp1998/vpn_backend/internal/server"
+	"github.com/shivamp1998/vpn_backend/internal/service"
+	"github.com/shivamp1998/vpn_backend/internal/storage"
+	"github.com/shivamp1998/vpn_backend/internal/tracing"
+	"github.com/shivamp1998/vpn_backend/internal/webhook"
 	pb "github.com/shivamp1998/vpn_backend/proto/gen"
 	"google.golang.org/grpc"
+	healthgrpc "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
 )
 
 func main() {
-	err := godotenv.Load()
+	cfg, err := config.Load(os.Args[0], os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if cfg != nil && cfg.PrintConfig {
+		if err := cfg.Print(os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if cfg.PrintConfig {
+		return
+	}
 
+	// Everything logged from here on, through log too, goes through the
+	// redaction layer.
+	logger, err := logging.New(os.Stderr, logging.Config{
+		Level:  cfg.Logging.Level,
+		Format: cfg.Logging.Format,
+	})
 	if err != nil {
-		log.Fatal("Error in loading environment file")
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	slog.SetDefault(logger)
+
+	if cfg.File != "" {
+		slog.Info("Loaded config", "file", cfg.File)
 	}
 
-	MONGODB_URI := os.Getenv("MONGODB_URI")
-	err = database.Connect(MONGODB_URI)
-	defer database.Disconnect()
+	auth.Configure(cfg.Auth.JWTSecret)
+	if cfg.Auth.JWTSecret == "" {
+		slog.Warn("Signing tokens with the development secret, as auth.dev_mode is on and auth.jwt_secret is not set")
+	}
 
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		Exporter:    cfg.Tracing.Exporter,
+		Endpoint:    cfg.Tracing.Endpoint,
+		ServiceName: cfg.Tracing.ServiceName,
+		SampleRatio: cfg.Tracing.SampleRatio,
+	})
 	if err != nil {
-		log.Fatal("Error in connection to database", err)
+		fatal("Tracing setup failed", err)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	// A second signal kills the process without waiting for the shutdown.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	context.AfterFunc(ctx, stop)
 
-	err = database.InitializeIndexes(ctx)
+	checker := health.NewChecker(health.CheckerConfig{})
+	checker.Add("key_material", auth.CheckSigningKey)
 
+	repos, closeStorage, err := storage.Open(cfg.Storage, checker)
 	if err != nil {
-		log.Printf("Warning: Failed to initialize indexes: %v", err)
+		fatal("Error in connection to database", err)
+	}
+
+	auditLog := audit.NewLogger(repos.Audit, audit.Options{
+		HashChain: cfg.Audit.HashChain,
+	})
+	checker.Add("audit", auditLog.Check)
+
+	clientNetwork := service.ClientNetworkConfig{
+		Network: netip.MustParsePrefix(cfg.Network.ClientNetwork),
+		DNS:     cfg.Network.DNS,
 	}
+	configService := service.NewConfigService(repos.Users, repos.Servers, repos.Keys, repos.ShareLinks, auditLog, clientNetwork)
 
-	mainServer := server.NewServer()
+	dispatcher := events.NewDispatcher(events.DispatcherConfig{}, repos.Outbox, repos.Locks)
 
-	go startGrpcServer(mainServer)
-	server.StartConnectServer(mainServer)
+	notifier := webhook.NewNotifier(repos.Webhooks, nil)
+	notifier.Subscribe(dispatcher)
 
-}
+	peerWatcher := service.NewPeerWatcher(service.PeerWatchConfig{}, repos.Servers, repos.Keys, repos.Outbox)
 
-func startGrpcServer(mainServer *server.Server) {
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = ":50051"
+	mainServer := server.NewServer(
+		service.NewUserService(repos.Users, auditLog),
+		service.NewServerService(repos.Servers, auditLog),
+		configService,
+		service.NewWebhookService(repos.Webhooks, notifier, auditLog),
+		peerWatcher,
+		auditLog,
+		dispatcher,
+		cfg.Server.PublicBaseURL,
+	)
+
+	chain := server.NewMiddleware(auditLog, server.MiddlewareConfig{
+		RateLimiter: newRateLimiter(cfg.RateLimit),
+		Observer:    metrics.RPCObserver{},
+	})
+
+	grpcServer := newGrpcServer(mainServer, chain, checker)
+	connectServer, err := server.NewConnectServer(cfg.Server.ConnectAddr, mainServer, chain, checker)
+	if err != nil {
+		closeStorage()
+		fatal("Connect server setup failed", err)
 	}
 
-	lis, err := net.Listen("tcp", port)
+	keyRotation, err := newKeyRotationScheduler(cfg.KeyRotation, configService, repos)
 	if err != nil {
-		log.Fatal("Error in listening to port", err)
+		closeStorage()
+		fatal("Key rotation setup failed", err)
+	}
+
+	// Components stop in reverse order: watches end first so the servers
+	// can drain, and storage is closed once nothing uses it.
+
+	manager := lifecycle.New(lifecycle.Config{
+		Timeout:    cfg.Shutdown.Timeout,
+		DrainDelay: cfg.Shutdown.DrainDelay,
+	})
+	manager.Add("tracing", nil, shutdownTracing)
+	manager.Add("storage", nil, func(ctx context.Context) error {
+		closeStorage()
+		return nil
+	})
+	manager.Add("audit log", runUntilCancelled(auditLog.Run), nil)
+	manager.Add("outbox dispatcher", runUntilCancelled(dispatcher.Run), nil)
+	manager.Add("client count reconciler", runUntilCancelled(service.NewClientCountReconciler(service.ClientCountConfig{}, repos.Servers).Run), nil)
+	manager.Add("key rotation scheduler", runUntilCancelled(keyRotation.Run), nil)
+	manager.Add("health checker", runUntilCancelled(checker.Run), nil)
+	manager.Add("server metrics", runUntilCancelled(metrics.NewServerSampler(metrics.ServerSamplerConfig{
+		PoolSize: clientNetwork.PoolSize(),
+	}, repos.Servers).Run), nil)
+	manager.Add("gRPC server", serveGrpc(cfg.Server.GrpcAddr, grpcServer), stopGrpc(grpcServer))
+	manager.Add("Connect server", serveHTTP("Connect RPC server", connectServer), connectServer.Shutdown)
+	if cfg.Server.MetricsAddr != "" {
+		metricsServer := metrics.NewServer(cfg.Server.MetricsAddr)
+		manager.Add("metrics server", serveHTTP("Metrics server", metricsServer), metricsServer.Shutdown)
+	}
+	manager.Add("peer watches", nil, peerWatcher.Close)
+	manager.OnShutdown(checker.Shutdown)
+
+	if err := manager.Run(ctx); err != nil {
+		fatal("Server stopped", err)
 	}
+	slog.Info("Shutdown complete")
+}
+
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
+
+func newGrpcServer(mainServer *server.Server, chain middleware.Chain, checker *health.Checker) *grpc.Server {
 	grpcServer := grpc.NewServer(
-		grpc.UnaryInterceptor(server.AuthInterceptor),
+		grpc.ChainUnaryInterceptor(chain.UnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(chain.StreamServerInterceptor()),
 	)
 
 	pb.RegisterUserServiceServer(grpcServer, mainServer)
 	pb.RegisterServerServiceServer(grpcServer, mainServer)
 	pb.RegisterConfigServiceServer(grpcServer, mainServer)
+	pb.RegisterAuditServiceServer(grpcServer, mainServer)
+	pb.RegisterEventServiceServer(grpcServer, mainServer)
+	pb.RegisterWebhookServiceServer(grpcServer, mainServer)
+	for service := range grpcServer.GetServiceInfo() {
+		checker.AddService(service)
+	}
+	healthgrpc.RegisterHealthServer(grpcServer, checker.HealthServer())
 	reflection.Register(grpcServer)
 
-	fmt.Print("Server connected on port", port)
-	err = grpcServer.Serve(lis)
+	return grpcServer
+}
+
+func serveGrpc(addr string, grpcServer *grpc.Server) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		lis, err := net.Listen("tcp", addr)
+		if err != nil {
+			return err
+		}
+
+		slog.Info("gRPC server listening", "addr", addr)
+		return grpcServer.Serve(lis)
+	}
+}
+
+// stopGrpc waits for in-flight calls to finish, and cuts them off if ctx
+// expires first.
+func stopGrpc(grpcServer *grpc.Server) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		stopped := make(chan struct{})
+		go func() {
+			grpcServer.GracefulStop()
+			close(stopped)
+		}()
+
+		select {
+		case <-stopped:
+			return nil
+		case <-ctx.Done():
+			grpcServer.Stop()
+			return ctx.Err()
+		}
+	}
+}
 
+func serveHTTP(name string, httpServer *http.Server) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		slog.Info(name+" listening", "addr", httpServer.Addr)
+		if err := httpServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	}
+}
+
+func runUntilCancelled(run func(ctx context.Context)) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		run(ctx)
+		return nil
+	}
+}
+
+// newRateLimiter returns nil, no limiting, if the rate is 0.
+func newRateLimiter(cfg config.RateLimitConfig) *middleware.RateLimiter {
+	if cfg.RPS == 0 {
+		return nil
+	}
+	return middleware.NewRateLimiter(cfg.RPS, cfg.Burst)
+}
+
+func newKeyRotationScheduler(cfg config.KeyRotationConfig, configService *service.ConfigService, repos *repository.Repositories) (*service.KeyRotationScheduler, error) {
+	plans, err := cfg.ParsePlans()
 	if err != nil {
-		log.Fatal("Error in serving the grpc server")
+		return nil, err
 	}
+
+	policies := make(map[string]service.KeyRotationPolicy, len(plans))
+	for name, plan := range plans {
+		policies[name] = service.KeyRotationPolicy{MaxAge: plan.MaxAge, Mode: plan.Mode}
+	}
+
+	return service.NewKeyRotationScheduler(service.KeyRotationConfig{
+		Interval: cfg.Interval,
+		Default: service.KeyRotationPolicy{
+			MaxAge: cfg.MaxAge,
+			Mode:   cfg.Mode,
+		},
+		Plans: policies,
+	}, configService, repos), nil
 }
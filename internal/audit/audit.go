@@ -0,0 +1,271 @@
+// Package audit records who did what to which resource in an append-only
+// log. Entries are written by the RPC interceptors for rejected requests and
+// by the services for the actions they perform.
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/shivamp1998/vpn_backend/internal/auth"
+	"github.com/shivamp1998/vpn_backend/internal/metrics"
+	"github.com/shivamp1998/vpn_backend/internal/model"
+	"github.com/shivamp1998/vpn_backend/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Options struct {
+	// HashChain links every entry to the one before it by hash, so edits to
+	// stored entries can be detected with VerifyChain.
+	HashChain bool
+	// RetryInterval is how often Run retries events storage refused.
+	RetryInterval time.Duration
+	// MaxPending bounds the events held in memory while storage refuses
+	// them. Past it the oldest are discarded, and logged in full.
+	MaxPending int
+}
+
+type Logger struct {
+	repo      repository.AuditRepository
+	hashChain bool
+	opts      Options
+
+	// mu serialises appends, which keeps this process's entries in order,
+	// and guards pending.
+	mu sync.Mutex
+	// pending holds events, oldest first, that could not be written yet.
+	// New events queue behind them so the log stays in order.
+	pending []*model.AuditEvent
+}
+
+func NewLogger(repo repository.AuditRepository, opts Options) *Logger {
+	if opts.RetryInterval <= 0 {
+		opts.RetryInterval = 10 * time.Second
+	}
+	if opts.MaxPending <= 0 {
+		opts.MaxPending = 10000
+	}
+
+	return &Logger{
+		repo:      repo,
+		hashChain: opts.HashChain,
+		opts:      opts,
+	}
+}
+
+// Entry describes one action to record. The actor defaults to the
+// authenticated user in ctx, and the procedure, source IP and user agent come
+// from the request info the interceptors put there.
+type Entry struct {
+	Action     string
+	TargetType string
+	TargetId   string
+	ActorId    primitive.ObjectID
+	ActorEmail string
+	// Err is the result of the action. A nil Err records success; otherwise
+	// the outcome is failure and the error text becomes the reason.
+	Err     error
+	Outcome string
+	Reason  string
+}
+
+// Record appends entry to the log. Failing to audit never fails the action
+// being audited: an entry storage refuses is kept and retried by Run, and
+// the logger reports not ready through Check until it is written. A nil
+// Logger records nothing.
+func (l *Logger) Record(ctx context.Context, entry Entry) {
+	if l == nil {
+		return
+	}
+
+	event := l.newEvent(ctx, entry)
+
+	// The request may already be cancelled, which must not lose the entry.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.pending = append(l.pending, event)
+	if err := l.flush(ctx); err != nil {
+		slog.ErrorContext(ctx, "Failed to record audit event, it will be retried",
+			"action", event.Action, "pending", len(l.pending), "error", err)
+	}
+}
+
+// Run retries the events storage refused every RetryInterval until ctx is
+// cancelled, and once more on the way out.
+func (l *Logger) Run(ctx context.Context) {
+	ticker := time.NewTicker(l.opts.RetryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+			defer cancel()
+			l.retry(flushCtx)
+			return
+		case <-ticker.C:
+			l.retry(ctx)
+		}
+	}
+}
+
+func (l *Logger) retry(ctx context.Context) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.pending) == 0 {
+		return
+	}
+	if err := l.flush(ctx); err != nil {
+		slog.ErrorContext(ctx, "Audit events are still waiting to be written", "pending", len(l.pending), "error", err)
+		return
+	}
+	slog.InfoContext(ctx, "Wrote the audit events that were waiting")
+}
+
+// Check returns an error while there are events that could not be written.
+// It is a health.Check.
+func (l *Logger) Check(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.pending) > 0 {
+		return fmt.Errorf("%d audit events are waiting to be written", len(l.pending))
+	}
+	return nil
+}
+
+// flush writes the pending events in order, stopping at the first failure.
+// Events past MaxPending are discarded, oldest first. The caller holds mu.
+func (l *Logger) flush(ctx context.Context) error {
+	defer func() { metrics.AuditPending(len(l.pending)) }()
+
+	for len(l.pending) > 0 {
+		if err := l.append(ctx, l.pending[0]); err != nil {
+			if over := len(l.pending) - l.opts.MaxPending; over > 0 {
+				for _, event := range l.pending[:over] {
+					slog.ErrorContext(ctx, "Discarding audit event that could not be written", "event", event)
+				}
+				metrics.AuditLost(over)
+				l.pending = l.pending[over:]
+			}
+			return err
+		}
+		l.pending[0] = nil
+		l.pending = l.pending[1:]
+	}
+	return nil
+}
+
+func (l *Logger) newEvent(ctx context.Context, entry Entry) *model.AuditEvent {
+	event := &model.AuditEvent{
+		Time:       time.Now().UTC().Truncate(time.Millisecond),
+		ActorId:    entry.ActorId,
+		ActorEmail: entry.ActorEmail,
+		Action:     entry.Action,
+		TargetType: entry.TargetType,
+		TargetId:   entry.TargetId,
+		Outcome:    entry.Outcome,
+		Reason:     entry.Reason,
+	}
+
+	if event.ActorId.IsZero() {
+		event.ActorId, _ = auth.GetUserIDFromContext(ctx)
+	}
+	if event.ActorEmail == "" {
+		event.ActorEmail, _ = auth.GetUserEmailFromContext(ctx)
+	}
+
+	if event.Outcome == "" {
+		event.Outcome = model.AuditOutcomeSuccess
+		if entry.Err != nil {
+			event.Outcome = model.AuditOutcomeFailure
+		}
+	}
+	if event.Reason == "" && entry.Err != nil {
+		event.Reason = entry.Err.Error()
+	}
+
+	if info, ok := RequestInfoFromContext(ctx); ok {
+		event.Procedure = info.Procedure
+		event.SourceIp = info.SourceIp
+		event.UserAgent = info.UserAgent
+	}
+
+	return event
+}
+
+// append assigns the next sequence number and stores event. The unique
+// sequence index makes the insert fail if another process took the number
+// first; each such conflict means the log moved on, so append tries again
+// with the new last event until ctx ends. The caller holds mu.
+func (l *Logger) append(ctx context.Context, event *model.AuditEvent) error {
+	for {
+		last, err := l.repo.Last(ctx)
+		if err != nil {
+			return err
+		}
+
+		event.Sequence = 1
+		event.PrevHash = ""
+		if last != nil {
+			event.Sequence = last.Sequence + 1
+			if l.hashChain {
+				event.PrevHash = last.Hash
+			}
+		}
+
+		if l.hashChain {
+			event.Hash = hashEvent(event)
+		}
+
+		err = l.repo.Append(ctx, event)
+		if !errors.Is(err, repository.ErrDuplicateKey) {
+			return err
+		}
+		if ctx.Err() != nil {
+			return fmt.Errorf("sequence %d was taken and %v", event.Sequence, ctx.Err())
+		}
+	}
+}
+
+// hashEvent covers every recorded field except the id, which the storage
+// backend assigns.
+func hashEvent(event *model.AuditEvent) string {
+	var actorId string
+	if !event.ActorId.IsZero() {
+		actorId = event.ActorId.Hex()
+	}
+
+	// Encoding a fixed array keeps the input stable across releases that add
+	// fields to AuditEvent.
+	canonical, _ := json.Marshal([]any{
+		event.Sequence,
+		event.Time.UTC().Format(time.RFC3339Nano),
+		actorId,
+		event.ActorEmail,
+		event.Action,
+		event.TargetType,
+		event.TargetId,
+		event.Procedure,
+		event.SourceIp,
+		event.UserAgent,
+		event.Outcome,
+		event.Reason,
+		event.PrevHash,
+	})
+
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,169 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/shivamp1998/vpn_backend/internal/model"
+	"github.com/shivamp1998/vpn_backend/internal/repository"
+)
+
+// tamperedRepository edits or hides events on their way out of storage, as
+// someone with write access to the database could.
+type tamperedRepository struct {
+	repository.AuditRepository
+	tamper func(events []*model.AuditEvent) []*model.AuditEvent
+}
+
+func (r tamperedRepository) List(ctx context.Context, filter repository.AuditFilter, limit int) ([]*model.AuditEvent, error) {
+	events, err := r.AuditRepository.List(ctx, filter, limit)
+	if err != nil {
+		return nil, err
+	}
+	return r.tamper(events), nil
+}
+
+func withSequence(sequence int64, edit func(event *model.AuditEvent)) func([]*model.AuditEvent) []*model.AuditEvent {
+	return func(events []*model.AuditEvent) []*model.AuditEvent {
+		for _, event := range events {
+			if event.Sequence == sequence {
+				edit(event)
+			}
+		}
+		return events
+	}
+}
+
+func without(sequence int64) func([]*model.AuditEvent) []*model.AuditEvent {
+	return func(events []*model.AuditEvent) []*model.AuditEvent {
+		var kept []*model.AuditEvent
+		for _, event := range events {
+			if event.Sequence != sequence {
+				kept = append(kept, event)
+			}
+		}
+		return kept
+	}
+}
+
+func TestVerifyDetectsTampering(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryRepositories().Audit
+
+	// More than a page, so the chain is checked across page boundaries.
+	const events = maxPageSize + 10
+	logger := NewLogger(repo, Options{HashChain: true})
+	for i := 0; i < events; i++ {
+		logger.Record(ctx, Entry{Action: model.AuditActionUserLogin, TargetId: fmt.Sprint(i)})
+	}
+
+	checked, err := logger.Verify(ctx)
+	if err != nil || checked != events {
+		t.Fatalf("untouched log: verified %d events, %v", checked, err)
+	}
+
+	tests := []struct {
+		name   string
+		tamper func([]*model.AuditEvent) []*model.AuditEvent
+		want   string
+	}{
+		{"edited event", withSequence(300, func(e *model.AuditEvent) { e.Outcome = model.AuditOutcomeFailure }), "event 300 does not match its hash"},
+		{"removed event", without(300), "events 300 to 300 are missing"},
+		{"removed first event", without(1), "events 1 to 1 are missing"},
+		{
+			// Rehashing the edited event only moves the break to the next one,
+			// which here is on the previous page.
+			"edited and rehashed event",
+			withSequence(10, func(e *model.AuditEvent) {
+				e.Reason = "nothing to see"
+				e.Hash = hashEvent(e)
+			}),
+			"event 11 does not follow event 10",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tampered := NewLogger(tamperedRepository{repo, tt.tamper}, Options{HashChain: true})
+
+			_, err := tampered.Verify(ctx)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got error %v, want one mentioning %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyNeedsHashChain(t *testing.T) {
+	ctx := context.Background()
+	logger := NewLogger(repository.NewMemoryRepositories().Audit, Options{})
+	logger.Record(ctx, Entry{Action: model.AuditActionUserLogin})
+
+	if _, err := logger.Verify(ctx); !errors.Is(err, ErrNotHashChained) {
+		t.Errorf("got error %v, want %v", err, ErrNotHashChained)
+	}
+}
+
+// flakyRepository fails appends while failures is above zero, counting down.
+type flakyRepository struct {
+	repository.AuditRepository
+	failures atomic.Int32
+	err      error
+}
+
+func (r *flakyRepository) Append(ctx context.Context, event *model.AuditEvent) error {
+	if r.failures.Add(-1) >= 0 {
+		return r.err
+	}
+	return r.AuditRepository.Append(ctx, event)
+}
+
+func TestRecordRetriesSequenceConflicts(t *testing.T) {
+	ctx := context.Background()
+	repo := &flakyRepository{AuditRepository: repository.NewMemoryRepositories().Audit, err: repository.ErrDuplicateKey}
+	repo.failures.Store(20)
+
+	logger := NewLogger(repo, Options{HashChain: true})
+	logger.Record(ctx, Entry{Action: model.AuditActionUserLogin})
+
+	if err := logger.Check(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if checked, err := logger.Verify(ctx); err != nil || checked != 1 {
+		t.Errorf("verified %d events, %v", checked, err)
+	}
+}
+
+func TestRecordKeepsEventsStorageRefuses(t *testing.T) {
+	ctx := context.Background()
+	repo := &flakyRepository{AuditRepository: repository.NewMemoryRepositories().Audit, err: errors.New("storage is down")}
+	repo.failures.Store(2)
+
+	logger := NewLogger(repo, Options{HashChain: true})
+	logger.Record(ctx, Entry{Action: model.AuditActionUserRegister})
+	logger.Record(ctx, Entry{Action: model.AuditActionUserLogin})
+
+	if err := logger.Check(ctx); err == nil {
+		t.Fatal("Check passed with events waiting to be written")
+	}
+
+	logger.retry(ctx)
+	if err := logger.Check(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err := repo.List(ctx, repository.AuditFilter{}, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored) != 2 || stored[1].Action != model.AuditActionUserRegister || stored[0].Action != model.AuditActionUserLogin {
+		t.Fatalf("stored %+v, want the register then the login", stored)
+	}
+	if _, err := logger.Verify(ctx); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,39 @@
+package audit
+
+import (
+	"context"
+
+	"github.com/shivamp1998/vpn_backend/internal/middleware"
+	"github.com/shivamp1998/vpn_backend/internal/model"
+	"google.golang.org/grpc/codes"
+)
+
+// Middleware puts the caller's details in the context for the services to
+// record, and records calls rejected for missing or insufficient
+// credentials. It must run before authentication and authorization.
+func (l *Logger) Middleware() middleware.Middleware {
+	return func(next middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, call *middleware.Call) error {
+			ctx = WithRequestInfo(ctx, RequestInfo{
+				Procedure: call.Procedure,
+				SourceIp:  call.PeerHost(),
+				UserAgent: call.Header.Get("User-Agent"),
+			})
+
+			err := next(ctx, call)
+
+			if code := middleware.Code(err); code == codes.Unauthenticated || code == codes.PermissionDenied {
+				l.recordDenied(ctx, err)
+			}
+			return err
+		}
+	}
+}
+
+func (l *Logger) recordDenied(ctx context.Context, err error) {
+	l.Record(ctx, Entry{
+		Action:  model.AuditActionRequestDenied,
+		Outcome: model.AuditOutcomeDenied,
+		Err:     err,
+	})
+}
@@ -0,0 +1,181 @@
+package audit
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/shivamp1998/vpn_backend/internal/model"
+	"github.com/shivamp1998/vpn_backend/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 500
+)
+
+var (
+	ErrInvalidPageToken = errors.New("invalid page token")
+	ErrNotHashChained   = errors.New("the audit log is not hash chained")
+)
+
+type Query struct {
+	ActorId    primitive.ObjectID
+	Action     string
+	TargetType string
+	TargetId   string
+	Outcome    string
+	Since      time.Time
+	Until      time.Time
+	PageSize   int
+	// PageToken is the NextPageToken of the previous page, or empty for the
+	// first page.
+	PageToken string
+}
+
+type Page struct {
+	Events []*model.AuditEvent
+	// NextPageToken is empty on the last page.
+	NextPageToken string
+}
+
+// List returns events matching query, newest first. Page tokens hold the
+// sequence of the last event returned, so entries appended while paging do
+// not shift later pages.
+func (l *Logger) List(ctx context.Context, query Query) (*Page, error) {
+	filter := repository.AuditFilter{
+		ActorId:    query.ActorId,
+		Action:     query.Action,
+		TargetType: query.TargetType,
+		TargetId:   query.TargetId,
+		Outcome:    query.Outcome,
+		Since:      query.Since,
+		Until:      query.Until,
+	}
+
+	if query.PageToken != "" {
+		sequence, err := decodePageToken(query.PageToken)
+		if err != nil {
+			return nil, err
+		}
+		filter.BeforeSequence = sequence
+	}
+
+	pageSize := query.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+
+	// Fetching one extra event tells whether there is a next page.
+	events, err := l.repo.List(ctx, filter, pageSize+1)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &Page{Events: events}
+	if len(events) > pageSize {
+		page.Events = events[:pageSize]
+		page.NextPageToken = encodePageToken(page.Events[pageSize-1].Sequence)
+	}
+	return page, nil
+}
+
+func encodePageToken(sequence int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(sequence, 10)))
+}
+
+func decodePageToken(token string) (int64, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, ErrInvalidPageToken
+	}
+
+	sequence, err := strconv.ParseInt(string(decoded), 10, 64)
+	if err != nil || sequence < 1 {
+		return 0, ErrInvalidPageToken
+	}
+	return sequence, nil
+}
+
+// Verify checks the hash chain of the whole log, reading it newest first a
+// page at a time, and returns the number of events checked. Removing events
+// from the newest end of the log cannot be detected this way.
+func (l *Logger) Verify(ctx context.Context) (int, error) {
+	var checked int
+	// oldest is the last event of the previous page, which the next page
+	// has to lead up to.
+	var oldest *model.AuditEvent
+	var filter repository.AuditFilter
+
+	for {
+		events, err := l.repo.List(ctx, filter, maxPageSize)
+		if err != nil {
+			return checked, err
+		}
+		if len(events) == 0 {
+			break
+		}
+		if oldest == nil && events[0].Hash == "" {
+			return 0, ErrNotHashChained
+		}
+
+		run := events
+		if oldest != nil {
+			run = append(run[:len(run):len(run)], oldest)
+		}
+		if err := VerifyChain(run); err != nil {
+			return checked, err
+		}
+
+		checked += len(events)
+		oldest = events[len(events)-1]
+		filter.BeforeSequence = oldest.Sequence
+	}
+
+	if oldest != nil {
+		if oldest.Sequence != 1 {
+			return checked, fmt.Errorf("audit events 1 to %d are missing", oldest.Sequence-1)
+		}
+		if oldest.PrevHash != "" {
+			return checked, errors.New("audit event 1 follows an event that does not exist")
+		}
+	}
+	return checked, nil
+}
+
+// VerifyChain checks that events, a contiguous run of a hash-chained log in
+// any order, are unmodified and that none are missing from the middle.
+func VerifyChain(events []*model.AuditEvent) error {
+	sorted := append([]*model.AuditEvent(nil), events...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Sequence < sorted[j].Sequence
+	})
+
+	for i, event := range sorted {
+		if event.Hash != hashEvent(event) {
+			return fmt.Errorf("audit event %d does not match its hash", event.Sequence)
+		}
+
+		if i == 0 {
+			continue
+		}
+
+		prev := sorted[i-1]
+		if event.Sequence != prev.Sequence+1 {
+			return fmt.Errorf("audit events %d to %d are missing", prev.Sequence+1, event.Sequence-1)
+		}
+		if event.PrevHash != prev.Hash {
+			return fmt.Errorf("audit event %d does not follow event %d", event.Sequence, prev.Sequence)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,21 @@
+package audit
+
+import "context"
+
+// RequestInfo is what the interceptors know about the caller of an RPC.
+type RequestInfo struct {
+	Procedure string
+	SourceIp  string
+	UserAgent string
+}
+
+type requestInfoKey struct{}
+
+func WithRequestInfo(ctx context.Context, info RequestInfo) context.Context {
+	return context.WithValue(ctx, requestInfoKey{}, info)
+}
+
+func RequestInfoFromContext(ctx context.Context) (RequestInfo, bool) {
+	info, ok := ctx.Value(requestInfoKey{}).(RequestInfo)
+	return info, ok
+}
@@ -3,22 +3,35 @@ package auth
 import (
 	"context"
 	"errors"
-	"os"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/shivamp1998/vpn_backend/internal/model"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-var jwtSecret []byte
+// developmentSecret signs tokens when JWT_SECRET is not set, which config
+// only allows in dev mode. Anyone can forge tokens with it, so a server using
+// it never reports ready.
+const developmentSecret = "default-secret-key-change-in-production"
 
-func init() {
-	secret := os.Getenv("JWT_SECRET")
+var jwtSecret = []byte(developmentSecret)
 
-	if secret == "" {
-		secret = "default-secret-key-change-in-production"
+// Configure sets the token signing secret, unless it is empty. It must be
+// called before the servers start.
+func Configure(secret string) {
+	if secret != "" {
+		jwtSecret = []byte(secret)
 	}
-	jwtSecret = []byte(secret)
+}
+
+// CheckSigningKey returns an error if there is no real key to sign tokens
+// with.
+func CheckSigningKey(ctx context.Context) error {
+	if string(jwtSecret) == developmentSecret {
+		return errors.New("no JWT secret is configured, tokens are signed with the development secret")
+	}
+	return nil
 }
 
 type contextKey string
@@ -26,20 +39,23 @@ type contextKey string
 const (
 	UserIdKey    contextKey = "user_id"
 	UserEmailKey contextKey = "user_email"
+	UserRoleKey  contextKey = "user_role"
 )
 
 type Claims struct {
 	UserId primitive.ObjectID `json:"user_id"`
 	Email  string             `json:"email"`
+	Role   string             `json:"role,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func GenerateToken(userId primitive.ObjectID, email string) (string, error) {
+func GenerateToken(userId primitive.ObjectID, email, role string) (string, error) {
 	expirationTime := time.Now().Add(24 * time.Hour)
 
 	claims := &Claims{
 		UserId: userId,
 		Email:  email,
+		Role:   role,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -104,3 +120,8 @@ func GetUserEmailFromContext(ctx context.Context) (string, error) {
 	}
 	return email, nil
 }
+
+func IsAdmin(ctx context.Context) bool {
+	role, _ := ctx.Value(UserRoleKey).(string)
+	return role == model.RoleAdmin
+}
@@ -0,0 +1,514 @@
+// Package config loads the server's settings. Each setting has a default
+// and can be set, from lowest to highest precedence, in a YAML or TOML file,
+// in the environment (a .env file is read if present) and on the command
+// line.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/netip"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// The tags on the settings below give the key in a config file, the
+// environment variable and the flag for each one. A secret tag keeps the
+// value out of PrintConfig: "true" hides all of it, "url" only the password.
+type Config struct {
+	Server      ServerConfig      `yaml:"server" toml:"server"`
+	Storage     StorageConfig     `yaml:"storage" toml:"storage"`
+	Auth        AuthConfig        `yaml:"auth" toml:"auth"`
+	Network     NetworkConfig     `yaml:"network" toml:"network"`
+	RateLimit   RateLimitConfig   `yaml:"rate_limit" toml:"rate_limit"`
+	Audit       AuditConfig       `yaml:"audit" toml:"audit"`
+	KeyRotation KeyRotationConfig `yaml:"key_rotation" toml:"key_rotation"`
+	Shutdown    ShutdownConfig    `yaml:"shutdown" toml:"shutdown"`
+	Tracing     TracingConfig     `yaml:"tracing" toml:"tracing"`
+	Logging     LoggingConfig     `yaml:"logging" toml:"logging"`
+
+	// File is the config file the settings were read from, if any.
+	File string `yaml:"-" toml:"-"`
+	// PrintConfig asks for the effective config to be printed instead of
+	// starting the server.
+	PrintConfig bool `yaml:"-" toml:"-"`
+	// Args are the command line arguments left after the flags.
+	Args []string `yaml:"-" toml:"-"`
+}
+
+type ServerConfig struct {
+	GrpcAddr    string `yaml:"grpc_addr" toml:"grpc_addr" env:"PORT" flag:"grpc-addr" usage:"address the gRPC server listens on"`
+	ConnectAddr string `yaml:"connect_addr" toml:"connect_addr" env:"CONNECT_PORT" flag:"connect-addr" usage:"address the Connect, REST and probe server listens on"`
+	// MetricsAddr is kept apart from ConnectAddr so the metrics are not
+	// public; the port should only be reachable by the scraper.
+	MetricsAddr string `yaml:"metrics_addr" toml:"metrics_addr" env:"METRICS_ADDR" flag:"metrics-addr" usage:"address the Prometheus metrics server listens on, empty for none"`
+	// PublicBaseURL prefixes the config share links.
+	PublicBaseURL string `yaml:"public_base_url" toml:"public_base_url" env:"PUBLIC_BASE_URL" flag:"public-base-url" usage:"external URL of the HTTP server, for share links"`
+}
+
+type StorageConfig struct {
+	Backend        string `yaml:"backend" toml:"backend" env:"STORAGE_BACKEND" flag:"storage-backend" usage:"mongo, postgres or sqlite"`
+	MongoURI       string `yaml:"mongodb_uri" toml:"mongodb_uri" env:"MONGODB_URI" flag:"mongodb-uri" secret:"url" usage:"MongoDB connection string"`
+	MongoDatabase  string `yaml:"mongodb_database" toml:"mongodb_database" env:"MONGODB_DATABASE" flag:"mongodb-database" usage:"MongoDB database name"`
+	MigrateOnStart bool   `yaml:"migrate_on_start" toml:"migrate_on_start" env:"MIGRATE_ON_START" flag:"migrate-on-start" usage:"apply pending MongoDB migrations at startup"`
+	PostgresDSN    string `yaml:"postgres_dsn" toml:"postgres_dsn" env:"POSTGRES_DSN" flag:"postgres-dsn" secret:"url" usage:"PostgreSQL connection string"`
+	SQLitePath     string `yaml:"sqlite_path" toml:"sqlite_path" env:"SQLITE_PATH" flag:"sqlite-path" usage:"SQLite database file"`
+}
+
+type AuthConfig struct {
+	// JWTSecret signs the access tokens. It must be set unless DevMode is.
+	JWTSecret string `yaml:"jwt_secret" toml:"jwt_secret" env:"JWT_SECRET" flag:"jwt-secret" secret:"true" usage:"secret that signs access tokens"`
+	// DevMode allows an empty JWTSecret, in which case tokens are signed
+	// with a development secret anyone can forge tokens with, and the server
+	// never reports ready.
+	DevMode bool `yaml:"dev_mode" toml:"dev_mode" env:"AUTH_DEV_MODE" flag:"auth-dev-mode" usage:"allow an empty JWT secret, signing tokens with a public development secret"`
+}
+
+type NetworkConfig struct {
+	// ClientNetwork is the IPv4 network client addresses are assigned from.
+	// The first host address is left to the server.
+	ClientNetwork string `yaml:"client_network" toml:"client_network" env:"CLIENT_NETWORK" flag:"client-network" usage:"IPv4 network clients get their addresses from"`
+	DNS           string `yaml:"dns" toml:"dns" env:"CLIENT_DNS" flag:"client-dns" usage:"DNS server written into client configs"`
+}
+
+type RateLimitConfig struct {
+	// RPS of 0 turns rate limiting off.
+	RPS   float64 `yaml:"rps" toml:"rps" env:"RATE_LIMIT_RPS" flag:"rate-limit-rps" usage:"calls per second allowed per caller, 0 for no limit"`
+	Burst int     `yaml:"burst" toml:"burst" env:"RATE_LIMIT_BURST" flag:"rate-limit-burst" usage:"calls a caller can make at once"`
+}
+
+type AuditConfig struct {
+	HashChain bool `yaml:"hash_chain" toml:"hash_chain" env:"AUDIT_HASH_CHAIN" flag:"audit-hash-chain" usage:"chain audit events by hash"`
+}
+
+type KeyRotationConfig struct {
+	Interval time.Duration `yaml:"interval" toml:"interval" env:"KEY_ROTATION_INTERVAL" flag:"key-rotation-interval" usage:"how often stale keys are looked for"`
+	// MaxAge of 0 disables rotation, except for plans that set their own.
+	MaxAge time.Duration `yaml:"max_age" toml:"max_age" env:"KEY_ROTATION_MAX_AGE" flag:"key-rotation-max-age" usage:"age at which keys are rotated, 0 to never rotate"`
+	Mode   string        `yaml:"mode" toml:"mode" env:"KEY_ROTATION_MODE" flag:"key-rotation-mode" usage:"server or client"`
+	// Plans is written as "plan=maxAge[:mode],...", for example
+	// "free=2160h:client,pro=720h:server".
+	Plans string `yaml:"plans" toml:"plans" env:"KEY_ROTATION_PLANS" flag:"key-rotation-plans" usage:"per plan policies, as plan=maxAge[:mode],..."`
+}
+
+// KeyRotationPlan is the policy of one plan in KeyRotationConfig.Plans. An
+// empty Mode leaves the default mode in place.
+type KeyRotationPlan struct {
+	MaxAge time.Duration
+	Mode   string
+}
+
+// ParsePlans parses Plans into the policy of each plan.
+func (c KeyRotationConfig) ParsePlans() (map[string]KeyRotationPlan, error) {
+	plans := make(map[string]KeyRotationPlan)
+
+	for _, entry := range strings.Split(c.Plans, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		plan, spec, ok := strings.Cut(entry, "=")
+		if !ok || plan == "" {
+			return nil, fmt.Errorf("%q is not plan=maxAge[:mode]", entry)
+		}
+		if _, ok := plans[plan]; ok {
+			return nil, fmt.Errorf("plan %q is given twice", plan)
+		}
+
+		age, mode, _ := strings.Cut(spec, ":")
+		maxAge, err := time.ParseDuration(age)
+		if err != nil || maxAge < 0 {
+			return nil, fmt.Errorf("max age of plan %q must be a duration such as 720h, not %q", plan, age)
+		}
+		if mode != "" && mode != "server" && mode != "client" {
+			return nil, fmt.Errorf("mode of plan %q must be server or client, not %q", plan, mode)
+		}
+
+		plans[plan] = KeyRotationPlan{MaxAge: maxAge, Mode: mode}
+	}
+
+	return plans, nil
+}
+
+type ShutdownConfig struct {
+	Timeout    time.Duration `yaml:"timeout" toml:"timeout" env:"SHUTDOWN_TIMEOUT" flag:"shutdown-timeout" usage:"time allowed for stopping the servers and workers"`
+	DrainDelay time.Duration `yaml:"drain_delay" toml:"drain_delay" env:"SHUTDOWN_DRAIN_DELAY" flag:"shutdown-drain-delay" usage:"time to keep serving after reporting not ready"`
+}
+
+type TracingConfig struct {
+	Exporter string `yaml:"exporter" toml:"exporter" env:"TRACING_EXPORTER" flag:"tracing-exporter" usage:"none, stdout or otlp"`
+	// Endpoint is an OTLP/gRPC collector URL; http:// connects without TLS.
+	Endpoint    string  `yaml:"endpoint" toml:"endpoint" env:"OTEL_EXPORTER_OTLP_ENDPOINT" flag:"tracing-endpoint" usage:"OTLP collector URL"`
+	ServiceName string  `yaml:"service_name" toml:"service_name" env:"OTEL_SERVICE_NAME" flag:"tracing-service-name" usage:"service name spans are reported under"`
+	SampleRatio float64 `yaml:"sample_ratio" toml:"sample_ratio" env:"TRACING_SAMPLE_RATIO" flag:"tracing-sample-ratio" usage:"share of new traces recorded, from 0 to 1"`
+}
+
+type LoggingConfig struct {
+	Level  string `yaml:"level" toml:"level" env:"LOG_LEVEL" flag:"log-level" usage:"debug, info, warn or error"`
+	Format string `yaml:"format" toml:"format" env:"LOG_FORMAT" flag:"log-format" usage:"json or text"`
+}
+
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			GrpcAddr:    ":50051",
+			ConnectAddr: ":50052",
+			MetricsAddr: ":9090",
+		},
+		Storage: StorageConfig{
+			Backend:       "mongo",
+			MongoDatabase: "vpn",
+			SQLitePath:    "vpn.db",
+		},
+		Network: NetworkConfig{
+			ClientNetwork: "10.0.0.0/24",
+			DNS:           "8.8.8.8",
+		},
+		RateLimit: RateLimitConfig{
+			RPS:   10,
+			Burst: 20,
+		},
+		KeyRotation: KeyRotationConfig{
+			Interval: time.Hour,
+		},
+		Shutdown: ShutdownConfig{
+			Timeout: 30 * time.Second,
+		},
+		Tracing: TracingConfig{
+			Exporter:    "none",
+			ServiceName: "vpn-backend",
+			SampleRatio: 1,
+		},
+		Logging: LoggingConfig{
+			Level:  "info",
+			Format: "json",
+		},
+	}
+}
+
+// Load reads the config named by --config or CONFIG_FILE, then applies the
+// environment and the flags in args. If the result is invalid it returns
+// the config together with an error listing every problem.
+func Load(name string, args []string) (*Config, error) {
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf(".env: %v", err)
+	}
+
+	config := Default()
+	fields := config.fields()
+
+	// Flags win over everything else, so they are only applied once the
+	// file and the environment have been.
+	var flagValues []func() error
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.StringVar(&config.File, "config", os.Getenv("CONFIG_FILE"), "YAML or TOML config file (env CONFIG_FILE)")
+	flags.BoolVar(&config.PrintConfig, "print-config", false, "print the effective config, secrets redacted, and exit")
+	for _, f := range fields {
+		record := func(value string) error {
+			flagValues = append(flagValues, func() error {
+				if err := f.set(value); err != nil {
+					return fmt.Errorf("-%s: %v", f.flag, err)
+				}
+				return nil
+			})
+			return nil
+		}
+		usage := f.usage + " (env " + f.env + ")"
+		if f.value.Kind() == reflect.Bool {
+			flags.BoolFunc(f.flag, usage, record)
+		} else {
+			flags.Func(f.flag, usage, record)
+		}
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+	config.Args = flags.Args()
+
+	if config.File != "" {
+		if err := config.readFile(config.File); err != nil {
+			return nil, err
+		}
+	}
+
+	// Empty variables are ignored, as .env files often leave settings blank.
+	var problems []string
+	for _, f := range fields {
+		if value := os.Getenv(f.env); value != "" {
+			if err := f.set(value); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %v", f.env, err))
+			}
+		}
+	}
+	for _, apply := range flagValues {
+		if err := apply(); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+
+	problems = append(problems, config.validate()...)
+	if len(problems) > 0 {
+		return config, fmt.Errorf("invalid configuration:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return config, nil
+}
+
+func (c *Config) readFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	switch filepath.Ext(path) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+	case ".toml":
+		metadata, err := toml.Decode(string(data), c)
+		if err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+		if undecoded := metadata.Undecoded(); len(undecoded) > 0 {
+			keys := make([]string, len(undecoded))
+			for i, key := range undecoded {
+				keys[i] = key.String()
+			}
+			return fmt.Errorf("%s: unknown settings %s", path, strings.Join(keys, ", "))
+		}
+	default:
+		return fmt.Errorf("%s: config files must end in .yaml, .yml or .toml", path)
+	}
+	return nil
+}
+
+// validate returns a description of every invalid setting, naming it by its
+// key in a config file.
+func (c *Config) validate() []string {
+	var problems []string
+	add := func(key, format string, args ...any) {
+		problems = append(problems, key+": "+fmt.Sprintf(format, args...))
+	}
+
+	if c.Server.GrpcAddr == "" {
+		add("server.grpc_addr", "must be set")
+	}
+	if c.Server.ConnectAddr == "" {
+		add("server.connect_addr", "must be set")
+	}
+	if c.Server.MetricsAddr != "" && (c.Server.MetricsAddr == c.Server.ConnectAddr || c.Server.MetricsAddr == c.Server.GrpcAddr) {
+		add("server.metrics_addr", "must differ from server.grpc_addr and server.connect_addr")
+	}
+	if c.Server.PublicBaseURL != "" {
+		if u, err := url.Parse(c.Server.PublicBaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			add("server.public_base_url", "must be an http or https URL")
+		}
+	}
+
+	if c.Auth.JWTSecret == "" && !c.Auth.DevMode {
+		add("auth.jwt_secret", "must be set, or auth.dev_mode turned on to sign tokens with the development secret")
+	}
+
+	switch c.Storage.Backend {
+	case "mongo":
+		if c.Storage.MongoURI == "" {
+			add("storage.mongodb_uri", "must be set for the mongo backend")
+		}
+		if c.Storage.MongoDatabase == "" {
+			add("storage.mongodb_database", "must be set for the mongo backend")
+		}
+	case "postgres":
+		if c.Storage.PostgresDSN == "" {
+			add("storage.postgres_dsn", "must be set for the postgres backend")
+		}
+	case "sqlite":
+		if c.Storage.SQLitePath == "" {
+			add("storage.sqlite_path", "must be set for the sqlite backend")
+		}
+	default:
+		add("storage.backend", "must be mongo, postgres or sqlite, not %q", c.Storage.Backend)
+	}
+
+	// Every peer address is looked at on each allocation, so the network is
+	// kept to a size that stays cheap.
+	if network, err := netip.ParsePrefix(c.Network.ClientNetwork); err != nil || !network.Addr().Is4() {
+		add("network.client_network", "must be an IPv4 network such as 10.0.0.0/24")
+	} else if network.Bits() < 16 || network.Bits() > 30 {
+		add("network.client_network", "must be between /16 and /30")
+	} else if network.Masked() != network {
+		add("network.client_network", "has host bits set, did you mean %s?", network.Masked())
+	}
+	if _, err := netip.ParseAddr(c.Network.DNS); c.Network.DNS != "" && err != nil {
+		add("network.dns", "must be an IP address")
+	}
+
+	if c.RateLimit.RPS < 0 {
+		add("rate_limit.rps", "must not be negative")
+	}
+	if c.RateLimit.Burst <= 0 {
+		add("rate_limit.burst", "must be greater than 0")
+	}
+
+	if c.KeyRotation.Interval <= 0 {
+		add("key_rotation.interval", "must be greater than 0")
+	}
+	if c.KeyRotation.MaxAge < 0 {
+		add("key_rotation.max_age", "must not be negative")
+	}
+	if c.KeyRotation.Mode != "" && c.KeyRotation.Mode != "server" && c.KeyRotation.Mode != "client" {
+		add("key_rotation.mode", "must be server or client, not %q", c.KeyRotation.Mode)
+	}
+	if _, err := c.KeyRotation.ParsePlans(); err != nil {
+		add("key_rotation.plans", "%v", err)
+	}
+
+	if c.Shutdown.Timeout <= 0 {
+		add("shutdown.timeout", "must be greater than 0")
+	}
+	if c.Shutdown.DrainDelay < 0 {
+		add("shutdown.drain_delay", "must not be negative")
+	}
+
+	switch c.Tracing.Exporter {
+	case "none", "stdout":
+	case "otlp":
+		if c.Tracing.Endpoint != "" {
+			if u, err := url.Parse(c.Tracing.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				add("tracing.endpoint", "must be an http or https URL")
+			}
+		}
+	default:
+		add("tracing.exporter", "must be none, stdout or otlp, not %q", c.Tracing.Exporter)
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		add("tracing.sample_ratio", "must be between 0 and 1")
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Logging.Level)); err != nil {
+		add("logging.level", "must be debug, info, warn or error, not %q", c.Logging.Level)
+	}
+	if c.Logging.Format != "json" && c.Logging.Format != "text" {
+		add("logging.format", "must be json or text, not %q", c.Logging.Format)
+	}
+
+	return problems
+}
+
+// Print writes the config as YAML with the secrets redacted.
+func (c *Config) Print(w io.Writer) error {
+	redacted := *c
+	for _, f := range redacted.fields() {
+		if f.secret != "" && f.value.String() != "" {
+			f.value.SetString(redact(f.value.String(), f.secret))
+		}
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&redacted); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+func redact(value, secret string) string {
+	if secret == "url" {
+		// Postgres DSNs can also be key=value pairs, which are hidden whole.
+		if u, err := url.Parse(value); err == nil && u.Scheme != "" && u.Host != "" {
+			return u.Redacted()
+		}
+	}
+	return "REDACTED"
+}
+
+type field struct {
+	env    string
+	flag   string
+	usage  string
+	secret string
+	value  reflect.Value
+}
+
+// fields lists the settings of c, section by section.
+func (c *Config) fields() []field {
+	var fields []field
+
+	config := reflect.ValueOf(c).Elem()
+	for i := range config.NumField() {
+		section := config.Field(i)
+		if section.Kind() != reflect.Struct {
+			continue
+		}
+		for j := range section.NumField() {
+			tag := section.Type().Field(j).Tag
+			fields = append(fields, field{
+				env:    tag.Get("env"),
+				flag:   tag.Get("flag"),
+				usage:  tag.Get("usage"),
+				secret: tag.Get("secret"),
+				value:  section.Field(j),
+			})
+		}
+	}
+	return fields
+}
+
+func (f field) set(value string) error {
+	switch f.value.Interface().(type) {
+	case string:
+		f.value.SetString(value)
+	case bool:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%q is not true or false", value)
+		}
+		f.value.SetBool(parsed)
+	case int:
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a whole number", value)
+		}
+		f.value.SetInt(int64(parsed))
+	case float64:
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", value)
+		}
+		f.value.SetFloat(parsed)
+	case time.Duration:
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration such as 30s or 1h", value)
+		}
+		f.value.SetInt(int64(parsed))
+	case []string:
+		var list []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		f.value.Set(reflect.ValueOf(list))
+	default:
+		panic("config: unsupported setting type " + f.value.Type().String())
+	}
+	return nil
+}
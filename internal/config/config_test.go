@@ -0,0 +1,274 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// validConfig is the default config with the settings that have no default
+// filled in.
+func validConfig() *Config {
+	c := Default()
+	c.Auth.JWTSecret = "secret"
+	c.Storage.MongoURI = "mongodb://localhost:27017"
+	return c
+}
+
+func TestValidate(t *testing.T) {
+	if problems := validConfig().validate(); len(problems) != 0 {
+		t.Fatalf("valid config has problems: %v", problems)
+	}
+
+	tests := []struct {
+		key    string
+		mutate func(c *Config)
+	}{
+		{"server.grpc_addr", func(c *Config) { c.Server.GrpcAddr = "" }},
+		{"server.connect_addr", func(c *Config) { c.Server.ConnectAddr = "" }},
+		{"server.metrics_addr", func(c *Config) { c.Server.MetricsAddr = c.Server.ConnectAddr }},
+		{"server.metrics_addr", func(c *Config) { c.Server.MetricsAddr = c.Server.GrpcAddr }},
+		{"server.public_base_url", func(c *Config) { c.Server.PublicBaseURL = "vpn.example.com" }},
+		{"server.public_base_url", func(c *Config) { c.Server.PublicBaseURL = "ftp://vpn.example.com" }},
+		{"auth.jwt_secret", func(c *Config) { c.Auth.JWTSecret = "" }},
+		{"storage.mongodb_uri", func(c *Config) { c.Storage.MongoURI = "" }},
+		{"storage.mongodb_database", func(c *Config) { c.Storage.MongoDatabase = "" }},
+		{"storage.postgres_dsn", func(c *Config) { c.Storage.Backend = "postgres" }},
+		{"storage.sqlite_path", func(c *Config) { c.Storage.Backend, c.Storage.SQLitePath = "sqlite", "" }},
+		{"storage.backend", func(c *Config) { c.Storage.Backend = "mysql" }},
+		{"network.client_network", func(c *Config) { c.Network.ClientNetwork = "10.0.0.0" }},
+		{"network.client_network", func(c *Config) { c.Network.ClientNetwork = "fd00::/64" }},
+		{"network.client_network", func(c *Config) { c.Network.ClientNetwork = "10.0.0.0/8" }},
+		{"network.client_network", func(c *Config) { c.Network.ClientNetwork = "10.0.0.0/31" }},
+		{"network.client_network", func(c *Config) { c.Network.ClientNetwork = "10.0.0.1/24" }},
+		{"network.dns", func(c *Config) { c.Network.DNS = "dns.example.com" }},
+		{"rate_limit.rps", func(c *Config) { c.RateLimit.RPS = -1 }},
+		{"rate_limit.burst", func(c *Config) { c.RateLimit.Burst = 0 }},
+		{"key_rotation.interval", func(c *Config) { c.KeyRotation.Interval = 0 }},
+		{"key_rotation.max_age", func(c *Config) { c.KeyRotation.MaxAge = -time.Hour }},
+		{"key_rotation.mode", func(c *Config) { c.KeyRotation.Mode = "later" }},
+		{"key_rotation.plans", func(c *Config) { c.KeyRotation.Plans = "free" }},
+		{"shutdown.timeout", func(c *Config) { c.Shutdown.Timeout = 0 }},
+		{"shutdown.drain_delay", func(c *Config) { c.Shutdown.DrainDelay = -time.Second }},
+		{"tracing.endpoint", func(c *Config) { c.Tracing.Exporter, c.Tracing.Endpoint = "otlp", "collector:4317" }},
+		{"tracing.exporter", func(c *Config) { c.Tracing.Exporter = "jaeger" }},
+		{"tracing.sample_ratio", func(c *Config) { c.Tracing.SampleRatio = 1.5 }},
+		{"tracing.sample_ratio", func(c *Config) { c.Tracing.SampleRatio = -0.1 }},
+		{"logging.level", func(c *Config) { c.Logging.Level = "verbose" }},
+		{"logging.format", func(c *Config) { c.Logging.Format = "xml" }},
+	}
+
+	for _, tt := range tests {
+		c := validConfig()
+		tt.mutate(c)
+
+		problems := c.validate()
+		if len(problems) != 1 || !strings.HasPrefix(problems[0], tt.key+": ") {
+			t.Errorf("%s: got problems %q, want one for it", tt.key, problems)
+		}
+	}
+
+	// Settings only one backend or exporter needs are not required of the
+	// others, and the optional ones may be left empty.
+	valid := []func(c *Config){
+		func(c *Config) { c.Auth.JWTSecret, c.Auth.DevMode = "", true },
+		func(c *Config) { c.Storage.Backend, c.Storage.MongoURI = "sqlite", "" },
+		func(c *Config) {
+			c.Storage.Backend, c.Storage.MongoURI, c.Storage.PostgresDSN = "postgres", "", "postgres://localhost/vpn"
+		},
+		func(c *Config) { c.Server.MetricsAddr = "" },
+		func(c *Config) { c.Network.DNS = "" },
+		func(c *Config) { c.Tracing.Exporter, c.Tracing.Endpoint = "otlp", "" },
+		func(c *Config) { c.Tracing.Exporter, c.Tracing.Endpoint = "otlp", "http://collector:4317" },
+		func(c *Config) { c.KeyRotation.Mode, c.KeyRotation.Plans = "server", "pro=720h:client" },
+	}
+	for i, mutate := range valid {
+		c := validConfig()
+		mutate(c)
+		if problems := c.validate(); len(problems) != 0 {
+			t.Errorf("valid case %d: got problems %q", i, problems)
+		}
+	}
+}
+
+// clearEnv unsets, for the test, every variable Load reads.
+func clearEnv(t *testing.T) {
+	t.Helper()
+
+	t.Setenv("CONFIG_FILE", "")
+	for _, f := range Default().fields() {
+		t.Setenv(f.env, "")
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	yamlFile := filepath.Join(dir, "config.yaml")
+	tomlFile := filepath.Join(dir, "config.toml")
+	if err := os.WriteFile(yamlFile, []byte("logging:\n  level: warn\nrate_limit:\n  burst: 5\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(tomlFile, []byte("[logging]\nlevel = \"warn\"\n\n[rate_limit]\nburst = 5\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		env       map[string]string
+		args      []string
+		wantLevel string
+		wantBurst int
+	}{
+		{"default", nil, nil, "info", 20},
+		{"yaml file", nil, []string{"--config", yamlFile}, "warn", 5},
+		{"toml file", nil, []string{"--config", tomlFile}, "warn", 5},
+		{"file from env", map[string]string{"CONFIG_FILE": yamlFile}, nil, "warn", 5},
+		{"env over file", map[string]string{"LOG_LEVEL": "error"}, []string{"--config", yamlFile}, "error", 5},
+		{"flag over env and file", map[string]string{"LOG_LEVEL": "error", "RATE_LIMIT_BURST": "7"}, []string{"--config", yamlFile, "--log-level", "debug"}, "debug", 7},
+		{"flag over default", nil, []string{"--rate-limit-burst", "9"}, "info", 9},
+		{"empty env ignored", map[string]string{"LOG_LEVEL": ""}, []string{"--config", yamlFile}, "warn", 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			t.Setenv("JWT_SECRET", "secret")
+			t.Setenv("STORAGE_BACKEND", "sqlite")
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			cfg, err := Load("test", tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Logging.Level != tt.wantLevel || cfg.RateLimit.Burst != tt.wantBurst {
+				t.Errorf("got level %q and burst %d, want %q and %d", cfg.Logging.Level, cfg.RateLimit.Burst, tt.wantLevel, tt.wantBurst)
+			}
+		})
+	}
+}
+
+func TestLoadRejects(t *testing.T) {
+	dir := t.TempDir()
+	unknown := filepath.Join(dir, "unknown.yaml")
+	if err := os.WriteFile(unknown, []byte("logging:\n  colour: true\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	ini := filepath.Join(dir, "config.ini")
+	if err := os.WriteFile(ini, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		env     map[string]string
+		args    []string
+		wantErr string
+	}{
+		{"unknown file setting", nil, []string{"--config", unknown}, "colour"},
+		{"unknown file type", nil, []string{"--config", ini}, ".yaml, .yml or .toml"},
+		{"missing file", nil, []string{"--config", filepath.Join(dir, "missing.yaml")}, "missing.yaml"},
+		{"bad env value", map[string]string{"RATE_LIMIT_BURST": "many"}, nil, "RATE_LIMIT_BURST"},
+		{"bad flag value", nil, []string{"--shutdown-timeout", "soon"}, "-shutdown-timeout"},
+		{"unknown flag", nil, []string{"--colour"}, "colour"},
+		{"every problem", map[string]string{"LOG_FORMAT": "xml"}, []string{"--log-level", "verbose"}, "logging.level"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			t.Setenv("JWT_SECRET", "secret")
+			t.Setenv("STORAGE_BACKEND", "sqlite")
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			_, err := Load("test", tt.args)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got error %v, want one mentioning %q", err, tt.wantErr)
+			}
+			if tt.name == "every problem" && !strings.Contains(err.Error(), "logging.format") {
+				t.Errorf("got error %v, want logging.format reported too", err)
+			}
+		})
+	}
+}
+
+func TestLoadRequiresJWTSecret(t *testing.T) {
+	clearEnv(t)
+
+	tests := []struct {
+		args    []string
+		wantErr bool
+	}{
+		{nil, true},
+		{[]string{"--auth-dev-mode"}, false},
+		{[]string{"--jwt-secret", "secret"}, false},
+		{[]string{"--auth-dev-mode", "--jwt-secret", "secret"}, false},
+	}
+
+	for _, tt := range tests {
+		args := append([]string{"--storage-backend", "sqlite"}, tt.args...)
+		cfg, err := Load("test", args)
+		if tt.wantErr {
+			if err == nil || !strings.Contains(err.Error(), "auth.jwt_secret") {
+				t.Errorf("%v: got error %v, want one about auth.jwt_secret", tt.args, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: %v", tt.args, err)
+		} else if cfg.Auth.DevMode != (tt.args[0] == "--auth-dev-mode") {
+			t.Errorf("%v: dev mode is %v", tt.args, cfg.Auth.DevMode)
+		}
+	}
+}
+
+func TestParsePlans(t *testing.T) {
+	tests := []struct {
+		plans   string
+		want    map[string]KeyRotationPlan
+		wantErr string
+	}{
+		{"", map[string]KeyRotationPlan{}, ""},
+		{" free=2160h:client , pro=720h ,", map[string]KeyRotationPlan{
+			"free": {MaxAge: 2160 * time.Hour, Mode: "client"},
+			"pro":  {MaxAge: 720 * time.Hour},
+		}, ""},
+		{"free=0s:server", map[string]KeyRotationPlan{"free": {Mode: "server"}}, ""},
+		{"free", nil, "plan=maxAge"},
+		{"=720h", nil, "plan=maxAge"},
+		{"free=monthly", nil, "max age of plan \"free\""},
+		{"free=-1h", nil, "max age of plan \"free\""},
+		{"free=720h:later", nil, "mode of plan \"free\""},
+		{"free=720h,free=24h", nil, "given twice"},
+	}
+
+	for _, tt := range tests {
+		got, err := KeyRotationConfig{Plans: tt.plans}.ParsePlans()
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%q: got error %v, want one containing %q", tt.plans, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", tt.plans, err)
+		} else if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: got %v, want %v", tt.plans, got, tt.want)
+		}
+	}
+}
+
+func TestLoadRejectsInvalidPlans(t *testing.T) {
+	clearEnv(t)
+
+	_, err := Load("test", []string{"--storage-backend", "sqlite", "--auth-dev-mode", "--key-rotation-plans", "free=720h,free=24h"})
+	if err == nil || !strings.Contains(err.Error(), "key_rotation.plans") {
+		t.Errorf("got error %v, want one about key_rotation.plans", err)
+	}
+}
@@ -2,71 +2,218 @@ package database
 
 import (
 	"context"
-	"log"
+	"errors"
+	"fmt"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-func InitializeIndexes(ctx context.Context) error {
-	if DB == nil {
-		return nil
+// createIndexes is safe to repeat: MongoDB treats creating an index that
+// already exists with the same keys and options as a no-op. An index with
+// the same name but a different definition is reported as an error rather
+// than skipped, since the code relying on it would silently misbehave.
+func createIndexes(ctx context.Context, db *mongo.Database, collection string, indexes ...mongo.IndexModel) error {
+	_, err := db.Collection(collection).Indexes().CreateMany(ctx, indexes)
+	if err != nil {
+		return fmt.Errorf("creating indexes on %s: %w", collection, err)
 	}
+	return nil
+}
 
-	if err := CreateUserIndexes(ctx); err != nil {
-		return err
-	}
+func createUserIndexes(ctx context.Context, db *mongo.Database) error {
+	return createIndexes(ctx, db, "users", mongo.IndexModel{
+		Keys:    bson.D{{Key: "email", Value: 1}},
+		Options: options.Index().SetUnique(true).SetName("email_unique"),
+	})
+}
+
+func createWireGuardKeysIndexes(ctx context.Context, db *mongo.Database) error {
+	return createIndexes(ctx, db, "wireguard_keys",
+		mongo.IndexModel{
+			Keys: bson.D{
+				{Key: "user_id", Value: 1},
+				{Key: "server_id", Value: 1},
+			},
+			Options: options.Index().SetUnique(true).SetName("user_server_unique"),
+		},
+		mongo.IndexModel{
+			// Serves both GetAllByServer and the rotation scheduler's
+			// last_rotated_at range scan.
+			Keys: bson.D{
+				{Key: "server_id", Value: 1},
+				{Key: "last_rotated_at", Value: 1},
+			},
+			Options: options.Index().SetName("server_rotated"),
+		},
+	)
+}
 
-	if err := createWireGuardKeysIndexes(ctx); err != nil {
+func createWireGuardKeysIpIndex(ctx context.Context, db *mongo.Database) error {
+	return createIndexes(ctx, db, "wireguard_keys", mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "server_id", Value: 1},
+			{Key: "ip_address", Value: 1},
+		},
+		Options: options.Index().SetUnique(true).SetName("server_ip_unique"),
+	})
+}
+
+func createConfigShareLinkIndexes(ctx context.Context, db *mongo.Database) error {
+	err := createIndexes(ctx, db, "config_share_links",
+		mongo.IndexModel{
+			Keys:    bson.D{{Key: "token_hash", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("token_hash_unique"),
+		},
+		mongo.IndexModel{
+			// Links are kept for a week after expiry so retrievals can still
+			// be traced back to them.
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(7 * 24 * 60 * 60).SetName("expires_at_ttl"),
+		},
+	)
+	if err != nil {
 		return err
 	}
 
-	log.Println("Database indexes initialized")
-	return nil
+	return createIndexes(ctx, db, "config_share_retrievals", mongo.IndexModel{
+		Keys:    bson.D{{Key: "link_id", Value: 1}},
+		Options: options.Index().SetName("link_id"),
+	})
 }
 
-func CreateUserIndexes(ctx context.Context) error {
-	userCollection := DB.Collection("users")
+func createServerIndexes(ctx context.Context, db *mongo.Database) error {
+	return createIndexes(ctx, db, "servers", mongo.IndexModel{
+		Keys:    bson.D{{Key: "region", Value: 1}},
+		Options: options.Index().SetName("region"),
+	})
+}
 
-	emailIndexModel := mongo.IndexModel{
-		Keys: map[string]interface{}{
-			"email": 1,
+func createNotificationIndexes(ctx context.Context, db *mongo.Database) error {
+	return createIndexes(ctx, db, "notifications", mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "user_id", Value: 1},
+			{Key: "created_at", Value: -1},
 		},
-		Options: options.Index().SetUnique(true).SetName("email_unique"),
-	}
+		Options: options.Index().SetName("user_created"),
+	})
+}
 
-	indexes := []mongo.IndexModel{emailIndexModel}
+func createAuditEventIndexes(ctx context.Context, db *mongo.Database) error {
+	return createIndexes(ctx, db, "audit_events",
+		mongo.IndexModel{
+			Keys:    bson.D{{Key: "sequence", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("sequence_unique"),
+		},
+		mongo.IndexModel{
+			Keys: bson.D{
+				{Key: "actor_id", Value: 1},
+				{Key: "sequence", Value: -1},
+			},
+			Options: options.Index().SetName("actor_sequence"),
+		},
+		mongo.IndexModel{
+			Keys: bson.D{
+				{Key: "target_id", Value: 1},
+				{Key: "sequence", Value: -1},
+			},
+			Options: options.Index().SetName("target_sequence"),
+		},
+	)
+}
 
-	_, err := userCollection.Indexes().CreateMany(ctx, indexes)
+func createOutboxIndexes(ctx context.Context, db *mongo.Database) error {
+	err := createIndexes(ctx, db, "outbox_events",
+		mongo.IndexModel{
+			Keys: bson.D{
+				{Key: "status", Value: 1},
+				{Key: "next_attempt_at", Value: 1},
+			},
+			Options: options.Index().SetName("status_next_attempt"),
+		},
+		mongo.IndexModel{
+			Keys: bson.D{
+				{Key: "status", Value: 1},
+				{Key: "occurred_at", Value: -1},
+			},
+			Options: options.Index().SetName("status_occurred"),
+		},
+	)
 	if err != nil {
-		if !isDuplicateIndexError(err) {
+		return err
+	}
+
+	// The relay looks for documents with staged events, which are few.
+	for _, collection := range []string{"users", "servers", "wireguard_keys"} {
+		err := createIndexes(ctx, db, collection, mongo.IndexModel{
+			Keys:    bson.D{{Key: "outbox._id", Value: 1}},
+			Options: options.Index().SetSparse(true).SetName("staged_outbox"),
+		})
+		if err != nil {
 			return err
 		}
-
-		log.Println("Index already exist, skipping...")
 	}
 	return nil
 }
 
-func isDuplicateIndexError(err error) bool {
-	return err != nil && (err.Error() == "index already exists" || err.Error() == "IndexOptionsConflict")
+func createOutboxTypeIndex(ctx context.Context, db *mongo.Database) error {
+	return createIndexes(ctx, db, "outbox_events", mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "type", Value: 1},
+			{Key: "occurred_at", Value: 1},
+		},
+		Options: options.Index().SetName("type_occurred"),
+	})
 }
 
-func createWireGuardKeysIndexes(ctx context.Context) error {
-	keysCollection := DB.Collection("wireguard_keys")
-	indexModel := mongo.IndexModel{
+func createOutboxServerIndex(ctx context.Context, db *mongo.Database) error {
+	return createIndexes(ctx, db, "outbox_events", mongo.IndexModel{
 		Keys: bson.D{
-			{Key: "user_id", Value: 1},
 			{Key: "server_id", Value: 1},
+			{Key: "type", Value: 1},
+			{Key: "occurred_at", Value: 1},
 		},
-		Options: options.Index().SetUnique(true).SetName("user_server_unique"),
+		Options: options.Index().SetName("server_type_occurred"),
+	})
+}
+
+// dropIndex drops the index called name, if it exists.
+func dropIndex(ctx context.Context, collection *mongo.Collection, name string) error {
+	_, err := collection.Indexes().DropOne(ctx, name)
+	var commandErr mongo.CommandError
+	if errors.As(err, &commandErr) && commandErr.Name == "IndexNotFound" {
+		return nil
 	}
+	return err
+}
 
-	_, err := keysCollection.Indexes().CreateOne(ctx, indexModel)
-	if err != nil && !isDuplicateIndexError(err) {
+func createWebhookIndexes(ctx context.Context, db *mongo.Database) error {
+	err := createIndexes(ctx, db, "webhooks", mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "event_types", Value: 1},
+			{Key: "enabled", Value: 1},
+		},
+		Options: options.Index().SetName("event_types_enabled"),
+	})
+	if err != nil {
 		return err
 	}
 
-	return nil
+	return createIndexes(ctx, db, "webhook_deliveries",
+		mongo.IndexModel{
+			Keys: bson.D{
+				{Key: "webhook_id", Value: 1},
+				{Key: "created_at", Value: -1},
+			},
+			Options: options.Index().SetName("webhook_created"),
+		},
+		mongo.IndexModel{
+			Keys: bson.D{
+				{Key: "webhook_id", Value: 1},
+				{Key: "event_id", Value: 1},
+			},
+			Options: options.Index().SetName("webhook_event"),
+		},
+	)
 }
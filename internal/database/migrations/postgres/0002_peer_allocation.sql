@@ -0,0 +1,7 @@
+CREATE UNIQUE INDEX server_ip_unique ON wireguard_keys (server_id, ip_address);
+
+-- current_clients was never maintained before peers were allocated through
+-- Allocate, so derive it from the peers that exist.
+UPDATE servers SET current_clients = (
+    SELECT COUNT(*) FROM wireguard_keys WHERE wireguard_keys.server_id = servers.id
+);
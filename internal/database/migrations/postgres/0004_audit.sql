@@ -0,0 +1,23 @@
+ALTER TABLE users ADD COLUMN role TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE audit_events (
+    id          CHAR(24) PRIMARY KEY,
+    sequence    BIGINT NOT NULL,
+    time        TIMESTAMPTZ NOT NULL,
+    actor_id    TEXT NOT NULL DEFAULT '',
+    actor_email TEXT NOT NULL DEFAULT '',
+    action      TEXT NOT NULL,
+    target_type TEXT NOT NULL DEFAULT '',
+    target_id   TEXT NOT NULL DEFAULT '',
+    procedure   TEXT NOT NULL DEFAULT '',
+    source_ip   TEXT NOT NULL DEFAULT '',
+    user_agent  TEXT NOT NULL DEFAULT '',
+    outcome     TEXT NOT NULL,
+    reason      TEXT NOT NULL DEFAULT '',
+    prev_hash   TEXT NOT NULL DEFAULT '',
+    hash        TEXT NOT NULL DEFAULT '',
+    CONSTRAINT sequence_unique UNIQUE (sequence)
+);
+
+CREATE INDEX audit_events_actor ON audit_events (actor_id, sequence);
+CREATE INDEX audit_events_target ON audit_events (target_id, sequence);
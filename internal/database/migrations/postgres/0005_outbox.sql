@@ -0,0 +1,18 @@
+ALTER TABLE servers ADD COLUMN status TEXT NOT NULL DEFAULT 'active';
+
+CREATE TABLE outbox_events (
+    id              CHAR(24) PRIMARY KEY,
+    type            TEXT NOT NULL,
+    aggregate_id    TEXT NOT NULL,
+    payload         TEXT NOT NULL,
+    occurred_at     TIMESTAMPTZ NOT NULL,
+    status          TEXT NOT NULL,
+    attempts        INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL,
+    last_error      TEXT NOT NULL DEFAULT '',
+    delivered_at    TIMESTAMPTZ,
+    delivered       TEXT NOT NULL DEFAULT '[]'
+);
+
+CREATE INDEX outbox_events_due ON outbox_events (status, next_attempt_at);
+CREATE INDEX outbox_events_status ON outbox_events (status, occurred_at);
@@ -0,0 +1 @@
+CREATE INDEX outbox_events_type ON outbox_events (type, occurred_at);
@@ -0,0 +1,8 @@
+ALTER TABLE outbox_events ADD COLUMN server_id TEXT NOT NULL DEFAULT '';
+
+-- Events written before the column existed carry the server in the payload.
+UPDATE outbox_events SET server_id = COALESCE(payload::jsonb ->> 'server_id', '')
+WHERE type IN ('PeerCreated', 'PeerRevoked', 'KeysRotated', 'ServerStatusChanged');
+
+DROP INDEX outbox_events_type;
+CREATE INDEX outbox_events_server ON outbox_events (server_id, type, occurred_at);
@@ -0,0 +1,85 @@
+-- Timestamps are stored as UTC text in a fixed format (see database.OpenSQLite),
+-- so they compare correctly as strings.
+
+CREATE TABLE users (
+    id            CHAR(24) PRIMARY KEY,
+    email         TEXT NOT NULL,
+    password_hash TEXT NOT NULL,
+    is_active     BOOLEAN NOT NULL DEFAULT TRUE,
+    plan          TEXT NOT NULL DEFAULT '',
+    created_at    TIMESTAMP NOT NULL,
+    updated_at    TIMESTAMP NOT NULL,
+    CONSTRAINT email_unique UNIQUE (email)
+);
+
+CREATE TABLE servers (
+    id                    CHAR(24) PRIMARY KEY,
+    name                  TEXT NOT NULL,
+    endpoint              TEXT NOT NULL,
+    public_key            TEXT NOT NULL,
+    private_key_encrypted TEXT NOT NULL,
+    region                TEXT NOT NULL,
+    max_clients           INTEGER NOT NULL,
+    current_clients       INTEGER NOT NULL DEFAULT 0,
+    key_rotation_max_age  INTEGER NOT NULL DEFAULT 0,
+    key_rotation_mode     TEXT NOT NULL DEFAULT '',
+    created_at            TIMESTAMP NOT NULL,
+    updated_at            TIMESTAMP NOT NULL
+);
+
+CREATE TABLE wireguard_keys (
+    id                    CHAR(24) PRIMARY KEY,
+    user_id               CHAR(24) NOT NULL,
+    server_id             CHAR(24) NOT NULL,
+    private_key_encrypted TEXT NOT NULL,
+    public_key            TEXT NOT NULL,
+    ip_address            TEXT NOT NULL,
+    created_at            TIMESTAMP NOT NULL,
+    last_rotated_at       TIMESTAMP NOT NULL,
+    rotation_required     BOOLEAN NOT NULL DEFAULT FALSE,
+    rotation_requested_at TIMESTAMP,
+    CONSTRAINT user_server_unique UNIQUE (user_id, server_id)
+);
+
+CREATE INDEX wireguard_keys_server_rotated ON wireguard_keys (server_id, last_rotated_at);
+
+CREATE TABLE config_share_links (
+    id         CHAR(24) PRIMARY KEY,
+    token_hash TEXT NOT NULL,
+    user_id    CHAR(24) NOT NULL,
+    server_id  CHAR(24) NOT NULL,
+    kind       TEXT NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    used_at    TIMESTAMP,
+    CONSTRAINT token_hash_unique UNIQUE (token_hash)
+);
+
+CREATE TABLE config_share_retrievals (
+    id         CHAR(24) PRIMARY KEY,
+    link_id    CHAR(24) NOT NULL,
+    user_id    CHAR(24) NOT NULL,
+    outcome    TEXT NOT NULL,
+    source_ip  TEXT NOT NULL,
+    user_agent TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE notifications (
+    id         CHAR(24) PRIMARY KEY,
+    user_id    CHAR(24) NOT NULL,
+    server_id  CHAR(24) NOT NULL,
+    type       TEXT NOT NULL,
+    message    TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    read_at    TIMESTAMP
+);
+
+CREATE INDEX notifications_user ON notifications (user_id, created_at);
+
+CREATE TABLE locks (
+    name        TEXT PRIMARY KEY,
+    owner       TEXT NOT NULL,
+    acquired_at TIMESTAMP NOT NULL,
+    expires_at  TIMESTAMP NOT NULL
+);
@@ -0,0 +1,2 @@
+ALTER TABLE servers ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE wireguard_keys ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
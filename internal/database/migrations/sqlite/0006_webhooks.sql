@@ -0,0 +1,27 @@
+CREATE TABLE webhooks (
+    id          CHAR(24) PRIMARY KEY,
+    url         TEXT NOT NULL,
+    secret      TEXT NOT NULL,
+    event_types TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    enabled     BOOLEAN NOT NULL,
+    created_by  TEXT NOT NULL DEFAULT '',
+    created_at  TIMESTAMP NOT NULL
+);
+
+CREATE TABLE webhook_deliveries (
+    id          CHAR(24) PRIMARY KEY,
+    webhook_id  CHAR(24) NOT NULL,
+    event_id    CHAR(24) NOT NULL,
+    event_type  TEXT NOT NULL,
+    attempt     INTEGER NOT NULL,
+    test        BOOLEAN NOT NULL,
+    success     BOOLEAN NOT NULL,
+    status_code INTEGER NOT NULL DEFAULT 0,
+    error       TEXT NOT NULL DEFAULT '',
+    duration_ms INTEGER NOT NULL,
+    created_at  TIMESTAMP NOT NULL
+);
+
+CREATE INDEX webhook_deliveries_webhook ON webhook_deliveries (webhook_id, created_at);
+CREATE INDEX webhook_deliveries_event ON webhook_deliveries (webhook_id, event_id);
@@ -0,0 +1,363 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const schemaMigrationsCollection = "schema_migrations"
+
+var ErrSchemaOutdated = errors.New("database schema is out of date")
+
+// MongoMigration is one step of the Mongo schema. Versions are applied in
+// ascending order and never renumbered once released. Up must be idempotent:
+// a run interrupted after Up but before the version is recorded repeats it.
+type MongoMigration struct {
+	Version     int
+	Name        string
+	Description string
+	Up          func(ctx context.Context, db *mongo.Database) error
+}
+
+// mongoMigrations is append-only. Add new steps at the end with the next
+// version number.
+var mongoMigrations = []MongoMigration{
+	{1, "users_indexes", "unique index on users.email", createUserIndexes},
+	{2, "wireguard_keys_indexes", "unique (user_id, server_id) and (server_id, last_rotated_at) on wireguard_keys", createWireGuardKeysIndexes},
+	{3, "config_share_link_indexes", "token hash, expiry TTL and retrieval indexes for share links", createConfigShareLinkIndexes},
+	{4, "servers_indexes", "region index on servers", createServerIndexes},
+	{5, "notifications_indexes", "(user_id, created_at) index on notifications", createNotificationIndexes},
+	{6, "backfill_key_rotation_fields", "set last_rotated_at and rotation_required on keys created before rotation", backfillKeyRotationFields},
+	{7, "wireguard_keys_ip_unique", "move peers sharing an address aside, then unique (server_id, ip_address) on wireguard_keys", uniqueWireGuardKeysIps},
+	{8, "recount_server_clients", "set servers.current_clients from the peers that exist", recountServerClients},
+	{9, "backfill_versions", "start servers and wireguard_keys without a version at 1", backfillVersions},
+	{10, "audit_events_indexes", "unique sequence, (actor_id, sequence) and (target_id, sequence) on audit_events", createAuditEventIndexes},
+	{11, "backfill_server_status", "mark servers created before statuses existed as active", backfillServerStatus},
+	{12, "outbox_indexes", "delivery indexes on outbox_events and staged event indexes on users, servers and wireguard_keys", createOutboxIndexes},
+	{13, "webhooks_indexes", "event type index on webhooks and delivery log indexes on webhook_deliveries", createWebhookIndexes},
+	{14, "outbox_type_index", "(type, occurred_at) index on outbox_events for peer watches", createOutboxTypeIndex},
+	{15, "outbox_server_id", "set server_id on outbox_events from the payload and index (server_id, type, occurred_at) in place of (type, occurred_at)", backfillOutboxServerId},
+}
+
+type MigrationStatus struct {
+	Version     int
+	Name        string
+	Description string
+	Applied     bool
+	AppliedAt   time.Time
+}
+
+type schemaMigration struct {
+	Version   int       `bson:"_id"`
+	Name      string    `bson:"name"`
+	AppliedAt time.Time `bson:"applied_at"`
+}
+
+// MongoMigrationStatus lists every known migration and whether it has been
+// applied to db.
+func MongoMigrationStatus(ctx context.Context, db *mongo.Database) ([]MigrationStatus, error) {
+	cursor, err := db.Collection(schemaMigrationsCollection).Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []schemaMigration
+	if err := cursor.All(ctx, &applied); err != nil {
+		return nil, err
+	}
+
+	appliedAt := make(map[int]time.Time)
+	for _, migration := range applied {
+		appliedAt[migration.Version] = migration.AppliedAt
+	}
+
+	statuses := make([]MigrationStatus, 0, len(mongoMigrations))
+	for _, migration := range sortedMongoMigrations() {
+		at, ok := appliedAt[migration.Version]
+		statuses = append(statuses, MigrationStatus{
+			Version:     migration.Version,
+			Name:        migration.Name,
+			Description: migration.Description,
+			Applied:     ok,
+			AppliedAt:   at,
+		})
+		delete(appliedAt, migration.Version)
+	}
+
+	for version := range appliedAt {
+		slog.Warn("Database has a migration this build does not know about", "version", version)
+	}
+
+	return statuses, nil
+}
+
+// MigrateMongo applies pending migrations in order and returns the ones it
+// applied. With dryRun set nothing is changed and the pending migrations are
+// returned instead.
+func MigrateMongo(ctx context.Context, db *mongo.Database, dryRun bool) ([]MigrationStatus, error) {
+	pending, err := pendingMongoMigrations(ctx, db)
+	if err != nil || dryRun {
+		return pending, err
+	}
+
+	migrations := make(map[int]MongoMigration)
+	for _, migration := range mongoMigrations {
+		migrations[migration.Version] = migration
+	}
+
+	var applied []MigrationStatus
+	for _, status := range pending {
+		if err := migrations[status.Version].Up(ctx, db); err != nil {
+			return applied, fmt.Errorf("migration %04d_%s failed: %w", status.Version, status.Name, err)
+		}
+
+		record := schemaMigration{Version: status.Version, Name: status.Name, AppliedAt: time.Now()}
+		_, err := db.Collection(schemaMigrationsCollection).InsertOne(ctx, record)
+		// Another replica finishing the same step first is fine.
+		if err != nil && !mongo.IsDuplicateKeyError(err) {
+			return applied, fmt.Errorf("recording migration %04d_%s: %w", status.Version, status.Name, err)
+		}
+
+		status.Applied = true
+		status.AppliedAt = record.AppliedAt
+		applied = append(applied, status)
+		slog.Info("Applied migration", "version", status.Version, "name", status.Name)
+	}
+
+	return applied, nil
+}
+
+// CheckMongoSchema returns an error wrapping ErrSchemaOutdated if db has
+// pending migrations.
+func CheckMongoSchema(ctx context.Context, db *mongo.Database) error {
+	pending, err := pendingMongoMigrations(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	names := make([]string, len(pending))
+	for i, status := range pending {
+		names[i] = fmt.Sprintf("%04d_%s", status.Version, status.Name)
+	}
+	return fmt.Errorf("%w: pending migrations %s", ErrSchemaOutdated, strings.Join(names, ", "))
+}
+
+func pendingMongoMigrations(ctx context.Context, db *mongo.Database) ([]MigrationStatus, error) {
+	statuses, err := MongoMigrationStatus(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []MigrationStatus
+	for _, status := range statuses {
+		if !status.Applied {
+			pending = append(pending, status)
+		}
+	}
+	return pending, nil
+}
+
+func sortedMongoMigrations() []MongoMigration {
+	sorted := append([]MongoMigration(nil), mongoMigrations...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Version < sorted[j].Version
+	})
+	return sorted
+}
+
+func backfillKeyRotationFields(ctx context.Context, db *mongo.Database) error {
+	keys := db.Collection("wireguard_keys")
+
+	// Peers without last_rotated_at are invisible to the rotation scheduler
+	// and can never match Rotate's compare-and-set filter.
+	_, err := keys.UpdateMany(ctx,
+		bson.M{"last_rotated_at": bson.M{"$exists": false}},
+		mongo.Pipeline{{{Key: "$set", Value: bson.M{"last_rotated_at": "$created_at"}}}},
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = keys.UpdateMany(ctx,
+		bson.M{"rotation_required": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"rotation_required": false}},
+	)
+	return err
+}
+
+// duplicatePeersCollection holds the peers uniqueWireGuardKeysIps took out of
+// wireguard_keys.
+const duplicatePeersCollection = "wireguard_keys_duplicates"
+
+// uniqueWireGuardKeysIps makes addresses unique per server. Peers allocated
+// before the index existed could share one; the oldest keeps it and the rest
+// are moved to duplicatePeersCollection, so their users get a new address
+// the next time they generate a config.
+func uniqueWireGuardKeysIps(ctx context.Context, db *mongo.Database) error {
+	keys := db.Collection("wireguard_keys")
+	cursor, err := keys.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$sort", Value: bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   bson.M{"server_id": "$server_id", "ip_address": "$ip_address"},
+			"ids":   bson.M{"$push": "$_id"},
+			"count": bson.M{"$sum": 1},
+		}}},
+		{{Key: "$match", Value: bson.M{"count": bson.M{"$gt": 1}}}},
+	})
+	if err != nil {
+		return err
+	}
+
+	var groups []struct {
+		Key struct {
+			ServerId  primitive.ObjectID `bson:"server_id"`
+			IpAddress string             `bson:"ip_address"`
+		} `bson:"_id"`
+		Ids []primitive.ObjectID `bson:"ids"`
+	}
+	if err := cursor.All(ctx, &groups); err != nil {
+		return err
+	}
+
+	duplicates := db.Collection(duplicatePeersCollection)
+	for _, group := range groups {
+		for _, id := range group.Ids[1:] {
+			var peer bson.M
+			if err := keys.FindOne(ctx, bson.M{"_id": id}).Decode(&peer); err != nil {
+				return err
+			}
+
+			// Copy before deleting, so an interrupted run loses nothing.
+			_, err := duplicates.ReplaceOne(ctx, bson.M{"_id": id}, peer, options.Replace().SetUpsert(true))
+			if err != nil {
+				return err
+			}
+			if _, err := keys.DeleteOne(ctx, bson.M{"_id": id}); err != nil {
+				return err
+			}
+
+			slog.Warn("Moved peer with a duplicate address aside",
+				"peer_id", id.Hex(),
+				"kept_peer_id", group.Ids[0].Hex(),
+				"server_id", group.Key.ServerId.Hex(),
+				"ip_address", group.Key.IpAddress,
+				"collection", duplicatePeersCollection,
+			)
+		}
+	}
+
+	return createWireGuardKeysIpIndex(ctx, db)
+}
+
+// recountServerClients fixes current_clients, which was not maintained before
+// peers were allocated through WireGuardKeysRepository.Allocate.
+func recountServerClients(ctx context.Context, db *mongo.Database) error {
+	cursor, err := db.Collection("servers").Find(ctx, bson.M{})
+	if err != nil {
+		return err
+	}
+
+	var servers []struct {
+		Id primitive.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &servers); err != nil {
+		return err
+	}
+
+	for _, server := range servers {
+		count, err := db.Collection("wireguard_keys").CountDocuments(ctx, bson.M{"server_id": server.Id})
+		if err != nil {
+			return err
+		}
+
+		update := bson.M{"$set": bson.M{"current_clients": count}}
+		if _, err := db.Collection("servers").UpdateOne(ctx, bson.M{"_id": server.Id}, update); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func backfillVersions(ctx context.Context, db *mongo.Database) error {
+	for _, collection := range []string{"servers", "wireguard_keys"} {
+		_, err := db.Collection(collection).UpdateMany(ctx,
+			bson.M{"version": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"version": 1}},
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func backfillServerStatus(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("servers").UpdateMany(ctx,
+		bson.M{"status": bson.M{"$in": bson.A{nil, ""}}},
+		bson.M{"$set": bson.M{"status": "active"}},
+	)
+	return err
+}
+
+// backfillOutboxServerId sets server_id on events stored before it existed,
+// from the server_id in their payload, and replaces the type index with one
+// that starts at server_id.
+func backfillOutboxServerId(ctx context.Context, db *mongo.Database) error {
+	outbox := db.Collection("outbox_events")
+
+	filter := bson.M{
+		"type":      bson.M{"$in": bson.A{"PeerCreated", "PeerRevoked", "KeysRotated", "ServerStatusChanged"}},
+		"server_id": bson.M{"$exists": false},
+	}
+	cursor, err := outbox.Find(ctx, filter, options.Find().SetProjection(bson.M{"payload": 1}))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var event struct {
+			Id      primitive.ObjectID `bson:"_id"`
+			Payload string             `bson:"payload"`
+		}
+		if err := cursor.Decode(&event); err != nil {
+			return err
+		}
+
+		var payload struct {
+			ServerId string `json:"server_id"`
+		}
+		if err := json.Unmarshal([]byte(event.Payload), &payload); err != nil {
+			return fmt.Errorf("outbox event %s: %w", event.Id.Hex(), err)
+		}
+
+		_, err := outbox.UpdateOne(ctx, bson.M{"_id": event.Id}, bson.M{"$set": bson.M{"server_id": payload.ServerId}})
+		if err != nil {
+			return err
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	if err := createOutboxServerIndex(ctx, db); err != nil {
+		return err
+	}
+	return dropIndex(ctx, outbox, "type_occurred")
+}
@@ -0,0 +1,223 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// newTestMongoDatabase returns an empty database of its own on the server in
+// MONGODB_TEST_URI, dropped when the test ends.
+func newTestMongoDatabase(t *testing.T) *mongo.Database {
+	t.Helper()
+
+	uri := os.Getenv("MONGODB_TEST_URI")
+	if uri == "" {
+		t.Skip("MONGODB_TEST_URI is not set")
+	}
+
+	ctx := context.Background()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := client.Database("vpn_test_" + primitive.NewObjectID().Hex())
+	t.Cleanup(func() {
+		db.Drop(ctx)
+		client.Disconnect(ctx)
+	})
+	return db
+}
+
+func TestMigrateMongoIsIdempotent(t *testing.T) {
+	ctx := context.Background()
+	db := newTestMongoDatabase(t)
+
+	pending, err := MigrateMongo(ctx, db, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != len(mongoMigrations) {
+		t.Fatalf("dry run found %d pending migrations, want %d", len(pending), len(mongoMigrations))
+	}
+	if n, err := db.Collection(schemaMigrationsCollection).CountDocuments(ctx, bson.M{}); err != nil || n != 0 {
+		t.Fatalf("dry run recorded %d migrations, %v", n, err)
+	}
+
+	applied, err := MigrateMongo(ctx, db, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != len(mongoMigrations) {
+		t.Fatalf("applied %d migrations, want %d", len(applied), len(mongoMigrations))
+	}
+	for i, status := range applied {
+		if i > 0 && status.Version <= applied[i-1].Version {
+			t.Errorf("applied %04d after %04d", status.Version, applied[i-1].Version)
+		}
+	}
+
+	applied, err = MigrateMongo(ctx, db, false)
+	if err != nil || len(applied) != 0 {
+		t.Fatalf("second run applied %d migrations, %v", len(applied), err)
+	}
+	if err := CheckMongoSchema(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+
+	// A run interrupted before recording a step repeats it, so every step
+	// must succeed on a schema it has already been applied to.
+	for _, migration := range sortedMongoMigrations() {
+		if err := migration.Up(ctx, db); err != nil {
+			t.Errorf("%04d_%s failed when repeated: %v", migration.Version, migration.Name, err)
+		}
+	}
+}
+
+func TestCheckMongoSchemaRefusesOutdated(t *testing.T) {
+	ctx := context.Background()
+	db := newTestMongoDatabase(t)
+
+	err := CheckMongoSchema(ctx, db)
+	if !errors.Is(err, ErrSchemaOutdated) {
+		t.Fatalf("empty database: got %v, want ErrSchemaOutdated", err)
+	}
+
+	if _, err := MigrateMongo(ctx, db, false); err != nil {
+		t.Fatal(err)
+	}
+
+	// A build that knows a step the database has not had refuses it, naming
+	// the step.
+	last := sortedMongoMigrations()[len(mongoMigrations)-1]
+	if _, err := db.Collection(schemaMigrationsCollection).DeleteOne(ctx, bson.M{"_id": last.Version}); err != nil {
+		t.Fatal(err)
+	}
+	err = CheckMongoSchema(ctx, db)
+	if !errors.Is(err, ErrSchemaOutdated) || !strings.Contains(err.Error(), last.Name) {
+		t.Fatalf("got %v, want ErrSchemaOutdated naming %s", err, last.Name)
+	}
+
+	statuses, err := MongoMigrationStatus(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, status := range statuses {
+		if status.Applied != (status.Version != last.Version) {
+			t.Errorf("%04d_%s applied is %v", status.Version, status.Name, status.Applied)
+		}
+	}
+
+	applied, err := MigrateMongo(ctx, db, false)
+	if err != nil || len(applied) != 1 || applied[0].Version != last.Version {
+		t.Fatalf("applied %v, %v; want only %04d", applied, err, last.Version)
+	}
+	if err := CheckMongoSchema(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUniqueWireGuardKeysIps(t *testing.T) {
+	ctx := context.Background()
+	db := newTestMongoDatabase(t)
+	keys := db.Collection("wireguard_keys")
+	duplicates := db.Collection(duplicatePeersCollection)
+
+	serverA, serverB := primitive.NewObjectID(), primitive.NewObjectID()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	peer := func(serverId primitive.ObjectID, ip string, age int) bson.M {
+		return bson.M{
+			"_id":        primitive.NewObjectID(),
+			"user_id":    primitive.NewObjectID(),
+			"server_id":  serverId,
+			"ip_address": ip,
+			"public_key": primitive.NewObjectID().Hex(),
+			"created_at": start.Add(time.Duration(age) * time.Hour),
+		}
+	}
+
+	// Inserted newest first, so the oldest is not simply the first stored.
+	newest := peer(serverA, "10.0.0.2/32", 3)
+	middle := peer(serverA, "10.0.0.2/32", 2)
+	oldest := peer(serverA, "10.0.0.2/32", 1)
+	other := peer(serverA, "10.0.0.3/32", 4)
+	otherServer := peer(serverB, "10.0.0.2/32", 5)
+	for _, doc := range []bson.M{newest, middle, oldest, other, otherServer} {
+		if _, err := keys.InsertOne(ctx, doc); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A run interrupted after copying a peer aside but before deleting it.
+	if _, err := duplicates.InsertOne(ctx, middle); err != nil {
+		t.Fatal(err)
+	}
+
+	ids := func(collection *mongo.Collection) map[primitive.ObjectID]bool {
+		t.Helper()
+		cursor, err := collection.Find(ctx, bson.M{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var docs []bson.M
+		if err := cursor.All(ctx, &docs); err != nil {
+			t.Fatal(err)
+		}
+		found := make(map[primitive.ObjectID]bool)
+		for _, doc := range docs {
+			found[doc["_id"].(primitive.ObjectID)] = true
+		}
+		return found
+	}
+	check := func() {
+		t.Helper()
+
+		kept := ids(keys)
+		for _, doc := range []bson.M{oldest, other, otherServer} {
+			if !kept[doc["_id"].(primitive.ObjectID)] {
+				t.Errorf("peer %v was moved", doc)
+			}
+		}
+		moved := ids(duplicates)
+		for _, doc := range []bson.M{newest, middle} {
+			id := doc["_id"].(primitive.ObjectID)
+			if kept[id] || !moved[id] {
+				t.Errorf("peer %v was not moved aside", doc)
+			}
+		}
+		if len(kept) != 3 || len(moved) != 2 {
+			t.Errorf("%d peers kept and %d moved, want 3 and 2", len(kept), len(moved))
+		}
+	}
+
+	if err := uniqueWireGuardKeysIps(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+	check()
+
+	var copied bson.M
+	if err := duplicates.FindOne(ctx, bson.M{"_id": newest["_id"]}).Decode(&copied); err != nil {
+		t.Fatal(err)
+	}
+	if copied["public_key"] != newest["public_key"] || copied["user_id"] != newest["user_id"] {
+		t.Errorf("moved peer is %v, want a copy of %v", copied, newest)
+	}
+
+	if err := uniqueWireGuardKeysIps(ctx, db); err != nil {
+		t.Fatalf("second run: %v", err)
+	}
+	check()
+
+	_, err := keys.InsertOne(ctx, peer(serverA, "10.0.0.2/32", 6))
+	if !mongo.IsDuplicateKeyError(err) {
+		t.Errorf("inserting a duplicate address: got %v, want a duplicate key error", err)
+	}
+}
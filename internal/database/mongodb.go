@@ -2,7 +2,7 @@ package database
 
 import (
 	"context"
-	"log"
+	"log/slog"
 	"time"
 
 	"go.mongodb.org/mongo-driver/mongo"
@@ -12,13 +12,15 @@ import (
 var Client *mongo.Client
 var DB *mongo.Database
 
-func Connect(uri string) error {
+// Connect opens Client and sets DB to the database called name. opts are
+// applied after the URI.
+func Connect(uri, name string, opts ...*options.ClientOptions) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
 	clientOptions := options.Client().ApplyURI(uri)
 
-	client, err := mongo.Connect(ctx, clientOptions)
+	client, err := mongo.Connect(ctx, append([]*options.ClientOptions{clientOptions}, opts...)...)
 
 	if err != nil {
 		return err
@@ -30,13 +32,18 @@ func Connect(uri string) error {
 	}
 
 	Client = client
-	DB = client.Database("vpn")
+	DB = client.Database(name)
 
-	log.Println("Connected to MongoDB")
+	slog.Info("Connected to MongoDB", "database", name)
 	return nil
 }
 
+// Disconnect closes the connection opened by Connect, if there is one.
 func Disconnect() error {
+	if Client == nil {
+		return nil
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
 	defer cancel()
 
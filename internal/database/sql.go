@@ -0,0 +1,172 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	_ "modernc.org/sqlite"
+)
+
+//go:embed migrations
+var sqlMigrations embed.FS
+
+func OpenPostgres(dsn string) (*sql.DB, error) {
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	slog.Info("Connected to PostgreSQL")
+	return db, nil
+}
+
+// OpenSQLite opens (creating if needed) the database file at path. Times are
+// written in a fixed UTC format so they sort correctly as text, and the pool
+// is limited to one connection because SQLite serialises writers anyway.
+func OpenSQLite(path string) (*sql.DB, error) {
+	dsn := "file:" + path + "?_time_format=sqlite&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(1)"
+
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	slog.Info("Opened SQLite database", "path", path)
+	return db, nil
+}
+
+type sqlMigration struct {
+	version int
+	name    string
+	path    string
+}
+
+// MigrateSQL applies the embedded migrations for dialect ("postgres" or
+// "sqlite") that have not been recorded in schema_migrations yet. Each
+// migration runs in its own transaction.
+func MigrateSQL(ctx context.Context, db *sql.DB, dialect string) error {
+	migrations, err := loadSQLMigrations(dialect)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		name       TEXT NOT NULL,
+		applied_at TIMESTAMP NOT NULL
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %v", err)
+	}
+
+	applied := make(map[int]bool)
+	rows, err := db.QueryContext(ctx, "SELECT version FROM schema_migrations")
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			rows.Close()
+			return err
+		}
+		applied[version] = true
+	}
+	rows.Close()
+
+	for _, migration := range migrations {
+		if applied[migration.version] {
+			continue
+		}
+
+		if err := applySQLMigration(ctx, db, dialect, migration); err != nil {
+			return fmt.Errorf("migration %04d_%s failed: %v", migration.version, migration.name, err)
+		}
+		slog.Info("Applied migration", "version", migration.version, "name", migration.name)
+	}
+
+	return nil
+}
+
+func applySQLMigration(ctx context.Context, db *sql.DB, dialect string, migration sqlMigration) error {
+	body, err := sqlMigrations.ReadFile(migration.path)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, string(body)); err != nil {
+		return err
+	}
+
+	insert := "INSERT INTO schema_migrations (version, name, applied_at) VALUES ($1, $2, $3)"
+	if dialect == "sqlite" {
+		insert = "INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)"
+	}
+	if _, err := tx.ExecContext(ctx, insert, migration.version, migration.name, time.Now().UTC()); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func loadSQLMigrations(dialect string) ([]sqlMigration, error) {
+	dir := "migrations/" + dialect
+	entries, err := fs.ReadDir(sqlMigrations, dir)
+	if err != nil {
+		return nil, fmt.Errorf("no migrations for %s: %v", dialect, err)
+	}
+
+	var migrations []sqlMigration
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".sql")
+		if !ok {
+			continue
+		}
+
+		number, rest, ok := strings.Cut(name, "_")
+		version, err := strconv.Atoi(number)
+		if !ok || err != nil {
+			return nil, fmt.Errorf("invalid migration file name %q", entry.Name())
+		}
+
+		migrations = append(migrations, sqlMigration{
+			version: version,
+			name:    rest,
+			path:    dir + "/" + entry.Name(),
+		})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].version < migrations[j].version
+	})
+	return migrations, nil
+}
@@ -0,0 +1,256 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shivamp1998/vpn_backend/internal/model"
+	"github.com/shivamp1998/vpn_backend/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const dispatcherLockName = "outbox_dispatcher"
+
+// Handler processes one event. Delivery is at least once, so a handler can
+// see the same event again and must tolerate that. Returning an error
+// schedules a retry.
+type Handler func(ctx context.Context, event *model.OutboxEvent) error
+
+type DispatcherConfig struct {
+	PollInterval   time.Duration
+	BatchSize      int
+	MaxAttempts    int
+	MinBackoff     time.Duration
+	MaxBackoff     time.Duration
+	HandlerTimeout time.Duration
+}
+
+type subscription struct {
+	name    string
+	types   map[string]bool
+	handler Handler
+}
+
+// Dispatcher delivers outbox events to subscribers in process. Only the
+// replica holding the dispatcher lease delivers; the others stand by.
+type Dispatcher struct {
+	config   DispatcherConfig
+	outbox   repository.OutboxRepository
+	lockRepo repository.LockRepository
+	owner    string
+
+	mu            sync.Mutex
+	subscriptions []subscription
+}
+
+func NewDispatcher(config DispatcherConfig, outbox repository.OutboxRepository, lockRepo repository.LockRepository) *Dispatcher {
+	if config.PollInterval <= 0 {
+		config.PollInterval = time.Second
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 100
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 10
+	}
+	if config.MinBackoff <= 0 {
+		config.MinBackoff = time.Second
+	}
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = time.Hour
+	}
+	if config.HandlerTimeout <= 0 {
+		config.HandlerTimeout = 30 * time.Second
+	}
+
+	return &Dispatcher{
+		config:   config,
+		outbox:   outbox,
+		lockRepo: lockRepo,
+		owner:    repository.NewLockOwner(),
+	}
+}
+
+// Subscribe registers handler for the given event types, or for every type
+// if none are given. The name is recorded against each event the handler
+// has processed, so it must stay the same across restarts.
+func (d *Dispatcher) Subscribe(name string, handler Handler, types ...string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, existing := range d.subscriptions {
+		if existing.name == name {
+			panic(fmt.Sprintf("events: subscriber %q registered twice", name))
+		}
+	}
+
+	sub := subscription{name: name, handler: handler}
+	if len(types) > 0 {
+		sub.types = make(map[string]bool)
+		for _, eventType := range types {
+			sub.types[eventType] = true
+		}
+	}
+	d.subscriptions = append(d.subscriptions, sub)
+}
+
+// Run delivers due events every PollInterval until ctx is cancelled.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.config.PollInterval)
+	defer ticker.Stop()
+	defer d.lockRepo.Release(context.WithoutCancel(ctx), dispatcherLockName, d.owner)
+
+	for {
+		if err := d.RunOnce(ctx); err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "Outbox dispatch failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce delivers every event that is currently due. The lease is renewed
+// before each event, and lasts long enough to deliver one, so no other
+// replica can take over while an event is being delivered.
+func (d *Dispatcher) RunOnce(ctx context.Context) error {
+	held, err := d.holdLease(ctx)
+	if err != nil || !held {
+		return err
+	}
+
+	for {
+		batch, err := d.outbox.Due(ctx, time.Now(), d.config.BatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, event := range batch {
+			// Another replica took over and delivers the rest.
+			if held, err := d.holdLease(ctx); err != nil || !held {
+				return err
+			}
+			if err := d.deliver(ctx, event); err != nil {
+				return err
+			}
+		}
+
+		if len(batch) < d.config.BatchSize {
+			return nil
+		}
+	}
+}
+
+// holdLease takes or renews the dispatcher lease, reporting false if another
+// replica holds it. The lease covers delivering an event to every
+// subscriber, and outlives a few missed polls, so a stalled replica hands
+// over without the others fighting over it every tick.
+func (d *Dispatcher) holdLease(ctx context.Context) (bool, error) {
+	ttl := 10*d.config.PollInterval + time.Duration(len(d.subscribers()))*d.config.HandlerTimeout
+
+	held, err := d.lockRepo.Acquire(ctx, dispatcherLockName, d.owner, ttl)
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire dispatcher lock: %v", err)
+	}
+	return held, nil
+}
+
+// deliver hands event to every matching subscriber that has not handled it
+// yet and saves the outcome. The returned error is about saving; handler
+// failures are recorded on the event.
+func (d *Dispatcher) deliver(ctx context.Context, event *model.OutboxEvent) error {
+	delivered := make(map[string]bool)
+	for _, name := range event.Delivered {
+		delivered[name] = true
+	}
+
+	var failures []string
+	for _, sub := range d.subscribers() {
+		if delivered[sub.name] || (sub.types != nil && !sub.types[event.Type]) {
+			continue
+		}
+
+		if err := d.handle(ctx, sub, event); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", sub.name, err))
+			continue
+		}
+		event.Delivered = append(event.Delivered, sub.name)
+	}
+
+	now := time.Now()
+	if len(failures) == 0 {
+		event.Status = model.OutboxStatusDelivered
+		event.DeliveredAt = &now
+		event.LastError = ""
+	} else {
+		event.Attempts++
+		event.LastError = strings.Join(failures, "; ")
+		event.NextAttemptAt = now.Add(d.backoff(event.Attempts))
+
+		if event.Attempts >= d.config.MaxAttempts {
+			event.Status = model.OutboxStatusDead
+			slog.WarnContext(ctx, "Outbox event moved to dead letters",
+				"event_id", event.Id.Hex(), "type", event.Type, "attempts", event.Attempts, "last_error", event.LastError)
+		}
+	}
+
+	return d.outbox.UpdateDelivery(context.WithoutCancel(ctx), event)
+}
+
+func (d *Dispatcher) handle(ctx context.Context, sub subscription, event *model.OutboxEvent) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, d.config.HandlerTimeout)
+	defer cancel()
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	return sub.handler(ctx, event)
+}
+
+func (d *Dispatcher) subscribers() []subscription {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return append([]subscription(nil), d.subscriptions...)
+}
+
+// backoff doubles from MinBackoff with each failed attempt, up to MaxBackoff.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.config.MinBackoff
+	for i := 1; i < attempts && delay < d.config.MaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, d.config.MaxBackoff)
+}
+
+// DeadLetters returns up to limit events that ran out of attempts, newest
+// first.
+func (d *Dispatcher) DeadLetters(ctx context.Context, limit int) ([]*model.OutboxEvent, error) {
+	return d.outbox.ListByStatus(ctx, model.OutboxStatusDead, limit)
+}
+
+var ErrNotDeadLetter = errors.New("event is not a dead letter")
+
+// Requeue gives a dead event a fresh set of attempts. Subscribers that
+// already handled it are not called again.
+func (d *Dispatcher) Requeue(ctx context.Context, id primitive.ObjectID) error {
+	requeued, err := d.outbox.Requeue(ctx, id, time.Now())
+	if err != nil {
+		return err
+	}
+	if !requeued {
+		return ErrNotDeadLetter
+	}
+	return nil
+}
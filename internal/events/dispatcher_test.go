@@ -0,0 +1,212 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shivamp1998/vpn_backend/internal/model"
+	"github.com/shivamp1998/vpn_backend/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const testEventType = "TestEvent"
+
+// addEvents stores n events the way a repository write would.
+func addEvents(t *testing.T, repos *repository.Repositories, n int) {
+	t.Helper()
+
+	for i := 0; i < n; i++ {
+		user := &model.User{Id: primitive.NewObjectID(), Email: fmt.Sprintf("user%d-%s@example.com", i, primitive.NewObjectID().Hex())}
+		event := &model.OutboxEvent{Type: testEventType, AggregateId: user.Id.Hex(), Payload: "{}"}
+		if err := repos.Users.Create(context.Background(), user, event); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// counter records how often each event reached a handler.
+type counter struct {
+	mu    sync.Mutex
+	calls map[primitive.ObjectID]int
+}
+
+func (c *counter) add(event *model.OutboxEvent) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.calls == nil {
+		c.calls = make(map[primitive.ObjectID]int)
+	}
+	c.calls[event.Id]++
+	return c.calls[event.Id]
+}
+
+func TestDispatcherRetriesThenDeadLetters(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemoryRepositories()
+	dispatcher := NewDispatcher(DispatcherConfig{MaxAttempts: 3, MinBackoff: time.Nanosecond}, repos.Outbox, repos.Locks)
+
+	var healthy, flaky counter
+	var failing sync.Mutex
+	fail := true
+	dispatcher.Subscribe("healthy", func(ctx context.Context, event *model.OutboxEvent) error {
+		healthy.add(event)
+		return nil
+	})
+	dispatcher.Subscribe("flaky", func(ctx context.Context, event *model.OutboxEvent) error {
+		flaky.add(event)
+		failing.Lock()
+		defer failing.Unlock()
+		if fail {
+			return errors.New("receiver is down")
+		}
+		return nil
+	}, testEventType)
+
+	addEvents(t, repos, 1)
+	for i := 0; i < 3; i++ {
+		time.Sleep(time.Millisecond)
+		if err := dispatcher.RunOnce(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dead, err := dispatcher.DeadLetters(ctx, 10)
+	if err != nil || len(dead) != 1 {
+		t.Fatalf("dead letters: %v, %v", dead, err)
+	}
+	event := dead[0]
+	if event.Attempts != 3 || !strings.Contains(event.LastError, "flaky: receiver is down") || fmt.Sprint(event.Delivered) != "[healthy]" {
+		t.Errorf("dead letter %+v", event)
+	}
+	if healthy.calls[event.Id] != 1 || flaky.calls[event.Id] != 3 {
+		t.Errorf("healthy called %d times, flaky %d times; want 1 and 3", healthy.calls[event.Id], flaky.calls[event.Id])
+	}
+
+	// A dead letter is left alone until it is requeued.
+	if err := dispatcher.RunOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if flaky.calls[event.Id] != 3 {
+		t.Errorf("dead letter was delivered again")
+	}
+
+	failing.Lock()
+	fail = false
+	failing.Unlock()
+	if err := dispatcher.Requeue(ctx, event.Id); err != nil {
+		t.Fatal(err)
+	}
+	if err := dispatcher.RunOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if healthy.calls[event.Id] != 1 || flaky.calls[event.Id] != 4 {
+		t.Errorf("after requeue healthy called %d times, flaky %d times; want 1 and 4", healthy.calls[event.Id], flaky.calls[event.Id])
+	}
+	delivered, err := repos.Outbox.ListByStatus(ctx, model.OutboxStatusDelivered, 10)
+	if err != nil || len(delivered) != 1 || delivered[0].LastError != "" {
+		t.Errorf("delivered events: %v, %v", delivered, err)
+	}
+	if err := dispatcher.Requeue(ctx, event.Id); !errors.Is(err, ErrNotDeadLetter) {
+		t.Errorf("requeue of a delivered event: got %v, want %v", err, ErrNotDeadLetter)
+	}
+}
+
+func TestDispatcherRecoversPanics(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemoryRepositories()
+	dispatcher := NewDispatcher(DispatcherConfig{}, repos.Outbox, repos.Locks)
+	dispatcher.Subscribe("panics", func(ctx context.Context, event *model.OutboxEvent) error {
+		panic("boom")
+	})
+
+	addEvents(t, repos, 1)
+	if err := dispatcher.RunOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	pending, err := repos.Outbox.ListByStatus(ctx, model.OutboxStatusPending, 10)
+	if err != nil || len(pending) != 1 || pending[0].Attempts != 1 || pending[0].LastError != "panics: panic: boom" {
+		t.Errorf("pending events: %v, %v", pending, err)
+	}
+}
+
+func TestDispatcherBackoff(t *testing.T) {
+	dispatcher := NewDispatcher(DispatcherConfig{MinBackoff: time.Second, MaxBackoff: 10 * time.Second}, nil, nil)
+
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{4, 8 * time.Second},
+		{5, 10 * time.Second},
+		{50, 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := dispatcher.backoff(tt.attempts); got != tt.want {
+			t.Errorf("backoff after %d attempts is %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+// TestDispatchersShareLease runs two dispatchers over one store while the
+// first delivers a batch that takes longer than a single lease, and checks
+// that no event is delivered by both.
+func TestDispatchersShareLease(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemoryRepositories()
+
+	const events = 4
+	const handlerTime = 40 * time.Millisecond
+	// A lease lasts 10ms plus one handler timeout, well short of the batch.
+	config := DispatcherConfig{PollInterval: time.Millisecond, HandlerTimeout: 50 * time.Millisecond}
+
+	var calls counter
+	var first, second *Dispatcher
+	for _, d := range []**Dispatcher{&first, &second} {
+		*d = NewDispatcher(config, repos.Outbox, repos.Locks)
+		(*d).Subscribe("slow", func(ctx context.Context, event *model.OutboxEvent) error {
+			if calls.add(event) > 1 {
+				t.Errorf("event %s delivered twice", event.Id.Hex())
+			}
+			time.Sleep(handlerTime)
+			return nil
+		})
+	}
+
+	addEvents(t, repos, events)
+
+	done := make(chan error)
+	go func() { done <- first.RunOnce(ctx) }()
+
+	// The second dispatcher keeps trying for as long as the first delivers.
+	deadline := time.After(events * handlerTime)
+	for polling := true; polling; {
+		select {
+		case <-deadline:
+			polling = false
+		case <-time.After(5 * time.Millisecond):
+			if err := second.RunOnce(ctx); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	delivered, err := repos.Outbox.ListByStatus(ctx, model.OutboxStatusDelivered, 10)
+	if err != nil || len(delivered) != events {
+		t.Errorf("%d events delivered, want %d (%v)", len(delivered), events, err)
+	}
+}
@@ -0,0 +1,122 @@
+// Package events defines the domain events written to the outbox and the
+// dispatcher that delivers them to subscribers.
+package events
+
+import (
+	"encoding/json"
+
+	"github.com/shivamp1998/vpn_backend/internal/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	TriggerUser      = "user"
+	TriggerScheduler = "scheduler"
+)
+
+type PeerCreated struct {
+	PeerId    string `json:"peer_id"`
+	UserId    string `json:"user_id"`
+	ServerId  string `json:"server_id"`
+	PublicKey string `json:"public_key"`
+	IpAddress string `json:"ip_address"`
+}
+
+type PeerRevoked struct {
+	PeerId    string `json:"peer_id"`
+	UserId    string `json:"user_id"`
+	ServerId  string `json:"server_id"`
+	PublicKey string `json:"public_key"`
+	IpAddress string `json:"ip_address"`
+}
+
+type KeysRotated struct {
+	PeerId            string `json:"peer_id"`
+	UserId            string `json:"user_id"`
+	ServerId          string `json:"server_id"`
+	PublicKey         string `json:"public_key"`
+	PreviousPublicKey string `json:"previous_public_key"`
+	// Trigger is TriggerUser or TriggerScheduler.
+	Trigger string `json:"trigger"`
+}
+
+type ServerStatusChanged struct {
+	ServerId       string `json:"server_id"`
+	PreviousStatus string `json:"previous_status"`
+	Status         string `json:"status"`
+}
+
+type UserRegistered struct {
+	UserId string `json:"user_id"`
+	Email  string `json:"email"`
+}
+
+// The constructors below need the ids of the records the events describe,
+// so records being created must have their ids assigned first.
+
+func NewPeerCreated(keys *model.WireGuardKeys) *model.OutboxEvent {
+	return newEvent(model.EventPeerCreated, keys.Id, keys.ServerId, PeerCreated{
+		PeerId:    keys.Id.Hex(),
+		UserId:    keys.UserId.Hex(),
+		ServerId:  keys.ServerId.Hex(),
+		PublicKey: keys.PublicKey,
+		IpAddress: keys.IpAddress,
+	})
+}
+
+func NewPeerRevoked(keys *model.WireGuardKeys) *model.OutboxEvent {
+	return newEvent(model.EventPeerRevoked, keys.Id, keys.ServerId, PeerRevoked{
+		PeerId:    keys.Id.Hex(),
+		UserId:    keys.UserId.Hex(),
+		ServerId:  keys.ServerId.Hex(),
+		PublicKey: keys.PublicKey,
+		IpAddress: keys.IpAddress,
+	})
+}
+
+func NewKeysRotated(keys *model.WireGuardKeys, previousPublicKey, trigger string) *model.OutboxEvent {
+	return newEvent(model.EventKeysRotated, keys.Id, keys.ServerId, KeysRotated{
+		PeerId:            keys.Id.Hex(),
+		UserId:            keys.UserId.Hex(),
+		ServerId:          keys.ServerId.Hex(),
+		PublicKey:         keys.PublicKey,
+		PreviousPublicKey: previousPublicKey,
+		Trigger:           trigger,
+	})
+}
+
+func NewServerStatusChanged(serverId primitive.ObjectID, previousStatus, status string) *model.OutboxEvent {
+	return newEvent(model.EventServerStatusChanged, serverId, serverId, ServerStatusChanged{
+		ServerId:       serverId.Hex(),
+		PreviousStatus: previousStatus,
+		Status:         status,
+	})
+}
+
+func NewUserRegistered(user *model.User) *model.OutboxEvent {
+	return newEvent(model.EventUserRegistered, user.Id, primitive.NilObjectID, UserRegistered{
+		UserId: user.Id.Hex(),
+		Email:  user.Email,
+	})
+}
+
+func newEvent(eventType string, aggregateId, serverId primitive.ObjectID, payload any) *model.OutboxEvent {
+	// The payload types only hold strings, which always encode.
+	encoded, _ := json.Marshal(payload)
+
+	event := &model.OutboxEvent{
+		Type:        eventType,
+		AggregateId: aggregateId.Hex(),
+		Payload:     string(encoded),
+	}
+	if !serverId.IsZero() {
+		event.ServerId = serverId.Hex()
+	}
+	return event
+}
+
+// Decode unpacks the payload of event into v, which should be a pointer to
+// the payload type matching event.Type.
+func Decode(event *model.OutboxEvent, v any) error {
+	return json.Unmarshal([]byte(event.Payload), v)
+}
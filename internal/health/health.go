@@ -0,0 +1,219 @@
+// Package health reports whether the server can take traffic, over the
+// grpc.health.v1 service and the /healthz and /readyz HTTP routes.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"maps"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const (
+	LivenessPath  = "/healthz"
+	ReadinessPath = "/readyz"
+)
+
+// Check returns an error if a dependency the server needs is unusable.
+type Check func(ctx context.Context) error
+
+type CheckerConfig struct {
+	// Interval is how often the gRPC serving status is refreshed.
+	Interval time.Duration
+	// Timeout bounds one run of all the checks.
+	Timeout time.Duration
+}
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Checker runs the readiness checks. The server is ready when every check
+// passes and Shutdown has not been called.
+type Checker struct {
+	config CheckerConfig
+	grpc   *health.Server
+
+	mu       sync.Mutex
+	checks   []namedCheck
+	services []string
+	// passed holds the outcome of each check on the latest run, nil before
+	// the first.
+	passed map[string]bool
+
+	shuttingDown atomic.Bool
+}
+
+func NewChecker(config CheckerConfig) *Checker {
+	if config.Interval <= 0 {
+		config.Interval = 10 * time.Second
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 5 * time.Second
+	}
+
+	grpcHealth := health.NewServer()
+	// Not serving until the checks have run once.
+	grpcHealth.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+
+	return &Checker{config: config, grpc: grpcHealth}
+}
+
+// Add registers a readiness check under name, which is shown in the /readyz
+// response.
+func (c *Checker) Add(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.checks = append(c.checks, namedCheck{name: name, check: check})
+}
+
+// AddService gives a gRPC service its own serving status, for clients that
+// check a service by name.
+func (c *Checker) AddService(service string) {
+	c.mu.Lock()
+	c.services = append(c.services, service)
+	c.mu.Unlock()
+
+	c.grpc.SetServingStatus(service, healthpb.HealthCheckResponse_NOT_SERVING)
+}
+
+// HealthServer is the grpc.health.v1 implementation to register on the gRPC
+// server.
+func (c *Checker) HealthServer() healthpb.HealthServer {
+	return c.grpc
+}
+
+// Run refreshes the readiness status every Interval until ctx is
+// cancelled. Both /readyz and the gRPC health service report the result of
+// the latest run.
+func (c *Checker) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.config.Interval)
+	defer ticker.Stop()
+
+	// Failures are logged when they change, the first run's included.
+	var previous map[string]string
+	for {
+		failures := c.check(ctx)
+		ready := len(failures) == 0
+		if !ready && !maps.Equal(failures, previous) && ctx.Err() == nil {
+			slog.WarnContext(ctx, "Not ready", "failures", failures)
+		}
+		previous = failures
+		c.setStatus(ready)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Shutdown marks every service as not serving, for good, so load balancers
+// stop sending new calls while the servers drain.
+func (c *Checker) Shutdown() {
+	c.shuttingDown.Store(true)
+	c.grpc.Shutdown()
+}
+
+func (c *Checker) setStatus(ready bool) {
+	status := healthpb.HealthCheckResponse_NOT_SERVING
+	if ready {
+		status = healthpb.HealthCheckResponse_SERVING
+	}
+
+	c.mu.Lock()
+	services := append([]string{""}, c.services...)
+	c.mu.Unlock()
+
+	// The health server ignores updates once Shutdown has been called.
+	for _, service := range services {
+		c.grpc.SetServingStatus(service, status)
+	}
+}
+
+// check runs every check, keeps the outcome for ReadinessHandler and returns
+// the errors of the failed ones by name.
+func (c *Checker) check(ctx context.Context) map[string]string {
+	ctx, cancel := context.WithTimeout(ctx, c.config.Timeout)
+	defer cancel()
+
+	c.mu.Lock()
+	checks := append([]namedCheck(nil), c.checks...)
+	c.mu.Unlock()
+
+	passed := make(map[string]bool)
+	failures := make(map[string]string)
+	for _, check := range checks {
+		err := check.check(ctx)
+		passed[check.name] = err == nil
+		if err != nil {
+			failures[check.name] = err.Error()
+		}
+	}
+
+	c.mu.Lock()
+	c.passed = passed
+	c.mu.Unlock()
+	return failures
+}
+
+type readinessResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+// LivenessHandler answers 200 for as long as the process can serve HTTP.
+func (c *Checker) LivenessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write([]byte("ok\n"))
+	})
+}
+
+// ReadinessHandler answers 200 if every check passed on the latest run and
+// 503 otherwise, with whether each check passed in the body. It serves the
+// result Run keeps rather than running the checks, so probes cannot load the
+// dependencies, and it leaves out the errors, which are logged instead.
+func (c *Checker) ReadinessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response := readinessResponse{Status: "ok", Checks: map[string]string{}}
+		code := http.StatusOK
+
+		c.mu.Lock()
+		passed := c.passed
+		c.mu.Unlock()
+
+		switch {
+		case c.shuttingDown.Load():
+			response.Status = "shutting down"
+			code = http.StatusServiceUnavailable
+		case passed == nil:
+			response.Status = "starting"
+			code = http.StatusServiceUnavailable
+		default:
+			for name, ok := range passed {
+				response.Checks[name] = "ok"
+				if !ok {
+					response.Checks[name] = "failing"
+					response.Status = "unavailable"
+					code = http.StatusServiceUnavailable
+				}
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(code)
+		json.NewEncoder(w).Encode(response)
+	})
+}
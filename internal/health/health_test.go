@@ -0,0 +1,71 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func readiness(t *testing.T, c *Checker) (int, readinessResponse, string) {
+	t.Helper()
+
+	recorder := httptest.NewRecorder()
+	c.ReadinessHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, ReadinessPath, nil))
+
+	var response readinessResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	return recorder.Code, response, recorder.Body.String()
+}
+
+func TestReadinessServesLatestRun(t *testing.T) {
+	ctx := context.Background()
+	c := NewChecker(CheckerConfig{})
+
+	var calls atomic.Int32
+	var failing atomic.Bool
+	c.Add("database", func(ctx context.Context) error {
+		calls.Add(1)
+		if failing.Load() {
+			return errors.New("dial tcp 10.1.2.3:27017: connection refused")
+		}
+		return nil
+	})
+
+	code, response, _ := readiness(t, c)
+	if code != http.StatusServiceUnavailable || response.Status != "starting" {
+		t.Errorf("before the first run: %d %+v", code, response)
+	}
+
+	c.check(ctx)
+	code, response, _ = readiness(t, c)
+	if code != http.StatusOK || response.Status != "ok" || response.Checks["database"] != "ok" {
+		t.Errorf("after a passing run: %d %+v", code, response)
+	}
+
+	failing.Store(true)
+	c.check(ctx)
+	code, response, body := readiness(t, c)
+	if code != http.StatusServiceUnavailable || response.Status != "unavailable" || response.Checks["database"] != "failing" {
+		t.Errorf("after a failing run: %d %+v", code, response)
+	}
+	if strings.Contains(body, "10.1.2.3") {
+		t.Errorf("response shows the check's error: %s", body)
+	}
+
+	if got := calls.Load(); got != 2 {
+		t.Errorf("check ran %d times, want only on the 2 runs", got)
+	}
+
+	c.Shutdown()
+	code, response, _ = readiness(t, c)
+	if code != http.StatusServiceUnavailable || response.Status != "shutting down" {
+		t.Errorf("after shutdown: %d %+v", code, response)
+	}
+}
@@ -0,0 +1,124 @@
+// Package lifecycle runs the servers and background workers of a process
+// together and stops them in order when the process shuts down.
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+type Config struct {
+	// DrainDelay is how long to keep serving after the shutdown hooks have
+	// run, so load balancers notice the process is no longer ready before
+	// its listeners close.
+	DrainDelay time.Duration
+	// Timeout bounds stopping every component, the drain delay excluded.
+	Timeout time.Duration
+}
+
+type component struct {
+	name string
+	run  func(ctx context.Context) error
+	stop func(ctx context.Context) error
+}
+
+// Manager starts its components together and stops them in the reverse of
+// the order they were added, so a component can rely on everything added
+// before it until it has stopped.
+type Manager struct {
+	config     Config
+	components []component
+	hooks      []func()
+}
+
+func New(config Config) *Manager {
+	if config.Timeout <= 0 {
+		config.Timeout = 30 * time.Second
+	}
+
+	return &Manager{config: config}
+}
+
+// Add registers a component. run is called in its own goroutine and must
+// return once its context is cancelled. stop, if set, is called before the
+// context is cancelled and should make run return once in-flight work is
+// done, or give up when its context expires. Either may be nil: a component
+// without run, such as a database connection, is only stopped.
+func (m *Manager) Add(name string, run, stop func(ctx context.Context) error) {
+	m.components = append(m.components, component{name: name, run: run, stop: stop})
+}
+
+// OnShutdown registers a function to call as soon as shutdown starts.
+func (m *Manager) OnShutdown(hook func()) {
+	m.hooks = append(m.hooks, hook)
+}
+
+type result struct {
+	name string
+	err  error
+}
+
+// Run starts every component and blocks until ctx is cancelled or one of
+// them returns, then stops them all. It returns an error if a component
+// returned before shutdown started.
+func (m *Manager) Run(ctx context.Context) error {
+	results := make(chan result, len(m.components))
+	cancels := make([]context.CancelFunc, len(m.components))
+	done := make([]chan struct{}, len(m.components))
+
+	for i, c := range m.components {
+		runCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		cancels[i] = cancel
+		done[i] = make(chan struct{})
+
+		if c.run == nil {
+			close(done[i])
+			continue
+		}
+		go func() {
+			defer close(done[i])
+			results <- result{name: c.name, err: c.run(runCtx)}
+		}()
+	}
+
+	var err error
+	select {
+	case <-ctx.Done():
+		slog.Info("Shutting down")
+	case res := <-results:
+		if res.err != nil {
+			err = fmt.Errorf("%s failed: %w", res.name, res.err)
+		} else {
+			err = fmt.Errorf("%s stopped unexpectedly", res.name)
+		}
+		slog.Error("Shutting down", "error", err)
+	}
+
+	for _, hook := range m.hooks {
+		hook()
+	}
+	time.Sleep(m.config.DrainDelay)
+
+	stopCtx, cancel := context.WithTimeout(context.Background(), m.config.Timeout)
+	defer cancel()
+
+	for i := len(m.components) - 1; i >= 0; i-- {
+		c := m.components[i]
+		if c.stop != nil {
+			if err := c.stop(stopCtx); err != nil {
+				slog.Error("Stopping component failed", "component", c.name, "error", err)
+			}
+		}
+		cancels[i]()
+
+		select {
+		case <-done[i]:
+		case <-stopCtx.Done():
+			slog.Error("Component did not stop in time", "component", c.name)
+		}
+	}
+
+	return err
+}
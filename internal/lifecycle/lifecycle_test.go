@@ -0,0 +1,204 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recorder notes what the components of a test did, in order.
+type recorder struct {
+	mu     sync.Mutex
+	events []string
+	times  map[string]time.Time
+}
+
+func newRecorder() *recorder {
+	return &recorder{times: make(map[string]time.Time)}
+}
+
+func (r *recorder) record(event string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+	r.times[event] = time.Now()
+}
+
+func (r *recorder) recorded() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.events...)
+}
+
+// run blocks until its context is cancelled, as a server does.
+func (r *recorder) run(name string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		<-ctx.Done()
+		r.record(name + " returned")
+		return nil
+	}
+}
+
+func (r *recorder) stop(name string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		r.record(name + " stopped")
+		return nil
+	}
+}
+
+// runManager runs m until cancel is called and returns what Run returned.
+func runManager(m *Manager) (cancel func(), result <-chan error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 1)
+	go func() { errs <- m.Run(ctx) }()
+	return cancel, errs
+}
+
+func wait(t *testing.T, errs <-chan error) error {
+	t.Helper()
+	select {
+	case err := <-errs:
+		return err
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return")
+		return nil
+	}
+}
+
+func TestStopsInReverseOrder(t *testing.T) {
+	r := newRecorder()
+	m := New(Config{})
+	m.Add("storage", nil, r.stop("storage"))
+	m.Add("worker", r.run("worker"), nil)
+	m.Add("server", r.run("server"), r.stop("server"))
+	m.OnShutdown(func() { r.record("first hook") })
+	m.OnShutdown(func() { r.record("second hook") })
+
+	cancel, errs := runManager(m)
+	time.Sleep(20 * time.Millisecond)
+	if events := r.recorded(); len(events) != 0 {
+		t.Fatalf("recorded %v before shutdown", events)
+	}
+
+	cancel()
+	if err := wait(t, errs); err != nil {
+		t.Fatal(err)
+	}
+
+	// A component's run is only cancelled once its stop has returned.
+	want := []string{
+		"first hook", "second hook",
+		"server stopped", "server returned",
+		"worker returned",
+		"storage stopped",
+	}
+	if got := r.recorded(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestDrainDelay(t *testing.T) {
+	const delay = 100 * time.Millisecond
+
+	r := newRecorder()
+	m := New(Config{DrainDelay: delay})
+	m.Add("server", r.run("server"), r.stop("server"))
+	m.OnShutdown(func() { r.record("not ready") })
+
+	cancel, errs := runManager(m)
+	cancel()
+	if err := wait(t, errs); err != nil {
+		t.Fatal(err)
+	}
+
+	// The server keeps serving for the delay after it reports not ready.
+	if drained := r.times["server stopped"].Sub(r.times["not ready"]); drained < delay {
+		t.Errorf("server stopped %v after shutdown started, want at least %v", drained, delay)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	const timeout = 100 * time.Millisecond
+
+	r := newRecorder()
+	stuck := make(chan struct{})
+	defer close(stuck)
+
+	m := New(Config{Timeout: timeout})
+	m.Add("storage", nil, r.stop("storage"))
+	// Neither the stop nor the run of this one returns by itself.
+	m.Add("stuck", func(ctx context.Context) error {
+		<-stuck
+		return nil
+	}, func(ctx context.Context) error {
+		<-ctx.Done()
+		r.record("stuck gave up")
+		return ctx.Err()
+	})
+
+	cancel, errs := runManager(m)
+	started := time.Now()
+	cancel()
+	if err := wait(t, errs); err != nil {
+		t.Fatal(err)
+	}
+
+	if elapsed := time.Since(started); elapsed < timeout || elapsed > timeout+time.Second {
+		t.Errorf("Run returned after %v, want about the %v timeout", elapsed, timeout)
+	}
+	want := []string{"stuck gave up", "storage stopped"}
+	if got := r.recorded(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestComponentFailureStopsTheRest(t *testing.T) {
+	errListen := errors.New("listen tcp :50051: address already in use")
+
+	tests := []struct {
+		name    string
+		err     error
+		wantErr string
+	}{
+		{"failed", errListen, "grpc server failed"},
+		{"returned", nil, "grpc server stopped unexpectedly"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newRecorder()
+			m := New(Config{})
+			m.Add("storage", nil, r.stop("storage"))
+			m.Add("worker", r.run("worker"), nil)
+			m.Add("grpc server", func(ctx context.Context) error {
+				return tt.err
+			}, r.stop("grpc server"))
+			m.Add("connect server", r.run("connect server"), r.stop("connect server"))
+			m.OnShutdown(func() { r.record("hook") })
+
+			// Nothing cancels the context: the failure alone shuts down.
+			err := m.Run(context.Background())
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("got error %v, want %q", err, tt.wantErr)
+			}
+			if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Errorf("error %v does not wrap %v", err, tt.err)
+			}
+
+			want := []string{
+				"hook",
+				"connect server stopped", "connect server returned",
+				"grpc server stopped",
+				"worker returned",
+				"storage stopped",
+			}
+			if got := r.recorded(); !reflect.DeepEqual(got, want) {
+				t.Errorf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+// Package logging sets up log/slog. Records carry the attributes of the call
+// they were logged in, such as its request id, user id and procedure, and
+// pass through a redaction layer that keeps keys, passwords and tokens out
+// of the output.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Attribute keys set on the records of a call.
+const (
+	RequestIDKey = "request_id"
+	UserIDKey    = "user_id"
+	ProcedureKey = "procedure"
+)
+
+// Formats.
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+type Config struct {
+	// Level is debug, info, warn or error.
+	Level string
+	// Format is FormatJSON or FormatText.
+	Format string
+}
+
+// New returns a logger writing to w as config says.
+func New(w io.Writer, config Config) (*slog.Logger, error) {
+	var level slog.Level
+	if config.Level != "" {
+		if err := level.UnmarshalText([]byte(config.Level)); err != nil {
+			return nil, fmt.Errorf("unknown log level %q", config.Level)
+		}
+	}
+
+	opts := &slog.HandlerOptions{Level: level, ReplaceAttr: redactAttr}
+	var handler slog.Handler
+	switch config.Format {
+	case FormatJSON, "":
+		handler = slog.NewJSONHandler(w, opts)
+	case FormatText:
+		handler = slog.NewTextHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("unknown log format %q", config.Format)
+	}
+
+	return slog.New(contextHandler{handler}), nil
+}
+
+// scope holds the attributes of a call. It is shared by every context
+// derived from the one it was created in, so attributes added deep in the
+// call, such as the user id once the token is checked, are seen by the
+// records logged on the way out too.
+type scope struct {
+	mu    sync.Mutex
+	attrs []slog.Attr
+}
+
+type scopeKey struct{}
+
+// NewContext starts a scope for a call with attrs. Every record logged with
+// the returned context, or one derived from it, carries them.
+func NewContext(ctx context.Context, attrs ...slog.Attr) context.Context {
+	return context.WithValue(ctx, scopeKey{}, &scope{attrs: attrs})
+}
+
+// Add adds attrs to the scope of ctx. It does nothing outside a scope.
+func Add(ctx context.Context, attrs ...slog.Attr) {
+	s, ok := ctx.Value(scopeKey{}).(*scope)
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attrs = append(s.attrs, attrs...)
+}
+
+// contextHandler adds the scope's attributes and the trace and span ids of
+// the context to every record.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if s, ok := ctx.Value(scopeKey{}).(*scope); ok {
+		s.mu.Lock()
+		r.AddAttrs(s.attrs...)
+		s.mu.Unlock()
+	}
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		r.AddAttrs(slog.String("trace_id", span.TraceID().String()), slog.String("span_id", span.SpanID().String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
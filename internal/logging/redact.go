@@ -0,0 +1,69 @@
+package logging
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+// sensitiveKeys are looked for in attribute keys, lower cased and with
+// dashes taken for underscores. The whole value of a matching attribute is
+// replaced.
+var sensitiveKeys = []string{"password", "secret", "token", "private_key", "privatekey", "authorization", "cookie"}
+
+// secretPatterns are replaced wherever they appear in a message or a value,
+// for secrets that end up in error messages or formatted structs.
+var secretPatterns = []*regexp.Regexp{
+	// JSON web tokens.
+	regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`),
+	// Credentials in an Authorization header.
+	regexp.MustCompile(`(?i)\b(bearer|basic)\s+[^\s"']+`),
+	// WireGuard keys, 32 bytes in base64. Public keys look the same and are
+	// hidden too.
+	regexp.MustCompile(`[A-Za-z0-9+/]{42}[AEIMQUYcgkosw048]=`),
+	// bcrypt password hashes.
+	regexp.MustCompile(`\$2[abxy]?\$\d{2}\$[./A-Za-z0-9]{53}`),
+}
+
+// redactAttr is the ReplaceAttr of every handler New returns. Values that
+// are neither strings nor numbers, bools, times or durations are logged as
+// their redacted string form, so a struct cannot carry a secret past it.
+func redactAttr(groups []string, a slog.Attr) slog.Attr {
+	if len(groups) == 0 && (a.Key == slog.TimeKey || a.Key == slog.LevelKey || a.Key == slog.SourceKey) {
+		return a
+	}
+	if sensitiveKey(a.Key) {
+		return slog.String(a.Key, redacted)
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, redactString(a.Value.String()))
+	case slog.KindAny:
+		if err, ok := a.Value.Any().(error); ok {
+			return slog.String(a.Key, redactString(err.Error()))
+		}
+		return slog.String(a.Key, redactString(fmt.Sprintf("%+v", a.Value.Any())))
+	}
+	return a
+}
+
+func sensitiveKey(key string) bool {
+	key = strings.ReplaceAll(strings.ToLower(key), "-", "_")
+	for _, sensitive := range sensitiveKeys {
+		if strings.Contains(key, sensitive) {
+			return true
+		}
+	}
+	return false
+}
+
+func redactString(s string) string {
+	for _, pattern := range secretPatterns {
+		s = pattern.ReplaceAllString(s, redacted)
+	}
+	return s
+}
@@ -0,0 +1,187 @@
+// Package metrics defines the Prometheus metrics the server exports on
+// /metrics. Every metric is registered on Registry, together with the Go
+// runtime and process collectors.
+//
+// RPCs, from gRPC, Connect and the REST gateway alike:
+//
+//	vpn_rpc_requests_total{procedure, code}          counter
+//	vpn_rpc_request_duration_seconds{procedure}      histogram
+//
+// MongoDB commands:
+//
+//	vpn_mongo_command_duration_seconds{command, outcome}  histogram
+//
+// Servers, sampled by ServerSampler:
+//
+//	vpn_server_peers{server_id, server_name}                      gauge
+//	vpn_server_ip_pool_utilization_ratio{server_id, server_name}  gauge
+//
+// Domain events:
+//
+//	vpn_config_generations_total{result}  counter
+//	vpn_key_rotations_total{trigger}      counter
+//	vpn_logins_total{result}              counter
+//
+// Audit log:
+//
+//	vpn_audit_events_pending      gauge
+//	vpn_audit_events_lost_total   counter
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc/codes"
+)
+
+// Path is where Handler is mounted.
+const Path = "/metrics"
+
+// Values of the result label.
+const (
+	ResultSuccess = "success"
+	// ResultFailure is a request refused for a reason of the caller's, such
+	// as a wrong password.
+	ResultFailure = "failure"
+	// ResultError is a request that failed on our side.
+	ResultError = "error"
+)
+
+var Registry = prometheus.NewRegistry()
+
+var (
+	// rpcRequests counts finished calls by full method name and status
+	// code name, e.g. "/vpn.UserService/Login" and "Unauthenticated".
+	rpcRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "vpn_rpc_requests_total",
+		Help: "RPCs finished, by procedure and status code.",
+	}, []string{"procedure", "code"})
+
+	// rpcDuration is the time from a call reaching the middleware chain to
+	// its handler returning; for streams, the life of the stream.
+	rpcDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "vpn_rpc_request_duration_seconds",
+		Help:    "Time taken by RPCs, by procedure.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"procedure"})
+
+	// mongoCommandDuration times commands sent to MongoDB by command name,
+	// e.g. "find" or "insert", and outcome, ResultSuccess or ResultError.
+	mongoCommandDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "vpn_mongo_command_duration_seconds",
+		Help:    "Time taken by MongoDB commands, by command and outcome.",
+		Buckets: prometheus.ExponentialBuckets(0.0005, 2, 14),
+	}, []string{"command", "outcome"})
+
+	// serverPeers is the number of peers allocated on each server.
+	serverPeers = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "vpn_server_peers",
+		Help: "Peers allocated on a server.",
+	}, []string{"server_id", "server_name"})
+
+	// serverPoolUtilization is the share of the client network's assignable
+	// addresses in use on each server, from 0 to 1.
+	serverPoolUtilization = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "vpn_server_ip_pool_utilization_ratio",
+		Help: "Share of a server's client addresses in use.",
+	}, []string{"server_id", "server_name"})
+
+	// configGenerations counts GenerateConfig calls by result.
+	configGenerations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "vpn_config_generations_total",
+		Help: "Client configs generated, by result.",
+	}, []string{"result"})
+
+	// keyRotations counts peer key pairs replaced, by trigger, "user" or
+	// "scheduler".
+	keyRotations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "vpn_key_rotations_total",
+		Help: "Peer keys rotated, by trigger.",
+	}, []string{"trigger"})
+
+	// logins counts login attempts by result; wrong credentials are a
+	// ResultFailure.
+	logins = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "vpn_logins_total",
+		Help: "Login attempts, by result.",
+	}, []string{"result"})
+
+	// auditPending is the number of audit events held in memory because
+	// storage refused them. Anything above zero needs attention.
+	auditPending = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "vpn_audit_events_pending",
+		Help: "Audit events waiting to be written.",
+	})
+
+	auditLost = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "vpn_audit_events_lost_total",
+		Help: "Audit events discarded because too many were waiting to be written.",
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		rpcRequests,
+		rpcDuration,
+		mongoCommandDuration,
+		serverPeers,
+		serverPoolUtilization,
+		configGenerations,
+		keyRotations,
+		logins,
+		auditPending,
+		auditLost,
+	)
+}
+
+// Handler serves the metrics in Registry.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// NewServer returns the HTTP server for Handler, listening on addr.
+func NewServer(addr string) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle(Path, Handler())
+	return &http.Server{Addr: addr, Handler: mux}
+}
+
+// RPCObserver records calls in vpn_rpc_requests_total and
+// vpn_rpc_request_duration_seconds. It is a middleware.Observer.
+type RPCObserver struct{}
+
+func (RPCObserver) ObserveCall(procedure string, code codes.Code, duration time.Duration) {
+	rpcRequests.WithLabelValues(procedure, code.String()).Inc()
+	rpcDuration.WithLabelValues(procedure).Observe(duration.Seconds())
+}
+
+// ConfigGenerated counts a config generation with result.
+func ConfigGenerated(result string) {
+	configGenerations.WithLabelValues(result).Inc()
+}
+
+// KeysRotated counts a key rotation made by trigger.
+func KeysRotated(trigger string) {
+	keyRotations.WithLabelValues(trigger).Inc()
+}
+
+// LoginAttempted counts a login attempt with result.
+func LoginAttempted(result string) {
+	logins.WithLabelValues(result).Inc()
+}
+
+// AuditPending sets the number of audit events waiting to be written.
+func AuditPending(n int) {
+	auditPending.Set(float64(n))
+}
+
+// AuditLost counts audit events discarded without being written.
+func AuditLost(n int) {
+	auditLost.Add(float64(n))
+}
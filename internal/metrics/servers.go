@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/shivamp1998/vpn_backend/internal/repository"
+)
+
+type ServerSamplerConfig struct {
+	// Interval is how often the server gauges are refreshed.
+	Interval time.Duration
+	// PoolSize is the number of client addresses a server can hand out.
+	PoolSize int
+}
+
+// ServerSampler keeps vpn_server_peers and
+// vpn_server_ip_pool_utilization_ratio up to date. The gauges are read from
+// storage on a timer rather than on every scrape.
+type ServerSampler struct {
+	config  ServerSamplerConfig
+	servers repository.ServerRepository
+}
+
+func NewServerSampler(config ServerSamplerConfig, servers repository.ServerRepository) *ServerSampler {
+	if config.Interval <= 0 {
+		config.Interval = 30 * time.Second
+	}
+
+	return &ServerSampler{config: config, servers: servers}
+}
+
+// Run samples the servers every Interval until ctx is cancelled.
+func (s *ServerSampler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.sample(ctx); err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "Sampling server metrics failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *ServerSampler) sample(ctx context.Context) error {
+	servers, err := s.servers.ListAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Reset drops the series of deleted or renamed servers.
+	serverPeers.Reset()
+	serverPoolUtilization.Reset()
+	for _, server := range servers {
+		id := server.Id.Hex()
+		serverPeers.WithLabelValues(id, server.Name).Set(float64(server.CurrentClients))
+		if s.config.PoolSize > 0 {
+			serverPoolUtilization.WithLabelValues(id, server.Name).Set(float64(server.CurrentClients) / float64(s.config.PoolSize))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+
+	"github.com/shivamp1998/vpn_backend/internal/auth"
+	"github.com/shivamp1998/vpn_backend/internal/logging"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Authenticate requires a valid bearer token on every procedure except the
+// public ones, and puts the token's user id, email and role in the context.
+func Authenticate(publicProcedures ...string) Middleware {
+	public := make(map[string]bool, len(publicProcedures))
+	for _, procedure := range publicProcedures {
+		public[procedure] = true
+	}
+
+	return func(next Handler) Handler {
+		return func(ctx context.Context, call *Call) error {
+			if public[call.Procedure] {
+				return next(ctx, call)
+			}
+
+			token, err := bearerToken(call.Header.Get("Authorization"))
+			if err != nil {
+				return status.Errorf(codes.Unauthenticated, "authentication required: %v", err)
+			}
+
+			claims, err := auth.ValidateToken(token)
+			if err != nil {
+				return status.Errorf(codes.Unauthenticated, "invalid token")
+			}
+
+			logging.Add(ctx, slog.String(logging.UserIDKey, claims.UserId.Hex()))
+			ctx = context.WithValue(ctx, auth.UserIdKey, claims.UserId)
+			ctx = context.WithValue(ctx, auth.UserEmailKey, claims.Email)
+			ctx = context.WithValue(ctx, auth.UserRoleKey, claims.Role)
+			return next(ctx, call)
+		}
+	}
+}
+
+func bearerToken(header string) (string, error) {
+	if header == "" {
+		return "", errors.New("authorization header missing")
+	}
+
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || scheme != "Bearer" || token == "" || strings.Contains(token, " ") {
+		return "", errors.New("invalid authorization header format")
+	}
+	return token, nil
+}
+
+// Authorize requires a role for some procedures. Rules map a full method
+// ("/vpn.ServerService/WatchPeers") or a whole service ("/vpn.AuditService/")
+// to the role it needs; procedures without a rule are open to any
+// authenticated caller. It must run after Authenticate.
+func Authorize(rules map[string]string) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, call *Call) error {
+			role, ok := rules[call.Procedure]
+			if !ok {
+				service, _, _ := strings.Cut(strings.TrimPrefix(call.Procedure, "/"), "/")
+				role, ok = rules["/"+service+"/"]
+			}
+
+			if ok {
+				if callerRole, _ := ctx.Value(auth.UserRoleKey).(string); callerRole != role {
+					return status.Errorf(codes.PermissionDenied, "%s role required", role)
+				}
+			}
+			return next(ctx, call)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"connectrpc.com/connect"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// ConnectInterceptor adapts the chain for connect.WithInterceptors.
+// Middleware returns gRPC status errors; they are converted to Connect
+// errors with ConnectError.
+func (c Chain) ConnectInterceptor() connect.Interceptor {
+	return connectInterceptor{chain: c}
+}
+
+type connectInterceptor struct {
+	chain Chain
+}
+
+func (i connectInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		if req.Spec().IsClient {
+			return next(ctx, req)
+		}
+
+		// The response does not exist until the handler returns, so headers
+		// are collected and copied onto whatever comes back.
+		responseHeader := http.Header{}
+		call := &Call{
+			Procedure:         req.Spec().Procedure,
+			Peer:              req.Peer().Addr,
+			Header:            req.Header(),
+			setResponseHeader: responseHeader.Set,
+		}
+
+		var resp connect.AnyResponse
+		err := i.chain.Then(func(ctx context.Context, call *Call) error {
+			if err := call.received(req.Any()); err != nil {
+				return err
+			}
+
+			var err error
+			resp, err = next(ctx, req)
+			return err
+		})(ctx, call)
+
+		err = ConnectError(err)
+		copyHeader(responseHeader, resp, err)
+		return resp, err
+	}
+}
+
+func (connectInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+func (i connectInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		call := &Call{
+			Procedure:         conn.Spec().Procedure,
+			Streaming:         true,
+			Peer:              conn.Peer().Addr,
+			Header:            conn.RequestHeader(),
+			setResponseHeader: conn.ResponseHeader().Set,
+		}
+
+		err := i.chain.Then(func(ctx context.Context, call *Call) error {
+			return next(ctx, &streamingHandlerConn{StreamingHandlerConn: conn, call: call})
+		})(ctx, call)
+		return ConnectError(err)
+	}
+}
+
+// ConnectError converts a gRPC status error to a Connect error with the same
+// code, message and details. Other errors are returned as they are.
+func ConnectError(err error) error {
+	var connectErr *connect.Error
+	if err == nil || errors.As(err, &connectErr) {
+		return err
+	}
+
+	st, ok := status.FromError(err)
+	if !ok || st.Code() == codes.Unknown {
+		return err
+	}
+
+	connectErr = connect.NewError(connect.Code(st.Code()), errors.New(st.Message()))
+	for _, detail := range st.Details() {
+		msg, ok := detail.(proto.Message)
+		if !ok {
+			continue
+		}
+		if errorDetail, err := connect.NewErrorDetail(msg); err == nil {
+			connectErr.AddDetail(errorDetail)
+		}
+	}
+	return connectErr
+}
+
+// streamingHandlerConn runs the call's receive checks on a stream.
+type streamingHandlerConn struct {
+	connect.StreamingHandlerConn
+	call *Call
+}
+
+func (c *streamingHandlerConn) Receive(msg any) error {
+	if err := c.StreamingHandlerConn.Receive(msg); err != nil {
+		return err
+	}
+	return c.call.received(msg)
+}
+
+func copyHeader(header http.Header, resp connect.AnyResponse, err error) {
+	// On error resp may be a nil *connect.Response inside a non-nil
+	// interface, so it is only touched on success.
+	var target http.Header
+	var connectErr *connect.Error
+	switch {
+	case errors.As(err, &connectErr):
+		target = connectErr.Meta()
+	case err == nil && resp != nil:
+		target = resp.Header()
+	default:
+		return
+	}
+	for key, values := range header {
+		target[key] = values
+	}
+}
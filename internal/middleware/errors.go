@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrorMapper turns an application error into a status with a message and
+// details fit for the caller, or returns nil if it does not know the error.
+type ErrorMapper func(err error) *status.Status
+
+// Errors gives every error a handler returns a status code, so both
+// transports report the same code and details. Errors that already have a
+// code pass through. Anything mapper does not know is logged and replaced by
+// a generic Internal error: its message may hold details the caller must not
+// see.
+func Errors(mapper ErrorMapper) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, call *Call) error {
+			err := next(ctx, call)
+			if err == nil || Code(err) != codes.Unknown {
+				return err
+			}
+
+			switch {
+			case errors.Is(err, context.Canceled):
+				return status.Error(codes.Canceled, "request canceled")
+			case errors.Is(err, context.DeadlineExceeded):
+				return status.Error(codes.DeadlineExceeded, "deadline exceeded")
+			}
+
+			if st := mapper(err); st != nil {
+				return st.Err()
+			}
+
+			slog.ErrorContext(ctx, "Internal error", "error", err)
+			return internalError(ctx)
+		}
+	}
+}
+
+// internalError is what the caller gets instead of an error it must not
+// see. The request id lets them point us at the logged one.
+func internalError(ctx context.Context) error {
+	st := status.New(codes.Internal, "internal error")
+	if id := RequestIDFromContext(ctx); id != "" {
+		if detailed, err := st.WithDetails(&errdetails.RequestInfo{RequestId: id}); err == nil {
+			st = detailed
+		}
+	}
+	return st.Err()
+}
@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"connectrpc.com/connect"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var errMapped = errors.New("mapped")
+
+func testMapper(err error) *status.Status {
+	if errors.Is(err, errMapped) {
+		st, _ := status.New(codes.NotFound, "not found").WithDetails(&errdetails.ErrorInfo{Reason: "MAPPED"})
+		return st
+	}
+	return nil
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		requestId   string
+		code        codes.Code
+		message     string
+		connectCode connect.Code
+	}{
+		{"nil", nil, "", codes.OK, "", 0},
+		{"status passes", status.Error(codes.PermissionDenied, "admin only"), "", codes.PermissionDenied, "admin only", connect.CodePermissionDenied},
+		{"connect error passes", connect.NewError(connect.CodeUnavailable, errors.New("draining")), "", codes.Unavailable, "", connect.CodeUnavailable},
+		{"canceled", fmt.Errorf("query: %w", context.Canceled), "", codes.Canceled, "request canceled", connect.CodeCanceled},
+		{"deadline", fmt.Errorf("query: %w", context.DeadlineExceeded), "", codes.DeadlineExceeded, "deadline exceeded", connect.CodeDeadlineExceeded},
+		{"mapped", fmt.Errorf("lookup: %w", errMapped), "", codes.NotFound, "not found", connect.CodeNotFound},
+		{"unknown", errors.New("dial tcp 10.0.0.5:27017: refused"), "", codes.Internal, "internal error", connect.CodeInternal},
+		{"unknown with request id", errors.New("dial tcp 10.0.0.5:27017: refused"), "req-1", codes.Internal, "internal error", connect.CodeInternal},
+	}
+
+	for _, tt := range tests {
+		ctx := context.Background()
+		if tt.requestId != "" {
+			ctx = context.WithValue(ctx, requestIDKey{}, tt.requestId)
+		}
+
+		err := Errors(testMapper)(func(ctx context.Context, call *Call) error {
+			return tt.err
+		})(ctx, &Call{})
+
+		if got := Code(err); got != tt.code {
+			t.Errorf("%s: code %s, want %s", tt.name, got, tt.code)
+			continue
+		}
+		if err == nil {
+			continue
+		}
+		if tt.message != "" && status.Convert(err).Message() != tt.message {
+			t.Errorf("%s: message %q, want %q", tt.name, status.Convert(err).Message(), tt.message)
+		}
+		if got := connect.CodeOf(ConnectError(err)); got != tt.connectCode {
+			t.Errorf("%s: Connect code %s, want %s", tt.name, got, tt.connectCode)
+		}
+
+		var requestInfo *errdetails.RequestInfo
+		var errorInfo *errdetails.ErrorInfo
+		for _, detail := range status.Convert(err).Details() {
+			switch detail := detail.(type) {
+			case *errdetails.RequestInfo:
+				requestInfo = detail
+			case *errdetails.ErrorInfo:
+				errorInfo = detail
+			}
+		}
+		if tt.requestId != "" && (requestInfo == nil || requestInfo.RequestId != tt.requestId) {
+			t.Errorf("%s: RequestInfo %v, want request id %s", tt.name, requestInfo, tt.requestId)
+		}
+		if tt.requestId == "" && requestInfo != nil {
+			t.Errorf("%s: unexpected RequestInfo %v", tt.name, requestInfo)
+		}
+		if (tt.name == "mapped") != (errorInfo != nil && errorInfo.Reason == "MAPPED") {
+			t.Errorf("%s: ErrorInfo %v", tt.name, errorInfo)
+		}
+	}
+}
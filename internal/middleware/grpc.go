@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// UnaryServerInterceptor adapts the chain for grpc.ChainUnaryInterceptor.
+func (c Chain) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		call := grpcCall(ctx, info.FullMethod, false)
+		call.setResponseHeader = func(key, value string) {
+			grpc.SetHeader(ctx, metadata.Pairs(key, value))
+		}
+
+		var resp any
+		err := c.Then(func(ctx context.Context, call *Call) error {
+			if err := call.received(req); err != nil {
+				return err
+			}
+
+			var err error
+			resp, err = handler(ctx, req)
+			return err
+		})(ctx, call)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor adapts the chain for grpc.ChainStreamInterceptor.
+func (c Chain) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		call := grpcCall(ss.Context(), info.FullMethod, true)
+		call.setResponseHeader = func(key, value string) {
+			ss.SetHeader(metadata.Pairs(key, value))
+		}
+
+		return c.Then(func(ctx context.Context, call *Call) error {
+			return handler(srv, &serverStream{ServerStream: ss, ctx: ctx, call: call})
+		})(ss.Context(), call)
+	}
+}
+
+func grpcCall(ctx context.Context, procedure string, streaming bool) *Call {
+	call := &Call{Procedure: procedure, Streaming: streaming, Header: http.Header{}}
+
+	if p, ok := peer.FromContext(ctx); ok {
+		call.Peer = p.Addr.String()
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for key, values := range md {
+			for _, value := range values {
+				call.Header.Add(key, value)
+			}
+		}
+	}
+	return call
+}
+
+// serverStream replaces the context of a stream, which is how a stream
+// interceptor passes values on to the handler, and runs the call's receive
+// checks.
+type serverStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	call *Call
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *serverStream) RecvMsg(msg any) error {
+	if err := s.ServerStream.RecvMsg(msg); err != nil {
+		return err
+	}
+	return s.call.received(msg)
+}
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/shivamp1998/vpn_backend/internal/logging"
+)
+
+// Logging starts the logging scope of a call, so everything logged during
+// it carries its request id and procedure, and writes one record per call
+// with its outcome and duration. Calls that failed on our side are logged
+// as errors.
+func Logging() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, call *Call) error {
+			ctx = logging.NewContext(ctx,
+				slog.String(logging.RequestIDKey, RequestIDFromContext(ctx)),
+				slog.String(logging.ProcedureKey, call.Procedure),
+			)
+
+			started := time.Now()
+			err := next(ctx, call)
+
+			code := Code(err)
+			level := slog.LevelInfo
+			if serverFault(code) {
+				level = slog.LevelError
+			}
+			slog.Log(ctx, level, "RPC finished",
+				"code", code.String(),
+				"duration_ms", float64(time.Since(started).Microseconds())/1000,
+				"peer", call.PeerHost(),
+			)
+			return err
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc/codes"
+)
+
+// Observer receives the outcome of every call.
+type Observer interface {
+	ObserveCall(procedure string, code codes.Code, duration time.Duration)
+}
+
+// Metrics reports every call to observer. A nil observer disables it.
+func Metrics(observer Observer) Middleware {
+	return func(next Handler) Handler {
+		if observer == nil {
+			return next
+		}
+
+		return func(ctx context.Context, call *Call) error {
+			started := time.Now()
+			err := next(ctx, call)
+
+			observer.ObserveCall(call.Procedure, Code(err), time.Since(started))
+			return err
+		}
+	}
+}
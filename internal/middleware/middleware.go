@@ -0,0 +1,97 @@
+// Package middleware is the request pipeline shared by the gRPC server and
+// the Connect handlers. Middleware is written once against Call and adapted
+// to each transport's unary and streaming interceptors.
+package middleware
+
+import (
+	"context"
+	"net"
+	"net/http"
+
+	"connectrpc.com/connect"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Call describes one RPC independently of the transport.
+type Call struct {
+	// Procedure is the full method name, e.g. "/vpn.UserService/Login".
+	Procedure string
+	Streaming bool
+	// Peer is the remote address as the transport reports it.
+	Peer string
+	// Header holds the request headers, or the gRPC metadata with canonical
+	// keys.
+	Header http.Header
+
+	setResponseHeader func(key, value string)
+	receiveChecks     []func(msg any) error
+}
+
+// SetResponseHeader adds a header (gRPC: metadata) to the response. On
+// streams it only takes effect before the first message is sent.
+func (c *Call) SetResponseHeader(key, value string) {
+	if c.setResponseHeader != nil {
+		c.setResponseHeader(key, value)
+	}
+}
+
+// OnReceive makes check run on every request message before the handler
+// gets it. If check fails, a unary call fails with its error and a stream's
+// receive returns it.
+func (c *Call) OnReceive(check func(msg any) error) {
+	c.receiveChecks = append(c.receiveChecks, check)
+}
+
+// received runs the OnReceive checks; adapters call it for each request
+// message.
+func (c *Call) received(msg any) error {
+	for _, check := range c.receiveChecks {
+		if err := check(msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PeerHost returns the host part of Peer.
+func (c *Call) PeerHost() string {
+	host, _, err := net.SplitHostPort(c.Peer)
+	if err != nil {
+		return c.Peer
+	}
+	return host
+}
+
+// Handler runs the rest of an RPC. For a stream it returns when the stream
+// ends.
+type Handler func(ctx context.Context, call *Call) error
+
+type Middleware func(next Handler) Handler
+
+// Chain is an ordered list of middleware; the first one sees the call first.
+type Chain []Middleware
+
+// Then wraps handler in every middleware of the chain.
+func (c Chain) Then(handler Handler) Handler {
+	for i := len(c) - 1; i >= 0; i-- {
+		handler = c[i](handler)
+	}
+	return handler
+}
+
+// Code returns the status code of an error from either transport. Nil is
+// OK and errors without a code are Unknown.
+func Code(err error) codes.Code {
+	if err == nil {
+		return codes.OK
+	}
+	if st, ok := status.FromError(err); ok {
+		return st.Code()
+	}
+	if code := connect.CodeOf(err); code != connect.CodeUnknown {
+		// Connect codes share their numbering with gRPC.
+		return codes.Code(code)
+	}
+	return codes.Unknown
+}
@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/shivamp1998/vpn_backend/internal/auth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RateLimiter is a token bucket per caller: each bucket holds up to burst
+// tokens and refills at rate tokens per second.
+type RateLimiter struct {
+	rate  float64
+	burst float64
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	return &RateLimiter{
+		rate:      rate,
+		burst:     float64(max(burst, 1)),
+		buckets:   make(map[string]*bucket),
+		lastSweep: time.Now(),
+	}
+}
+
+// Allow takes a token from key's bucket, reporting false if it is empty.
+func (l *RateLimiter) Allow(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, updated: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens = min(l.burst, b.tokens+now.Sub(b.updated).Seconds()*l.rate)
+	b.updated = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// sweep drops buckets that have refilled completely, which are the same as
+// no bucket, so memory stays bounded by the recently active callers.
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+
+	full := time.Duration(l.burst / l.rate * float64(time.Second))
+	for key, b := range l.buckets {
+		if now.Sub(b.updated) >= full {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// RateLimit rejects calls with ResourceExhausted once the caller's bucket is
+// empty. Authenticated callers are limited per user, others per address, so
+// it must run after Authenticate. A stream counts as one call. A nil limiter
+// disables limiting.
+func RateLimit(limiter *RateLimiter) Middleware {
+	return func(next Handler) Handler {
+		if limiter == nil {
+			return next
+		}
+
+		return func(ctx context.Context, call *Call) error {
+			key := "ip:" + call.PeerHost()
+			if userId, err := auth.GetUserIDFromContext(ctx); err == nil {
+				key = "user:" + userId.Hex()
+			}
+
+			if !limiter.Allow(key) {
+				return status.Errorf(codes.ResourceExhausted, "rate limit exceeded")
+			}
+			return next(ctx, call)
+		}
+	}
+}
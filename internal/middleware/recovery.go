@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+)
+
+// Recovery turns a panic in the rest of the chain into an Internal error,
+// logging the stack instead of crashing the process.
+func Recovery() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, call *Call) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					slog.ErrorContext(ctx, "Panic", "panic", fmt.Sprint(r), "stack", string(debug.Stack()))
+					err = internalError(ctx)
+				}
+			}()
+
+			return next(ctx, call)
+		}
+	}
+}
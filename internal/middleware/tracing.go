@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"context"
+	"strings"
+
+	"github.com/shivamp1998/vpn_backend/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/codes"
+)
+
+var tracer = otel.Tracer("github.com/shivamp1998/vpn_backend/internal/middleware")
+
+// Tracing starts a server span for every call, as a child of the trace the
+// caller sent in its headers if there is one. The span is named after the
+// procedure without its leading slash, e.g. "vpn.UserService/Login".
+func Tracing() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, call *Call) error {
+			ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(call.Header))
+
+			service, method, _ := strings.Cut(strings.TrimPrefix(call.Procedure, "/"), "/")
+			ctx, span := tracer.Start(ctx, strings.TrimPrefix(call.Procedure, "/"),
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					attribute.String("rpc.service", service),
+					attribute.String("rpc.method", method),
+					tracing.ProcedureKey.String(call.Procedure),
+					tracing.RequestIDKey.String(RequestIDFromContext(ctx)),
+				),
+			)
+			defer span.End()
+
+			err := next(ctx, call)
+
+			code := Code(err)
+			span.SetAttributes(attribute.Int("rpc.grpc.status_code", int(code)))
+			if serverFault(code) {
+				span.RecordError(err)
+				span.SetStatus(otelcodes.Error, err.Error())
+			}
+			return err
+		}
+	}
+}
+
+// serverFault reports whether code means the call failed on our side, as
+// opposed to being refused because of the request.
+func serverFault(code codes.Code) bool {
+	switch code {
+	case codes.Unknown, codes.DeadlineExceeded, codes.Unimplemented, codes.Internal, codes.Unavailable, codes.DataLoss:
+		return true
+	}
+	return false
+}
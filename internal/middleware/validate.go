@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"context"
+
+	"buf.build/go/protovalidate"
+	"google.golang.org/protobuf/proto"
+)
+
+// Validate checks every request message against the protovalidate rules of
+// its proto definition. A message that breaks them fails with the
+// *protovalidate.ValidationError, for Errors to map to InvalidArgument.
+func Validate(validator protovalidate.Validator) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, call *Call) error {
+			call.OnReceive(func(msg any) error {
+				if msg, ok := msg.(proto.Message); ok {
+					return validator.Validate(msg)
+				}
+				return nil
+			})
+			return next(ctx, call)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	AuditOutcomeSuccess = "success"
+	AuditOutcomeFailure = "failure"
+	AuditOutcomeDenied  = "denied"
+)
+
+const (
+	AuditActionUserRegister     = "user.register"
+	AuditActionUserLogin        = "user.login"
+	AuditActionUserSetRole      = "user.set_role"
+	AuditActionServerCreate     = "server.create"
+	AuditActionServerUpdate     = "server.update"
+	AuditActionConfigGenerate   = "config.generate"
+	AuditActionKeysRotate       = "keys.rotate"
+	AuditActionKeysFlagRotation = "keys.flag_rotation"
+	AuditActionKeysRevoke       = "keys.revoke"
+	AuditActionShareLinkCreate  = "share_link.create"
+	AuditActionShareLinkRedeem  = "share_link.redeem"
+	AuditActionAuditList        = "audit.list"
+	AuditActionEventRequeue     = "event.requeue"
+	AuditActionWebhookCreate    = "webhook.create"
+	AuditActionWebhookDelete    = "webhook.delete"
+	AuditActionWebhookTest      = "webhook.test"
+	AuditActionRequestDenied    = "request.denied"
+)
+
+const (
+	AuditTargetUser      = "user"
+	AuditTargetServer    = "server"
+	AuditTargetKeys      = "wireguard_keys"
+	AuditTargetShareLink = "share_link"
+	AuditTargetEvent     = "outbox_event"
+	AuditTargetWebhook   = "webhook"
+)
+
+// AuditEvent is one entry of the append-only audit log. Sequence orders the
+// log; when hash chaining is enabled Hash covers the entry and PrevHash, so
+// editing or removing an entry breaks every hash after it.
+type AuditEvent struct {
+	Id         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Sequence   int64              `bson:"sequence" json:"sequence"`
+	Time       time.Time          `bson:"time" json:"time"`
+	ActorId    primitive.ObjectID `bson:"actor_id,omitempty" json:"actor_id,omitempty"`
+	ActorEmail string             `bson:"actor_email,omitempty" json:"actor_email,omitempty"`
+	Action     string             `bson:"action" json:"action"`
+	TargetType string             `bson:"target_type,omitempty" json:"target_type,omitempty"`
+	TargetId   string             `bson:"target_id,omitempty" json:"target_id,omitempty"`
+	Procedure  string             `bson:"procedure,omitempty" json:"procedure,omitempty"`
+	SourceIp   string             `bson:"source_ip,omitempty" json:"source_ip,omitempty"`
+	UserAgent  string             `bson:"user_agent,omitempty" json:"user_agent,omitempty"`
+	Outcome    string             `bson:"outcome" json:"outcome"`
+	Reason     string             `bson:"reason,omitempty" json:"reason,omitempty"`
+	PrevHash   string             `bson:"prev_hash,omitempty" json:"prev_hash,omitempty"`
+	Hash       string             `bson:"hash,omitempty" json:"hash,omitempty"`
+}
@@ -0,0 +1,41 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	ShareLinkKindConfig = "conf"
+	ShareLinkKindQRCode = "qr"
+)
+
+type ConfigShareLink struct {
+	Id        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	TokenHash string             `bson:"token_hash" json:"-"`
+	UserId    primitive.ObjectID `bson:"user_id" json:"user_id"`
+	ServerId  primitive.ObjectID `bson:"server_id" json:"server_id"`
+	Kind      string             `bson:"kind" json:"kind"`
+	ExpiresAt time.Time          `bson:"expires_at" json:"expires_at"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	UsedAt    *time.Time         `bson:"used_at,omitempty" json:"used_at,omitempty"`
+}
+
+const (
+	ShareRetrievalSuccess     = "success"
+	ShareRetrievalNotFound    = "not_found"
+	ShareRetrievalExpired     = "expired"
+	ShareRetrievalAlreadyUsed = "already_used"
+	ShareRetrievalFailed      = "failed"
+)
+
+type ConfigShareRetrieval struct {
+	Id        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	LinkId    primitive.ObjectID `bson:"link_id,omitempty" json:"link_id,omitempty"`
+	UserId    primitive.ObjectID `bson:"user_id,omitempty" json:"user_id,omitempty"`
+	Outcome   string             `bson:"outcome" json:"outcome"`
+	SourceIp  string             `bson:"source_ip" json:"source_ip"`
+	UserAgent string             `bson:"user_agent" json:"user_agent"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
@@ -0,0 +1,22 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	NotificationKeysRotated         = "keys_rotated"
+	NotificationKeyRotationRequired = "key_rotation_required"
+)
+
+type Notification struct {
+	Id        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserId    primitive.ObjectID `bson:"user_id" json:"user_id"`
+	ServerId  primitive.ObjectID `bson:"server_id,omitempty" json:"server_id,omitempty"`
+	Type      string             `bson:"type" json:"type"`
+	Message   string             `bson:"message" json:"message"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	ReadAt    *time.Time         `bson:"read_at,omitempty" json:"read_at,omitempty"`
+}
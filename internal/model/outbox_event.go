@@ -0,0 +1,46 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	EventPeerCreated         = "PeerCreated"
+	EventPeerRevoked         = "PeerRevoked"
+	EventKeysRotated         = "KeysRotated"
+	EventServerStatusChanged = "ServerStatusChanged"
+	EventUserRegistered      = "UserRegistered"
+)
+
+const (
+	OutboxStatusPending   = "pending"
+	OutboxStatusDelivered = "delivered"
+	// OutboxStatusDead marks an event that ran out of delivery attempts. It
+	// stays in the outbox until it is requeued.
+	OutboxStatusDead = "dead"
+)
+
+// OutboxEvent is a domain event stored alongside the state change that
+// caused it, and delivered to subscribers afterwards.
+type OutboxEvent struct {
+	Id          primitive.ObjectID `bson:"_id" json:"id"`
+	Type        string             `bson:"type" json:"type"`
+	AggregateId string             `bson:"aggregate_id" json:"aggregate_id"`
+	// ServerId is the server the event concerns, empty for events that
+	// concern none. Peer watches read only their server's events by it.
+	ServerId string `bson:"server_id,omitempty" json:"server_id,omitempty"`
+	// Payload is the JSON encoding of the event's fields.
+	Payload    string    `bson:"payload" json:"payload"`
+	OccurredAt time.Time `bson:"occurred_at" json:"occurred_at"`
+
+	Status        string     `bson:"status" json:"status"`
+	Attempts      int        `bson:"attempts" json:"attempts"`
+	NextAttemptAt time.Time  `bson:"next_attempt_at" json:"next_attempt_at"`
+	LastError     string     `bson:"last_error,omitempty" json:"last_error,omitempty"`
+	DeliveredAt   *time.Time `bson:"delivered_at,omitempty" json:"delivered_at,omitempty"`
+	// Delivered lists the subscribers that have handled the event, so a
+	// retry only goes to the ones that failed.
+	Delivered []string `bson:"delivered" json:"delivered"`
+}
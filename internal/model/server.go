@@ -1,11 +1,19 @@
 package model
 
 import (
+	"log/slog"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+const (
+	ServerStatusActive = "active"
+	// ServerStatusDraining keeps existing peers but takes no new ones.
+	ServerStatusDraining = "draining"
+	ServerStatusDisabled = "disabled"
+)
+
 type Server struct {
 	Id                  primitive.ObjectID `bson:"_id,omit_empty" json:"id"`
 	Name                string             `bson:"name" json:"name"`
@@ -15,6 +23,22 @@ type Server struct {
 	Region              string             `bson:"region" json:"region"`
 	MaxClients          int32              `bson:"max_clients" json:"max_clients"`
 	CurrentClients      int32              `bson:"current_clients" json:"current_clients"`
+	KeyRotationMaxAge   time.Duration      `bson:"key_rotation_max_age,omitempty" json:"key_rotation_max_age,omitempty"`
+	KeyRotationMode     string             `bson:"key_rotation_mode,omitempty" json:"key_rotation_mode,omitempty"`
+	Status              string             `bson:"status" json:"status"`
 	CreatedAt           time.Time          `bson:"created_at" json:"created_at"`
 	UpdatedAt           time.Time          `bson:"updated_at" json:"updated_at"`
+	// Version is bumped on every edit and checked by conditional updates.
+	// Allocations only move CurrentClients and leave it alone.
+	Version int64 `bson:"version" json:"version"`
+}
+
+// LogValue leaves the private key out of logs.
+func (s *Server) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("id", s.Id.Hex()),
+		slog.String("name", s.Name),
+		slog.String("endpoint", s.Endpoint),
+		slog.String("status", s.Status),
+	)
 }
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"log/slog"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -13,4 +14,17 @@ type User struct {
 	CreatedAt    time.Time          `bson:"created_at" json:"created_at"`
 	UpdatedAt    time.Time          `bson:"updated_at" json:"updated_at"`
 	IsActive     bool               `bson:"is_active" json:"is_active"`
+	Plan         string             `bson:"plan,omitempty" json:"plan,omitempty"`
+	Role         string             `bson:"role,omitempty" json:"role,omitempty"`
 }
+
+// LogValue leaves the password hash out of logs.
+func (u *User) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("id", u.Id.Hex()),
+		slog.String("email", u.Email),
+		slog.String("role", u.Role),
+	)
+}
+
+const RoleAdmin = "admin"
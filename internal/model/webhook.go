@@ -0,0 +1,46 @@
+package model
+
+import (
+	"log/slog"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Webhook subscribes an external URL to outbox events. Secret signs every
+// payload sent to it and is only shown to the admin who created it.
+type Webhook struct {
+	Id          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Url         string             `bson:"url" json:"url"`
+	Secret      string             `bson:"secret" json:"-"`
+	EventTypes  []string           `bson:"event_types" json:"event_types"`
+	Description string             `bson:"description,omitempty" json:"description,omitempty"`
+	Enabled     bool               `bson:"enabled" json:"enabled"`
+	CreatedBy   primitive.ObjectID `bson:"created_by,omitempty" json:"created_by,omitempty"`
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// LogValue leaves the signing secret out of logs.
+func (w *Webhook) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("id", w.Id.Hex()),
+		slog.String("url", w.Url),
+	)
+}
+
+// WebhookDelivery is one attempt to deliver an event to a webhook.
+type WebhookDelivery struct {
+	Id        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	WebhookId primitive.ObjectID `bson:"webhook_id" json:"webhook_id"`
+	EventId   primitive.ObjectID `bson:"event_id" json:"event_id"`
+	EventType string             `bson:"event_type" json:"event_type"`
+	Attempt   int                `bson:"attempt" json:"attempt"`
+	// Test marks deliveries sent by an admin's test fire rather than by an
+	// event.
+	Test       bool      `bson:"test,omitempty" json:"test,omitempty"`
+	Success    bool      `bson:"success" json:"success"`
+	StatusCode int       `bson:"status_code,omitempty" json:"status_code,omitempty"`
+	Error      string    `bson:"error,omitempty" json:"error,omitempty"`
+	DurationMs int64     `bson:"duration_ms" json:"duration_ms"`
+	CreatedAt  time.Time `bson:"created_at" json:"created_at"`
+}
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"log/slog"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -15,4 +16,24 @@ type WireGuardKeys struct {
 	IpAddress           string             `bson:"ip_address" json:"ip_address"`
 	CreatedAt           time.Time          `bson:"created_at" json:"created_at"`
 	LastRotatedAt       time.Time          `bson:"last_rotated_at" json:"last_rotated_at"`
+	RotationRequired    bool               `bson:"rotation_required" json:"rotation_required"`
+	RotationRequestedAt *time.Time         `bson:"rotation_requested_at,omitempty" json:"rotation_requested_at,omitempty"`
+	// Version is bumped on every write and checked by conditional updates.
+	Version int64 `bson:"version" json:"version"`
 }
+
+// LogValue leaves the key pair out of logs.
+func (k *WireGuardKeys) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("id", k.Id.Hex()),
+		slog.String("user_id", k.UserId.Hex()),
+		slog.String("server_id", k.ServerId.Hex()),
+		slog.String("ip_address", k.IpAddress),
+		slog.Int64("version", k.Version),
+	)
+}
+
+const (
+	KeyRotationModeServer = "server"
+	KeyRotationModeClient = "client"
+)
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/shivamp1998/vpn_backend/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoAuditRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoAuditRepository(db *mongo.Database) *MongoAuditRepository {
+	return &MongoAuditRepository{
+		collection: db.Collection("audit_events"),
+	}
+}
+
+func (r *MongoAuditRepository) Append(ctx context.Context, event *model.AuditEvent) error {
+	event.Id = primitive.NewObjectID()
+
+	_, err := r.collection.InsertOne(ctx, event)
+	return duplicateKeyError(err)
+}
+
+func (r *MongoAuditRepository) Last(ctx context.Context) (*model.AuditEvent, error) {
+	var event model.AuditEvent
+
+	opts := options.FindOne().SetSort(bson.D{{Key: "sequence", Value: -1}})
+	err := r.collection.FindOne(ctx, bson.M{}, opts).Decode(&event)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+func (r *MongoAuditRepository) List(ctx context.Context, filter AuditFilter, limit int) ([]*model.AuditEvent, error) {
+	query := bson.M{}
+
+	if !filter.ActorId.IsZero() {
+		query["actor_id"] = filter.ActorId
+	}
+	if filter.Action != "" {
+		query["action"] = filter.Action
+	}
+	if filter.TargetType != "" {
+		query["target_type"] = filter.TargetType
+	}
+	if filter.TargetId != "" {
+		query["target_id"] = filter.TargetId
+	}
+	if filter.Outcome != "" {
+		query["outcome"] = filter.Outcome
+	}
+
+	timeRange := bson.M{}
+	if !filter.Since.IsZero() {
+		timeRange["$gte"] = filter.Since
+	}
+	if !filter.Until.IsZero() {
+		timeRange["$lt"] = filter.Until
+	}
+	if len(timeRange) > 0 {
+		query["time"] = timeRange
+	}
+
+	if filter.BeforeSequence > 0 {
+		query["sequence"] = bson.M{"$lt": filter.BeforeSequence}
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "sequence", Value: -1}}).SetLimit(int64(limit))
+	cursor, err := r.collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var events []*model.AuditEvent
+	err = cursor.All(ctx, &events)
+	return events, err
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/shivamp1998/vpn_backend/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoConfigShareLinkRepository struct {
+	collection *mongo.Collection
+	retrievals *mongo.Collection
+}
+
+func NewMongoConfigShareLinkRepository(db *mongo.Database) *MongoConfigShareLinkRepository {
+	return &MongoConfigShareLinkRepository{
+		collection: db.Collection("config_share_links"),
+		retrievals: db.Collection("config_share_retrievals"),
+	}
+}
+
+func (r *MongoConfigShareLinkRepository) Create(ctx context.Context, link *model.ConfigShareLink) error {
+	link.Id = primitive.NewObjectID()
+	link.CreatedAt = time.Now()
+	link.UsedAt = nil
+
+	_, err := r.collection.InsertOne(ctx, link)
+	return duplicateKeyError(err)
+}
+
+func (r *MongoConfigShareLinkRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*model.ConfigShareLink, error) {
+	var link model.ConfigShareLink
+	filter := bson.M{"token_hash": tokenHash}
+
+	err := r.collection.FindOne(ctx, filter).Decode(&link)
+
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrShareLinkNotFound
+	}
+
+	return &link, err
+}
+
+// Consume marks an unused, unexpired link as used and returns it. The check
+// and the update happen in a single operation so a link can only be consumed
+// once even with concurrent requests.
+func (r *MongoConfigShareLinkRepository) Consume(ctx context.Context, tokenHash string, now time.Time) (*model.ConfigShareLink, error) {
+	var link model.ConfigShareLink
+	filter := bson.M{
+		"token_hash": tokenHash,
+		"used_at":    bson.M{"$exists": false},
+		"expires_at": bson.M{"$gt": now},
+	}
+	update := bson.M{"$set": bson.M{"used_at": now}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&link)
+
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrShareLinkNotAvailable
+	}
+
+	return &link, err
+}
+
+func (r *MongoConfigShareLinkRepository) RecordRetrieval(ctx context.Context, retrieval *model.ConfigShareRetrieval) error {
+	retrieval.Id = primitive.NewObjectID()
+	retrieval.CreatedAt = time.Now()
+
+	_, err := r.retrievals.InsertOne(ctx, retrieval)
+	return err
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// NewLockOwner returns an owner name for lock leases that is unique to this
+// process.
+func NewLockOwner() string {
+	hostname, _ := os.Hostname()
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	return fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), hex.EncodeToString(suffix))
+}
+
+// MongoLockRepository implements named leases so that background jobs run on only
+// one backend replica at a time.
+type MongoLockRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoLockRepository(db *mongo.Database) *MongoLockRepository {
+	return &MongoLockRepository{
+		collection: db.Collection("locks"),
+	}
+}
+
+// Acquire takes the lease on name for owner, or extends it if owner already
+// holds it. It returns false if another owner holds an unexpired lease.
+func (r *MongoLockRepository) Acquire(ctx context.Context, name, owner string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	filter := bson.M{
+		"_id": name,
+		"$or": bson.A{
+			bson.M{"owner": owner},
+			bson.M{"expires_at": bson.M{"$lte": now}},
+		},
+	}
+	update := bson.M{"$set": bson.M{
+		"owner":       owner,
+		"acquired_at": now,
+		"expires_at":  now.Add(ttl),
+	}}
+
+	_, err := r.collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+
+	// The upsert collides with the existing _id when someone else holds it.
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *MongoLockRepository) Release(ctx context.Context, name, owner string) error {
+	filter := bson.M{"_id": name, "owner": owner}
+	_, err := r.collection.DeleteOne(ctx, filter)
+	return err
+}
@@ -0,0 +1,865 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/shivamp1998/vpn_backend/internal/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryStore keeps every collection in process memory. It enforces the same
+// unique constraints as the Mongo indexes and returns the same errors, which
+// makes it a drop-in replacement for tests and local development.
+type MemoryStore struct {
+	mu sync.Mutex
+
+	users         map[primitive.ObjectID]model.User
+	servers       map[primitive.ObjectID]model.Server
+	serverOrder   []primitive.ObjectID
+	keys          map[primitive.ObjectID]model.WireGuardKeys
+	shareLinks    map[primitive.ObjectID]model.ConfigShareLink
+	retrievals    []model.ConfigShareRetrieval
+	notifications []model.Notification
+	locks         map[string]memoryLock
+	auditEvents   []model.AuditEvent
+	outbox        []model.OutboxEvent
+	webhooks      map[primitive.ObjectID]model.Webhook
+	deliveries    []model.WebhookDelivery
+}
+
+type memoryLock struct {
+	owner     string
+	expiresAt time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		users:      make(map[primitive.ObjectID]model.User),
+		servers:    make(map[primitive.ObjectID]model.Server),
+		keys:       make(map[primitive.ObjectID]model.WireGuardKeys),
+		shareLinks: make(map[primitive.ObjectID]model.ConfigShareLink),
+		locks:      make(map[string]memoryLock),
+		webhooks:   make(map[primitive.ObjectID]model.Webhook),
+	}
+}
+
+func NewMemoryRepositories() *Repositories {
+	return NewMemoryStore().Repositories()
+}
+
+func (m *MemoryStore) Repositories() *Repositories {
+	return &Repositories{
+		Users:         NewMemoryUserRepository(m),
+		Servers:       NewMemoryServerRepository(m),
+		Keys:          NewMemoryWireGuardKeysRepository(m),
+		ShareLinks:    NewMemoryConfigShareLinkRepository(m),
+		Notifications: NewMemoryNotificationRepository(m),
+		Locks:         NewMemoryLockRepository(m),
+		Audit:         NewMemoryAuditRepository(m),
+		Outbox:        NewMemoryOutboxRepository(m),
+		Webhooks:      NewMemoryWebhookRepository(m),
+	}
+}
+
+// Notifications returns a copy of every notification created so far.
+func (m *MemoryStore) Notifications() []model.Notification {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]model.Notification(nil), m.notifications...)
+}
+
+// addOutboxEvents stores events. Callers must hold the store lock.
+func (m *MemoryStore) addOutboxEvents(events []*model.OutboxEvent) {
+	prepareOutboxEvents(events, time.Now())
+	for _, event := range events {
+		m.outbox = append(m.outbox, *event)
+	}
+}
+
+// Retrievals returns a copy of every share link retrieval recorded so far.
+func (m *MemoryStore) Retrievals() []model.ConfigShareRetrieval {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]model.ConfigShareRetrieval(nil), m.retrievals...)
+}
+
+type MemoryUserRepository struct {
+	store *MemoryStore
+}
+
+func NewMemoryUserRepository(store *MemoryStore) *MemoryUserRepository {
+	return &MemoryUserRepository{store: store}
+}
+
+func (r *MemoryUserRepository) Create(ctx context.Context, user *model.User, events ...*model.OutboxEvent) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, existing := range r.store.users {
+		if existing.Email == user.Email {
+			return fmt.Errorf("%w: email_unique", ErrDuplicateKey)
+		}
+	}
+
+	if user.Id.IsZero() {
+		user.Id = primitive.NewObjectID()
+	}
+	user.CreatedAt = time.Now()
+	user.UpdatedAt = time.Now()
+	user.IsActive = true
+
+	r.store.users[user.Id] = *user
+	r.store.addOutboxEvents(events)
+	return nil
+}
+
+func (r *MemoryUserRepository) GetByEmail(ctx context.Context, email string) (*model.User, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, user := range r.store.users {
+		if user.Email == email {
+			return &user, nil
+		}
+	}
+	return nil, ErrUserNotFound
+}
+
+func (r *MemoryUserRepository) GetById(ctx context.Context, id primitive.ObjectID) (*model.User, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	user, ok := r.store.users[id]
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+	return &user, nil
+}
+
+func (r *MemoryUserRepository) SetRole(ctx context.Context, id primitive.ObjectID, role string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	user, ok := r.store.users[id]
+	if !ok {
+		return ErrUserNotFound
+	}
+
+	user.Role = role
+	user.UpdatedAt = time.Now()
+	r.store.users[id] = user
+	return nil
+}
+
+type MemoryServerRepository struct {
+	store *MemoryStore
+}
+
+func NewMemoryServerRepository(store *MemoryStore) *MemoryServerRepository {
+	return &MemoryServerRepository{store: store}
+}
+
+func (r *MemoryServerRepository) Create(ctx context.Context, server *model.Server) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	server.Id = primitive.NewObjectID()
+	server.CreatedAt = time.Now()
+	server.UpdatedAt = time.Now()
+	server.CurrentClients = 0
+	server.Version = 1
+
+	r.store.servers[server.Id] = *server
+	r.store.serverOrder = append(r.store.serverOrder, server.Id)
+	return nil
+}
+
+func (r *MemoryServerRepository) GetById(ctx context.Context, id primitive.ObjectID) (*model.Server, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	server, ok := r.store.servers[id]
+	if !ok {
+		return nil, ErrServerNotFound
+	}
+	return &server, nil
+}
+
+func (r *MemoryServerRepository) ListAll(ctx context.Context) ([]*model.Server, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var servers []*model.Server
+	for _, id := range r.store.serverOrder {
+		server := r.store.servers[id]
+		servers = append(servers, &server)
+	}
+	return servers, nil
+}
+
+// RecountClients has nothing to correct: the count changes under the same
+// lock as the peers.
+func (r *MemoryServerRepository) RecountClients(ctx context.Context) (int, error) {
+	return 0, nil
+}
+
+func (r *MemoryServerRepository) Update(ctx context.Context, server *model.Server, events ...*model.OutboxEvent) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	existing, ok := r.store.servers[server.Id]
+	if !ok {
+		return ErrServerNotFound
+	}
+
+	if existing.Version != server.Version {
+		return &VersionConflictError{Collection: "servers", Id: server.Id, Version: server.Version}
+	}
+
+	server.UpdatedAt = time.Now()
+	server.Version++
+	server.CurrentClients = existing.CurrentClients
+	server.CreatedAt = existing.CreatedAt
+
+	r.store.servers[server.Id] = *server
+	r.store.addOutboxEvents(events)
+	return nil
+}
+
+type MemoryWireGuardKeysRepository struct {
+	store *MemoryStore
+}
+
+func NewMemoryWireGuardKeysRepository(store *MemoryStore) *MemoryWireGuardKeysRepository {
+	return &MemoryWireGuardKeysRepository{store: store}
+}
+
+// checkUnique mirrors the user_server_unique and server_ip_unique indexes.
+// Callers must hold the store lock.
+func (r *MemoryWireGuardKeysRepository) checkUnique(keys *model.WireGuardKeys) error {
+	for id, existing := range r.store.keys {
+		if id == keys.Id || existing.ServerId != keys.ServerId {
+			continue
+		}
+
+		if existing.UserId == keys.UserId {
+			return fmt.Errorf("%w: user_server_unique", ErrDuplicateKey)
+		}
+
+		if existing.IpAddress == keys.IpAddress {
+			return fmt.Errorf("%w: server_ip_unique", ErrDuplicateKey)
+		}
+	}
+	return nil
+}
+
+func (r *MemoryWireGuardKeysRepository) Create(ctx context.Context, keys *model.WireGuardKeys) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if keys.Id.IsZero() {
+		keys.Id = primitive.NewObjectID()
+	}
+	if err := r.checkUnique(keys); err != nil {
+		return err
+	}
+
+	keys.CreatedAt = time.Now()
+	keys.LastRotatedAt = time.Now()
+	keys.Version = 1
+
+	r.store.keys[keys.Id] = *keys
+	return nil
+}
+
+func (r *MemoryWireGuardKeysRepository) Allocate(ctx context.Context, keys *model.WireGuardKeys, events ...*model.OutboxEvent) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	server, ok := r.store.servers[keys.ServerId]
+	if !ok {
+		return ErrServerNotFound
+	}
+
+	if server.CurrentClients >= server.MaxClients {
+		return ErrServerFull
+	}
+
+	if keys.Id.IsZero() {
+		keys.Id = primitive.NewObjectID()
+	}
+	if err := r.checkUnique(keys); err != nil {
+		return err
+	}
+
+	keys.CreatedAt = time.Now()
+	keys.LastRotatedAt = keys.CreatedAt
+	keys.Version = 1
+
+	server.CurrentClients++
+	r.store.servers[server.Id] = server
+	r.store.keys[keys.Id] = *keys
+	r.store.addOutboxEvents(events)
+	return nil
+}
+
+func (r *MemoryWireGuardKeysRepository) Revoke(ctx context.Context, keys *model.WireGuardKeys, events ...*model.OutboxEvent) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	existing, ok := r.store.keys[keys.Id]
+	if !ok {
+		return ErrKeysNotFound
+	}
+
+	if server, ok := r.store.servers[existing.ServerId]; ok && server.CurrentClients > 0 {
+		server.CurrentClients--
+		r.store.servers[server.Id] = server
+	}
+
+	delete(r.store.keys, keys.Id)
+	r.store.addOutboxEvents(events)
+	return nil
+}
+
+func (r *MemoryWireGuardKeysRepository) GetByUserAndServer(ctx context.Context, userId, serverId primitive.ObjectID) (*model.WireGuardKeys, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, keys := range r.store.keys {
+		if keys.UserId == userId && keys.ServerId == serverId {
+			return &keys, nil
+		}
+	}
+	return nil, ErrKeysNotFound
+}
+
+func (r *MemoryWireGuardKeysRepository) Update(ctx context.Context, keys *model.WireGuardKeys) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	existing, ok := r.store.keys[keys.Id]
+	if !ok {
+		return ErrKeysNotFound
+	}
+
+	if existing.Version != keys.Version {
+		return &VersionConflictError{Collection: "wireguard_keys", Id: keys.Id, Version: keys.Version}
+	}
+
+	if err := r.checkUnique(keys); err != nil {
+		return err
+	}
+
+	keys.LastRotatedAt = time.Now()
+	keys.Version++
+	keys.CreatedAt = existing.CreatedAt
+
+	r.store.keys[keys.Id] = *keys
+	return nil
+}
+
+func (r *MemoryWireGuardKeysRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	delete(r.store.keys, id)
+	return nil
+}
+
+func (r *MemoryWireGuardKeysRepository) GetAllByServer(ctx context.Context, serverId primitive.ObjectID) ([]*model.WireGuardKeys, error) {
+	return r.find(func(keys *model.WireGuardKeys) bool {
+		return keys.ServerId == serverId
+	}), nil
+}
+
+func (r *MemoryWireGuardKeysRepository) GetAllByServerRotatedBefore(ctx context.Context, serverId primitive.ObjectID, before time.Time) ([]*model.WireGuardKeys, error) {
+	return r.find(func(keys *model.WireGuardKeys) bool {
+		return keys.ServerId == serverId && keys.LastRotatedAt.Before(before)
+	}), nil
+}
+
+func (r *MemoryWireGuardKeysRepository) find(match func(keys *model.WireGuardKeys) bool) []*model.WireGuardKeys {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var result []*model.WireGuardKeys
+	for _, keys := range r.store.keys {
+		if match(&keys) {
+			result = append(result, &keys)
+		}
+	}
+	return result
+}
+
+func (r *MemoryWireGuardKeysRepository) Rotate(ctx context.Context, keys *model.WireGuardKeys, previousRotatedAt time.Time, events ...*model.OutboxEvent) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	existing, ok := r.store.keys[keys.Id]
+	if !ok || !existing.LastRotatedAt.Equal(previousRotatedAt) {
+		return false, nil
+	}
+
+	keys.LastRotatedAt = time.Now()
+	keys.RotationRequired = false
+	keys.RotationRequestedAt = nil
+
+	existing.PrivateKeyEncrypted = keys.PrivateKeyEncrypted
+	existing.PublicKey = keys.PublicKey
+	existing.LastRotatedAt = keys.LastRotatedAt
+	existing.RotationRequired = false
+	existing.RotationRequestedAt = nil
+	existing.Version++
+	keys.Version = existing.Version
+
+	r.store.keys[keys.Id] = existing
+	r.store.addOutboxEvents(events)
+	return true, nil
+}
+
+func (r *MemoryWireGuardKeysRepository) MarkRotationRequired(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	existing, ok := r.store.keys[id]
+	if !ok || existing.RotationRequired {
+		return false, nil
+	}
+
+	now := time.Now()
+	existing.RotationRequired = true
+	existing.RotationRequestedAt = &now
+	existing.Version++
+
+	r.store.keys[id] = existing
+	return true, nil
+}
+
+type MemoryConfigShareLinkRepository struct {
+	store *MemoryStore
+}
+
+func NewMemoryConfigShareLinkRepository(store *MemoryStore) *MemoryConfigShareLinkRepository {
+	return &MemoryConfigShareLinkRepository{store: store}
+}
+
+func (r *MemoryConfigShareLinkRepository) Create(ctx context.Context, link *model.ConfigShareLink) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, existing := range r.store.shareLinks {
+		if existing.TokenHash == link.TokenHash {
+			return fmt.Errorf("%w: token_hash_unique", ErrDuplicateKey)
+		}
+	}
+
+	link.Id = primitive.NewObjectID()
+	link.CreatedAt = time.Now()
+	link.UsedAt = nil
+
+	r.store.shareLinks[link.Id] = *link
+	return nil
+}
+
+func (r *MemoryConfigShareLinkRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*model.ConfigShareLink, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, link := range r.store.shareLinks {
+		if link.TokenHash == tokenHash {
+			return &link, nil
+		}
+	}
+	return nil, ErrShareLinkNotFound
+}
+
+func (r *MemoryConfigShareLinkRepository) Consume(ctx context.Context, tokenHash string, now time.Time) (*model.ConfigShareLink, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for id, link := range r.store.shareLinks {
+		if link.TokenHash != tokenHash {
+			continue
+		}
+
+		if link.UsedAt != nil || !link.ExpiresAt.After(now) {
+			break
+		}
+
+		usedAt := now
+		link.UsedAt = &usedAt
+		r.store.shareLinks[id] = link
+		return &link, nil
+	}
+	return nil, ErrShareLinkNotAvailable
+}
+
+func (r *MemoryConfigShareLinkRepository) RecordRetrieval(ctx context.Context, retrieval *model.ConfigShareRetrieval) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	retrieval.Id = primitive.NewObjectID()
+	retrieval.CreatedAt = time.Now()
+
+	r.store.retrievals = append(r.store.retrievals, *retrieval)
+	return nil
+}
+
+type MemoryNotificationRepository struct {
+	store *MemoryStore
+}
+
+func NewMemoryNotificationRepository(store *MemoryStore) *MemoryNotificationRepository {
+	return &MemoryNotificationRepository{store: store}
+}
+
+func (r *MemoryNotificationRepository) Create(ctx context.Context, notification *model.Notification) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	notification.Id = primitive.NewObjectID()
+	notification.CreatedAt = time.Now()
+
+	r.store.notifications = append(r.store.notifications, *notification)
+	return nil
+}
+
+type MemoryLockRepository struct {
+	store *MemoryStore
+}
+
+func NewMemoryLockRepository(store *MemoryStore) *MemoryLockRepository {
+	return &MemoryLockRepository{store: store}
+}
+
+func (r *MemoryLockRepository) Acquire(ctx context.Context, name, owner string, ttl time.Duration) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	now := time.Now()
+	lock, held := r.store.locks[name]
+	if held && lock.owner != owner && lock.expiresAt.After(now) {
+		return false, nil
+	}
+
+	r.store.locks[name] = memoryLock{owner: owner, expiresAt: now.Add(ttl)}
+	return true, nil
+}
+
+func (r *MemoryLockRepository) Release(ctx context.Context, name, owner string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if lock, held := r.store.locks[name]; held && lock.owner == owner {
+		delete(r.store.locks, name)
+	}
+	return nil
+}
+
+type MemoryAuditRepository struct {
+	store *MemoryStore
+}
+
+func NewMemoryAuditRepository(store *MemoryStore) *MemoryAuditRepository {
+	return &MemoryAuditRepository{store: store}
+}
+
+func (r *MemoryAuditRepository) Append(ctx context.Context, event *model.AuditEvent) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, existing := range r.store.auditEvents {
+		if existing.Sequence == event.Sequence {
+			return fmt.Errorf("%w: sequence_unique", ErrDuplicateKey)
+		}
+	}
+
+	event.Id = primitive.NewObjectID()
+	r.store.auditEvents = append(r.store.auditEvents, *event)
+	return nil
+}
+
+func (r *MemoryAuditRepository) Last(ctx context.Context) (*model.AuditEvent, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var last *model.AuditEvent
+	for i := range r.store.auditEvents {
+		if last == nil || r.store.auditEvents[i].Sequence > last.Sequence {
+			event := r.store.auditEvents[i]
+			last = &event
+		}
+	}
+	return last, nil
+}
+
+func (r *MemoryAuditRepository) List(ctx context.Context, filter AuditFilter, limit int) ([]*model.AuditEvent, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var events []*model.AuditEvent
+	for _, event := range r.store.auditEvents {
+		switch {
+		case !filter.ActorId.IsZero() && event.ActorId != filter.ActorId,
+			filter.Action != "" && event.Action != filter.Action,
+			filter.TargetType != "" && event.TargetType != filter.TargetType,
+			filter.TargetId != "" && event.TargetId != filter.TargetId,
+			filter.Outcome != "" && event.Outcome != filter.Outcome,
+			!filter.Since.IsZero() && event.Time.Before(filter.Since),
+			!filter.Until.IsZero() && !event.Time.Before(filter.Until),
+			filter.BeforeSequence > 0 && event.Sequence >= filter.BeforeSequence:
+			continue
+		}
+		events = append(events, &event)
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Sequence > events[j].Sequence
+	})
+
+	if len(events) > limit {
+		events = events[:limit]
+	}
+	return events, nil
+}
+
+type MemoryOutboxRepository struct {
+	store *MemoryStore
+}
+
+func NewMemoryOutboxRepository(store *MemoryStore) *MemoryOutboxRepository {
+	return &MemoryOutboxRepository{store: store}
+}
+
+func (r *MemoryOutboxRepository) Due(ctx context.Context, now time.Time, limit int) ([]*model.OutboxEvent, error) {
+	events := r.find(func(event *model.OutboxEvent) bool {
+		return event.Status == model.OutboxStatusPending && !event.NextAttemptAt.After(now)
+	})
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].OccurredAt.Before(events[j].OccurredAt)
+	})
+
+	if len(events) > limit {
+		events = events[:limit]
+	}
+	return events, nil
+}
+
+func (r *MemoryOutboxRepository) UpdateDelivery(ctx context.Context, event *model.OutboxEvent) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for i := range r.store.outbox {
+		stored := &r.store.outbox[i]
+		if stored.Id != event.Id {
+			continue
+		}
+
+		stored.Status = event.Status
+		stored.Attempts = event.Attempts
+		stored.NextAttemptAt = event.NextAttemptAt
+		stored.LastError = event.LastError
+		stored.DeliveredAt = event.DeliveredAt
+		stored.Delivered = append([]string(nil), event.Delivered...)
+		return nil
+	}
+	return nil
+}
+
+func (r *MemoryOutboxRepository) ListSince(ctx context.Context, serverId primitive.ObjectID, types []string, since time.Time, limit int) ([]*model.OutboxEvent, error) {
+	events := r.find(func(event *model.OutboxEvent) bool {
+		if event.ServerId != serverId.Hex() || event.OccurredAt.Before(since) {
+			return false
+		}
+		for _, eventType := range types {
+			if event.Type == eventType {
+				return true
+			}
+		}
+		return false
+	})
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].OccurredAt.Before(events[j].OccurredAt)
+	})
+
+	if len(events) > limit {
+		events = events[:limit]
+	}
+	return events, nil
+}
+
+func (r *MemoryOutboxRepository) ListByStatus(ctx context.Context, status string, limit int) ([]*model.OutboxEvent, error) {
+	events := r.find(func(event *model.OutboxEvent) bool {
+		return event.Status == status
+	})
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].OccurredAt.After(events[j].OccurredAt)
+	})
+
+	if len(events) > limit {
+		events = events[:limit]
+	}
+	return events, nil
+}
+
+func (r *MemoryOutboxRepository) Requeue(ctx context.Context, id primitive.ObjectID, now time.Time) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for i := range r.store.outbox {
+		stored := &r.store.outbox[i]
+		if stored.Id != id || stored.Status != model.OutboxStatusDead {
+			continue
+		}
+
+		stored.Status = model.OutboxStatusPending
+		stored.Attempts = 0
+		stored.NextAttemptAt = now
+		return true, nil
+	}
+	return false, nil
+}
+
+func (r *MemoryOutboxRepository) find(match func(event *model.OutboxEvent) bool) []*model.OutboxEvent {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var events []*model.OutboxEvent
+	for _, event := range r.store.outbox {
+		if match(&event) {
+			event.Delivered = append([]string(nil), event.Delivered...)
+			events = append(events, &event)
+		}
+	}
+	return events
+}
+
+type MemoryWebhookRepository struct {
+	store *MemoryStore
+}
+
+func NewMemoryWebhookRepository(store *MemoryStore) *MemoryWebhookRepository {
+	return &MemoryWebhookRepository{store: store}
+}
+
+func (r *MemoryWebhookRepository) Create(ctx context.Context, webhook *model.Webhook) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	webhook.Id = primitive.NewObjectID()
+	webhook.CreatedAt = time.Now()
+
+	stored := *webhook
+	stored.EventTypes = append([]string(nil), webhook.EventTypes...)
+	r.store.webhooks[webhook.Id] = stored
+	return nil
+}
+
+func (r *MemoryWebhookRepository) GetById(ctx context.Context, id primitive.ObjectID) (*model.Webhook, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	webhook, ok := r.store.webhooks[id]
+	if !ok {
+		return nil, ErrWebhookNotFound
+	}
+	webhook.EventTypes = append([]string(nil), webhook.EventTypes...)
+	return &webhook, nil
+}
+
+func (r *MemoryWebhookRepository) List(ctx context.Context) ([]*model.Webhook, error) {
+	return r.find(func(webhook *model.Webhook) bool { return true }), nil
+}
+
+func (r *MemoryWebhookRepository) ListByEventType(ctx context.Context, eventType string) ([]*model.Webhook, error) {
+	return r.find(func(webhook *model.Webhook) bool {
+		if !webhook.Enabled {
+			return false
+		}
+		for _, subscribed := range webhook.EventTypes {
+			if subscribed == eventType {
+				return true
+			}
+		}
+		return false
+	}), nil
+}
+
+func (r *MemoryWebhookRepository) find(match func(webhook *model.Webhook) bool) []*model.Webhook {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var webhooks []*model.Webhook
+	for _, webhook := range r.store.webhooks {
+		if match(&webhook) {
+			webhook.EventTypes = append([]string(nil), webhook.EventTypes...)
+			webhooks = append(webhooks, &webhook)
+		}
+	}
+
+	sort.Slice(webhooks, func(i, j int) bool {
+		if !webhooks[i].CreatedAt.Equal(webhooks[j].CreatedAt) {
+			return webhooks[i].CreatedAt.Before(webhooks[j].CreatedAt)
+		}
+		return webhooks[i].Id.Hex() < webhooks[j].Id.Hex()
+	})
+	return webhooks
+}
+
+func (r *MemoryWebhookRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.webhooks[id]; !ok {
+		return ErrWebhookNotFound
+	}
+	delete(r.store.webhooks, id)
+	return nil
+}
+
+func (r *MemoryWebhookRepository) RecordDelivery(ctx context.Context, delivery *model.WebhookDelivery) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	delivery.Id = primitive.NewObjectID()
+	if delivery.CreatedAt.IsZero() {
+		delivery.CreatedAt = time.Now()
+	}
+	r.store.deliveries = append(r.store.deliveries, *delivery)
+	return nil
+}
+
+func (r *MemoryWebhookRepository) ListDeliveries(ctx context.Context, webhookId primitive.ObjectID, limit int) ([]*model.WebhookDelivery, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var deliveries []*model.WebhookDelivery
+	for i := len(r.store.deliveries) - 1; i >= 0 && len(deliveries) < limit; i-- {
+		if delivery := r.store.deliveries[i]; delivery.WebhookId == webhookId {
+			deliveries = append(deliveries, &delivery)
+		}
+	}
+	return deliveries, nil
+}
+
+func (r *MemoryWebhookRepository) Delivered(ctx context.Context, webhookId, eventId primitive.ObjectID) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, delivery := range r.store.deliveries {
+		if delivery.WebhookId == webhookId && delivery.EventId == eventId && delivery.Success {
+			return true, nil
+		}
+	}
+	return false, nil
+}
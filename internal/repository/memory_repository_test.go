@@ -0,0 +1,14 @@
+package repository_test
+
+import (
+	"testing"
+
+	"github.com/shivamp1998/vpn_backend/internal/repository"
+	"github.com/shivamp1998/vpn_backend/internal/repository/repotest"
+)
+
+func TestMemoryRepositories(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) *repository.Repositories {
+		return repository.NewMemoryRepositories()
+	})
+}
@@ -0,0 +1,177 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/shivamp1998/vpn_backend/internal/database"
+	"github.com/shivamp1998/vpn_backend/internal/events"
+	"github.com/shivamp1998/vpn_backend/internal/model"
+	"github.com/shivamp1998/vpn_backend/internal/repository"
+	"github.com/shivamp1998/vpn_backend/internal/repository/repotest"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// newMongoDatabase returns a migrated database of its own on the server in
+// MONGODB_TEST_URI, dropped when the test ends.
+func newMongoDatabase(t *testing.T) *mongo.Database {
+	t.Helper()
+
+	uri := os.Getenv("MONGODB_TEST_URI")
+	if uri == "" {
+		t.Skip("MONGODB_TEST_URI is not set")
+	}
+
+	ctx := context.Background()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := client.Database("vpn_test_" + primitive.NewObjectID().Hex())
+	t.Cleanup(func() {
+		db.Drop(ctx)
+		client.Disconnect(ctx)
+	})
+
+	if _, err := database.MigrateMongo(ctx, db, false); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// TestMongoRepositories runs the conformance suite with a database of its
+// own for each check.
+func TestMongoRepositories(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) *repository.Repositories {
+		return repository.NewMongoRepositories(newMongoDatabase(t))
+	})
+}
+
+// TestMongoRecountClients leaks a slot the way an Allocate interrupted
+// between its two writes does, and checks that the recount gives it back.
+func TestMongoRecountClients(t *testing.T) {
+	ctx := context.Background()
+	db := newMongoDatabase(t)
+	repos := repository.NewMongoRepositories(db)
+
+	server := &model.Server{Name: "recount", Endpoint: "1.1.1.1:51820", MaxClients: 5, Status: model.ServerStatusActive}
+	if err := repos.Servers.Create(ctx, server); err != nil {
+		t.Fatal(err)
+	}
+	keys := &model.WireGuardKeys{UserId: primitive.NewObjectID(), ServerId: server.Id, PublicKey: "pub", IpAddress: "10.0.0.2/32"}
+	if err := repos.Keys.Allocate(ctx, keys); err != nil {
+		t.Fatal(err)
+	}
+
+	leak := func() {
+		t.Helper()
+		_, err := db.Collection("servers").UpdateOne(ctx, bson.M{"_id": server.Id}, bson.M{"$inc": bson.M{"current_clients": 1}})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	recount := func(want int) {
+		t.Helper()
+		corrected, err := repos.Servers.RecountClients(ctx)
+		if err != nil || corrected != want {
+			t.Fatalf("recount corrected %d servers, %v; want %d", corrected, err, want)
+		}
+	}
+
+	leak()
+	// The first sighting could be an allocation in progress.
+	recount(0)
+	// A count that moved since could still be one.
+	leak()
+	recount(0)
+	recount(1)
+	recount(0)
+
+	got, err := repos.Servers.GetById(ctx, server.Id)
+	if err != nil || got.CurrentClients != 1 {
+		t.Fatalf("current_clients after recount: %+v, %v", got, err)
+	}
+}
+
+// failNext makes the next command named command fail, through the
+// failCommand fail point. It skips the test if the server was not started
+// with enableTestCommands.
+func failNext(t *testing.T, db *mongo.Database, command string) {
+	t.Helper()
+
+	ctx := context.Background()
+	admin := db.Client().Database("admin")
+	err := admin.RunCommand(ctx, bson.D{
+		{Key: "configureFailPoint", Value: "failCommand"},
+		{Key: "mode", Value: bson.M{"times": 1}},
+		{Key: "data", Value: bson.M{"failCommands": bson.A{command}, "errorCode": 2}},
+	}).Err()
+	if err != nil {
+		t.Skipf("failCommand fail point is not available: %v", err)
+	}
+	t.Cleanup(func() {
+		admin.RunCommand(ctx, bson.D{{Key: "configureFailPoint", Value: "failCommand"}, {Key: "mode", Value: "off"}})
+	})
+}
+
+// TestMongoRevokeSurvivesFailedDelete fails the delete that follows the
+// write committing a revoke, and checks that the revoke still happened with
+// its event, and that the relay finishes it.
+func TestMongoRevokeSurvivesFailedDelete(t *testing.T) {
+	ctx := context.Background()
+	db := newMongoDatabase(t)
+	repos := repository.NewMongoRepositories(db)
+
+	server := &model.Server{Name: "revoke", Endpoint: "1.1.1.1:51820", MaxClients: 5, Status: model.ServerStatusActive}
+	if err := repos.Servers.Create(ctx, server); err != nil {
+		t.Fatal(err)
+	}
+	keys := &model.WireGuardKeys{Id: primitive.NewObjectID(), UserId: primitive.NewObjectID(), ServerId: server.Id, PublicKey: "pub", IpAddress: "10.0.0.2/32"}
+	if err := repos.Keys.Allocate(ctx, keys, events.NewPeerCreated(keys)); err != nil {
+		t.Fatal(err)
+	}
+
+	failNext(t, db, "delete")
+	if err := repos.Keys.Revoke(ctx, keys, events.NewPeerRevoked(keys)); err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+
+	stored, err := db.Collection("wireguard_keys").CountDocuments(ctx, bson.M{"_id": keys.Id})
+	if err != nil || stored != 1 {
+		t.Fatalf("the delete was not failed: %d peers stored, %v", stored, err)
+	}
+	if _, err := repos.Keys.GetByUserAndServer(ctx, keys.UserId, server.Id); !errors.Is(err, repository.ErrKeysNotFound) {
+		t.Errorf("revoked peer is still read: %v", err)
+	}
+	if err := repos.Keys.Revoke(ctx, keys, events.NewPeerRevoked(keys)); !errors.Is(err, repository.ErrKeysNotFound) {
+		t.Errorf("second revoke: got %v, want %v", err, repository.ErrKeysNotFound)
+	}
+
+	due, err := repos.Outbox.Due(ctx, time.Now().Add(time.Second), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var types []string
+	for _, event := range due {
+		types = append(types, event.Type)
+	}
+	if want := []string{model.EventPeerCreated, model.EventPeerRevoked}; fmt.Sprint(types) != fmt.Sprint(want) {
+		t.Errorf("outbox holds %v, want %v", types, want)
+	}
+
+	stored, err = db.Collection("wireguard_keys").CountDocuments(ctx, bson.M{"_id": keys.Id})
+	if err != nil || stored != 0 {
+		t.Errorf("relay left %d revoked peers, %v", stored, err)
+	}
+	got, err := repos.Servers.GetById(ctx, server.Id)
+	if err != nil || got.CurrentClients != 0 {
+		t.Errorf("current_clients after the relay: %+v, %v", got, err)
+	}
+}
@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/shivamp1998/vpn_backend/internal/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type MongoNotificationRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoNotificationRepository(db *mongo.Database) *MongoNotificationRepository {
+	return &MongoNotificationRepository{
+		collection: db.Collection("notifications"),
+	}
+}
+
+func (r *MongoNotificationRepository) Create(ctx context.Context, notification *model.Notification) error {
+	notification.Id = primitive.NewObjectID()
+	notification.CreatedAt = time.Now()
+
+	_, err := r.collection.InsertOne(ctx, notification)
+	return err
+}
@@ -0,0 +1,200 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/shivamp1998/vpn_backend/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Without multi-document transactions, Mongo writes stage their events in an
+// outbox array on the document they change, which makes the events part of
+// the same single-document write. Due relays staged events into the
+// outbox_events collection before reading it.
+const stagedOutboxField = "outbox"
+
+var stagedOutboxCollections = []string{"users", "servers", "wireguard_keys"}
+
+// prepareOutboxEvents fills in the fields every backend sets when an event is
+// first stored.
+func prepareOutboxEvents(events []*model.OutboxEvent, now time.Time) {
+	for _, event := range events {
+		if event.Id.IsZero() {
+			event.Id = primitive.NewObjectID()
+		}
+		event.OccurredAt = now
+		event.Status = model.OutboxStatusPending
+		event.Attempts = 0
+		event.NextAttemptAt = now
+		event.Delivered = []string{}
+	}
+}
+
+// withStagedOutbox returns doc with events staged on it, ready to insert.
+func withStagedOutbox(doc any, events []*model.OutboxEvent) (any, error) {
+	if len(events) == 0 {
+		return doc, nil
+	}
+
+	prepareOutboxEvents(events, time.Now())
+
+	raw, err := bson.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields bson.D
+	if err := bson.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+	return append(fields, bson.E{Key: stagedOutboxField, Value: events}), nil
+}
+
+// stageOutbox adds events to update.
+func stageOutbox(update bson.M, events []*model.OutboxEvent) bson.M {
+	if len(events) > 0 {
+		prepareOutboxEvents(events, time.Now())
+		update["$push"] = bson.M{stagedOutboxField: bson.M{"$each": events}}
+	}
+	return update
+}
+
+type MongoOutboxRepository struct {
+	db         *mongo.Database
+	collection *mongo.Collection
+}
+
+func NewMongoOutboxRepository(db *mongo.Database) *MongoOutboxRepository {
+	return &MongoOutboxRepository{
+		db:         db,
+		collection: db.Collection("outbox_events"),
+	}
+}
+
+func (r *MongoOutboxRepository) Due(ctx context.Context, now time.Time, limit int) ([]*model.OutboxEvent, error) {
+	if err := r.relayStaged(ctx); err != nil {
+		return nil, err
+	}
+
+	filter := bson.M{
+		"status":          model.OutboxStatusPending,
+		"next_attempt_at": bson.M{"$lte": now},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "occurred_at", Value: 1}}).SetLimit(int64(limit))
+	return r.find(ctx, filter, opts)
+}
+
+// relayStaged moves staged events into outbox_events. An event is inserted
+// before it is removed from its document, and inserting it twice is a no-op,
+// so a relay interrupted at any point loses nothing. The events of revoked
+// peers are relayed as their revokes are finished.
+func (r *MongoOutboxRepository) relayStaged(ctx context.Context) error {
+	if err := finishRevokes(ctx, r.db); err != nil {
+		return err
+	}
+
+	for _, name := range stagedOutboxCollections {
+		collection := r.db.Collection(name)
+
+		filter := bson.M{stagedOutboxField + "._id": bson.M{"$exists": true}, revokedField: notRevoked}
+		opts := options.Find().SetProjection(bson.M{stagedOutboxField: 1})
+		cursor, err := collection.Find(ctx, filter, opts)
+		if err != nil {
+			return err
+		}
+
+		var docs []struct {
+			Id     primitive.ObjectID   `bson:"_id"`
+			Outbox []*model.OutboxEvent `bson:"outbox"`
+		}
+		if err := cursor.All(ctx, &docs); err != nil {
+			return err
+		}
+
+		for _, doc := range docs {
+			ids := make([]primitive.ObjectID, 0, len(doc.Outbox))
+			for _, event := range doc.Outbox {
+				if _, err := r.collection.InsertOne(ctx, event); err != nil && !mongo.IsDuplicateKeyError(err) {
+					return err
+				}
+				ids = append(ids, event.Id)
+			}
+
+			pull := bson.M{"$pull": bson.M{stagedOutboxField: bson.M{"_id": bson.M{"$in": ids}}}}
+			if _, err := collection.UpdateOne(ctx, bson.M{"_id": doc.Id}, pull); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (r *MongoOutboxRepository) UpdateDelivery(ctx context.Context, event *model.OutboxEvent) error {
+	update := bson.M{
+		"$set": bson.M{
+			"status":          event.Status,
+			"attempts":        event.Attempts,
+			"next_attempt_at": event.NextAttemptAt,
+			"last_error":      event.LastError,
+			"delivered_at":    event.DeliveredAt,
+			"delivered":       event.Delivered,
+		},
+	}
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": event.Id}, update)
+	return err
+}
+
+// ListSince relays staged events first, so it sees every change that has
+// been written.
+func (r *MongoOutboxRepository) ListSince(ctx context.Context, serverId primitive.ObjectID, types []string, since time.Time, limit int) ([]*model.OutboxEvent, error) {
+	if err := r.relayStaged(ctx); err != nil {
+		return nil, err
+	}
+
+	filter := bson.M{
+		"server_id":   serverId.Hex(),
+		"type":        bson.M{"$in": types},
+		"occurred_at": bson.M{"$gte": since},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "occurred_at", Value: 1}, {Key: "_id", Value: 1}}).SetLimit(int64(limit))
+	return r.find(ctx, filter, opts)
+}
+
+func (r *MongoOutboxRepository) ListByStatus(ctx context.Context, status string, limit int) ([]*model.OutboxEvent, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "occurred_at", Value: -1}}).SetLimit(int64(limit))
+	return r.find(ctx, bson.M{"status": status}, opts)
+}
+
+func (r *MongoOutboxRepository) Requeue(ctx context.Context, id primitive.ObjectID, now time.Time) (bool, error) {
+	filter := bson.M{"_id": id, "status": model.OutboxStatusDead}
+	update := bson.M{
+		"$set": bson.M{
+			"status":          model.OutboxStatusPending,
+			"attempts":        0,
+			"next_attempt_at": now,
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+func (r *MongoOutboxRepository) find(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]*model.OutboxEvent, error) {
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var events []*model.OutboxEvent
+	err = cursor.All(ctx, &events)
+	return events, err
+}
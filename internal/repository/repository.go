@@ -0,0 +1,245 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/shivamp1998/vpn_backend/internal/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	ErrUserNotFound          = errors.New("user not found")
+	ErrServerNotFound        = errors.New("server not found")
+	ErrKeysNotFound          = errors.New("keys not found")
+	ErrShareLinkNotFound     = errors.New("share link not found")
+	ErrShareLinkNotAvailable = errors.New("share link not available")
+	ErrServerFull            = errors.New("server has no free client slots")
+	ErrWebhookNotFound       = errors.New("webhook not found")
+
+	// ErrDuplicateKey is returned when a write violates a unique index.
+	ErrDuplicateKey = errors.New("duplicate key")
+
+	// ErrVersionConflict matches every *VersionConflictError.
+	ErrVersionConflict = errors.New("version conflict")
+)
+
+// VersionConflictError is returned by conditional updates when the document
+// no longer has the version the caller read, because someone else wrote it
+// in between.
+type VersionConflictError struct {
+	Collection string
+	Id         primitive.ObjectID
+	Version    int64
+}
+
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("%s %s was modified concurrently: version %d is no longer current", e.Collection, e.Id.Hex(), e.Version)
+}
+
+func (e *VersionConflictError) Is(target error) bool {
+	return target == ErrVersionConflict
+}
+
+// Writes that take outbox events store them in the same atomic step as the
+// change itself: the events exist if and only if the change was made. Ids
+// already set on a record being created are kept, so events can refer to it.
+
+type UserRepository interface {
+	Create(ctx context.Context, user *model.User, events ...*model.OutboxEvent) error
+	GetByEmail(ctx context.Context, email string) (*model.User, error)
+	GetById(ctx context.Context, id primitive.ObjectID) (*model.User, error)
+	// SetRole changes the role of an existing user. It returns
+	// ErrUserNotFound if there is no user with the id.
+	SetRole(ctx context.Context, id primitive.ObjectID, role string) error
+}
+
+type ServerRepository interface {
+	Create(ctx context.Context, server *model.Server) error
+	GetById(ctx context.Context, id primitive.ObjectID) (*model.Server, error)
+	ListAll(ctx context.Context) ([]*model.Server, error)
+	// Update saves server if it is still at server.Version, and bumps the
+	// version. It returns a *VersionConflictError otherwise. CurrentClients
+	// is not written; it is owned by WireGuardKeysRepository.Allocate.
+	Update(ctx context.Context, server *model.Server, events ...*model.OutboxEvent) error
+	// RecountClients corrects current_clients on servers where it no longer
+	// matches the peers that exist, and returns how many it corrected.
+	// Backends that keep the count in the same transaction as the peers have
+	// nothing to correct.
+	RecountClients(ctx context.Context) (int, error)
+}
+
+type WireGuardKeysRepository interface {
+	Create(ctx context.Context, keys *model.WireGuardKeys) error
+	// Allocate creates a peer and takes one of its server's client slots as
+	// a single atomic step, except on Mongo, where a slot taken without a
+	// peer is left to ServerRepository.RecountClients. It returns
+	// ErrServerFull if the server is at
+	// max_clients and ErrDuplicateKey if the user already has a peer on the
+	// server or keys.IpAddress is taken.
+	Allocate(ctx context.Context, keys *model.WireGuardKeys, events ...*model.OutboxEvent) error
+	// Revoke deletes a peer and frees its server's client slot as a single
+	// atomic step. On Mongo the peer is gone, and the events stored, in one
+	// write; its slot can be freed later. It returns ErrKeysNotFound if the
+	// peer no longer exists.
+	Revoke(ctx context.Context, keys *model.WireGuardKeys, events ...*model.OutboxEvent) error
+	GetByUserAndServer(ctx context.Context, userId, serverId primitive.ObjectID) (*model.WireGuardKeys, error)
+	// Update saves keys if they are still at keys.Version, and bumps the
+	// version. It returns a *VersionConflictError otherwise.
+	Update(ctx context.Context, keys *model.WireGuardKeys) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	GetAllByServer(ctx context.Context, serverId primitive.ObjectID) ([]*model.WireGuardKeys, error)
+	GetAllByServerRotatedBefore(ctx context.Context, serverId primitive.ObjectID, before time.Time) ([]*model.WireGuardKeys, error)
+	Rotate(ctx context.Context, keys *model.WireGuardKeys, previousRotatedAt time.Time, events ...*model.OutboxEvent) (bool, error)
+	MarkRotationRequired(ctx context.Context, id primitive.ObjectID) (bool, error)
+}
+
+type ConfigShareLinkRepository interface {
+	Create(ctx context.Context, link *model.ConfigShareLink) error
+	GetByTokenHash(ctx context.Context, tokenHash string) (*model.ConfigShareLink, error)
+	Consume(ctx context.Context, tokenHash string, now time.Time) (*model.ConfigShareLink, error)
+	RecordRetrieval(ctx context.Context, retrieval *model.ConfigShareRetrieval) error
+}
+
+type NotificationRepository interface {
+	Create(ctx context.Context, notification *model.Notification) error
+}
+
+// AuditFilter narrows AuditRepository.List. Zero fields match everything.
+type AuditFilter struct {
+	ActorId    primitive.ObjectID
+	Action     string
+	TargetType string
+	TargetId   string
+	Outcome    string
+	Since      time.Time
+	Until      time.Time
+	// BeforeSequence, if set, only matches events older than it. It is how
+	// pages after the first are fetched.
+	BeforeSequence int64
+}
+
+// AuditRepository is append-only: there is no way to change or remove an
+// event once written.
+type AuditRepository interface {
+	// Append stores event, returning ErrDuplicateKey if its sequence is
+	// already taken.
+	Append(ctx context.Context, event *model.AuditEvent) error
+	// Last returns the event with the highest sequence, or nil if the log is
+	// empty.
+	Last(ctx context.Context) (*model.AuditEvent, error)
+	// List returns at most limit events matching filter, newest first.
+	List(ctx context.Context, filter AuditFilter, limit int) ([]*model.AuditEvent, error)
+}
+
+// OutboxRepository is the delivery side of the outbox. Events are added by
+// the writes that cause them.
+type OutboxRepository interface {
+	// Due returns up to limit pending events whose next attempt is at or
+	// before now, oldest first.
+	Due(ctx context.Context, now time.Time, limit int) ([]*model.OutboxEvent, error)
+	// UpdateDelivery saves the delivery state of event: status, attempts,
+	// next attempt, last error and delivered subscribers.
+	UpdateDelivery(ctx context.Context, event *model.OutboxEvent) error
+	// ListSince returns up to limit events of the given types about serverId
+	// that occurred at or after since, whatever their delivery status, oldest
+	// first.
+	ListSince(ctx context.Context, serverId primitive.ObjectID, types []string, since time.Time, limit int) ([]*model.OutboxEvent, error)
+	// ListByStatus returns up to limit events with status, newest first.
+	ListByStatus(ctx context.Context, status string, limit int) ([]*model.OutboxEvent, error)
+	// Requeue makes a dead event pending again with its attempts reset. It
+	// returns false if the event does not exist or is not dead.
+	Requeue(ctx context.Context, id primitive.ObjectID, now time.Time) (bool, error)
+}
+
+type WebhookRepository interface {
+	Create(ctx context.Context, webhook *model.Webhook) error
+	GetById(ctx context.Context, id primitive.ObjectID) (*model.Webhook, error)
+	List(ctx context.Context) ([]*model.Webhook, error)
+	// ListByEventType returns the enabled webhooks subscribed to eventType.
+	ListByEventType(ctx context.Context, eventType string) ([]*model.Webhook, error)
+	// Delete removes a webhook. Its delivery log is kept.
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	RecordDelivery(ctx context.Context, delivery *model.WebhookDelivery) error
+	// ListDeliveries returns up to limit deliveries to a webhook, newest
+	// first.
+	ListDeliveries(ctx context.Context, webhookId primitive.ObjectID, limit int) ([]*model.WebhookDelivery, error)
+	// Delivered reports whether the event has been successfully delivered to
+	// the webhook.
+	Delivered(ctx context.Context, webhookId, eventId primitive.ObjectID) (bool, error)
+}
+
+type LockRepository interface {
+	Acquire(ctx context.Context, name, owner string, ttl time.Duration) (bool, error)
+	Release(ctx context.Context, name, owner string) error
+}
+
+var (
+	_ UserRepository            = (*MongoUserRepository)(nil)
+	_ ServerRepository          = (*MongoServerRepository)(nil)
+	_ WireGuardKeysRepository   = (*MongoWireGuardKeysRepository)(nil)
+	_ ConfigShareLinkRepository = (*MongoConfigShareLinkRepository)(nil)
+	_ NotificationRepository    = (*MongoNotificationRepository)(nil)
+	_ LockRepository            = (*MongoLockRepository)(nil)
+	_ AuditRepository           = (*MongoAuditRepository)(nil)
+	_ OutboxRepository          = (*MongoOutboxRepository)(nil)
+	_ WebhookRepository         = (*MongoWebhookRepository)(nil)
+
+	_ UserRepository            = (*MemoryUserRepository)(nil)
+	_ ServerRepository          = (*MemoryServerRepository)(nil)
+	_ WireGuardKeysRepository   = (*MemoryWireGuardKeysRepository)(nil)
+	_ ConfigShareLinkRepository = (*MemoryConfigShareLinkRepository)(nil)
+	_ NotificationRepository    = (*MemoryNotificationRepository)(nil)
+	_ LockRepository            = (*MemoryLockRepository)(nil)
+	_ AuditRepository           = (*MemoryAuditRepository)(nil)
+	_ OutboxRepository          = (*MemoryOutboxRepository)(nil)
+	_ WebhookRepository         = (*MemoryWebhookRepository)(nil)
+
+	_ UserRepository            = (*SQLUserRepository)(nil)
+	_ ServerRepository          = (*SQLServerRepository)(nil)
+	_ WireGuardKeysRepository   = (*SQLWireGuardKeysRepository)(nil)
+	_ ConfigShareLinkRepository = (*SQLConfigShareLinkRepository)(nil)
+	_ NotificationRepository    = (*SQLNotificationRepository)(nil)
+	_ LockRepository            = (*SQLLockRepository)(nil)
+	_ AuditRepository           = (*SQLAuditRepository)(nil)
+	_ OutboxRepository          = (*SQLOutboxRepository)(nil)
+	_ WebhookRepository         = (*SQLWebhookRepository)(nil)
+)
+
+func duplicateKeyError(err error) error {
+	if mongo.IsDuplicateKeyError(err) {
+		return fmt.Errorf("%w: %v", ErrDuplicateKey, err)
+	}
+	return err
+}
+
+// Repositories bundles one implementation of every repository so a storage
+// backend can be selected in one place.
+type Repositories struct {
+	Users         UserRepository
+	Servers       ServerRepository
+	Keys          WireGuardKeysRepository
+	ShareLinks    ConfigShareLinkRepository
+	Notifications NotificationRepository
+	Locks         LockRepository
+	Audit         AuditRepository
+	Outbox        OutboxRepository
+	Webhooks      WebhookRepository
+}
+
+func NewMongoRepositories(db *mongo.Database) *Repositories {
+	return &Repositories{
+		Users:         NewMongoUserRepository(db),
+		Servers:       NewMongoServerRepository(db),
+		Keys:          NewMongoWireGuardKeysRepository(db),
+		ShareLinks:    NewMongoConfigShareLinkRepository(db),
+		Notifications: NewMongoNotificationRepository(db),
+		Locks:         NewMongoLockRepository(db),
+		Audit:         NewMongoAuditRepository(db),
+		Outbox:        NewMongoOutboxRepository(db),
+		Webhooks:      NewMongoWebhookRepository(db),
+	}
+}
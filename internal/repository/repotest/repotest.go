@@ -0,0 +1,833 @@
+// Package repotest holds a conformance suite for storage backends. Every
+// implementation of repository.Repositories is expected to pass it, which
+// keeps the Mongo, SQL and in-memory backends interchangeable.
+//
+// A backend test typically looks like:
+//
+//	func TestPostgres(t *testing.T) {
+//		db := openTestDatabase(t) // t.Skip when no local instance is available
+//		repotest.Run(t, func(t *testing.T) *repository.Repositories {
+//			truncateTables(t, db)
+//			return repository.NewPostgresRepositories(db)
+//		})
+//	}
+package repotest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shivamp1998/vpn_backend/internal/model"
+	"github.com/shivamp1998/vpn_backend/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type check struct {
+	name string
+	run  func(ctx context.Context, repos *repository.Repositories) error
+}
+
+var checks = []check{
+	{"users", checkUsers},
+	{"servers", checkServers},
+	{"wireguard keys", checkKeys},
+	{"key rotation", checkKeyRotation},
+	{"peer allocation", checkPeerAllocation},
+	{"concurrent peer allocation", checkConcurrentPeerAllocation},
+	{"share links", checkShareLinks},
+	{"notifications", checkNotifications},
+	{"locks", checkLocks},
+	{"audit events", checkAuditEvents},
+	{"outbox", checkOutbox},
+	{"webhooks", checkWebhooks},
+}
+
+// Run runs every check as a subtest against a fresh set of repositories
+// obtained from newRepos, which must return an empty store on each call and
+// fail t if it cannot.
+func Run(t *testing.T, newRepos func(t *testing.T) *repository.Repositories) {
+	for _, c := range checks {
+		t.Run(c.name, func(t *testing.T) {
+			if err := c.run(context.Background(), newRepos(t)); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func expectError(err, target error, what string) error {
+	if !errors.Is(err, target) {
+		return fmt.Errorf("%s: got error %v, want %v", what, err, target)
+	}
+	return nil
+}
+
+func checkUsers(ctx context.Context, repos *repository.Repositories) error {
+	user := &model.User{Email: "user@example.com", PasswordHash: "hash", Plan: "pro"}
+	if err := repos.Users.Create(ctx, user); err != nil {
+		return fmt.Errorf("create: %v", err)
+	}
+
+	if user.Id.IsZero() || !user.IsActive || user.CreatedAt.IsZero() {
+		return errors.New("create did not assign id, is_active and created_at")
+	}
+
+	byEmail, err := repos.Users.GetByEmail(ctx, user.Email)
+	if err != nil {
+		return fmt.Errorf("get by email: %v", err)
+	}
+
+	if byEmail.Id != user.Id || byEmail.PasswordHash != "hash" || byEmail.Plan != "pro" {
+		return fmt.Errorf("get by email returned %+v", byEmail)
+	}
+
+	byId, err := repos.Users.GetById(ctx, user.Id)
+	if err != nil || byId.Email != user.Email {
+		return fmt.Errorf("get by id: %v", err)
+	}
+
+	err = repos.Users.Create(ctx, &model.User{Email: user.Email, PasswordHash: "other"})
+	if err := expectError(err, repository.ErrDuplicateKey, "duplicate email"); err != nil {
+		return err
+	}
+
+	_, err = repos.Users.GetByEmail(ctx, "missing@example.com")
+	if err := expectError(err, repository.ErrUserNotFound, "missing email"); err != nil {
+		return err
+	}
+
+	_, err = repos.Users.GetById(ctx, primitive.NewObjectID())
+	if err := expectError(err, repository.ErrUserNotFound, "missing id"); err != nil {
+		return err
+	}
+
+	if err := repos.Users.SetRole(ctx, user.Id, model.RoleAdmin); err != nil {
+		return fmt.Errorf("set role: %v", err)
+	}
+
+	promoted, err := repos.Users.GetById(ctx, user.Id)
+	if err != nil || promoted.Role != model.RoleAdmin {
+		return fmt.Errorf("set role did not store the role: %v", err)
+	}
+
+	err = repos.Users.SetRole(ctx, primitive.NewObjectID(), model.RoleAdmin)
+	return expectError(err, repository.ErrUserNotFound, "set role of missing id")
+}
+
+func checkServers(ctx context.Context, repos *repository.Repositories) error {
+	first := &model.Server{Name: "first", Endpoint: "1.1.1.1:51820", Region: "eu", MaxClients: 10, CurrentClients: 5}
+	second := &model.Server{Name: "second", Endpoint: "2.2.2.2:51820", Region: "us", MaxClients: 20, KeyRotationMaxAge: time.Hour}
+
+	for _, server := range []*model.Server{first, second} {
+		if err := repos.Servers.Create(ctx, server); err != nil {
+			return fmt.Errorf("create: %v", err)
+		}
+	}
+
+	if first.CurrentClients != 0 {
+		return errors.New("create did not reset current_clients")
+	}
+
+	got, err := repos.Servers.GetById(ctx, second.Id)
+	if err != nil {
+		return fmt.Errorf("get: %v", err)
+	}
+
+	if got.Name != "second" || got.KeyRotationMaxAge != time.Hour {
+		return fmt.Errorf("get returned %+v", got)
+	}
+
+	servers, err := repos.Servers.ListAll(ctx)
+	if err != nil {
+		return fmt.Errorf("list: %v", err)
+	}
+
+	if len(servers) != 2 {
+		return fmt.Errorf("list returned %d servers, want 2", len(servers))
+	}
+
+	if got.Version != 1 {
+		return fmt.Errorf("new server has version %d, want 1", got.Version)
+	}
+
+	stale := *got
+	got.CurrentClients = 3
+	got.Region = "ap"
+	if err := repos.Servers.Update(ctx, got); err != nil {
+		return fmt.Errorf("update: %v", err)
+	}
+
+	if got.Version != 2 {
+		return fmt.Errorf("update left version at %d, want 2", got.Version)
+	}
+
+	updated, err := repos.Servers.GetById(ctx, second.Id)
+	if err != nil || updated.Region != "ap" || updated.Version != 2 {
+		return fmt.Errorf("update was not persisted: %+v, %v", updated, err)
+	}
+
+	// current_clients belongs to peer allocation and is never written by Update.
+	if updated.CurrentClients != 0 {
+		return fmt.Errorf("update wrote current_clients = %d", updated.CurrentClients)
+	}
+
+	stale.Name = "lost update"
+	err = repos.Servers.Update(ctx, &stale)
+	if err := expectError(err, repository.ErrVersionConflict, "update with stale version"); err != nil {
+		return err
+	}
+
+	err = repos.Servers.Update(ctx, &model.Server{Id: primitive.NewObjectID(), Version: 1})
+	if err := expectError(err, repository.ErrServerNotFound, "update missing server"); err != nil {
+		return err
+	}
+
+	_, err = repos.Servers.GetById(ctx, primitive.NewObjectID())
+	return expectError(err, repository.ErrServerNotFound, "missing server")
+}
+
+func checkKeys(ctx context.Context, repos *repository.Repositories) error {
+	userId := primitive.NewObjectID()
+	serverId := primitive.NewObjectID()
+
+	keys := &model.WireGuardKeys{UserId: userId, ServerId: serverId, PublicKey: "pub", PrivateKeyEncrypted: "priv", IpAddress: "10.0.0.2/32"}
+	if err := repos.Keys.Create(ctx, keys); err != nil {
+		return fmt.Errorf("create: %v", err)
+	}
+
+	err := repos.Keys.Create(ctx, &model.WireGuardKeys{UserId: userId, ServerId: serverId, IpAddress: "10.0.0.3/32"})
+	if err := expectError(err, repository.ErrDuplicateKey, "duplicate user and server"); err != nil {
+		return err
+	}
+
+	other := &model.WireGuardKeys{UserId: primitive.NewObjectID(), ServerId: serverId, IpAddress: "10.0.0.3/32"}
+	if err := repos.Keys.Create(ctx, other); err != nil {
+		return fmt.Errorf("create second peer: %v", err)
+	}
+
+	got, err := repos.Keys.GetByUserAndServer(ctx, userId, serverId)
+	if err != nil {
+		return fmt.Errorf("get: %v", err)
+	}
+
+	if got.Id != keys.Id || got.PublicKey != "pub" || got.IpAddress != "10.0.0.2/32" {
+		return fmt.Errorf("get returned %+v", got)
+	}
+
+	all, err := repos.Keys.GetAllByServer(ctx, serverId)
+	if err != nil || len(all) != 2 {
+		return fmt.Errorf("get all by server returned %d peers, %v", len(all), err)
+	}
+
+	stale := *got
+	got.IpAddress = "10.0.0.9/32"
+	if err := repos.Keys.Update(ctx, got); err != nil {
+		return fmt.Errorf("update: %v", err)
+	}
+
+	if updated, err := repos.Keys.GetByUserAndServer(ctx, userId, serverId); err != nil || updated.IpAddress != "10.0.0.9/32" || updated.Version != 2 {
+		return fmt.Errorf("update was not persisted: %v", err)
+	}
+
+	stale.PublicKey = "lost update"
+	err = repos.Keys.Update(ctx, &stale)
+	if err := expectError(err, repository.ErrVersionConflict, "update with stale version"); err != nil {
+		return err
+	}
+
+	if err := repos.Keys.Delete(ctx, keys.Id); err != nil {
+		return fmt.Errorf("delete: %v", err)
+	}
+
+	_, err = repos.Keys.GetByUserAndServer(ctx, userId, serverId)
+	return expectError(err, repository.ErrKeysNotFound, "deleted peer")
+}
+
+func checkKeyRotation(ctx context.Context, repos *repository.Repositories) error {
+	serverId := primitive.NewObjectID()
+	keys := &model.WireGuardKeys{UserId: primitive.NewObjectID(), ServerId: serverId, PublicKey: "old", IpAddress: "10.0.0.2/32"}
+	if err := repos.Keys.Create(ctx, keys); err != nil {
+		return fmt.Errorf("create: %v", err)
+	}
+
+	stale, err := repos.Keys.GetAllByServerRotatedBefore(ctx, serverId, time.Now().Add(time.Minute))
+	if err != nil || len(stale) != 1 {
+		return fmt.Errorf("rotated before returned %d peers, %v", len(stale), err)
+	}
+
+	fresh, err := repos.Keys.GetAllByServerRotatedBefore(ctx, serverId, time.Now().Add(-time.Minute))
+	if err != nil || len(fresh) != 0 {
+		return fmt.Errorf("rotated before returned %d fresh peers, %v", len(fresh), err)
+	}
+
+	flagged, err := repos.Keys.MarkRotationRequired(ctx, keys.Id)
+	if err != nil || !flagged {
+		return fmt.Errorf("mark rotation required: %v, %v", flagged, err)
+	}
+
+	flagged, err = repos.Keys.MarkRotationRequired(ctx, keys.Id)
+	if err != nil || flagged {
+		return fmt.Errorf("second mark rotation required should be a no-op: %v, %v", flagged, err)
+	}
+
+	current, err := repos.Keys.GetByUserAndServer(ctx, keys.UserId, serverId)
+	if err != nil || !current.RotationRequired || current.RotationRequestedAt == nil {
+		return fmt.Errorf("rotation flag was not persisted: %v", err)
+	}
+
+	previous := current.LastRotatedAt
+	current.PublicKey = "new"
+	rotated, err := repos.Keys.Rotate(ctx, current, previous)
+	if err != nil || !rotated {
+		return fmt.Errorf("rotate: %v, %v", rotated, err)
+	}
+
+	stale, err = repos.Keys.GetAllByServerRotatedBefore(ctx, serverId, time.Now().Add(time.Minute))
+	if err != nil || len(stale) != 1 || stale[0].PublicKey != "new" || stale[0].RotationRequired {
+		return fmt.Errorf("rotation was not persisted: %v", err)
+	}
+
+	// Creation, the rotation flag and the rotation itself are three writes.
+	if stale[0].Version != 3 || current.Version != 3 {
+		return fmt.Errorf("rotation left version at %d (stored %d), want 3", current.Version, stale[0].Version)
+	}
+
+	rotated, err = repos.Keys.Rotate(ctx, &model.WireGuardKeys{Id: keys.Id, PublicKey: "lost"}, previous)
+	if err != nil || rotated {
+		return fmt.Errorf("rotate with a stale timestamp should not apply: %v, %v", rotated, err)
+	}
+
+	return nil
+}
+
+func checkPeerAllocation(ctx context.Context, repos *repository.Repositories) error {
+	server := &model.Server{Name: "small", Endpoint: "1.1.1.1:51820", Region: "eu", MaxClients: 2}
+	if err := repos.Servers.Create(ctx, server); err != nil {
+		return fmt.Errorf("create server: %v", err)
+	}
+
+	userId := primitive.NewObjectID()
+	if err := repos.Keys.Allocate(ctx, &model.WireGuardKeys{UserId: userId, ServerId: server.Id, IpAddress: "10.0.0.2/32"}); err != nil {
+		return fmt.Errorf("allocate: %v", err)
+	}
+
+	err := repos.Keys.Allocate(ctx, &model.WireGuardKeys{UserId: userId, ServerId: server.Id, IpAddress: "10.0.0.3/32"})
+	if err := expectError(err, repository.ErrDuplicateKey, "second peer for the same user"); err != nil {
+		return err
+	}
+
+	err = repos.Keys.Allocate(ctx, &model.WireGuardKeys{UserId: primitive.NewObjectID(), ServerId: server.Id, IpAddress: "10.0.0.2/32"})
+	if err := expectError(err, repository.ErrDuplicateKey, "address already in use"); err != nil {
+		return err
+	}
+
+	if err := repos.Keys.Allocate(ctx, &model.WireGuardKeys{UserId: primitive.NewObjectID(), ServerId: server.Id, IpAddress: "10.0.0.3/32"}); err != nil {
+		return fmt.Errorf("allocate last slot: %v", err)
+	}
+
+	err = repos.Keys.Allocate(ctx, &model.WireGuardKeys{UserId: primitive.NewObjectID(), ServerId: server.Id, IpAddress: "10.0.0.4/32"})
+	if err := expectError(err, repository.ErrServerFull, "full server"); err != nil {
+		return err
+	}
+
+	err = repos.Keys.Allocate(ctx, &model.WireGuardKeys{UserId: primitive.NewObjectID(), ServerId: primitive.NewObjectID(), IpAddress: "10.0.0.2/32"})
+	if err := expectError(err, repository.ErrServerNotFound, "missing server"); err != nil {
+		return err
+	}
+
+	// Failed allocations must not leak slots.
+	got, err := repos.Servers.GetById(ctx, server.Id)
+	if err != nil {
+		return fmt.Errorf("get server: %v", err)
+	}
+	if got.CurrentClients != 2 {
+		return fmt.Errorf("current_clients is %d after two allocations", got.CurrentClients)
+	}
+
+	// A correct count is never changed, however often it is recounted.
+	for i := 0; i < 2; i++ {
+		if corrected, err := repos.Servers.RecountClients(ctx); err != nil || corrected != 0 {
+			return fmt.Errorf("recount of correct counts corrected %d servers, %v", corrected, err)
+		}
+	}
+	return nil
+}
+
+// checkConcurrentPeerAllocation races more clients than there are slots for
+// a small pool of addresses, retrying on conflicts the way ConfigService
+// does, and checks that no address is handed out twice and the server never
+// goes over capacity.
+func checkConcurrentPeerAllocation(ctx context.Context, repos *repository.Repositories) error {
+	const clients = 40
+	const maxClients = 12
+
+	server := &model.Server{Name: "busy", Endpoint: "1.1.1.1:51820", Region: "eu", MaxClients: maxClients}
+	if err := repos.Servers.Create(ctx, server); err != nil {
+		return fmt.Errorf("create server: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, clients)
+
+	for i := 0; i < clients; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			keys := &model.WireGuardKeys{UserId: primitive.NewObjectID(), ServerId: server.Id}
+			for attempt := 0; ; attempt++ {
+				// Everyone starts on the same few addresses to force conflicts.
+				keys.IpAddress = fmt.Sprintf("10.0.0.%d/32", 2+(i+attempt)%(maxClients+4))
+
+				err := repos.Keys.Allocate(ctx, keys)
+				if err == nil || errors.Is(err, repository.ErrServerFull) {
+					return
+				}
+
+				if !errors.Is(err, repository.ErrDuplicateKey) || attempt > 100 {
+					errs <- fmt.Errorf("allocate: %v", err)
+					return
+				}
+			}
+		}(i)
+	}
+
+	wg.Wait()
+	close(errs)
+	if err := <-errs; err != nil {
+		return err
+	}
+
+	peers, err := repos.Keys.GetAllByServer(ctx, server.Id)
+	if err != nil {
+		return fmt.Errorf("get all by server: %v", err)
+	}
+
+	if len(peers) != maxClients {
+		return fmt.Errorf("allocated %d peers, want %d", len(peers), maxClients)
+	}
+
+	seen := make(map[string]bool)
+	for _, peer := range peers {
+		if seen[peer.IpAddress] {
+			return fmt.Errorf("address %s allocated twice", peer.IpAddress)
+		}
+		seen[peer.IpAddress] = true
+	}
+
+	got, err := repos.Servers.GetById(ctx, server.Id)
+	if err != nil {
+		return fmt.Errorf("get server: %v", err)
+	}
+	if got.CurrentClients != maxClients {
+		return fmt.Errorf("current_clients is %d, want %d", got.CurrentClients, maxClients)
+	}
+	return nil
+}
+
+func checkShareLinks(ctx context.Context, repos *repository.Repositories) error {
+	now := time.Now()
+	link := &model.ConfigShareLink{
+		TokenHash: "hash",
+		UserId:    primitive.NewObjectID(),
+		ServerId:  primitive.NewObjectID(),
+		Kind:      model.ShareLinkKindConfig,
+		ExpiresAt: now.Add(time.Hour),
+	}
+	if err := repos.ShareLinks.Create(ctx, link); err != nil {
+		return fmt.Errorf("create: %v", err)
+	}
+
+	err := repos.ShareLinks.Create(ctx, &model.ConfigShareLink{TokenHash: "hash", ExpiresAt: now.Add(time.Hour)})
+	if err := expectError(err, repository.ErrDuplicateKey, "duplicate token"); err != nil {
+		return err
+	}
+
+	consumed, err := repos.ShareLinks.Consume(ctx, "hash", now)
+	if err != nil || consumed.Id != link.Id || consumed.UsedAt == nil {
+		return fmt.Errorf("consume: %v", err)
+	}
+
+	_, err = repos.ShareLinks.Consume(ctx, "hash", now)
+	if err := expectError(err, repository.ErrShareLinkNotAvailable, "second consume"); err != nil {
+		return err
+	}
+
+	expired := &model.ConfigShareLink{TokenHash: "expired", Kind: model.ShareLinkKindQRCode, ExpiresAt: now.Add(-time.Second)}
+	if err := repos.ShareLinks.Create(ctx, expired); err != nil {
+		return fmt.Errorf("create expired: %v", err)
+	}
+
+	_, err = repos.ShareLinks.Consume(ctx, "expired", now)
+	if err := expectError(err, repository.ErrShareLinkNotAvailable, "expired consume"); err != nil {
+		return err
+	}
+
+	stored, err := repos.ShareLinks.GetByTokenHash(ctx, "expired")
+	if err != nil || stored.UsedAt != nil || stored.Kind != model.ShareLinkKindQRCode {
+		return fmt.Errorf("get by token hash: %v", err)
+	}
+
+	_, err = repos.ShareLinks.GetByTokenHash(ctx, "missing")
+	if err := expectError(err, repository.ErrShareLinkNotFound, "missing token"); err != nil {
+		return err
+	}
+
+	retrieval := &model.ConfigShareRetrieval{LinkId: link.Id, UserId: link.UserId, Outcome: model.ShareRetrievalSuccess, SourceIp: "127.0.0.1"}
+	if err := repos.ShareLinks.RecordRetrieval(ctx, retrieval); err != nil {
+		return fmt.Errorf("record retrieval: %v", err)
+	}
+
+	// Retrievals for unknown tokens have no link or user.
+	return repos.ShareLinks.RecordRetrieval(ctx, &model.ConfigShareRetrieval{Outcome: model.ShareRetrievalNotFound})
+}
+
+func checkNotifications(ctx context.Context, repos *repository.Repositories) error {
+	notification := &model.Notification{UserId: primitive.NewObjectID(), Type: model.NotificationKeysRotated, Message: "rotated"}
+	if err := repos.Notifications.Create(ctx, notification); err != nil {
+		return fmt.Errorf("create: %v", err)
+	}
+
+	if notification.Id.IsZero() || notification.CreatedAt.IsZero() {
+		return errors.New("create did not assign id and created_at")
+	}
+	return nil
+}
+
+func checkLocks(ctx context.Context, repos *repository.Repositories) error {
+	acquired, err := repos.Locks.Acquire(ctx, "job", "a", time.Minute)
+	if err != nil || !acquired {
+		return fmt.Errorf("first acquire: %v, %v", acquired, err)
+	}
+
+	acquired, err = repos.Locks.Acquire(ctx, "job", "b", time.Minute)
+	if err != nil || acquired {
+		return fmt.Errorf("acquire held by another owner: %v, %v", acquired, err)
+	}
+
+	acquired, err = repos.Locks.Acquire(ctx, "job", "a", time.Minute)
+	if err != nil || !acquired {
+		return fmt.Errorf("re-acquire by owner: %v, %v", acquired, err)
+	}
+
+	if err := repos.Locks.Release(ctx, "job", "b"); err != nil {
+		return fmt.Errorf("release by non-owner: %v", err)
+	}
+
+	acquired, err = repos.Locks.Acquire(ctx, "job", "b", time.Minute)
+	if err != nil || acquired {
+		return fmt.Errorf("release by non-owner freed the lock: %v, %v", acquired, err)
+	}
+
+	if err := repos.Locks.Release(ctx, "job", "a"); err != nil {
+		return fmt.Errorf("release: %v", err)
+	}
+
+	acquired, err = repos.Locks.Acquire(ctx, "job", "b", -time.Second)
+	if err != nil || !acquired {
+		return fmt.Errorf("acquire after release: %v, %v", acquired, err)
+	}
+
+	acquired, err = repos.Locks.Acquire(ctx, "job", "a", time.Minute)
+	if err != nil || !acquired {
+		return fmt.Errorf("acquire after lease expired: %v, %v", acquired, err)
+	}
+
+	return nil
+}
+
+func checkAuditEvents(ctx context.Context, repos *repository.Repositories) error {
+	last, err := repos.Audit.Last(ctx)
+	if err != nil || last != nil {
+		return fmt.Errorf("last of empty log: %v, %v", last, err)
+	}
+
+	actorId := primitive.NewObjectID()
+	start := time.Now().UTC().Truncate(time.Millisecond)
+	for sequence := int64(1); sequence <= 5; sequence++ {
+		event := &model.AuditEvent{
+			Sequence:   sequence,
+			Time:       start.Add(time.Duration(sequence) * time.Second),
+			Action:     model.AuditActionServerCreate,
+			TargetType: model.AuditTargetServer,
+			TargetId:   fmt.Sprintf("server-%d", sequence%2),
+			Outcome:    model.AuditOutcomeSuccess,
+		}
+		if sequence%2 == 0 {
+			event.ActorId = actorId
+			event.Outcome = model.AuditOutcomeFailure
+		}
+
+		if err := repos.Audit.Append(ctx, event); err != nil {
+			return fmt.Errorf("append %d: %v", sequence, err)
+		}
+	}
+
+	err = repos.Audit.Append(ctx, &model.AuditEvent{Sequence: 3, Time: start, Action: "duplicate", Outcome: model.AuditOutcomeSuccess})
+	if err := expectError(err, repository.ErrDuplicateKey, "duplicate sequence"); err != nil {
+		return err
+	}
+
+	last, err = repos.Audit.Last(ctx)
+	if err != nil || last == nil || last.Sequence != 5 || !last.Time.Equal(start.Add(5*time.Second)) {
+		return fmt.Errorf("last: %+v, %v", last, err)
+	}
+
+	all, err := repos.Audit.List(ctx, repository.AuditFilter{}, 10)
+	if err != nil || len(all) != 5 || all[0].Sequence != 5 || all[4].Sequence != 1 {
+		return fmt.Errorf("list all: %d events, %v", len(all), err)
+	}
+
+	byActor, err := repos.Audit.List(ctx, repository.AuditFilter{ActorId: actorId}, 10)
+	if err != nil || len(byActor) != 2 || byActor[0].ActorId != actorId {
+		return fmt.Errorf("list by actor: %d events, %v", len(byActor), err)
+	}
+
+	filter := repository.AuditFilter{
+		TargetId:       "server-1",
+		Outcome:        model.AuditOutcomeSuccess,
+		Since:          start.Add(2 * time.Second),
+		BeforeSequence: 5,
+	}
+	filtered, err := repos.Audit.List(ctx, filter, 10)
+	if err != nil || len(filtered) != 1 || filtered[0].Sequence != 3 {
+		return fmt.Errorf("list with filter: %d events, %v", len(filtered), err)
+	}
+
+	limited, err := repos.Audit.List(ctx, repository.AuditFilter{Until: start.Add(4 * time.Second)}, 2)
+	if err != nil || len(limited) != 2 || limited[0].Sequence != 3 {
+		return fmt.Errorf("list with limit: %d events, %v", len(limited), err)
+	}
+
+	return nil
+}
+
+func outboxEvent(eventType string) *model.OutboxEvent {
+	return &model.OutboxEvent{Type: eventType, AggregateId: primitive.NewObjectID().Hex(), Payload: "{}"}
+}
+
+func serverEvent(eventType string, serverId primitive.ObjectID) *model.OutboxEvent {
+	event := outboxEvent(eventType)
+	event.ServerId = serverId.Hex()
+	return event
+}
+
+// checkOutbox checks that events are stored with successful writes only, and
+// the delivery bookkeeping the dispatcher relies on.
+func checkOutbox(ctx context.Context, repos *repository.Repositories) error {
+	user := &model.User{Id: primitive.NewObjectID(), Email: "events@example.com", PasswordHash: "hash"}
+	if err := repos.Users.Create(ctx, user, outboxEvent(model.EventUserRegistered)); err != nil {
+		return fmt.Errorf("create user: %v", err)
+	}
+	err := repos.Users.Create(ctx, &model.User{Email: user.Email}, outboxEvent("duplicate user"))
+	if err := expectError(err, repository.ErrDuplicateKey, "duplicate user"); err != nil {
+		return err
+	}
+
+	server := &model.Server{Name: "events", Endpoint: "1.1.1.1:51820", Region: "eu", MaxClients: 1, Status: model.ServerStatusActive}
+	if err := repos.Servers.Create(ctx, server); err != nil {
+		return fmt.Errorf("create server: %v", err)
+	}
+
+	server.Status = model.ServerStatusDraining
+	if err := repos.Servers.Update(ctx, server, serverEvent(model.EventServerStatusChanged, server.Id)); err != nil {
+		return fmt.Errorf("update server: %v", err)
+	}
+	stale := *server
+	stale.Version--
+	err = repos.Servers.Update(ctx, &stale, outboxEvent("stale server update"))
+	if err := expectError(err, repository.ErrVersionConflict, "stale server update"); err != nil {
+		return err
+	}
+
+	got, err := repos.Servers.GetById(ctx, server.Id)
+	if err != nil || got.Status != model.ServerStatusDraining {
+		return fmt.Errorf("server status after update: %+v, %v", got, err)
+	}
+
+	keys := &model.WireGuardKeys{Id: primitive.NewObjectID(), UserId: user.Id, ServerId: server.Id, PublicKey: "pub", IpAddress: "10.0.0.2/32"}
+	if err := repos.Keys.Allocate(ctx, keys, serverEvent(model.EventPeerCreated, server.Id)); err != nil {
+		return fmt.Errorf("allocate: %v", err)
+	}
+	err = repos.Keys.Allocate(ctx, &model.WireGuardKeys{UserId: primitive.NewObjectID(), ServerId: server.Id, IpAddress: "10.0.0.3/32"}, outboxEvent("full server"))
+	if err := expectError(err, repository.ErrServerFull, "full server"); err != nil {
+		return err
+	}
+
+	previousRotatedAt := keys.LastRotatedAt
+	keys.PublicKey = "rotated"
+	rotated, err := repos.Keys.Rotate(ctx, keys, previousRotatedAt, serverEvent(model.EventKeysRotated, server.Id))
+	if err != nil || !rotated {
+		return fmt.Errorf("rotate: %v, %v", rotated, err)
+	}
+	rotated, err = repos.Keys.Rotate(ctx, keys, previousRotatedAt, outboxEvent("lost rotation"))
+	if err != nil || rotated {
+		return fmt.Errorf("rotate with stale last_rotated_at: %v, %v", rotated, err)
+	}
+
+	if err := repos.Keys.Revoke(ctx, keys, serverEvent(model.EventPeerRevoked, server.Id)); err != nil {
+		return fmt.Errorf("revoke: %v", err)
+	}
+	err = repos.Keys.Revoke(ctx, keys, outboxEvent("second revoke"))
+	if err := expectError(err, repository.ErrKeysNotFound, "second revoke"); err != nil {
+		return err
+	}
+
+	got, err = repos.Servers.GetById(ctx, server.Id)
+	if err != nil {
+		return fmt.Errorf("get server: %v", err)
+	}
+	if got.CurrentClients != 0 {
+		return fmt.Errorf("current_clients is %d after revoke", got.CurrentClients)
+	}
+
+	due, err := repos.Outbox.Due(ctx, time.Now().Add(time.Second), 10)
+	if err != nil {
+		return fmt.Errorf("due: %v", err)
+	}
+
+	want := []string{model.EventUserRegistered, model.EventServerStatusChanged, model.EventPeerCreated, model.EventKeysRotated, model.EventPeerRevoked}
+	var types []string
+	for _, event := range due {
+		types = append(types, event.Type)
+	}
+	if fmt.Sprint(types) != fmt.Sprint(want) {
+		return fmt.Errorf("outbox holds %v, want %v", types, want)
+	}
+
+	peerEvents, err := repos.Outbox.ListSince(ctx, server.Id, []string{model.EventPeerCreated, model.EventPeerRevoked}, due[2].OccurredAt, 10)
+	if err != nil || len(peerEvents) != 2 || peerEvents[0].Id != due[2].Id || peerEvents[1].Id != due[4].Id {
+		return fmt.Errorf("peer events since allocation: %v, %v", peerEvents, err)
+	}
+	if peerEvents[0].ServerId != server.Id.Hex() {
+		return fmt.Errorf("stored event has server_id %q, want %q", peerEvents[0].ServerId, server.Id.Hex())
+	}
+
+	other, err := repos.Outbox.ListSince(ctx, primitive.NewObjectID(), []string{model.EventPeerCreated, model.EventPeerRevoked}, time.Time{}, 10)
+	if err != nil || len(other) != 0 {
+		return fmt.Errorf("events of another server: %v, %v", other, err)
+	}
+
+	later, err := repos.Outbox.ListSince(ctx, server.Id, []string{model.EventPeerCreated}, due[4].OccurredAt.Add(time.Second), 10)
+	if err != nil || len(later) != 0 {
+		return fmt.Errorf("events after the last one: %v, %v", later, err)
+	}
+
+	first, second := due[0], due[1]
+	if first.Status != model.OutboxStatusPending || first.Id.IsZero() || first.OccurredAt.IsZero() {
+		return fmt.Errorf("stored event %+v", first)
+	}
+
+	first.Status = model.OutboxStatusDelivered
+	first.Delivered = []string{"a", "b"}
+	deliveredAt := time.Now()
+	first.DeliveredAt = &deliveredAt
+	if err := repos.Outbox.UpdateDelivery(ctx, first); err != nil {
+		return fmt.Errorf("mark delivered: %v", err)
+	}
+
+	second.Attempts = 3
+	second.LastError = "b: unavailable"
+	second.NextAttemptAt = time.Now().Add(time.Hour)
+	if err := repos.Outbox.UpdateDelivery(ctx, second); err != nil {
+		return fmt.Errorf("schedule retry: %v", err)
+	}
+
+	due, err = repos.Outbox.Due(ctx, time.Now().Add(time.Second), 10)
+	if err != nil || len(due) != 3 {
+		return fmt.Errorf("due after delivery: %d events, %v", len(due), err)
+	}
+
+	second.Status = model.OutboxStatusDead
+	if err := repos.Outbox.UpdateDelivery(ctx, second); err != nil {
+		return fmt.Errorf("mark dead: %v", err)
+	}
+
+	dead, err := repos.Outbox.ListByStatus(ctx, model.OutboxStatusDead, 10)
+	if err != nil || len(dead) != 1 || dead[0].Id != second.Id || dead[0].Attempts != 3 || dead[0].LastError != second.LastError {
+		return fmt.Errorf("dead letters: %v, %v", dead, err)
+	}
+
+	delivered, err := repos.Outbox.ListByStatus(ctx, model.OutboxStatusDelivered, 10)
+	if err != nil || len(delivered) != 1 || len(delivered[0].Delivered) != 2 || delivered[0].DeliveredAt == nil {
+		return fmt.Errorf("delivered events: %v, %v", delivered, err)
+	}
+
+	requeued, err := repos.Outbox.Requeue(ctx, first.Id, time.Now())
+	if err != nil || requeued {
+		return fmt.Errorf("requeue of delivered event: %v, %v", requeued, err)
+	}
+
+	requeued, err = repos.Outbox.Requeue(ctx, second.Id, time.Now())
+	if err != nil || !requeued {
+		return fmt.Errorf("requeue: %v, %v", requeued, err)
+	}
+
+	due, err = repos.Outbox.Due(ctx, time.Now().Add(time.Second), 10)
+	if err != nil || len(due) != 4 || due[0].Id != second.Id || due[0].Attempts != 0 {
+		return fmt.Errorf("due after requeue: %d events, %v", len(due), err)
+	}
+	return nil
+}
+
+func checkWebhooks(ctx context.Context, repos *repository.Repositories) error {
+	peers := &model.Webhook{Url: "https://example.com/peers", Secret: "s1", EventTypes: []string{model.EventPeerCreated, model.EventPeerRevoked}, Enabled: true}
+	users := &model.Webhook{Url: "https://example.com/users", Secret: "s2", EventTypes: []string{model.EventUserRegistered}, Enabled: true}
+	disabled := &model.Webhook{Url: "https://example.com/off", Secret: "s3", EventTypes: []string{model.EventPeerCreated}}
+
+	for _, webhook := range []*model.Webhook{peers, users, disabled} {
+		if err := repos.Webhooks.Create(ctx, webhook); err != nil {
+			return fmt.Errorf("create webhook: %v", err)
+		}
+	}
+
+	got, err := repos.Webhooks.GetById(ctx, peers.Id)
+	if err != nil || got.Url != peers.Url || got.Secret != peers.Secret || len(got.EventTypes) != 2 || !got.Enabled {
+		return fmt.Errorf("get webhook: %+v, %v", got, err)
+	}
+
+	all, err := repos.Webhooks.List(ctx)
+	if err != nil || len(all) != 3 {
+		return fmt.Errorf("list webhooks: %d, %v", len(all), err)
+	}
+
+	subscribed, err := repos.Webhooks.ListByEventType(ctx, model.EventPeerCreated)
+	if err != nil || len(subscribed) != 1 || subscribed[0].Id != peers.Id {
+		return fmt.Errorf("webhooks for %s: %v, %v", model.EventPeerCreated, subscribed, err)
+	}
+
+	eventId := primitive.NewObjectID()
+	for attempt, success := range []bool{false, true} {
+		delivery := &model.WebhookDelivery{
+			WebhookId: peers.Id, EventId: eventId, EventType: model.EventPeerCreated,
+			Attempt: attempt + 1, Success: success, StatusCode: 500, Error: "unexpected status 500", DurationMs: 12,
+			CreatedAt: time.Now().Add(time.Duration(attempt) * time.Second),
+		}
+		if err := repos.Webhooks.RecordDelivery(ctx, delivery); err != nil {
+			return fmt.Errorf("record delivery: %v", err)
+		}
+
+		delivered, err := repos.Webhooks.Delivered(ctx, peers.Id, eventId)
+		if err != nil || delivered != success {
+			return fmt.Errorf("delivered after attempt %d: %v, %v", attempt+1, delivered, err)
+		}
+	}
+
+	deliveries, err := repos.Webhooks.ListDeliveries(ctx, peers.Id, 10)
+	if err != nil || len(deliveries) != 2 || deliveries[0].Attempt != 2 || deliveries[1].Error == "" || deliveries[1].DurationMs != 12 {
+		return fmt.Errorf("deliveries: %v, %v", deliveries, err)
+	}
+
+	if err := repos.Webhooks.Delete(ctx, peers.Id); err != nil {
+		return fmt.Errorf("delete webhook: %v", err)
+	}
+	if err := expectError(repos.Webhooks.Delete(ctx, peers.Id), repository.ErrWebhookNotFound, "second delete"); err != nil {
+		return err
+	}
+	_, err = repos.Webhooks.GetById(ctx, peers.Id)
+	return expectError(err, repository.ErrWebhookNotFound, "get deleted webhook")
+}
@@ -2,50 +2,65 @@ package repository
 
 import (
 	"context"
-	"errors"
+	"log/slog"
+	"sync"
 	"time"
 
-	"github.com/shivamp1998/vpn_backend/internal/database"
 	"github.com/shivamp1998/vpn_backend/internal/model"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-type ServerRepository struct {
+type MongoServerRepository struct {
 	collection *mongo.Collection
+	keys       *mongo.Collection
+
+	mu sync.Mutex
+	// miscounted holds the servers whose count was wrong on the previous
+	// RecountClients, with the counts seen then.
+	miscounted map[primitive.ObjectID]clientCount
+}
+
+type clientCount struct {
+	current int32
+	peers   int32
 }
 
-func NewServerRepository() *ServerRepository {
-	return &ServerRepository{
-		collection: database.DB.Collection("servers"),
+func NewMongoServerRepository(db *mongo.Database) *MongoServerRepository {
+	return &MongoServerRepository{
+		collection: db.Collection("servers"),
+		keys:       db.Collection("wireguard_keys"),
+		miscounted: make(map[primitive.ObjectID]clientCount),
 	}
 }
 
-func (r *ServerRepository) Create(ctx context.Context, server *model.Server) error {
+func (r *MongoServerRepository) Create(ctx context.Context, server *model.Server) error {
 	server.Id = primitive.NewObjectID()
 	server.CreatedAt = time.Now()
 	server.UpdatedAt = time.Now()
 	server.CurrentClients = 0
+	server.Version = 1
 
 	_, err := r.collection.InsertOne(ctx, server)
 	return err
 }
 
-func (r *ServerRepository) GetById(ctx context.Context, id primitive.ObjectID) (*model.Server, error) {
+func (r *MongoServerRepository) GetById(ctx context.Context, id primitive.ObjectID) (*model.Server, error) {
 	var server model.Server
 	filter := bson.M{"_id": id}
 
 	err := r.collection.FindOne(ctx, filter).Decode(&server)
 
 	if err == mongo.ErrNoDocuments {
-		return nil, errors.New("server not found")
+		return nil, ErrServerNotFound
 	}
 
 	return &server, err
 }
 
-func (r *ServerRepository) ListAll(ctx context.Context) ([]*model.Server, error) {
+func (r *MongoServerRepository) ListAll(ctx context.Context) ([]*model.Server, error) {
 	var servers []*model.Server
 
 	cursor, err := r.collection.Find(ctx, bson.M{})
@@ -60,11 +75,109 @@ func (r *ServerRepository) ListAll(ctx context.Context) ([]*model.Server, error)
 	return servers, err
 }
 
-func (r *ServerRepository) Update(ctx context.Context, server *model.Server) error {
-	server.UpdatedAt = time.Now()
-	filter := bson.M{"_id": server.Id}
-	update := bson.M{"$set": server}
+func (r *MongoServerRepository) Update(ctx context.Context, server *model.Server, events ...*model.OutboxEvent) error {
+	updatedAt := time.Now()
+	filter := bson.M{"_id": server.Id, "version": server.Version}
+	update := bson.M{
+		"$set": bson.M{
+			"name":                  server.Name,
+			"endpoint":              server.Endpoint,
+			"public_key":            server.PublicKey,
+			"private_key_encrypted": server.PrivateKeyEncrypted,
+			"region":                server.Region,
+			"max_clients":           server.MaxClients,
+			"key_rotation_max_age":  server.KeyRotationMaxAge,
+			"key_rotation_mode":     server.KeyRotationMode,
+			"status":                server.Status,
+			"updated_at":            updatedAt,
+		},
+		"$inc": bson.M{"version": 1},
+	}
 
-	_, err := r.collection.UpdateOne(ctx, filter, update)
-	return err
+	result, err := r.collection.UpdateOne(ctx, filter, stageOutbox(update, events))
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		if _, err := r.GetById(ctx, server.Id); err != nil {
+			return err
+		}
+		return &VersionConflictError{Collection: "servers", Id: server.Id, Version: server.Version}
+	}
+
+	server.UpdatedAt = updatedAt
+	server.Version++
+	return nil
+}
+
+// RecountClients repairs the slots Allocate and Revoke can leak. They change
+// current_clients and the peer in separate writes, so a crash or a failed
+// write in between leaves the count off by one for good.
+//
+// An allocation or revoke in progress is also off by one until its second
+// write, so a server is only corrected once it has been found off by the
+// same counts on two calls in a row, and only if its count has not changed
+// since. Calls should be far enough apart for any write in progress to have
+// finished.
+func (r *MongoServerRepository) RecountClients(ctx context.Context) (int, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{}, options.Find().SetProjection(bson.M{"current_clients": 1}))
+	if err != nil {
+		return 0, err
+	}
+	var servers []struct {
+		Id             primitive.ObjectID `bson:"_id"`
+		CurrentClients int32              `bson:"current_clients"`
+	}
+	if err := cursor.All(ctx, &servers); err != nil {
+		return 0, err
+	}
+
+	// Counted after the servers were read, so a write in progress shows up
+	// as a count that is too high, never as one that is too low.
+	pipeline := mongo.Pipeline{{{Key: "$group", Value: bson.M{"_id": "$server_id", "peers": bson.M{"$sum": 1}}}}}
+	cursor, err = r.keys.Aggregate(ctx, pipeline)
+	if err != nil {
+		return 0, err
+	}
+	var groups []struct {
+		ServerId primitive.ObjectID `bson:"_id"`
+		Peers    int32              `bson:"peers"`
+	}
+	if err := cursor.All(ctx, &groups); err != nil {
+		return 0, err
+	}
+	peers := make(map[primitive.ObjectID]int32, len(groups))
+	for _, group := range groups {
+		peers[group.ServerId] = group.Peers
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	miscounted := make(map[primitive.ObjectID]clientCount)
+	corrected := 0
+	for _, server := range servers {
+		count := clientCount{current: server.CurrentClients, peers: peers[server.Id]}
+		if count.current == count.peers {
+			continue
+		}
+		if r.miscounted[server.Id] != count {
+			miscounted[server.Id] = count
+			continue
+		}
+
+		filter := bson.M{"_id": server.Id, "current_clients": count.current}
+		result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"current_clients": count.peers}})
+		if err != nil {
+			return corrected, err
+		}
+		if result.ModifiedCount > 0 {
+			slog.WarnContext(ctx, "Corrected client count", "server_id", server.Id.Hex(), "current_clients", count.current, "peers", count.peers)
+			corrected++
+		}
+	}
+	r.miscounted = miscounted
+
+	return corrected, nil
 }
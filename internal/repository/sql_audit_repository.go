@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/shivamp1998/vpn_backend/internal/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const sqlAuditColumns = `id, sequence, time, actor_id, actor_email, action, target_type, target_id,
+	procedure, source_ip, user_agent, outcome, reason, prev_hash, hash`
+
+type SQLAuditRepository struct {
+	db      *sql.DB
+	dialect *sqlDialect
+}
+
+func (r *SQLAuditRepository) Append(ctx context.Context, event *model.AuditEvent) error {
+	event.Id = primitive.NewObjectID()
+
+	var actorId string
+	if !event.ActorId.IsZero() {
+		actorId = event.ActorId.Hex()
+	}
+
+	query := r.dialect.rebind("INSERT INTO audit_events (" + sqlAuditColumns + ") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
+	_, err := r.db.ExecContext(ctx, query,
+		event.Id.Hex(), event.Sequence, sqlTime(event.Time), actorId, event.ActorEmail, event.Action, event.TargetType,
+		event.TargetId, event.Procedure, event.SourceIp, event.UserAgent, event.Outcome, event.Reason, event.PrevHash, event.Hash)
+	return r.dialect.wrapError(err)
+}
+
+func (r *SQLAuditRepository) Last(ctx context.Context) (*model.AuditEvent, error) {
+	query := "SELECT " + sqlAuditColumns + " FROM audit_events ORDER BY sequence DESC LIMIT 1"
+	event, err := r.scan(r.db.QueryRowContext(ctx, query))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return event, err
+}
+
+func (r *SQLAuditRepository) List(ctx context.Context, filter AuditFilter, limit int) ([]*model.AuditEvent, error) {
+	var conditions []string
+	var args []any
+
+	where := func(condition string, arg any) {
+		conditions = append(conditions, condition)
+		args = append(args, arg)
+	}
+
+	if !filter.ActorId.IsZero() {
+		where("actor_id = ?", filter.ActorId.Hex())
+	}
+	if filter.Action != "" {
+		where("action = ?", filter.Action)
+	}
+	if filter.TargetType != "" {
+		where("target_type = ?", filter.TargetType)
+	}
+	if filter.TargetId != "" {
+		where("target_id = ?", filter.TargetId)
+	}
+	if filter.Outcome != "" {
+		where("outcome = ?", filter.Outcome)
+	}
+	if !filter.Since.IsZero() {
+		where("time >= ?", sqlTime(filter.Since))
+	}
+	if !filter.Until.IsZero() {
+		where("time < ?", sqlTime(filter.Until))
+	}
+	if filter.BeforeSequence > 0 {
+		where("sequence < ?", filter.BeforeSequence)
+	}
+
+	query := "SELECT " + sqlAuditColumns + " FROM audit_events"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY sequence DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := r.db.QueryContext(ctx, r.dialect.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*model.AuditEvent
+	for rows.Next() {
+		event, err := r.scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+func (r *SQLAuditRepository) scan(row rowScanner) (*model.AuditEvent, error) {
+	var event model.AuditEvent
+	var id, actorId string
+
+	err := row.Scan(&id, &event.Sequence, &event.Time, &actorId, &event.ActorEmail, &event.Action, &event.TargetType,
+		&event.TargetId, &event.Procedure, &event.SourceIp, &event.UserAgent, &event.Outcome, &event.Reason,
+		&event.PrevHash, &event.Hash)
+	if err != nil {
+		return nil, err
+	}
+
+	if actorId != "" {
+		if event.ActorId, err = parseObjectId(actorId); err != nil {
+			return nil, err
+		}
+	}
+	event.Id, err = parseObjectId(id)
+	return &event, err
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/shivamp1998/vpn_backend/internal/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const sqlShareLinkColumns = "id, token_hash, user_id, server_id, kind, expires_at, created_at, used_at"
+
+type SQLConfigShareLinkRepository struct {
+	db      *sql.DB
+	dialect *sqlDialect
+}
+
+func (r *SQLConfigShareLinkRepository) Create(ctx context.Context, link *model.ConfigShareLink) error {
+	link.Id = primitive.NewObjectID()
+	link.CreatedAt = sqlNow()
+	link.UsedAt = nil
+
+	query := r.dialect.rebind("INSERT INTO config_share_links (" + sqlShareLinkColumns + ") VALUES (?, ?, ?, ?, ?, ?, ?, ?)")
+	_, err := r.db.ExecContext(ctx, query,
+		link.Id.Hex(), link.TokenHash, link.UserId.Hex(), link.ServerId.Hex(), link.Kind,
+		sqlTime(link.ExpiresAt), link.CreatedAt, nullTime(link.UsedAt))
+	return r.dialect.wrapError(err)
+}
+
+func (r *SQLConfigShareLinkRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*model.ConfigShareLink, error) {
+	query := r.dialect.rebind("SELECT " + sqlShareLinkColumns + " FROM config_share_links WHERE token_hash = ?")
+	link, err := r.scan(r.db.QueryRowContext(ctx, query, tokenHash))
+	if err == sql.ErrNoRows {
+		return nil, ErrShareLinkNotFound
+	}
+	return link, err
+}
+
+func (r *SQLConfigShareLinkRepository) Consume(ctx context.Context, tokenHash string, now time.Time) (*model.ConfigShareLink, error) {
+	now = sqlTime(now)
+	query := r.dialect.rebind(`UPDATE config_share_links SET used_at = ?
+		WHERE token_hash = ? AND used_at IS NULL AND expires_at > ?
+		RETURNING ` + sqlShareLinkColumns)
+
+	link, err := r.scan(r.db.QueryRowContext(ctx, query, now, tokenHash, now))
+	if err == sql.ErrNoRows {
+		return nil, ErrShareLinkNotAvailable
+	}
+	return link, err
+}
+
+func (r *SQLConfigShareLinkRepository) RecordRetrieval(ctx context.Context, retrieval *model.ConfigShareRetrieval) error {
+	retrieval.Id = primitive.NewObjectID()
+	retrieval.CreatedAt = sqlNow()
+
+	query := r.dialect.rebind(`INSERT INTO config_share_retrievals
+		(id, link_id, user_id, outcome, source_ip, user_agent, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)`)
+	_, err := r.db.ExecContext(ctx, query,
+		retrieval.Id.Hex(), retrieval.LinkId.Hex(), retrieval.UserId.Hex(), retrieval.Outcome,
+		retrieval.SourceIp, retrieval.UserAgent, retrieval.CreatedAt)
+	return err
+}
+
+func (r *SQLConfigShareLinkRepository) scan(row rowScanner) (*model.ConfigShareLink, error) {
+	var link model.ConfigShareLink
+	var id, userId, serverId string
+	var usedAt sql.NullTime
+
+	err := row.Scan(&id, &link.TokenHash, &userId, &serverId, &link.Kind, &link.ExpiresAt, &link.CreatedAt, &usedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	link.UsedAt = timePointer(usedAt)
+
+	if link.Id, err = parseObjectId(id); err != nil {
+		return nil, err
+	}
+	if link.UserId, err = parseObjectId(userId); err != nil {
+		return nil, err
+	}
+	link.ServerId, err = parseObjectId(serverId)
+	return &link, err
+}
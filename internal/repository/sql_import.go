@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/shivamp1998/vpn_backend/internal/model"
+)
+
+// SQLImporter copies records into a SQL backend as they are, keeping ids and
+// timestamps, which the repositories' Create methods would overwrite. Rows
+// that already exist are skipped, so an interrupted import can be re-run.
+type SQLImporter struct {
+	db      *sql.DB
+	dialect *sqlDialect
+}
+
+type ImportCounts struct {
+	Users   int
+	Servers int
+	Keys    int
+}
+
+func NewSQLiteImporter(db *sql.DB) *SQLImporter {
+	return &SQLImporter{db: db, dialect: sqliteDialect}
+}
+
+func NewPostgresImporter(db *sql.DB) *SQLImporter {
+	return &SQLImporter{db: db, dialect: postgresDialect}
+}
+
+// Import writes users, servers and keys in a single transaction and returns
+// how many rows of each were inserted.
+func (i *SQLImporter) Import(ctx context.Context, users []*model.User, servers []*model.Server, keys []*model.WireGuardKeys) (ImportCounts, error) {
+	var counts ImportCounts
+
+	tx, err := i.db.BeginTx(ctx, nil)
+	if err != nil {
+		return counts, err
+	}
+	defer tx.Rollback()
+
+	userQuery := i.dialect.rebind("INSERT INTO users (" + sqlUserColumns + ") VALUES (?, ?, ?, ?, ?, ?, ?, ?) ON CONFLICT DO NOTHING")
+	for _, user := range users {
+		inserted, err := i.exec(ctx, tx, userQuery,
+			user.Id.Hex(), user.Email, user.PasswordHash, user.IsActive, user.Plan, sqlTime(user.CreatedAt), sqlTime(user.UpdatedAt), user.Role)
+		if err != nil {
+			return counts, fmt.Errorf("user %s: %v", user.Id.Hex(), err)
+		}
+		counts.Users += inserted
+	}
+
+	serverQuery := i.dialect.rebind("INSERT INTO servers (" + sqlServerColumns + ") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) ON CONFLICT DO NOTHING")
+	for _, server := range servers {
+		inserted, err := i.exec(ctx, tx, serverQuery,
+			server.Id.Hex(), server.Name, server.Endpoint, server.PublicKey, server.PrivateKeyEncrypted, server.Region,
+			server.MaxClients, server.CurrentClients, int64(server.KeyRotationMaxAge), server.KeyRotationMode, importStatus(server.Status),
+			sqlTime(server.CreatedAt), sqlTime(server.UpdatedAt), importVersion(server.Version))
+		if err != nil {
+			return counts, fmt.Errorf("server %s: %v", server.Id.Hex(), err)
+		}
+		counts.Servers += inserted
+	}
+
+	keysQuery := i.dialect.rebind("INSERT INTO wireguard_keys (" + sqlKeysColumns + ") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) ON CONFLICT DO NOTHING")
+	for _, k := range keys {
+		// Keys created before rotation tracking have no last_rotated_at.
+		lastRotatedAt := k.LastRotatedAt
+		if lastRotatedAt.IsZero() {
+			lastRotatedAt = k.CreatedAt
+		}
+
+		inserted, err := i.exec(ctx, tx, keysQuery,
+			k.Id.Hex(), k.UserId.Hex(), k.ServerId.Hex(), k.PrivateKeyEncrypted, k.PublicKey, k.IpAddress,
+			sqlTime(k.CreatedAt), sqlTime(lastRotatedAt), k.RotationRequired, nullTime(k.RotationRequestedAt), importVersion(k.Version))
+		if err != nil {
+			return counts, fmt.Errorf("keys %s: %v", k.Id.Hex(), err)
+		}
+		counts.Keys += inserted
+	}
+
+	// Client counts copied from the source may be stale; derive them from
+	// the peers that actually exist.
+	recount := "UPDATE servers SET current_clients = (SELECT COUNT(*) FROM wireguard_keys WHERE wireguard_keys.server_id = servers.id)"
+	if _, err := tx.ExecContext(ctx, recount); err != nil {
+		return counts, fmt.Errorf("recounting server clients: %v", err)
+	}
+
+	return counts, tx.Commit()
+}
+
+func (i *SQLImporter) exec(ctx context.Context, tx *sql.Tx, query string, args ...any) (int, error) {
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	affected, err := result.RowsAffected()
+	return int(affected), err
+}
+
+// importStatus treats servers created before statuses existed as active,
+// matching the backfill migration for Mongo.
+func importStatus(status string) string {
+	if status == "" {
+		return model.ServerStatusActive
+	}
+	return status
+}
+
+// importVersion starts documents written before versioning at 1, matching
+// what the backfill migration does for Mongo.
+func importVersion(version int64) int64 {
+	if version < 1 {
+		return 1
+	}
+	return version
+}
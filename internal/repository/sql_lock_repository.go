@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type SQLLockRepository struct {
+	db      *sql.DB
+	dialect *sqlDialect
+}
+
+func (r *SQLLockRepository) Acquire(ctx context.Context, name, owner string, ttl time.Duration) (bool, error) {
+	now := sqlNow()
+
+	// The conflicting row is only overwritten when we already own it or its
+	// lease has run out; otherwise no row is affected.
+	query := r.dialect.rebind(`INSERT INTO locks (name, owner, acquired_at, expires_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (name) DO UPDATE SET owner = excluded.owner, acquired_at = excluded.acquired_at, expires_at = excluded.expires_at
+		WHERE locks.owner = excluded.owner OR locks.expires_at <= excluded.acquired_at`)
+	result, err := r.db.ExecContext(ctx, query, name, owner, now, now.Add(ttl))
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+func (r *SQLLockRepository) Release(ctx context.Context, name, owner string) error {
+	_, err := r.db.ExecContext(ctx, r.dialect.rebind("DELETE FROM locks WHERE name = ? AND owner = ?"), name, owner)
+	return err
+}
@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/shivamp1998/vpn_backend/internal/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type SQLNotificationRepository struct {
+	db      *sql.DB
+	dialect *sqlDialect
+}
+
+func (r *SQLNotificationRepository) Create(ctx context.Context, notification *model.Notification) error {
+	notification.Id = primitive.NewObjectID()
+	notification.CreatedAt = sqlNow()
+
+	query := r.dialect.rebind(`INSERT INTO notifications
+		(id, user_id, server_id, type, message, created_at, read_at) VALUES (?, ?, ?, ?, ?, ?, ?)`)
+	_, err := r.db.ExecContext(ctx, query,
+		notification.Id.Hex(), notification.UserId.Hex(), notification.ServerId.Hex(), notification.Type,
+		notification.Message, notification.CreatedAt, nullTime(notification.ReadAt))
+	return err
+}
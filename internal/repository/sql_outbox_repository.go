@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/shivamp1998/vpn_backend/internal/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const sqlOutboxColumns = "id, type, aggregate_id, server_id, payload, occurred_at, status, attempts, next_attempt_at, last_error, delivered_at, delivered"
+
+// insertOutboxEvents adds events in tx, the transaction making the change
+// they describe.
+func insertOutboxEvents(ctx context.Context, tx *sql.Tx, dialect *sqlDialect, events []*model.OutboxEvent) error {
+	prepareOutboxEvents(events, sqlNow())
+
+	query := dialect.rebind("INSERT INTO outbox_events (" + sqlOutboxColumns + ") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
+	for _, event := range events {
+		_, err := tx.ExecContext(ctx, query,
+			event.Id.Hex(), event.Type, event.AggregateId, event.ServerId, event.Payload, event.OccurredAt, event.Status,
+			event.Attempts, event.NextAttemptAt, event.LastError, nullTime(event.DeliveredAt), "[]")
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type SQLOutboxRepository struct {
+	db      *sql.DB
+	dialect *sqlDialect
+}
+
+func (r *SQLOutboxRepository) Due(ctx context.Context, now time.Time, limit int) ([]*model.OutboxEvent, error) {
+	query := r.dialect.rebind("SELECT " + sqlOutboxColumns + " FROM outbox_events WHERE status = ? AND next_attempt_at <= ? ORDER BY occurred_at LIMIT ?")
+	return r.query(ctx, query, model.OutboxStatusPending, sqlTime(now), limit)
+}
+
+func (r *SQLOutboxRepository) UpdateDelivery(ctx context.Context, event *model.OutboxEvent) error {
+	delivered, err := json.Marshal(event.Delivered)
+	if err != nil {
+		return err
+	}
+
+	query := r.dialect.rebind(`UPDATE outbox_events SET status = ?, attempts = ?, next_attempt_at = ?, last_error = ?,
+		delivered_at = ?, delivered = ? WHERE id = ?`)
+	_, err = r.db.ExecContext(ctx, query,
+		event.Status, event.Attempts, sqlTime(event.NextAttemptAt), event.LastError,
+		nullTime(event.DeliveredAt), string(delivered), event.Id.Hex())
+	return err
+}
+
+func (r *SQLOutboxRepository) ListSince(ctx context.Context, serverId primitive.ObjectID, types []string, since time.Time, limit int) ([]*model.OutboxEvent, error) {
+	if len(types) == 0 {
+		return nil, nil
+	}
+
+	args := []any{serverId.Hex(), sqlTime(since)}
+	for _, eventType := range types {
+		args = append(args, eventType)
+	}
+	args = append(args, limit)
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(types)), ", ")
+	query := r.dialect.rebind("SELECT " + sqlOutboxColumns + " FROM outbox_events WHERE server_id = ? AND occurred_at >= ? AND type IN (" +
+		placeholders + ") ORDER BY occurred_at, id LIMIT ?")
+	return r.query(ctx, query, args...)
+}
+
+func (r *SQLOutboxRepository) ListByStatus(ctx context.Context, status string, limit int) ([]*model.OutboxEvent, error) {
+	query := r.dialect.rebind("SELECT " + sqlOutboxColumns + " FROM outbox_events WHERE status = ? ORDER BY occurred_at DESC LIMIT ?")
+	return r.query(ctx, query, status, limit)
+}
+
+func (r *SQLOutboxRepository) Requeue(ctx context.Context, id primitive.ObjectID, now time.Time) (bool, error) {
+	query := r.dialect.rebind("UPDATE outbox_events SET status = ?, attempts = 0, next_attempt_at = ? WHERE id = ? AND status = ?")
+	result, err := r.db.ExecContext(ctx, query, model.OutboxStatusPending, sqlTime(now), id.Hex(), model.OutboxStatusDead)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+func (r *SQLOutboxRepository) query(ctx context.Context, query string, args ...any) ([]*model.OutboxEvent, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*model.OutboxEvent
+	for rows.Next() {
+		event, err := r.scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+func (r *SQLOutboxRepository) scan(row rowScanner) (*model.OutboxEvent, error) {
+	var event model.OutboxEvent
+	var id, delivered string
+	var deliveredAt sql.NullTime
+
+	err := row.Scan(&id, &event.Type, &event.AggregateId, &event.ServerId, &event.Payload, &event.OccurredAt, &event.Status,
+		&event.Attempts, &event.NextAttemptAt, &event.LastError, &deliveredAt, &delivered)
+	if err != nil {
+		return nil, err
+	}
+
+	event.DeliveredAt = timePointer(deliveredAt)
+	if err := json.Unmarshal([]byte(delivered), &event.Delivered); err != nil {
+		return nil, err
+	}
+
+	event.Id, err = parseObjectId(id)
+	return &event, err
+}
@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// sqlDialect captures the differences between the SQL backends. Queries are
+// written with "?" placeholders and rewritten by rebind where needed.
+type sqlDialect struct {
+	name              string
+	rebind            func(query string) string
+	isUniqueViolation func(err error) bool
+}
+
+var postgresDialect = &sqlDialect{
+	name:   "postgres",
+	rebind: rebindDollar,
+	isUniqueViolation: func(err error) bool {
+		var pgErr *pgconn.PgError
+		return errors.As(err, &pgErr) && pgErr.Code == "23505"
+	},
+}
+
+var sqliteDialect = &sqlDialect{
+	name:   "sqlite",
+	rebind: func(query string) string { return query },
+	isUniqueViolation: func(err error) bool {
+		var sqliteErr *sqlite.Error
+		if !errors.As(err, &sqliteErr) {
+			return false
+		}
+		return sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE || sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY
+	},
+}
+
+func NewPostgresRepositories(db *sql.DB) *Repositories {
+	return newSQLRepositories(db, postgresDialect)
+}
+
+func NewSQLiteRepositories(db *sql.DB) *Repositories {
+	return newSQLRepositories(db, sqliteDialect)
+}
+
+func newSQLRepositories(db *sql.DB, dialect *sqlDialect) *Repositories {
+	return &Repositories{
+		Users:         &SQLUserRepository{db: db, dialect: dialect},
+		Servers:       &SQLServerRepository{db: db, dialect: dialect},
+		Keys:          &SQLWireGuardKeysRepository{db: db, dialect: dialect},
+		ShareLinks:    &SQLConfigShareLinkRepository{db: db, dialect: dialect},
+		Notifications: &SQLNotificationRepository{db: db, dialect: dialect},
+		Locks:         &SQLLockRepository{db: db, dialect: dialect},
+		Audit:         &SQLAuditRepository{db: db, dialect: dialect},
+		Outbox:        &SQLOutboxRepository{db: db, dialect: dialect},
+		Webhooks:      &SQLWebhookRepository{db: db, dialect: dialect},
+	}
+}
+
+func rebindDollar(query string) string {
+	var rebound strings.Builder
+	n := 0
+
+	for _, r := range query {
+		if r == '?' {
+			n++
+			rebound.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		rebound.WriteRune(r)
+	}
+	return rebound.String()
+}
+
+func (d *sqlDialect) wrapError(err error) error {
+	if err != nil && d.isUniqueViolation(err) {
+		return fmt.Errorf("%w: %v", ErrDuplicateKey, err)
+	}
+	return err
+}
+
+// sqlNow returns the current time at the precision every SQL backend can
+// store, so values written and read back compare equal.
+func sqlNow() time.Time {
+	return sqlTime(time.Now())
+}
+
+func sqlTime(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Microsecond)
+}
+
+func parseObjectId(hex string) (primitive.ObjectID, error) {
+	return primitive.ObjectIDFromHex(strings.TrimSpace(hex))
+}
+
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: sqlTime(*t), Valid: true}
+}
+
+func timePointer(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	value := t.Time.UTC()
+	return &value
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
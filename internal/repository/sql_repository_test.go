@@ -0,0 +1,63 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/shivamp1998/vpn_backend/internal/database"
+	"github.com/shivamp1998/vpn_backend/internal/repository"
+	"github.com/shivamp1998/vpn_backend/internal/repository/repotest"
+)
+
+func TestSQLiteRepositories(t *testing.T) {
+	// Each check gets a database file of its own.
+	repotest.Run(t, func(t *testing.T) *repository.Repositories {
+		db, err := database.OpenSQLite(filepath.Join(t.TempDir(), "test.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { db.Close() })
+
+		if err := database.MigrateSQL(context.Background(), db, "sqlite"); err != nil {
+			t.Fatal(err)
+		}
+		return repository.NewSQLiteRepositories(db)
+	})
+}
+
+// TestPostgresRepositories runs against the database in POSTGRES_TEST_DSN.
+// Every table in it is emptied, so it must not point at real data.
+func TestPostgresRepositories(t *testing.T) {
+	dsn := os.Getenv("POSTGRES_TEST_DSN")
+	if dsn == "" {
+		t.Skip("POSTGRES_TEST_DSN is not set")
+	}
+
+	ctx := context.Background()
+	db, err := database.OpenPostgres(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if err := database.MigrateSQL(ctx, db, "postgres"); err != nil {
+		t.Fatal(err)
+	}
+
+	repotest.Run(t, func(t *testing.T) *repository.Repositories {
+		if err := truncatePostgres(ctx, db); err != nil {
+			t.Fatal(err)
+		}
+		return repository.NewPostgresRepositories(db)
+	})
+}
+
+func truncatePostgres(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, `TRUNCATE users, servers, wireguard_keys, config_share_links,
+		config_share_retrievals, notifications, locks, audit_events, outbox_events,
+		webhooks, webhook_deliveries CASCADE`)
+	return err
+}
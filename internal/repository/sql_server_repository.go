@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/shivamp1998/vpn_backend/internal/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const sqlServerColumns = "id, name, endpoint, public_key, private_key_encrypted, region, max_clients, current_clients, key_rotation_max_age, key_rotation_mode, status, created_at, updated_at, version"
+
+type SQLServerRepository struct {
+	db      *sql.DB
+	dialect *sqlDialect
+}
+
+func (r *SQLServerRepository) Create(ctx context.Context, server *model.Server) error {
+	server.Id = primitive.NewObjectID()
+	server.CreatedAt = sqlNow()
+	server.UpdatedAt = server.CreatedAt
+	server.CurrentClients = 0
+	server.Version = 1
+
+	query := r.dialect.rebind("INSERT INTO servers (" + sqlServerColumns + ") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
+	_, err := r.db.ExecContext(ctx, query,
+		server.Id.Hex(), server.Name, server.Endpoint, server.PublicKey, server.PrivateKeyEncrypted, server.Region,
+		server.MaxClients, server.CurrentClients, int64(server.KeyRotationMaxAge), server.KeyRotationMode, server.Status,
+		server.CreatedAt, server.UpdatedAt, server.Version)
+	return r.dialect.wrapError(err)
+}
+
+func (r *SQLServerRepository) GetById(ctx context.Context, id primitive.ObjectID) (*model.Server, error) {
+	query := r.dialect.rebind("SELECT " + sqlServerColumns + " FROM servers WHERE id = ?")
+	server, err := r.scan(r.db.QueryRowContext(ctx, query, id.Hex()))
+	if err == sql.ErrNoRows {
+		return nil, ErrServerNotFound
+	}
+	return server, err
+}
+
+func (r *SQLServerRepository) ListAll(ctx context.Context) ([]*model.Server, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+sqlServerColumns+" FROM servers ORDER BY created_at, id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var servers []*model.Server
+	for rows.Next() {
+		server, err := r.scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		servers = append(servers, server)
+	}
+	return servers, rows.Err()
+}
+
+func (r *SQLServerRepository) Update(ctx context.Context, server *model.Server, events ...*model.OutboxEvent) error {
+	updatedAt := sqlNow()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := r.dialect.rebind(`UPDATE servers SET name = ?, endpoint = ?, public_key = ?, private_key_encrypted = ?,
+		region = ?, max_clients = ?, key_rotation_max_age = ?, key_rotation_mode = ?, status = ?, updated_at = ?,
+		version = version + 1 WHERE id = ? AND version = ?`)
+	result, err := tx.ExecContext(ctx, query,
+		server.Name, server.Endpoint, server.PublicKey, server.PrivateKeyEncrypted,
+		server.Region, server.MaxClients, int64(server.KeyRotationMaxAge), server.KeyRotationMode, server.Status,
+		updatedAt, server.Id.Hex(), server.Version)
+	if err != nil {
+		return r.dialect.wrapError(err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		var exists int
+		err := tx.QueryRowContext(ctx, r.dialect.rebind("SELECT 1 FROM servers WHERE id = ?"), server.Id.Hex()).Scan(&exists)
+		if err == sql.ErrNoRows {
+			return ErrServerNotFound
+		}
+		if err != nil {
+			return err
+		}
+		return &VersionConflictError{Collection: "servers", Id: server.Id, Version: server.Version}
+	}
+
+	if err := insertOutboxEvents(ctx, tx, r.dialect, events); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	server.UpdatedAt = updatedAt
+	server.Version++
+	return nil
+}
+
+// RecountClients has nothing to correct: Allocate and Revoke change the
+// count in the transaction that changes the peers.
+func (r *SQLServerRepository) RecountClients(ctx context.Context) (int, error) {
+	return 0, nil
+}
+
+func (r *SQLServerRepository) scan(row rowScanner) (*model.Server, error) {
+	var server model.Server
+	var id string
+	var maxAge int64
+
+	err := row.Scan(&id, &server.Name, &server.Endpoint, &server.PublicKey, &server.PrivateKeyEncrypted, &server.Region,
+		&server.MaxClients, &server.CurrentClients, &maxAge, &server.KeyRotationMode, &server.Status, &server.CreatedAt, &server.UpdatedAt, &server.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	server.KeyRotationMaxAge = time.Duration(maxAge)
+	server.Id, err = parseObjectId(id)
+	return &server, err
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/shivamp1998/vpn_backend/internal/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const sqlUserColumns = "id, email, password_hash, is_active, plan, created_at, updated_at, role"
+
+type SQLUserRepository struct {
+	db      *sql.DB
+	dialect *sqlDialect
+}
+
+func (r *SQLUserRepository) Create(ctx context.Context, user *model.User, events ...*model.OutboxEvent) error {
+	if user.Id.IsZero() {
+		user.Id = primitive.NewObjectID()
+	}
+	user.CreatedAt = sqlNow()
+	user.UpdatedAt = user.CreatedAt
+	user.IsActive = true
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := r.dialect.rebind("INSERT INTO users (" + sqlUserColumns + ") VALUES (?, ?, ?, ?, ?, ?, ?, ?)")
+	_, err = tx.ExecContext(ctx, query,
+		user.Id.Hex(), user.Email, user.PasswordHash, user.IsActive, user.Plan, user.CreatedAt, user.UpdatedAt, user.Role)
+	if err != nil {
+		return r.dialect.wrapError(err)
+	}
+
+	if err := insertOutboxEvents(ctx, tx, r.dialect, events); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *SQLUserRepository) GetByEmail(ctx context.Context, email string) (*model.User, error) {
+	query := r.dialect.rebind("SELECT " + sqlUserColumns + " FROM users WHERE email = ?")
+	return r.scan(r.db.QueryRowContext(ctx, query, email))
+}
+
+func (r *SQLUserRepository) GetById(ctx context.Context, id primitive.ObjectID) (*model.User, error) {
+	query := r.dialect.rebind("SELECT " + sqlUserColumns + " FROM users WHERE id = ?")
+	return r.scan(r.db.QueryRowContext(ctx, query, id.Hex()))
+}
+
+func (r *SQLUserRepository) SetRole(ctx context.Context, id primitive.ObjectID, role string) error {
+	query := r.dialect.rebind("UPDATE users SET role = ?, updated_at = ? WHERE id = ?")
+	result, err := r.db.ExecContext(ctx, query, role, sqlNow(), id.Hex())
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *SQLUserRepository) scan(row rowScanner) (*model.User, error) {
+	var user model.User
+	var id string
+
+	err := row.Scan(&id, &user.Email, &user.PasswordHash, &user.IsActive, &user.Plan, &user.CreatedAt, &user.UpdatedAt, &user.Role)
+	if err == sql.ErrNoRows {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	user.Id, err = parseObjectId(id)
+	return &user, err
+}
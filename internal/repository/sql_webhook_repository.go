@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"slices"
+
+	"github.com/shivamp1998/vpn_backend/internal/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const sqlWebhookColumns = "id, url, secret, event_types, description, enabled, created_by, created_at"
+
+const sqlWebhookDeliveryColumns = `id, webhook_id, event_id, event_type, attempt, test, success, status_code, error,
+	duration_ms, created_at`
+
+type SQLWebhookRepository struct {
+	db      *sql.DB
+	dialect *sqlDialect
+}
+
+func (r *SQLWebhookRepository) Create(ctx context.Context, webhook *model.Webhook) error {
+	webhook.Id = primitive.NewObjectID()
+	webhook.CreatedAt = sqlNow()
+
+	eventTypes, err := json.Marshal(webhook.EventTypes)
+	if err != nil {
+		return err
+	}
+
+	var createdBy string
+	if !webhook.CreatedBy.IsZero() {
+		createdBy = webhook.CreatedBy.Hex()
+	}
+
+	query := r.dialect.rebind("INSERT INTO webhooks (" + sqlWebhookColumns + ") VALUES (?, ?, ?, ?, ?, ?, ?, ?)")
+	_, err = r.db.ExecContext(ctx, query, webhook.Id.Hex(), webhook.Url, webhook.Secret, string(eventTypes),
+		webhook.Description, webhook.Enabled, createdBy, webhook.CreatedAt)
+	return r.dialect.wrapError(err)
+}
+
+func (r *SQLWebhookRepository) GetById(ctx context.Context, id primitive.ObjectID) (*model.Webhook, error) {
+	query := r.dialect.rebind("SELECT " + sqlWebhookColumns + " FROM webhooks WHERE id = ?")
+	webhook, err := r.scan(r.db.QueryRowContext(ctx, query, id.Hex()))
+	if err == sql.ErrNoRows {
+		return nil, ErrWebhookNotFound
+	}
+	return webhook, err
+}
+
+func (r *SQLWebhookRepository) List(ctx context.Context) ([]*model.Webhook, error) {
+	return r.query(ctx, "SELECT "+sqlWebhookColumns+" FROM webhooks ORDER BY created_at, id")
+}
+
+func (r *SQLWebhookRepository) ListByEventType(ctx context.Context, eventType string) ([]*model.Webhook, error) {
+	query := r.dialect.rebind("SELECT " + sqlWebhookColumns + " FROM webhooks WHERE enabled = ? ORDER BY created_at, id")
+	webhooks, err := r.query(ctx, query, true)
+	if err != nil {
+		return nil, err
+	}
+
+	// There are few webhooks, so the event types are matched here rather
+	// than in a dialect-specific JSON query.
+	return slices.DeleteFunc(webhooks, func(webhook *model.Webhook) bool {
+		return !slices.Contains(webhook.EventTypes, eventType)
+	}), nil
+}
+
+func (r *SQLWebhookRepository) query(ctx context.Context, query string, args ...any) ([]*model.Webhook, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var webhooks []*model.Webhook
+	for rows.Next() {
+		webhook, err := r.scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	return webhooks, rows.Err()
+}
+
+func (r *SQLWebhookRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.db.ExecContext(ctx, r.dialect.rebind("DELETE FROM webhooks WHERE id = ?"), id.Hex())
+	if err != nil {
+		return err
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+func (r *SQLWebhookRepository) RecordDelivery(ctx context.Context, delivery *model.WebhookDelivery) error {
+	delivery.Id = primitive.NewObjectID()
+	if delivery.CreatedAt.IsZero() {
+		delivery.CreatedAt = sqlNow()
+	}
+
+	query := r.dialect.rebind("INSERT INTO webhook_deliveries (" + sqlWebhookDeliveryColumns + ") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
+	_, err := r.db.ExecContext(ctx, query,
+		delivery.Id.Hex(), delivery.WebhookId.Hex(), delivery.EventId.Hex(), delivery.EventType, delivery.Attempt,
+		delivery.Test, delivery.Success, delivery.StatusCode, delivery.Error, delivery.DurationMs, sqlTime(delivery.CreatedAt))
+	return r.dialect.wrapError(err)
+}
+
+func (r *SQLWebhookRepository) ListDeliveries(ctx context.Context, webhookId primitive.ObjectID, limit int) ([]*model.WebhookDelivery, error) {
+	query := r.dialect.rebind("SELECT " + sqlWebhookDeliveryColumns + " FROM webhook_deliveries WHERE webhook_id = ? ORDER BY created_at DESC, id DESC LIMIT ?")
+	rows, err := r.db.QueryContext(ctx, query, webhookId.Hex(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []*model.WebhookDelivery
+	for rows.Next() {
+		var delivery model.WebhookDelivery
+		var id, webhookId, eventId string
+
+		err := rows.Scan(&id, &webhookId, &eventId, &delivery.EventType, &delivery.Attempt, &delivery.Test,
+			&delivery.Success, &delivery.StatusCode, &delivery.Error, &delivery.DurationMs, &delivery.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		if delivery.Id, err = parseObjectId(id); err != nil {
+			return nil, err
+		}
+		if delivery.WebhookId, err = parseObjectId(webhookId); err != nil {
+			return nil, err
+		}
+		if delivery.EventId, err = parseObjectId(eventId); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, &delivery)
+	}
+	return deliveries, rows.Err()
+}
+
+func (r *SQLWebhookRepository) Delivered(ctx context.Context, webhookId, eventId primitive.ObjectID) (bool, error) {
+	query := r.dialect.rebind("SELECT 1 FROM webhook_deliveries WHERE webhook_id = ? AND event_id = ? AND success = ? LIMIT 1")
+
+	var found int
+	err := r.db.QueryRowContext(ctx, query, webhookId.Hex(), eventId.Hex(), true).Scan(&found)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (r *SQLWebhookRepository) scan(row rowScanner) (*model.Webhook, error) {
+	var webhook model.Webhook
+	var id, eventTypes, createdBy string
+
+	err := row.Scan(&id, &webhook.Url, &webhook.Secret, &eventTypes, &webhook.Description, &webhook.Enabled,
+		&createdBy, &webhook.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(eventTypes), &webhook.EventTypes); err != nil {
+		return nil, err
+	}
+	if createdBy != "" {
+		if webhook.CreatedBy, err = parseObjectId(createdBy); err != nil {
+			return nil, err
+		}
+	}
+	webhook.Id, err = parseObjectId(id)
+	return &webhook, err
+}
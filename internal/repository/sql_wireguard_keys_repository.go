@@ -0,0 +1,258 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/shivamp1998/vpn_backend/internal/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const sqlKeysColumns = "id, user_id, server_id, private_key_encrypted, public_key, ip_address, created_at, last_rotated_at, rotation_required, rotation_requested_at, version"
+
+type SQLWireGuardKeysRepository struct {
+	db      *sql.DB
+	dialect *sqlDialect
+}
+
+func (r *SQLWireGuardKeysRepository) Create(ctx context.Context, keys *model.WireGuardKeys) error {
+	if keys.Id.IsZero() {
+		keys.Id = primitive.NewObjectID()
+	}
+	keys.CreatedAt = sqlNow()
+	keys.LastRotatedAt = keys.CreatedAt
+	keys.Version = 1
+
+	query := r.dialect.rebind("INSERT INTO wireguard_keys (" + sqlKeysColumns + ") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
+	_, err := r.db.ExecContext(ctx, query,
+		keys.Id.Hex(), keys.UserId.Hex(), keys.ServerId.Hex(), keys.PrivateKeyEncrypted, keys.PublicKey, keys.IpAddress,
+		keys.CreatedAt, keys.LastRotatedAt, keys.RotationRequired, nullTime(keys.RotationRequestedAt), keys.Version)
+	return r.dialect.wrapError(err)
+}
+
+func (r *SQLWireGuardKeysRepository) Allocate(ctx context.Context, keys *model.WireGuardKeys, events ...*model.OutboxEvent) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	reserve := r.dialect.rebind("UPDATE servers SET current_clients = current_clients + 1 WHERE id = ? AND current_clients < max_clients")
+	result, err := tx.ExecContext(ctx, reserve, keys.ServerId.Hex())
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		var exists int
+		err := tx.QueryRowContext(ctx, r.dialect.rebind("SELECT 1 FROM servers WHERE id = ?"), keys.ServerId.Hex()).Scan(&exists)
+		if err == sql.ErrNoRows {
+			return ErrServerNotFound
+		}
+		if err != nil {
+			return err
+		}
+		return ErrServerFull
+	}
+
+	if keys.Id.IsZero() {
+		keys.Id = primitive.NewObjectID()
+	}
+	keys.CreatedAt = sqlNow()
+	keys.LastRotatedAt = keys.CreatedAt
+	keys.Version = 1
+
+	insert := r.dialect.rebind("INSERT INTO wireguard_keys (" + sqlKeysColumns + ") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
+	_, err = tx.ExecContext(ctx, insert,
+		keys.Id.Hex(), keys.UserId.Hex(), keys.ServerId.Hex(), keys.PrivateKeyEncrypted, keys.PublicKey, keys.IpAddress,
+		keys.CreatedAt, keys.LastRotatedAt, keys.RotationRequired, nullTime(keys.RotationRequestedAt), keys.Version)
+	if err != nil {
+		return r.dialect.wrapError(err)
+	}
+
+	if err := insertOutboxEvents(ctx, tx, r.dialect, events); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *SQLWireGuardKeysRepository) Revoke(ctx context.Context, keys *model.WireGuardKeys, events ...*model.OutboxEvent) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var serverId string
+	err = tx.QueryRowContext(ctx, r.dialect.rebind("DELETE FROM wireguard_keys WHERE id = ? RETURNING server_id"), keys.Id.Hex()).Scan(&serverId)
+	if err == sql.ErrNoRows {
+		return ErrKeysNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	release := r.dialect.rebind("UPDATE servers SET current_clients = current_clients - 1 WHERE id = ? AND current_clients > 0")
+	if _, err := tx.ExecContext(ctx, release, serverId); err != nil {
+		return err
+	}
+
+	if err := insertOutboxEvents(ctx, tx, r.dialect, events); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *SQLWireGuardKeysRepository) GetByUserAndServer(ctx context.Context, userId, serverId primitive.ObjectID) (*model.WireGuardKeys, error) {
+	query := r.dialect.rebind("SELECT " + sqlKeysColumns + " FROM wireguard_keys WHERE user_id = ? AND server_id = ?")
+	keys, err := r.scan(r.db.QueryRowContext(ctx, query, userId.Hex(), serverId.Hex()))
+	if err == sql.ErrNoRows {
+		return nil, ErrKeysNotFound
+	}
+	return keys, err
+}
+
+func (r *SQLWireGuardKeysRepository) Update(ctx context.Context, keys *model.WireGuardKeys) error {
+	lastRotatedAt := sqlNow()
+
+	query := r.dialect.rebind(`UPDATE wireguard_keys SET user_id = ?, server_id = ?, private_key_encrypted = ?,
+		public_key = ?, ip_address = ?, last_rotated_at = ?, rotation_required = ?,
+		rotation_requested_at = ?, version = version + 1 WHERE id = ? AND version = ?`)
+	result, err := r.db.ExecContext(ctx, query,
+		keys.UserId.Hex(), keys.ServerId.Hex(), keys.PrivateKeyEncrypted,
+		keys.PublicKey, keys.IpAddress, lastRotatedAt, keys.RotationRequired,
+		nullTime(keys.RotationRequestedAt), keys.Id.Hex(), keys.Version)
+	if err != nil {
+		return r.dialect.wrapError(err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		var exists int
+		err := r.db.QueryRowContext(ctx, r.dialect.rebind("SELECT 1 FROM wireguard_keys WHERE id = ?"), keys.Id.Hex()).Scan(&exists)
+		if err == sql.ErrNoRows {
+			return ErrKeysNotFound
+		}
+		if err != nil {
+			return err
+		}
+		return &VersionConflictError{Collection: "wireguard_keys", Id: keys.Id, Version: keys.Version}
+	}
+
+	keys.LastRotatedAt = lastRotatedAt
+	keys.Version++
+	return nil
+}
+
+func (r *SQLWireGuardKeysRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.db.ExecContext(ctx, r.dialect.rebind("DELETE FROM wireguard_keys WHERE id = ?"), id.Hex())
+	return err
+}
+
+func (r *SQLWireGuardKeysRepository) GetAllByServer(ctx context.Context, serverId primitive.ObjectID) ([]*model.WireGuardKeys, error) {
+	query := r.dialect.rebind("SELECT " + sqlKeysColumns + " FROM wireguard_keys WHERE server_id = ?")
+	return r.query(ctx, query, serverId.Hex())
+}
+
+func (r *SQLWireGuardKeysRepository) GetAllByServerRotatedBefore(ctx context.Context, serverId primitive.ObjectID, before time.Time) ([]*model.WireGuardKeys, error) {
+	query := r.dialect.rebind("SELECT " + sqlKeysColumns + " FROM wireguard_keys WHERE server_id = ? AND last_rotated_at < ?")
+	return r.query(ctx, query, serverId.Hex(), sqlTime(before))
+}
+
+func (r *SQLWireGuardKeysRepository) Rotate(ctx context.Context, keys *model.WireGuardKeys, previousRotatedAt time.Time, events ...*model.OutboxEvent) (bool, error) {
+	rotatedAt := sqlNow()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	query := r.dialect.rebind(`UPDATE wireguard_keys SET private_key_encrypted = ?, public_key = ?, last_rotated_at = ?,
+		rotation_required = ?, rotation_requested_at = NULL, version = version + 1
+		WHERE id = ? AND last_rotated_at = ? RETURNING version`)
+	var version int64
+	err = tx.QueryRowContext(ctx, query,
+		keys.PrivateKeyEncrypted, keys.PublicKey, rotatedAt, false, keys.Id.Hex(), sqlTime(previousRotatedAt)).Scan(&version)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if err := insertOutboxEvents(ctx, tx, r.dialect, events); err != nil {
+		return false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+
+	keys.LastRotatedAt = rotatedAt
+	keys.Version = version
+	keys.RotationRequired = false
+	keys.RotationRequestedAt = nil
+	return true, nil
+}
+
+func (r *SQLWireGuardKeysRepository) MarkRotationRequired(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	query := r.dialect.rebind("UPDATE wireguard_keys SET rotation_required = ?, rotation_requested_at = ?, version = version + 1 WHERE id = ? AND rotation_required = ?")
+	result, err := r.db.ExecContext(ctx, query, true, sqlNow(), id.Hex(), false)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+func (r *SQLWireGuardKeysRepository) query(ctx context.Context, query string, args ...any) ([]*model.WireGuardKeys, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []*model.WireGuardKeys
+	for rows.Next() {
+		keys, err := r.scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, keys)
+	}
+	return result, rows.Err()
+}
+
+func (r *SQLWireGuardKeysRepository) scan(row rowScanner) (*model.WireGuardKeys, error) {
+	var keys model.WireGuardKeys
+	var id, userId, serverId string
+	var requestedAt sql.NullTime
+
+	err := row.Scan(&id, &userId, &serverId, &keys.PrivateKeyEncrypted, &keys.PublicKey, &keys.IpAddress,
+		&keys.CreatedAt, &keys.LastRotatedAt, &keys.RotationRequired, &requestedAt, &keys.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	keys.RotationRequestedAt = timePointer(requestedAt)
+
+	if keys.Id, err = parseObjectId(id); err != nil {
+		return nil, err
+	}
+	if keys.UserId, err = parseObjectId(userId); err != nil {
+		return nil, err
+	}
+	keys.ServerId, err = parseObjectId(serverId)
+	return &keys, err
+}
@@ -0,0 +1,347 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/shivamp1998/vpn_backend/internal/model"
+	"github.com/shivamp1998/vpn_backend/internal/tracing"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/shivamp1998/vpn_backend/internal/repository")
+
+// Traced wraps every repository in repos so each call is a span, named
+// after the interface and method, e.g. "WireGuardKeysRepository.Allocate".
+// system names the database in the db.system.name attribute.
+func Traced(repos *Repositories, system string) *Repositories {
+	s := spans{system: system}
+	return &Repositories{
+		Users:         tracedUserRepository{s, repos.Users},
+		Servers:       tracedServerRepository{s, repos.Servers},
+		Keys:          tracedWireGuardKeysRepository{s, repos.Keys},
+		ShareLinks:    tracedConfigShareLinkRepository{s, repos.ShareLinks},
+		Notifications: tracedNotificationRepository{s, repos.Notifications},
+		Locks:         tracedLockRepository{s, repos.Locks},
+		Audit:         tracedAuditRepository{s, repos.Audit},
+		Outbox:        tracedOutboxRepository{s, repos.Outbox},
+		Webhooks:      tracedWebhookRepository{s, repos.Webhooks},
+	}
+}
+
+type spans struct {
+	system string
+}
+
+func (s spans) start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	attrs = append(attrs, attribute.String("db.system.name", s.system))
+	return tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+func serverIdAttr(id primitive.ObjectID) attribute.KeyValue {
+	return tracing.ServerIDKey.String(id.Hex())
+}
+
+func userIdAttr(id primitive.ObjectID) attribute.KeyValue {
+	return tracing.UserIDKey.String(id.Hex())
+}
+
+type tracedUserRepository struct {
+	spans
+	next UserRepository
+}
+
+func (r tracedUserRepository) Create(ctx context.Context, user *model.User, events ...*model.OutboxEvent) (err error) {
+	ctx, span := r.start(ctx, "UserRepository.Create")
+	defer func() { tracing.End(span, err) }()
+	return r.next.Create(ctx, user, events...)
+}
+
+func (r tracedUserRepository) GetByEmail(ctx context.Context, email string) (_ *model.User, err error) {
+	ctx, span := r.start(ctx, "UserRepository.GetByEmail")
+	defer func() { tracing.End(span, err) }()
+	return r.next.GetByEmail(ctx, email)
+}
+
+func (r tracedUserRepository) GetById(ctx context.Context, id primitive.ObjectID) (_ *model.User, err error) {
+	ctx, span := r.start(ctx, "UserRepository.GetById", userIdAttr(id))
+	defer func() { tracing.End(span, err) }()
+	return r.next.GetById(ctx, id)
+}
+
+func (r tracedUserRepository) SetRole(ctx context.Context, id primitive.ObjectID, role string) (err error) {
+	ctx, span := r.start(ctx, "UserRepository.SetRole", userIdAttr(id))
+	defer func() { tracing.End(span, err) }()
+	return r.next.SetRole(ctx, id, role)
+}
+
+type tracedServerRepository struct {
+	spans
+	next ServerRepository
+}
+
+func (r tracedServerRepository) Create(ctx context.Context, server *model.Server) (err error) {
+	ctx, span := r.start(ctx, "ServerRepository.Create")
+	defer func() { tracing.End(span, err) }()
+	return r.next.Create(ctx, server)
+}
+
+func (r tracedServerRepository) GetById(ctx context.Context, id primitive.ObjectID) (_ *model.Server, err error) {
+	ctx, span := r.start(ctx, "ServerRepository.GetById", serverIdAttr(id))
+	defer func() { tracing.End(span, err) }()
+	return r.next.GetById(ctx, id)
+}
+
+func (r tracedServerRepository) ListAll(ctx context.Context) (_ []*model.Server, err error) {
+	ctx, span := r.start(ctx, "ServerRepository.ListAll")
+	defer func() { tracing.End(span, err) }()
+	return r.next.ListAll(ctx)
+}
+
+func (r tracedServerRepository) RecountClients(ctx context.Context) (_ int, err error) {
+	ctx, span := r.start(ctx, "ServerRepository.RecountClients")
+	defer func() { tracing.End(span, err) }()
+	return r.next.RecountClients(ctx)
+}
+
+func (r tracedServerRepository) Update(ctx context.Context, server *model.Server, events ...*model.OutboxEvent) (err error) {
+	ctx, span := r.start(ctx, "ServerRepository.Update", serverIdAttr(server.Id))
+	defer func() { tracing.End(span, err) }()
+	return r.next.Update(ctx, server, events...)
+}
+
+type tracedWireGuardKeysRepository struct {
+	spans
+	next WireGuardKeysRepository
+}
+
+func (r tracedWireGuardKeysRepository) Create(ctx context.Context, keys *model.WireGuardKeys) (err error) {
+	ctx, span := r.start(ctx, "WireGuardKeysRepository.Create", serverIdAttr(keys.ServerId), userIdAttr(keys.UserId))
+	defer func() { tracing.End(span, err) }()
+	return r.next.Create(ctx, keys)
+}
+
+func (r tracedWireGuardKeysRepository) Allocate(ctx context.Context, keys *model.WireGuardKeys, events ...*model.OutboxEvent) (err error) {
+	ctx, span := r.start(ctx, "WireGuardKeysRepository.Allocate", serverIdAttr(keys.ServerId), userIdAttr(keys.UserId))
+	defer func() { tracing.End(span, err) }()
+	return r.next.Allocate(ctx, keys, events...)
+}
+
+func (r tracedWireGuardKeysRepository) Revoke(ctx context.Context, keys *model.WireGuardKeys, events ...*model.OutboxEvent) (err error) {
+	ctx, span := r.start(ctx, "WireGuardKeysRepository.Revoke", serverIdAttr(keys.ServerId), userIdAttr(keys.UserId))
+	defer func() { tracing.End(span, err) }()
+	return r.next.Revoke(ctx, keys, events...)
+}
+
+func (r tracedWireGuardKeysRepository) GetByUserAndServer(ctx context.Context, userId, serverId primitive.ObjectID) (_ *model.WireGuardKeys, err error) {
+	ctx, span := r.start(ctx, "WireGuardKeysRepository.GetByUserAndServer", serverIdAttr(serverId), userIdAttr(userId))
+	defer func() { tracing.End(span, err) }()
+	return r.next.GetByUserAndServer(ctx, userId, serverId)
+}
+
+func (r tracedWireGuardKeysRepository) Update(ctx context.Context, keys *model.WireGuardKeys) (err error) {
+	ctx, span := r.start(ctx, "WireGuardKeysRepository.Update", serverIdAttr(keys.ServerId), userIdAttr(keys.UserId))
+	defer func() { tracing.End(span, err) }()
+	return r.next.Update(ctx, keys)
+}
+
+func (r tracedWireGuardKeysRepository) Delete(ctx context.Context, id primitive.ObjectID) (err error) {
+	ctx, span := r.start(ctx, "WireGuardKeysRepository.Delete")
+	defer func() { tracing.End(span, err) }()
+	return r.next.Delete(ctx, id)
+}
+
+func (r tracedWireGuardKeysRepository) GetAllByServer(ctx context.Context, serverId primitive.ObjectID) (_ []*model.WireGuardKeys, err error) {
+	ctx, span := r.start(ctx, "WireGuardKeysRepository.GetAllByServer", serverIdAttr(serverId))
+	defer func() { tracing.End(span, err) }()
+	return r.next.GetAllByServer(ctx, serverId)
+}
+
+func (r tracedWireGuardKeysRepository) GetAllByServerRotatedBefore(ctx context.Context, serverId primitive.ObjectID, before time.Time) (_ []*model.WireGuardKeys, err error) {
+	ctx, span := r.start(ctx, "WireGuardKeysRepository.GetAllByServerRotatedBefore", serverIdAttr(serverId))
+	defer func() { tracing.End(span, err) }()
+	return r.next.GetAllByServerRotatedBefore(ctx, serverId, before)
+}
+
+func (r tracedWireGuardKeysRepository) Rotate(ctx context.Context, keys *model.WireGuardKeys, previousRotatedAt time.Time, events ...*model.OutboxEvent) (_ bool, err error) {
+	ctx, span := r.start(ctx, "WireGuardKeysRepository.Rotate", serverIdAttr(keys.ServerId), userIdAttr(keys.UserId))
+	defer func() { tracing.End(span, err) }()
+	return r.next.Rotate(ctx, keys, previousRotatedAt, events...)
+}
+
+func (r tracedWireGuardKeysRepository) MarkRotationRequired(ctx context.Context, id primitive.ObjectID) (_ bool, err error) {
+	ctx, span := r.start(ctx, "WireGuardKeysRepository.MarkRotationRequired")
+	defer func() { tracing.End(span, err) }()
+	return r.next.MarkRotationRequired(ctx, id)
+}
+
+type tracedConfigShareLinkRepository struct {
+	spans
+	next ConfigShareLinkRepository
+}
+
+func (r tracedConfigShareLinkRepository) Create(ctx context.Context, link *model.ConfigShareLink) (err error) {
+	ctx, span := r.start(ctx, "ConfigShareLinkRepository.Create")
+	defer func() { tracing.End(span, err) }()
+	return r.next.Create(ctx, link)
+}
+
+func (r tracedConfigShareLinkRepository) GetByTokenHash(ctx context.Context, tokenHash string) (_ *model.ConfigShareLink, err error) {
+	ctx, span := r.start(ctx, "ConfigShareLinkRepository.GetByTokenHash")
+	defer func() { tracing.End(span, err) }()
+	return r.next.GetByTokenHash(ctx, tokenHash)
+}
+
+func (r tracedConfigShareLinkRepository) Consume(ctx context.Context, tokenHash string, now time.Time) (_ *model.ConfigShareLink, err error) {
+	ctx, span := r.start(ctx, "ConfigShareLinkRepository.Consume")
+	defer func() { tracing.End(span, err) }()
+	return r.next.Consume(ctx, tokenHash, now)
+}
+
+func (r tracedConfigShareLinkRepository) RecordRetrieval(ctx context.Context, retrieval *model.ConfigShareRetrieval) (err error) {
+	ctx, span := r.start(ctx, "ConfigShareLinkRepository.RecordRetrieval")
+	defer func() { tracing.End(span, err) }()
+	return r.next.RecordRetrieval(ctx, retrieval)
+}
+
+type tracedNotificationRepository struct {
+	spans
+	next NotificationRepository
+}
+
+func (r tracedNotificationRepository) Create(ctx context.Context, notification *model.Notification) (err error) {
+	ctx, span := r.start(ctx, "NotificationRepository.Create")
+	defer func() { tracing.End(span, err) }()
+	return r.next.Create(ctx, notification)
+}
+
+type tracedAuditRepository struct {
+	spans
+	next AuditRepository
+}
+
+func (r tracedAuditRepository) Append(ctx context.Context, event *model.AuditEvent) (err error) {
+	ctx, span := r.start(ctx, "AuditRepository.Append")
+	defer func() { tracing.End(span, err) }()
+	return r.next.Append(ctx, event)
+}
+
+func (r tracedAuditRepository) Last(ctx context.Context) (_ *model.AuditEvent, err error) {
+	ctx, span := r.start(ctx, "AuditRepository.Last")
+	defer func() { tracing.End(span, err) }()
+	return r.next.Last(ctx)
+}
+
+func (r tracedAuditRepository) List(ctx context.Context, filter AuditFilter, limit int) (_ []*model.AuditEvent, err error) {
+	ctx, span := r.start(ctx, "AuditRepository.List")
+	defer func() { tracing.End(span, err) }()
+	return r.next.List(ctx, filter, limit)
+}
+
+type tracedOutboxRepository struct {
+	spans
+	next OutboxRepository
+}
+
+func (r tracedOutboxRepository) Due(ctx context.Context, now time.Time, limit int) (_ []*model.OutboxEvent, err error) {
+	ctx, span := r.start(ctx, "OutboxRepository.Due")
+	defer func() { tracing.End(span, err) }()
+	return r.next.Due(ctx, now, limit)
+}
+
+func (r tracedOutboxRepository) UpdateDelivery(ctx context.Context, event *model.OutboxEvent) (err error) {
+	ctx, span := r.start(ctx, "OutboxRepository.UpdateDelivery")
+	defer func() { tracing.End(span, err) }()
+	return r.next.UpdateDelivery(ctx, event)
+}
+
+func (r tracedOutboxRepository) ListSince(ctx context.Context, serverId primitive.ObjectID, types []string, since time.Time, limit int) (_ []*model.OutboxEvent, err error) {
+	ctx, span := r.start(ctx, "OutboxRepository.ListSince", serverIdAttr(serverId))
+	defer func() { tracing.End(span, err) }()
+	return r.next.ListSince(ctx, serverId, types, since, limit)
+}
+
+func (r tracedOutboxRepository) ListByStatus(ctx context.Context, status string, limit int) (_ []*model.OutboxEvent, err error) {
+	ctx, span := r.start(ctx, "OutboxRepository.ListByStatus")
+	defer func() { tracing.End(span, err) }()
+	return r.next.ListByStatus(ctx, status, limit)
+}
+
+func (r tracedOutboxRepository) Requeue(ctx context.Context, id primitive.ObjectID, now time.Time) (_ bool, err error) {
+	ctx, span := r.start(ctx, "OutboxRepository.Requeue")
+	defer func() { tracing.End(span, err) }()
+	return r.next.Requeue(ctx, id, now)
+}
+
+type tracedWebhookRepository struct {
+	spans
+	next WebhookRepository
+}
+
+func (r tracedWebhookRepository) Create(ctx context.Context, webhook *model.Webhook) (err error) {
+	ctx, span := r.start(ctx, "WebhookRepository.Create")
+	defer func() { tracing.End(span, err) }()
+	return r.next.Create(ctx, webhook)
+}
+
+func (r tracedWebhookRepository) GetById(ctx context.Context, id primitive.ObjectID) (_ *model.Webhook, err error) {
+	ctx, span := r.start(ctx, "WebhookRepository.GetById")
+	defer func() { tracing.End(span, err) }()
+	return r.next.GetById(ctx, id)
+}
+
+func (r tracedWebhookRepository) List(ctx context.Context) (_ []*model.Webhook, err error) {
+	ctx, span := r.start(ctx, "WebhookRepository.List")
+	defer func() { tracing.End(span, err) }()
+	return r.next.List(ctx)
+}
+
+func (r tracedWebhookRepository) ListByEventType(ctx context.Context, eventType string) (_ []*model.Webhook, err error) {
+	ctx, span := r.start(ctx, "WebhookRepository.ListByEventType")
+	defer func() { tracing.End(span, err) }()
+	return r.next.ListByEventType(ctx, eventType)
+}
+
+func (r tracedWebhookRepository) Delete(ctx context.Context, id primitive.ObjectID) (err error) {
+	ctx, span := r.start(ctx, "WebhookRepository.Delete")
+	defer func() { tracing.End(span, err) }()
+	return r.next.Delete(ctx, id)
+}
+
+func (r tracedWebhookRepository) RecordDelivery(ctx context.Context, delivery *model.WebhookDelivery) (err error) {
+	ctx, span := r.start(ctx, "WebhookRepository.RecordDelivery")
+	defer func() { tracing.End(span, err) }()
+	return r.next.RecordDelivery(ctx, delivery)
+}
+
+func (r tracedWebhookRepository) ListDeliveries(ctx context.Context, webhookId primitive.ObjectID, limit int) (_ []*model.WebhookDelivery, err error) {
+	ctx, span := r.start(ctx, "WebhookRepository.ListDeliveries")
+	defer func() { tracing.End(span, err) }()
+	return r.next.ListDeliveries(ctx, webhookId, limit)
+}
+
+func (r tracedWebhookRepository) Delivered(ctx context.Context, webhookId, eventId primitive.ObjectID) (_ bool, err error) {
+	ctx, span := r.start(ctx, "WebhookRepository.Delivered")
+	defer func() { tracing.End(span, err) }()
+	return r.next.Delivered(ctx, webhookId, eventId)
+}
+
+type tracedLockRepository struct {
+	spans
+	next LockRepository
+}
+
+func (r tracedLockRepository) Acquire(ctx context.Context, name, owner string, ttl time.Duration) (_ bool, err error) {
+	ctx, span := r.start(ctx, "LockRepository.Acquire", attribute.String("lock", name))
+	defer func() { tracing.End(span, err) }()
+	return r.next.Acquire(ctx, name, owner, ttl)
+}
+
+func (r tracedLockRepository) Release(ctx context.Context, name, owner string) (err error) {
+	ctx, span := r.start(ctx, "LockRepository.Release", attribute.String("lock", name))
+	defer func() { tracing.End(span, err) }()
+	return r.next.Release(ctx, name, owner)
+}
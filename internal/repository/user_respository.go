@@ -2,37 +2,42 @@ package repository
 
 import (
 	"context"
-	"errors"
 	"time"
 
-	"github.com/shivamp1998/vpn_backend/internal/database"
 	"github.com/shivamp1998/vpn_backend/internal/model"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
-type UserRespository struct {
+type MongoUserRepository struct {
 	collection *mongo.Collection
 }
 
-func NewUserRepository() *UserRespository {
-	return &UserRespository{
-		collection: database.DB.Collection("users"),
+func NewMongoUserRepository(db *mongo.Database) *MongoUserRepository {
+	return &MongoUserRepository{
+		collection: db.Collection("users"),
 	}
 }
 
-func (r *UserRespository) Create(ctx context.Context, user *model.User) error {
-	user.Id = primitive.NewObjectID()
+func (r *MongoUserRepository) Create(ctx context.Context, user *model.User, events ...*model.OutboxEvent) error {
+	if user.Id.IsZero() {
+		user.Id = primitive.NewObjectID()
+	}
 	user.CreatedAt = time.Now()
 	user.UpdatedAt = time.Now()
 	user.IsActive = true
 
-	_, err := r.collection.InsertOne(ctx, user)
-	return err
+	doc, err := withStagedOutbox(user, events)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.collection.InsertOne(ctx, doc)
+	return duplicateKeyError(err)
 }
 
-func (r *UserRespository) GetByEmail(ctx context.Context, email string) (*model.User, error) {
+func (r *MongoUserRepository) GetByEmail(ctx context.Context, email string) (*model.User, error) {
 	var user model.User
 	query := bson.M{
 		"email": email,
@@ -41,8 +46,35 @@ func (r *UserRespository) GetByEmail(ctx context.Context, email string) (*model.
 	err := r.collection.FindOne(ctx, query).Decode(&user)
 
 	if err == mongo.ErrNoDocuments {
-		return nil, errors.New("user not found")
+		return nil, ErrUserNotFound
+	}
+
+	return &user, err
+}
+
+func (r *MongoUserRepository) GetById(ctx context.Context, id primitive.ObjectID) (*model.User, error) {
+	var user model.User
+	filter := bson.M{"_id": id}
+
+	err := r.collection.FindOne(ctx, filter).Decode(&user)
+
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrUserNotFound
 	}
 
 	return &user, err
 }
+
+func (r *MongoUserRepository) SetRole(ctx context.Context, id primitive.ObjectID, role string) error {
+	update := bson.M{"$set": bson.M{"role": role, "updated_at": time.Now()}}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/shivamp1998/vpn_backend/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoWebhookRepository struct {
+	collection *mongo.Collection
+	deliveries *mongo.Collection
+}
+
+func NewMongoWebhookRepository(db *mongo.Database) *MongoWebhookRepository {
+	return &MongoWebhookRepository{
+		collection: db.Collection("webhooks"),
+		deliveries: db.Collection("webhook_deliveries"),
+	}
+}
+
+func (r *MongoWebhookRepository) Create(ctx context.Context, webhook *model.Webhook) error {
+	webhook.Id = primitive.NewObjectID()
+	webhook.CreatedAt = time.Now()
+
+	_, err := r.collection.InsertOne(ctx, webhook)
+	return err
+}
+
+func (r *MongoWebhookRepository) GetById(ctx context.Context, id primitive.ObjectID) (*model.Webhook, error) {
+	var webhook model.Webhook
+
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&webhook)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrWebhookNotFound
+	}
+	return &webhook, err
+}
+
+func (r *MongoWebhookRepository) List(ctx context.Context) ([]*model.Webhook, error) {
+	return r.find(ctx, bson.M{})
+}
+
+func (r *MongoWebhookRepository) ListByEventType(ctx context.Context, eventType string) ([]*model.Webhook, error) {
+	return r.find(ctx, bson.M{"enabled": true, "event_types": eventType})
+}
+
+func (r *MongoWebhookRepository) find(ctx context.Context, filter bson.M) ([]*model.Webhook, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var webhooks []*model.Webhook
+	err = cursor.All(ctx, &webhooks)
+	return webhooks, err
+}
+
+func (r *MongoWebhookRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+func (r *MongoWebhookRepository) RecordDelivery(ctx context.Context, delivery *model.WebhookDelivery) error {
+	delivery.Id = primitive.NewObjectID()
+	if delivery.CreatedAt.IsZero() {
+		delivery.CreatedAt = time.Now()
+	}
+
+	_, err := r.deliveries.InsertOne(ctx, delivery)
+	return err
+}
+
+func (r *MongoWebhookRepository) ListDeliveries(ctx context.Context, webhookId primitive.ObjectID, limit int) ([]*model.WebhookDelivery, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).SetLimit(int64(limit))
+	cursor, err := r.deliveries.Find(ctx, bson.M{"webhook_id": webhookId}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var deliveries []*model.WebhookDelivery
+	err = cursor.All(ctx, &deliveries)
+	return deliveries, err
+}
+
+func (r *MongoWebhookRepository) Delivered(ctx context.Context, webhookId, eventId primitive.ObjectID) (bool, error) {
+	filter := bson.M{"webhook_id": webhookId, "event_id": eventId, "success": true}
+
+	count, err := r.deliveries.CountDocuments(ctx, filter, options.Count().SetLimit(1))
+	return count > 0, err
+}
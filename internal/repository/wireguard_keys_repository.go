@@ -2,73 +2,242 @@ package repository
 
 import (
 	"context"
-	"errors"
 	"fmt"
+	"log/slog"
 	"time"
 
-	"github.com/shivamp1998/vpn_backend/internal/database"
 	"github.com/shivamp1998/vpn_backend/internal/model"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-type WireGuardKeysRepository struct {
+type MongoWireGuardKeysRepository struct {
+	db         *mongo.Database
 	collection *mongo.Collection
+	servers    *mongo.Collection
 }
 
-func NewWireGuardKeysRepository() *WireGuardKeysRepository {
-	return &WireGuardKeysRepository{
-		collection: database.DB.Collection("wireguard_keys"),
+func NewMongoWireGuardKeysRepository(db *mongo.Database) *MongoWireGuardKeysRepository {
+	return &MongoWireGuardKeysRepository{
+		db:         db,
+		collection: db.Collection("wireguard_keys"),
+		servers:    db.Collection("servers"),
 	}
 }
 
-func (r *WireGuardKeysRepository) Create(ctx context.Context, keys *model.WireGuardKeys) error {
-	keys.Id = primitive.NewObjectID()
+// revokedField marks a peer whose revoke has been committed but not yet
+// finished. Such a peer is left out of every read and write, as if it had
+// been deleted already.
+const revokedField = "revoked_at"
+
+var notRevoked = bson.M{"$exists": false}
+
+func (r *MongoWireGuardKeysRepository) Create(ctx context.Context, keys *model.WireGuardKeys) error {
+	return r.insert(ctx, keys, nil)
+}
+
+func (r *MongoWireGuardKeysRepository) insert(ctx context.Context, keys *model.WireGuardKeys, events []*model.OutboxEvent) error {
+	if keys.Id.IsZero() {
+		keys.Id = primitive.NewObjectID()
+	}
 	keys.CreatedAt = time.Now()
 	keys.LastRotatedAt = time.Now()
+	keys.Version = 1
 
-	_, err := r.collection.InsertOne(ctx, keys)
-	return err
+	doc, err := withStagedOutbox(keys, events)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.collection.InsertOne(ctx, doc)
+	return duplicateKeyError(err)
 }
 
-func (r *WireGuardKeysRepository) GetByUserAndServer(ctx context.Context, userId, serverId primitive.ObjectID) (*model.WireGuardKeys, error) {
+// Allocate reserves a slot with a conditional increment on the server before
+// inserting, and hands the slot back if the insert fails. This keeps
+// current_clients from ever exceeding max_clients without needing a replica
+// set for multi-document transactions; the unique indexes on the keys
+// collection reject duplicate peers and addresses. The events are staged on
+// the inserted peer, so they only exist if the peer does.
+//
+// The increment and the insert are separate writes, so this is not atomic:
+// a crash, or a failed insert whose slot cannot be handed back, leaves a
+// slot taken without a peer. MongoServerRepository.RecountClients, run
+// periodically, gives such slots back.
+func (r *MongoWireGuardKeysRepository) Allocate(ctx context.Context, keys *model.WireGuardKeys, events ...*model.OutboxEvent) error {
+	filter := bson.M{
+		"_id":   keys.ServerId,
+		"$expr": bson.M{"$lt": bson.A{"$current_clients", "$max_clients"}},
+	}
+	result, err := r.servers.UpdateOne(ctx, filter, bson.M{"$inc": bson.M{"current_clients": 1}})
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		count, err := r.servers.CountDocuments(ctx, bson.M{"_id": keys.ServerId})
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return ErrServerNotFound
+		}
+		return ErrServerFull
+	}
+
+	if err := r.insert(ctx, keys, events); err != nil {
+		release := bson.M{"$inc": bson.M{"current_clients": -1}}
+		if _, releaseErr := r.servers.UpdateOne(context.WithoutCancel(ctx), bson.M{"_id": keys.ServerId}, release); releaseErr != nil {
+			return fmt.Errorf("%w (releasing client slot also failed: %v)", err, releaseErr)
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (r *MongoWireGuardKeysRepository) GetByUserAndServer(ctx context.Context, userId, serverId primitive.ObjectID) (*model.WireGuardKeys, error) {
 	var keys model.WireGuardKeys
 
 	filter := bson.M{
-		"user_id":   userId,
-		"server_id": serverId,
+		"user_id":    userId,
+		"server_id":  serverId,
+		revokedField: notRevoked,
 	}
 
 	err := r.collection.FindOne(ctx, filter).Decode(&keys)
-	fmt.Print(keys)
 
 	if err == mongo.ErrNoDocuments {
-		return nil, errors.New("keys not found")
+		return nil, ErrKeysNotFound
 	}
 
 	return &keys, err
 }
 
-func (r *WireGuardKeysRepository) Update(ctx context.Context, keys *model.WireGuardKeys) error {
-	keys.LastRotatedAt = time.Now()
-	filter := bson.M{"_id": keys.Id}
-	update := bson.M{"$set": keys}
-	_, err := r.collection.UpdateOne(ctx, filter, update)
-	return err
+func (r *MongoWireGuardKeysRepository) Update(ctx context.Context, keys *model.WireGuardKeys) error {
+	lastRotatedAt := time.Now()
+	filter := bson.M{"_id": keys.Id, "version": keys.Version, revokedField: notRevoked}
+	update := bson.M{
+		"$set": bson.M{
+			"user_id":               keys.UserId,
+			"server_id":             keys.ServerId,
+			"private_key_encrypted": keys.PrivateKeyEncrypted,
+			"public_key":            keys.PublicKey,
+			"ip_address":            keys.IpAddress,
+			"last_rotated_at":       lastRotatedAt,
+			"rotation_required":     keys.RotationRequired,
+			"rotation_requested_at": keys.RotationRequestedAt,
+		},
+		"$inc": bson.M{"version": 1},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return duplicateKeyError(err)
+	}
+
+	if result.MatchedCount == 0 {
+		count, err := r.collection.CountDocuments(ctx, bson.M{"_id": keys.Id, revokedField: notRevoked})
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return ErrKeysNotFound
+		}
+		return &VersionConflictError{Collection: "wireguard_keys", Id: keys.Id, Version: keys.Version}
+	}
+
+	keys.LastRotatedAt = lastRotatedAt
+	keys.Version++
+	return nil
+}
+
+// Revoke marks the peer revoked and stages the events on it in one write,
+// which commits the revoke together with its events. It then finishes the
+// revoke; if that fails, the outbox relay finishes it instead.
+func (r *MongoWireGuardKeysRepository) Revoke(ctx context.Context, keys *model.WireGuardKeys, events ...*model.OutboxEvent) error {
+	filter := bson.M{"_id": keys.Id, revokedField: notRevoked}
+	update := stageOutbox(bson.M{"$set": bson.M{revokedField: time.Now()}}, events)
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrKeysNotFound
+	}
+
+	// The revoke has happened, so it is finished even if ctx has been
+	// cancelled since.
+	if err := finishRevokes(context.WithoutCancel(ctx), r.db, keys.Id); err != nil {
+		slog.WarnContext(ctx, "Revoked peer left for the outbox relay to delete", "peer_id", keys.Id.Hex(), "error", err)
+	}
+	return nil
+}
+
+// finishRevokes finishes the committed revokes of the peers with ids, or of
+// every peer if there are none: it relays the events staged on each peer,
+// deletes the peer and frees its slot. Each step can be repeated, and only
+// the caller that deletes the peer frees the slot. A slot a crash keeps from
+// being freed is given back by MongoServerRepository.RecountClients.
+func finishRevokes(ctx context.Context, db *mongo.Database, ids ...primitive.ObjectID) error {
+	keys := db.Collection("wireguard_keys")
+
+	filter := bson.M{revokedField: bson.M{"$exists": true}}
+	if len(ids) > 0 {
+		filter["_id"] = bson.M{"$in": ids}
+	}
+	cursor, err := keys.Find(ctx, filter, options.Find().SetProjection(bson.M{"server_id": 1, stagedOutboxField: 1}))
+	if err != nil {
+		return err
+	}
+
+	var revoked []struct {
+		Id       primitive.ObjectID   `bson:"_id"`
+		ServerId primitive.ObjectID   `bson:"server_id"`
+		Outbox   []*model.OutboxEvent `bson:"outbox"`
+	}
+	if err := cursor.All(ctx, &revoked); err != nil {
+		return err
+	}
+
+	for _, peer := range revoked {
+		for _, event := range peer.Outbox {
+			if _, err := db.Collection("outbox_events").InsertOne(ctx, event); err != nil && !mongo.IsDuplicateKeyError(err) {
+				return err
+			}
+		}
+
+		result, err := keys.DeleteOne(ctx, bson.M{"_id": peer.Id})
+		if err != nil {
+			return err
+		}
+		if result.DeletedCount == 0 {
+			continue
+		}
+
+		_, err = db.Collection("servers").UpdateOne(ctx, bson.M{"_id": peer.ServerId}, bson.M{"$inc": bson.M{"current_clients": -1}})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-func (r *WireGuardKeysRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+func (r *MongoWireGuardKeysRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
 	filter := bson.M{"_id": id}
 	_, err := r.collection.DeleteOne(ctx, filter)
 	return err
 }
 
-func (r *WireGuardKeysRepository) GetAllByServer(ctx context.Context, serverId primitive.ObjectID) ([]*model.WireGuardKeys, error) {
+func (r *MongoWireGuardKeysRepository) GetAllByServer(ctx context.Context, serverId primitive.ObjectID) ([]*model.WireGuardKeys, error) {
 	var keys []*model.WireGuardKeys
 
 	filter := bson.M{
-		"server_id": serverId,
+		"server_id":  serverId,
+		revokedField: notRevoked,
 	}
 
 	cursor, err := r.collection.Find(ctx, filter)
@@ -80,3 +249,75 @@ func (r *WireGuardKeysRepository) GetAllByServer(ctx context.Context, serverId p
 	err = cursor.All(ctx, &keys)
 	return keys, err
 }
+
+func (r *MongoWireGuardKeysRepository) GetAllByServerRotatedBefore(ctx context.Context, serverId primitive.ObjectID, before time.Time) ([]*model.WireGuardKeys, error) {
+	var keys []*model.WireGuardKeys
+
+	filter := bson.M{
+		"server_id":       serverId,
+		"last_rotated_at": bson.M{"$lt": before},
+		revokedField:      notRevoked,
+	}
+
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	err = cursor.All(ctx, &keys)
+	return keys, err
+}
+
+// Rotate replaces the key pair only if the peer has not been rotated since
+// previousRotatedAt was read. It returns false if another writer got there
+// first.
+func (r *MongoWireGuardKeysRepository) Rotate(ctx context.Context, keys *model.WireGuardKeys, previousRotatedAt time.Time, events ...*model.OutboxEvent) (bool, error) {
+	keys.LastRotatedAt = time.Now()
+	keys.RotationRequired = false
+	keys.RotationRequestedAt = nil
+
+	filter := bson.M{"_id": keys.Id, "last_rotated_at": previousRotatedAt, revokedField: notRevoked}
+	update := bson.M{
+		"$set": bson.M{
+			"private_key_encrypted": keys.PrivateKeyEncrypted,
+			"public_key":            keys.PublicKey,
+			"last_rotated_at":       keys.LastRotatedAt,
+			"rotation_required":     false,
+		},
+		"$unset": bson.M{"rotation_requested_at": ""},
+		"$inc":   bson.M{"version": 1},
+	}
+
+	var rotated model.WireGuardKeys
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := r.collection.FindOneAndUpdate(ctx, filter, stageOutbox(update, events), opts).Decode(&rotated)
+	if err == mongo.ErrNoDocuments {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	keys.Version = rotated.Version
+	return true, nil
+}
+
+// MarkRotationRequired flags the peer for client-side rotation. It returns
+// false if the peer was already flagged.
+func (r *MongoWireGuardKeysRepository) MarkRotationRequired(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	filter := bson.M{"_id": id, "rotation_required": bson.M{"$ne": true}, revokedField: notRevoked}
+	update := bson.M{
+		"$set": bson.M{
+			"rotation_required":     true,
+			"rotation_requested_at": time.Now(),
+		},
+		"$inc": bson.M{"version": 1},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
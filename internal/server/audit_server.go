@@ -0,0 +1,86 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/shivamp1998/vpn_backend/internal/audit"
+	"github.com/shivamp1998/vpn_backend/internal/model"
+	pb "github.com/shivamp1998/vpn_backend/proto/gen"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (s *Server) ListAuditEvents(ctx context.Context, req *pb.ListAuditEventsRequest) (*pb.ListAuditEventsResponse, error) {
+	query := audit.Query{
+		Action:     req.Action,
+		TargetType: req.TargetType,
+		TargetId:   req.TargetId,
+		Outcome:    req.Outcome,
+		PageSize:   int(req.PageSize),
+		PageToken:  req.PageToken,
+	}
+
+	if req.ActorId != "" {
+		actorId, err := primitive.ObjectIDFromHex(req.ActorId)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid actor id")
+		}
+		query.ActorId = actorId
+	}
+	if req.Since != 0 {
+		query.Since = time.Unix(req.Since, 0)
+	}
+	if req.Until != 0 {
+		query.Until = time.Unix(req.Until, 0)
+	}
+
+	page, err := s.auditLog.List(ctx, query)
+
+	// Reading the log is itself an audited action.
+	s.auditLog.Record(ctx, audit.Entry{Action: model.AuditActionAuditList, Err: err})
+
+	if errors.Is(err, audit.ErrInvalidPageToken) {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list audit events")
+	}
+
+	events := make([]*pb.AuditEvent, 0, len(page.Events))
+	for _, event := range page.Events {
+		events = append(events, auditEventToProto(event))
+	}
+
+	return &pb.ListAuditEventsResponse{
+		Events:        events,
+		NextPageToken: page.NextPageToken,
+	}, nil
+}
+
+func auditEventToProto(event *model.AuditEvent) *pb.AuditEvent {
+	var actorId string
+	if !event.ActorId.IsZero() {
+		actorId = event.ActorId.Hex()
+	}
+
+	return &pb.AuditEvent{
+		Id:         event.Id.Hex(),
+		Sequence:   event.Sequence,
+		OccurredAt: event.Time.UnixMilli(),
+		ActorId:    actorId,
+		ActorEmail: event.ActorEmail,
+		Action:     event.Action,
+		TargetType: event.TargetType,
+		TargetId:   event.TargetId,
+		Procedure:  event.Procedure,
+		SourceIp:   event.SourceIp,
+		UserAgent:  event.UserAgent,
+		Outcome:    event.Outcome,
+		Reason:     event.Reason,
+		PrevHash:   event.PrevHash,
+		Hash:       event.Hash,
+	}
+}
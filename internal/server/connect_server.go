@@ -2,102 +2,106 @@ package server
 
 import (
 	"context"
-	"errors"
-	"log"
+	"fmt"
 	"net/http"
-	"os"
 
 	"github.com/rs/cors"
+	"github.com/shivamp1998/vpn_backend/internal/health"
+	"github.com/shivamp1998/vpn_backend/internal/middleware"
 	gen "github.com/shivamp1998/vpn_backend/proto/gen"
-	"golang.org/x/net/http2"
-	"golang.org/x/net/http2/h2c"
 
 	"connectrpc.com/connect"
 	genconnect "github.com/shivamp1998/vpn_backend/proto/gen/genconnect"
 )
 
-func StartConnectServer(mainServer *Server) {
-	connectPort := os.Getenv("CONNECT_PORT")
-
-	if connectPort == "" {
-		connectPort = ":50052"
-	}
-
+// NewConnectServer returns the HTTP server for the Connect handlers, the REST
+// gateway and the probes, listening on addr. The metrics have a listener of
+// their own, as everything here is public.
+func NewConnectServer(addr string, mainServer *Server, chain middleware.Chain, checker *health.Checker) (*http.Server, error) {
 	userServiceHandler := &connectUserServiceHandler{server: mainServer}
 	configServiceHandler := &connectConfigServiceHandler{server: mainServer}
 	serverServiceHandler := &connectServerServiceHandler{server: mainServer}
+	auditServiceHandler := &connectAuditServiceHandler{server: mainServer}
+	eventServiceHandler := &connectEventServiceHandler{server: mainServer}
+	webhookServiceHandler := &connectWebhookServiceHandler{server: mainServer}
+
+	interceptors := connect.WithInterceptors(chain.ConnectInterceptor())
 
 	mux := http.NewServeMux()
 
 	userServicePath, userServiceHTTPHandler := genconnect.NewUserServiceHandler(
 		userServiceHandler,
-		connect.WithInterceptors(newConnectAuthInterceptor()),
+		interceptors,
 	)
 	mux.Handle(userServicePath, userServiceHTTPHandler)
 
 	serverServicePath, serverServiceHTTPHandler := genconnect.NewServerServiceHandler(
 		serverServiceHandler,
-		connect.WithInterceptors(newConnectAuthInterceptor()),
+		interceptors,
 	)
 	mux.Handle(serverServicePath, serverServiceHTTPHandler)
 
 	configServicePath, configServiceHTTPHandler := genconnect.NewConfigServiceHandler(
 		configServiceHandler,
-		connect.WithInterceptors(newConnectAuthInterceptor()),
+		interceptors,
 	)
 	mux.Handle(configServicePath, configServiceHTTPHandler)
 
-	c := cors.New(cors.Options{
-		AllowedOrigins:   []string{"*"},
-		AllowedMethods:   []string{"GET", "POST", "OPTIONS"},
-		AllowedHeaders:   []string{"*"},
-		AllowCredentials: true,
-	})
-	handler := c.Handler(mux)
-	http2Server := &http2.Server{}
-	httpServer := &http.Server{
-		Addr:    connectPort,
-		Handler: h2c.NewHandler(handler, http2Server),
-	}
-
-	log.Printf("Connect RPC server listening on %s", connectPort)
-	if err := httpServer.ListenAndServe(); err != nil {
-		log.Fatalf("Connect server failed: %v", err)
-	}
-}
-
-func newConnectAuthInterceptor() connect.UnaryInterceptorFunc {
-	return func(next connect.UnaryFunc) connect.UnaryFunc {
-		return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
-			procedure := req.Spec().Procedure
+	auditServicePath, auditServiceHTTPHandler := genconnect.NewAuditServiceHandler(
+		auditServiceHandler,
+		interceptors,
+	)
+	mux.Handle(auditServicePath, auditServiceHTTPHandler)
 
-			if IsPublicEndpoint(procedure) {
-				return next(ctx, req)
-			}
+	eventServicePath, eventServiceHTTPHandler := genconnect.NewEventServiceHandler(
+		eventServiceHandler,
+		interceptors,
+	)
+	mux.Handle(eventServicePath, eventServiceHTTPHandler)
 
-			authHeader := req.Header().Get("authorization")
+	webhookServicePath, webhookServiceHTTPHandler := genconnect.NewWebhookServiceHandler(
+		webhookServiceHandler,
+		interceptors,
+	)
+	mux.Handle(webhookServicePath, webhookServiceHTTPHandler)
 
-			token, err := ExtractTokenFromHeader(authHeader)
+	gatewayHandler, err := newGatewayHandler(mainServer, chain)
+	if err != nil {
+		return nil, fmt.Errorf("REST gateway setup failed: %v", err)
+	}
+	mux.Handle(gatewayPath, gatewayHandler)
 
-			if err != nil {
-				return nil, connect.NewError(
-					connect.CodeUnauthenticated,
-					err,
-				)
-			}
+	openAPIHandler, err := newOpenAPIHandler()
+	if err != nil {
+		return nil, fmt.Errorf("OpenAPI document is invalid: %v", err)
+	}
+	mux.Handle(openAPIPath, openAPIHandler)
 
-			ctx, err = ValidateAndSetUserContext(ctx, token)
-			if err != nil {
-				return nil, connect.NewError(
-					connect.CodeUnauthenticated,
-					err,
-				)
-			}
+	mux.Handle(shareLinkPath, newShareLinkHandler(mainServer))
+	mux.Handle(health.LivenessPath, checker.LivenessHandler())
+	mux.Handle(health.ReadinessPath, checker.ReadinessHandler())
 
-			return next(ctx, req)
+	c := cors.New(cors.Options{
+		AllowedOrigins:   []string{"*"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"*"},
+		AllowCredentials: true,
+	})
+	// gRPC and Connect clients speak HTTP/2 without TLS. Served by net/http
+	// rather than h2c, those connections are drained by Shutdown too.
+	var protocols http.Protocols
+	protocols.SetHTTP1(true)
+	protocols.SetUnencryptedHTTP2(true)
+
+	return &http.Server{
+		Addr:      addr,
+		Handler:   c.Handler(mux),
+		Protocols: &protocols,
+	}, nil
+}
 
-		}
-	}
+func connectError(err error) error {
+	return middleware.ConnectError(err)
 }
 
 type connectUserServiceHandler struct {
@@ -111,7 +115,7 @@ func (h *connectUserServiceHandler) Login(
 	resp, err := h.server.Login(ctx, req.Msg)
 
 	if err != nil {
-		return nil, err
+		return nil, connectError(err)
 	}
 	return connect.NewResponse(resp), nil
 }
@@ -123,7 +127,7 @@ func (h *connectUserServiceHandler) Register(
 	resp, err := h.server.Register(ctx, req.Msg)
 
 	if err != nil {
-		return nil, err
+		return nil, connectError(err)
 	}
 	return connect.NewResponse(resp), nil
 }
@@ -132,6 +136,20 @@ type connectServerServiceHandler struct {
 	server *Server
 }
 
+func (h *connectServerServiceHandler) WatchPeers(
+	ctx context.Context,
+	req *connect.Request[gen.WatchPeersRequest],
+	stream *connect.ServerStream[gen.WatchPeersResponse],
+) error {
+	err := h.server.watchPeers(ctx, req.Msg, stream.Send)
+
+	if err != nil {
+		return connectError(err)
+	}
+
+	return nil
+}
+
 func (h *connectServerServiceHandler) CreateServer(
 	ctx context.Context,
 	req *connect.Request[gen.CreateServerRequest],
@@ -139,7 +157,7 @@ func (h *connectServerServiceHandler) CreateServer(
 	resp, err := h.server.CreateServer(ctx, req.Msg)
 
 	if err != nil {
-		return nil, err
+		return nil, connectError(err)
 	}
 
 	return connect.NewResponse(resp), nil
@@ -152,7 +170,7 @@ func (h *connectServerServiceHandler) ListServers(
 	resp, err := h.server.ListServers(ctx, req.Msg)
 
 	if err != nil {
-		return nil, err
+		return nil, connectError(err)
 	}
 
 	return connect.NewResponse(resp), nil
@@ -165,10 +183,27 @@ func (h *connectServerServiceHandler) GetServer(
 	resp, err := h.server.GetServer(ctx, req.Msg)
 
 	if err != nil {
-		return nil, err
+		return nil, connectError(err)
 	}
 
-	return connect.NewResponse(resp), nil
+	res := connect.NewResponse(resp)
+	res.Header().Set("ETag", versionETag(resp.Server.Version))
+	return res, nil
+}
+
+func (h *connectServerServiceHandler) UpdateServer(
+	ctx context.Context,
+	req *connect.Request[gen.UpdateServerRequest],
+) (*connect.Response[gen.UpdateServerResponse], error) {
+	resp, err := h.server.UpdateServer(ctx, req.Msg)
+
+	if err != nil {
+		return nil, connectError(err)
+	}
+
+	res := connect.NewResponse(resp)
+	res.Header().Set("ETag", versionETag(resp.Server.Version))
+	return res, nil
 }
 
 type connectConfigServiceHandler struct {
@@ -182,10 +217,12 @@ func (h *connectConfigServiceHandler) GenerateConfig(
 	resp, err := h.server.GenerateConfig(ctx, req.Msg)
 
 	if err != nil {
-		return nil, err
+		return nil, connectError(err)
 	}
 
-	return connect.NewResponse(resp), nil
+	res := connect.NewResponse(resp)
+	res.Header().Set("ETag", versionETag(resp.KeysVersion))
+	return res, nil
 }
 
 func (h *connectConfigServiceHandler) GetConfig(
@@ -195,7 +232,33 @@ func (h *connectConfigServiceHandler) GetConfig(
 	resp, err := h.server.GetConfig(ctx, req.Msg)
 
 	if err != nil {
-		return nil, err
+		return nil, connectError(err)
+	}
+
+	return connect.NewResponse(resp), nil
+}
+
+func (h *connectConfigServiceHandler) RevokePeer(
+	ctx context.Context,
+	req *connect.Request[gen.RevokePeerRequest],
+) (*connect.Response[gen.RevokePeerResponse], error) {
+	resp, err := h.server.RevokePeer(ctx, req.Msg)
+
+	if err != nil {
+		return nil, connectError(err)
+	}
+
+	return connect.NewResponse(resp), nil
+}
+
+func (h *connectConfigServiceHandler) CreateConfigShareLink(
+	ctx context.Context,
+	req *connect.Request[gen.CreateConfigShareLinkRequest],
+) (*connect.Response[gen.CreateConfigShareLinkResponse], error) {
+	resp, err := h.server.CreateConfigShareLink(ctx, req.Msg)
+
+	if err != nil {
+		return nil, connectError(err)
 	}
 
 	return connect.NewResponse(resp), nil
@@ -205,9 +268,129 @@ func (h *connectConfigServiceHandler) RotateKeys(
 	ctx context.Context,
 	req *connect.Request[gen.GenerateConfigRequest],
 ) (*connect.Response[gen.GetConfigResponse], error) {
-	// TODO: Implement RotateKeys in grpc_server.go first
-	return nil, connect.NewError(
-		connect.CodeUnimplemented,
-		errors.New("RotateKeys is not yet implemented"),
-	)
+	resp, err := h.server.RotateKeys(ctx, req.Msg)
+
+	if err != nil {
+		return nil, connectError(err)
+	}
+
+	res := connect.NewResponse(resp)
+	res.Header().Set("ETag", versionETag(resp.KeysVersion))
+	return res, nil
+}
+
+type connectAuditServiceHandler struct {
+	server *Server
+}
+
+func (h *connectAuditServiceHandler) ListAuditEvents(
+	ctx context.Context,
+	req *connect.Request[gen.ListAuditEventsRequest],
+) (*connect.Response[gen.ListAuditEventsResponse], error) {
+	resp, err := h.server.ListAuditEvents(ctx, req.Msg)
+
+	if err != nil {
+		return nil, connectError(err)
+	}
+
+	return connect.NewResponse(resp), nil
+}
+
+type connectEventServiceHandler struct {
+	server *Server
+}
+
+func (h *connectEventServiceHandler) ListDeadLetterEvents(
+	ctx context.Context,
+	req *connect.Request[gen.ListDeadLetterEventsRequest],
+) (*connect.Response[gen.ListDeadLetterEventsResponse], error) {
+	resp, err := h.server.ListDeadLetterEvents(ctx, req.Msg)
+
+	if err != nil {
+		return nil, connectError(err)
+	}
+
+	return connect.NewResponse(resp), nil
+}
+
+func (h *connectEventServiceHandler) RequeueDeadLetterEvent(
+	ctx context.Context,
+	req *connect.Request[gen.RequeueDeadLetterEventRequest],
+) (*connect.Response[gen.RequeueDeadLetterEventResponse], error) {
+	resp, err := h.server.RequeueDeadLetterEvent(ctx, req.Msg)
+
+	if err != nil {
+		return nil, connectError(err)
+	}
+
+	return connect.NewResponse(resp), nil
+}
+
+type connectWebhookServiceHandler struct {
+	server *Server
+}
+
+func (h *connectWebhookServiceHandler) CreateWebhook(
+	ctx context.Context,
+	req *connect.Request[gen.CreateWebhookRequest],
+) (*connect.Response[gen.CreateWebhookResponse], error) {
+	resp, err := h.server.CreateWebhook(ctx, req.Msg)
+
+	if err != nil {
+		return nil, connectError(err)
+	}
+
+	return connect.NewResponse(resp), nil
+}
+
+func (h *connectWebhookServiceHandler) ListWebhooks(
+	ctx context.Context,
+	req *connect.Request[gen.ListWebhooksRequest],
+) (*connect.Response[gen.ListWebhooksResponse], error) {
+	resp, err := h.server.ListWebhooks(ctx, req.Msg)
+
+	if err != nil {
+		return nil, connectError(err)
+	}
+
+	return connect.NewResponse(resp), nil
+}
+
+func (h *connectWebhookServiceHandler) DeleteWebhook(
+	ctx context.Context,
+	req *connect.Request[gen.DeleteWebhookRequest],
+) (*connect.Response[gen.DeleteWebhookResponse], error) {
+	resp, err := h.server.DeleteWebhook(ctx, req.Msg)
+
+	if err != nil {
+		return nil, connectError(err)
+	}
+
+	return connect.NewResponse(resp), nil
+}
+
+func (h *connectWebhookServiceHandler) ListWebhookDeliveries(
+	ctx context.Context,
+	req *connect.Request[gen.ListWebhookDeliveriesRequest],
+) (*connect.Response[gen.ListWebhookDeliveriesResponse], error) {
+	resp, err := h.server.ListWebhookDeliveries(ctx, req.Msg)
+
+	if err != nil {
+		return nil, connectError(err)
+	}
+
+	return connect.NewResponse(resp), nil
+}
+
+func (h *connectWebhookServiceHandler) TestWebhook(
+	ctx context.Context,
+	req *connect.Request[gen.TestWebhookRequest],
+) (*connect.Response[gen.TestWebhookResponse], error) {
+	resp, err := h.server.TestWebhook(ctx, req.Msg)
+
+	if err != nil {
+		return nil, connectError(err)
+	}
+
+	return connect.NewResponse(resp), nil
 }
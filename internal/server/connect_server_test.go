@@ -0,0 +1,44 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/shivamp1998/vpn_backend/internal/audit"
+	"github.com/shivamp1998/vpn_backend/internal/health"
+	"github.com/shivamp1998/vpn_backend/internal/metrics"
+	"github.com/shivamp1998/vpn_backend/internal/repository"
+)
+
+// TestConnectServerHidesMetrics checks that the public server leaves the
+// metrics to their own listener.
+func TestConnectServerHidesMetrics(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	chain := NewMiddleware(audit.NewLogger(repos.Audit, audit.Options{}), MiddlewareConfig{})
+
+	connectServer, err := NewConnectServer(":0", &Server{}, chain, health.NewChecker(health.CheckerConfig{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	metricsServer := metrics.NewServer(":0")
+
+	tests := []struct {
+		name   string
+		server *http.Server
+		path   string
+		want   int
+	}{
+		{"connect", connectServer, metrics.Path, http.StatusNotFound},
+		{"connect", connectServer, health.LivenessPath, http.StatusOK},
+		{"metrics", metricsServer, metrics.Path, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		recorder := httptest.NewRecorder()
+		tt.server.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if recorder.Code != tt.want {
+			t.Errorf("%s server, GET %s: %d, want %d", tt.name, tt.path, recorder.Code, tt.want)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package server
+
+import (
+	"errors"
+	"strings"
+
+	"buf.build/go/protovalidate"
+	"github.com/shivamp1998/vpn_backend/internal/service"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+// errorDomain is the ErrorInfo domain of every error this service reports.
+const errorDomain = "vpn_backend"
+
+var kindCodes = map[service.Kind]codes.Code{
+	service.KindInvalidArgument:    codes.InvalidArgument,
+	service.KindNotFound:           codes.NotFound,
+	service.KindAlreadyExists:      codes.AlreadyExists,
+	service.KindUnauthenticated:    codes.Unauthenticated,
+	service.KindPermissionDenied:   codes.PermissionDenied,
+	service.KindResourceExhausted:  codes.ResourceExhausted,
+	service.KindFailedPrecondition: codes.FailedPrecondition,
+	service.KindAborted:            codes.Aborted,
+	service.KindUnavailable:        codes.Unavailable,
+}
+
+// errorStatus maps domain errors and request validation failures to a status
+// for middleware.Errors. The status carries an ErrorInfo with the error's
+// reason and, when request fields are at fault, a BadRequest naming them.
+func errorStatus(err error) *status.Status {
+	var validationErr *protovalidate.ValidationError
+	if errors.As(err, &validationErr) {
+		return validationStatus(validationErr)
+	}
+
+	domainErr := service.AsError(err)
+	if domainErr == nil {
+		return nil
+	}
+
+	code, ok := kindCodes[domainErr.Kind]
+	if !ok {
+		return nil
+	}
+
+	details := []protoadapt.MessageV1{&errdetails.ErrorInfo{Reason: domainErr.Reason, Domain: errorDomain}}
+	if domainErr.Field != "" {
+		details = append(details, &errdetails.BadRequest{
+			FieldViolations: []*errdetails.BadRequest_FieldViolation{
+				{Field: domainErr.Field, Description: domainErr.Message},
+			},
+		})
+	}
+
+	st := status.New(code, domainErr.Message)
+	if detailed, err := st.WithDetails(details...); err == nil {
+		st = detailed
+	}
+	return st
+}
+
+func validationStatus(err *protovalidate.ValidationError) *status.Status {
+	badRequest := &errdetails.BadRequest{}
+	messages := make([]string, 0, len(err.Violations))
+	for _, violation := range err.Violations {
+		field := protovalidate.FieldPathString(violation.Proto.GetField())
+		badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       field,
+			Description: violation.Proto.GetMessage(),
+		})
+		messages = append(messages, field+": "+violation.Proto.GetMessage())
+	}
+
+	st := status.New(codes.InvalidArgument, "invalid request: "+strings.Join(messages, "; "))
+	if detailed, err := st.WithDetails(&errdetails.ErrorInfo{Reason: "INVALID_ARGUMENT", Domain: errorDomain}, badRequest); err == nil {
+		st = detailed
+	}
+	return st
+}
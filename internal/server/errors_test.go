@@ -0,0 +1,122 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"buf.build/go/protovalidate"
+	"connectrpc.com/connect"
+	"github.com/shivamp1998/vpn_backend/internal/middleware"
+	"github.com/shivamp1998/vpn_backend/internal/repository"
+	"github.com/shivamp1998/vpn_backend/internal/service"
+	gen "github.com/shivamp1998/vpn_backend/proto/gen"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestErrorStatus(t *testing.T) {
+	validationErr := protovalidate.Validate(&gen.RegisterRequest{Email: "user@example.com", Password: "short"})
+	if validationErr == nil {
+		t.Fatal("short password passed validation")
+	}
+
+	tests := []struct {
+		name        string
+		err         error
+		grpcCode    codes.Code
+		connectCode connect.Code
+		reason      string
+		field       string
+	}{
+		{"invalid credentials", service.ErrInvalidCredentials, codes.Unauthenticated, connect.CodeUnauthenticated, "INVALID_CREDENTIALS", ""},
+		{"email taken", service.ErrEmailTaken, codes.AlreadyExists, connect.CodeAlreadyExists, "EMAIL_TAKEN", ""},
+		{"already exists", service.ErrAlreadyExists, codes.AlreadyExists, connect.CodeAlreadyExists, "ALREADY_EXISTS", ""},
+		{"user not found", service.ErrUserNotFound, codes.NotFound, connect.CodeNotFound, "USER_NOT_FOUND", ""},
+		{"server not found", service.ErrServerNotFound, codes.NotFound, connect.CodeNotFound, "SERVER_NOT_FOUND", ""},
+		{"peer not found", service.ErrPeerNotFound, codes.NotFound, connect.CodeNotFound, "PEER_NOT_FOUND", ""},
+		{"webhook not found", service.ErrWebhookNotFound, codes.NotFound, connect.CodeNotFound, "WEBHOOK_NOT_FOUND", ""},
+		{"share link not found", service.ErrShareLinkNotFound, codes.NotFound, connect.CodeNotFound, "SHARE_LINK_NOT_FOUND", ""},
+		{"concurrent update", service.ErrConcurrentUpdate, codes.Aborted, connect.CodeAborted, "CONCURRENT_UPDATE", ""},
+		{"address conflict", service.ErrAddressConflict, codes.Aborted, connect.CodeAborted, "ADDRESS_CONFLICT", ""},
+		{"server full", service.ErrServerFull, codes.ResourceExhausted, connect.CodeResourceExhausted, "SERVER_FULL", ""},
+		{"address pool exhausted", service.ErrAddressPoolExhausted, codes.ResourceExhausted, connect.CodeResourceExhausted, "ADDRESS_POOL_EXHAUSTED", ""},
+		{"server unavailable", service.ErrServerUnavailable, codes.FailedPrecondition, connect.CodeFailedPrecondition, "SERVER_UNAVAILABLE", ""},
+		{"server misconfigured", service.ErrServerMisconfigured, codes.FailedPrecondition, connect.CodeFailedPrecondition, "SERVER_MISCONFIGURED", ""},
+		{"share link expired", service.ErrShareLinkExpired, codes.FailedPrecondition, connect.CodeFailedPrecondition, "SHARE_LINK_EXPIRED", ""},
+		{"share link used", service.ErrShareLinkUsed, codes.FailedPrecondition, connect.CodeFailedPrecondition, "SHARE_LINK_USED", ""},
+		{"share link QR code", service.ErrShareLinkQRCode, codes.FailedPrecondition, connect.CodeFailedPrecondition, "SHARE_LINK_QR_CODE_FAILED", ""},
+		{"version mismatch", service.ErrVersionMismatch, codes.FailedPrecondition, connect.CodeFailedPrecondition, "VERSION_MISMATCH", ""},
+		{"watch closed", service.ErrWatchClosed, codes.Unavailable, connect.CodeUnavailable, "SHUTTING_DOWN", ""},
+		{"invalid server id", service.ErrInvalidServerId, codes.InvalidArgument, connect.CodeInvalidArgument, "INVALID_ARGUMENT", "server_id"},
+		{"invalid webhook id", service.ErrInvalidWebhookId, codes.InvalidArgument, connect.CodeInvalidArgument, "INVALID_ARGUMENT", "webhook_id"},
+		{"invalid resume token", service.ErrInvalidResumeToken, codes.InvalidArgument, connect.CodeInvalidArgument, "INVALID_ARGUMENT", "resume_token"},
+		{"invalid argument", service.InvalidArgument("url", "bad url"), codes.InvalidArgument, connect.CodeInvalidArgument, "INVALID_ARGUMENT", "url"},
+		{"wrapped", fmt.Errorf("update: %w", service.ErrServerNotFound), codes.NotFound, connect.CodeNotFound, "SERVER_NOT_FOUND", ""},
+		{"repository not found", fmt.Errorf("get: %w", repository.ErrServerNotFound), codes.NotFound, connect.CodeNotFound, "SERVER_NOT_FOUND", ""},
+		{"repository keys not found", repository.ErrKeysNotFound, codes.NotFound, connect.CodeNotFound, "PEER_NOT_FOUND", ""},
+		{"repository server full", repository.ErrServerFull, codes.ResourceExhausted, connect.CodeResourceExhausted, "SERVER_FULL", ""},
+		{"repository version conflict", repository.ErrVersionConflict, codes.Aborted, connect.CodeAborted, "CONCURRENT_UPDATE", ""},
+		{"repository duplicate key", repository.ErrDuplicateKey, codes.AlreadyExists, connect.CodeAlreadyExists, "ALREADY_EXISTS", ""},
+		{"request validation", validationErr, codes.InvalidArgument, connect.CodeInvalidArgument, "INVALID_ARGUMENT", "password"},
+		{"internal kind", &service.Error{Kind: service.KindInternal, Message: "secret detail"}, codes.Internal, connect.CodeInternal, "", ""},
+		{"unknown", errors.New("connection refused by 10.0.0.5"), codes.Internal, connect.CodeInternal, "", ""},
+	}
+
+	handler := middleware.Errors(errorStatus)
+	for _, tt := range tests {
+		err := handler(func(ctx context.Context, call *middleware.Call) error {
+			return tt.err
+		})(context.Background(), &middleware.Call{})
+
+		st := status.Convert(err)
+		if st.Code() != tt.grpcCode {
+			t.Errorf("%s: gRPC code %s, want %s", tt.name, st.Code(), tt.grpcCode)
+			continue
+		}
+		if got := connect.CodeOf(middleware.ConnectError(err)); got != tt.connectCode {
+			t.Errorf("%s: Connect code %s, want %s", tt.name, got, tt.connectCode)
+		}
+
+		if tt.grpcCode == codes.Internal {
+			if st.Message() != "internal error" {
+				t.Errorf("%s: internal error leaked %q", tt.name, st.Message())
+			}
+			continue
+		}
+
+		info, badRequest := statusDetails(st)
+		if info == nil || info.Reason != tt.reason || info.Domain != errorDomain {
+			t.Errorf("%s: ErrorInfo %v, want reason %s", tt.name, info, tt.reason)
+		}
+		if tt.field == "" {
+			if badRequest != nil {
+				t.Errorf("%s: unexpected BadRequest %v", tt.name, badRequest)
+			}
+		} else if badRequest == nil || len(badRequest.FieldViolations) == 0 || badRequest.FieldViolations[0].Field != tt.field {
+			t.Errorf("%s: BadRequest %v, want one naming %s", tt.name, badRequest, tt.field)
+		}
+
+		// Connect clients get the same details.
+		var connectErr *connect.Error
+		if !errors.As(middleware.ConnectError(err), &connectErr) || len(connectErr.Details()) != len(st.Details()) {
+			t.Errorf("%s: Connect error does not carry the %d details", tt.name, len(st.Details()))
+		}
+	}
+}
+
+func statusDetails(st *status.Status) (*errdetails.ErrorInfo, *errdetails.BadRequest) {
+	var info *errdetails.ErrorInfo
+	var badRequest *errdetails.BadRequest
+	for _, detail := range st.Details() {
+		switch detail := detail.(type) {
+		case *errdetails.ErrorInfo:
+			info = detail
+		case *errdetails.BadRequest:
+			badRequest = detail
+		}
+	}
+	return info, badRequest
+}
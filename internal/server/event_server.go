@@ -0,0 +1,72 @@
+package server
+
+import (
+	"context"
+	"errors"
+
+	"github.com/shivamp1998/vpn_backend/internal/audit"
+	"github.com/shivamp1998/vpn_backend/internal/events"
+	"github.com/shivamp1998/vpn_backend/internal/model"
+	pb "github.com/shivamp1998/vpn_backend/proto/gen"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultDeadLetterPageSize = 50
+	maxDeadLetterPageSize     = 500
+)
+
+func (s *Server) ListDeadLetterEvents(ctx context.Context, req *pb.ListDeadLetterEventsRequest) (*pb.ListDeadLetterEventsResponse, error) {
+	limit := int(req.PageSize)
+	if limit <= 0 {
+		limit = defaultDeadLetterPageSize
+	}
+	limit = min(limit, maxDeadLetterPageSize)
+
+	deadLetters, err := s.dispatcher.DeadLetters(ctx, limit)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list dead letters")
+	}
+
+	resp := &pb.ListDeadLetterEventsResponse{}
+	for _, event := range deadLetters {
+		resp.Events = append(resp.Events, &pb.OutboxEvent{
+			Id:          event.Id.Hex(),
+			Type:        event.Type,
+			AggregateId: event.AggregateId,
+			Payload:     event.Payload,
+			OccurredAt:  event.OccurredAt.UnixMilli(),
+			Attempts:    int32(event.Attempts),
+			LastError:   event.LastError,
+			Delivered:   event.Delivered,
+		})
+	}
+	return resp, nil
+}
+
+func (s *Server) RequeueDeadLetterEvent(ctx context.Context, req *pb.RequeueDeadLetterEventRequest) (*pb.RequeueDeadLetterEventResponse, error) {
+	id, err := primitive.ObjectIDFromHex(req.EventId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid event id")
+	}
+
+	err = s.dispatcher.Requeue(ctx, id)
+
+	s.auditLog.Record(ctx, audit.Entry{
+		Action:     model.AuditActionEventRequeue,
+		TargetType: model.AuditTargetEvent,
+		TargetId:   req.EventId,
+		Err:        err,
+	})
+
+	if errors.Is(err, events.ErrNotDeadLetter) {
+		return nil, status.Errorf(codes.NotFound, "%v", err)
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to requeue event")
+	}
+
+	return &pb.RequeueDeadLetterEventResponse{}, nil
+}
@@ -0,0 +1,188 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/shivamp1998/vpn_backend/internal/middleware"
+	gen "github.com/shivamp1998/vpn_backend/proto/gen"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	gatewayPath = "/v1/"
+	openAPIPath = "/openapi.json"
+)
+
+// newGatewayHandler serves the REST routes from the google.api.http options
+// in vpn.proto. Calls go to mainServer in process, through the same chain as
+// the gRPC server.
+func newGatewayHandler(mainServer *Server, chain middleware.Chain) (http.Handler, error) {
+	conn := newLocalConn(chain.UnaryServerInterceptor())
+	conn.register(&gen.UserService_ServiceDesc, mainServer)
+	conn.register(&gen.ServerService_ServiceDesc, mainServer)
+	conn.register(&gen.ConfigService_ServiceDesc, mainServer)
+	conn.register(&gen.AuditService_ServiceDesc, mainServer)
+	conn.register(&gen.EventService_ServiceDesc, mainServer)
+	conn.register(&gen.WebhookService_ServiceDesc, mainServer)
+
+	mux := runtime.NewServeMux(
+		runtime.WithIncomingHeaderMatcher(gatewayIncomingHeader),
+		runtime.WithOutgoingHeaderMatcher(gatewayOutgoingHeader),
+	)
+
+	ctx := context.Background()
+	err := errors.Join(
+		gen.RegisterUserServiceHandlerClient(ctx, mux, gen.NewUserServiceClient(conn)),
+		gen.RegisterServerServiceHandlerClient(ctx, mux, gen.NewServerServiceClient(conn)),
+		gen.RegisterConfigServiceHandlerClient(ctx, mux, gen.NewConfigServiceClient(conn)),
+		gen.RegisterAuditServiceHandlerClient(ctx, mux, gen.NewAuditServiceClient(conn)),
+		gen.RegisterEventServiceHandlerClient(ctx, mux, gen.NewEventServiceClient(conn)),
+		gen.RegisterWebhookServiceHandlerClient(ctx, mux, gen.NewWebhookServiceClient(conn)),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The chain reads the caller's address from the gRPC peer, as rate
+		// limiting and audit need it.
+		if addr, err := netip.ParseAddrPort(r.RemoteAddr); err == nil {
+			r = r.WithContext(peer.NewContext(r.Context(), &peer.Peer{Addr: net.TCPAddrFromAddrPort(addr)}))
+		}
+		mux.ServeHTTP(w, r)
+	}), nil
+}
+
+// gatewayIncomingHeader passes the headers the chain reads on to it under
+// their own names. Authorization is always passed.
+func gatewayIncomingHeader(key string) (string, bool) {
+	switch strings.ToLower(key) {
+	case "x-request-id", "user-agent", "traceparent", "tracestate", "baggage":
+		return strings.ToLower(key), true
+	}
+	return runtime.DefaultHeaderMatcher(key)
+}
+
+// gatewayOutgoingHeader sends the response headers a REST client looks for
+// as plain HTTP headers rather than Grpc-Metadata- ones.
+func gatewayOutgoingHeader(key string) (string, bool) {
+	switch key {
+	case "x-request-id", "etag":
+		return key, true
+	}
+	return runtime.MetadataHeaderPrefix + key, true
+}
+
+// localConn is a grpc.ClientConnInterface that calls the registered services
+// directly, so the gateway does not need a connection to our own gRPC port.
+type localConn struct {
+	interceptor grpc.UnaryServerInterceptor
+	methods     map[string]localMethod
+}
+
+type localMethod struct {
+	impl    any
+	handler grpc.MethodHandler
+}
+
+func newLocalConn(interceptor grpc.UnaryServerInterceptor) *localConn {
+	return &localConn{interceptor: interceptor, methods: map[string]localMethod{}}
+}
+
+func (c *localConn) register(desc *grpc.ServiceDesc, impl any) {
+	for _, method := range desc.Methods {
+		c.methods["/"+desc.ServiceName+"/"+method.MethodName] = localMethod{impl: impl, handler: method.Handler}
+	}
+}
+
+func (c *localConn) Invoke(ctx context.Context, method string, args, reply any, opts ...grpc.CallOption) error {
+	m, ok := c.methods[method]
+	if !ok {
+		return status.Errorf(codes.Unimplemented, "method %s not implemented", method)
+	}
+
+	md, _ := metadata.FromOutgoingContext(ctx)
+	ctx = metadata.NewIncomingContext(ctx, md)
+	stream := &localStream{method: method, header: metadata.MD{}, trailer: metadata.MD{}}
+	ctx = grpc.NewContextWithServerTransportStream(ctx, stream)
+
+	resp, err := m.handler(m.impl, ctx, func(in any) error {
+		proto.Merge(in.(proto.Message), args.(proto.Message))
+		return nil
+	}, c.interceptor)
+
+	for _, opt := range opts {
+		switch opt := opt.(type) {
+		case grpc.HeaderCallOption:
+			*opt.HeaderAddr = stream.header
+		case grpc.TrailerCallOption:
+			*opt.TrailerAddr = stream.trailer
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	proto.Merge(reply.(proto.Message), resp.(proto.Message))
+	return nil
+}
+
+func (c *localConn) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return nil, status.Errorf(codes.Unimplemented, "streaming method %s is not available over REST", method)
+}
+
+// localStream collects the headers and trailers a handler sets.
+type localStream struct {
+	method  string
+	header  metadata.MD
+	trailer metadata.MD
+}
+
+func (s *localStream) Method() string {
+	return s.method
+}
+
+func (s *localStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func (s *localStream) SendHeader(md metadata.MD) error {
+	return s.SetHeader(md)
+}
+
+func (s *localStream) SetTrailer(md metadata.MD) error {
+	s.trailer = metadata.Join(s.trailer, md)
+	return nil
+}
+
+// newOpenAPIHandler serves the OpenAPI document generated from vpn.proto,
+// converted once from YAML to JSON.
+func newOpenAPIHandler() (http.Handler, error) {
+	var document any
+	if err := yaml.Unmarshal(gen.OpenAPI, &document); err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(document)
+	if err != nil {
+		return nil, err
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	}), nil
+}
@@ -0,0 +1,437 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/shivamp1998/vpn_backend/internal/audit"
+	"github.com/shivamp1998/vpn_backend/internal/auth"
+	"github.com/shivamp1998/vpn_backend/internal/events"
+	"github.com/shivamp1998/vpn_backend/internal/health"
+	"github.com/shivamp1998/vpn_backend/internal/model"
+	"github.com/shivamp1998/vpn_backend/internal/repository"
+	"github.com/shivamp1998/vpn_backend/internal/service"
+	"github.com/shivamp1998/vpn_backend/internal/webhook"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// gatewayClient sends REST requests to a Connect server backed by memory
+// repositories.
+type gatewayClient struct {
+	t       *testing.T
+	handler http.Handler
+	repos   *repository.Repositories
+	admin   string
+}
+
+func newGatewayClient(t *testing.T) *gatewayClient {
+	t.Helper()
+
+	repos := repository.NewMemoryRepositories()
+	auditLog := audit.NewLogger(repos.Audit, audit.Options{})
+	configService := service.NewConfigService(repos.Users, repos.Servers, repos.Keys, repos.ShareLinks, auditLog, service.ClientNetworkConfig{})
+	dispatcher := events.NewDispatcher(events.DispatcherConfig{}, repos.Outbox, repos.Locks)
+	notifier := webhook.NewNotifier(repos.Webhooks, nil)
+
+	mainServer := NewServer(
+		service.NewUserService(repos.Users, auditLog),
+		service.NewServerService(repos.Servers, auditLog),
+		configService,
+		service.NewWebhookService(repos.Webhooks, notifier, auditLog),
+		service.NewPeerWatcher(service.PeerWatchConfig{}, repos.Servers, repos.Keys, repos.Outbox),
+		auditLog,
+		dispatcher,
+		"https://vpn.example.com",
+	)
+	chain := NewMiddleware(auditLog, MiddlewareConfig{})
+
+	connectServer, err := NewConnectServer(":0", mainServer, chain, health.NewChecker(health.CheckerConfig{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	admin, err := auth.GenerateToken(primitive.NewObjectID(), "admin@example.com", model.RoleAdmin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &gatewayClient{t: t, handler: connectServer.Handler, repos: repos, admin: admin}
+}
+
+// do sends a request with token as its bearer credentials, if any, and
+// returns the response with its JSON body.
+func (c *gatewayClient) do(method, path, token, body string, header http.Header) (*httptest.ResponseRecorder, map[string]any) {
+	c.t.Helper()
+
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	r.RemoteAddr = "203.0.113.7:4242"
+	for key, values := range header {
+		r.Header[key] = values
+	}
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	if body != "" {
+		r.Header.Set("Content-Type", "application/json")
+	}
+
+	recorder := httptest.NewRecorder()
+	c.handler.ServeHTTP(recorder, r)
+
+	var decoded map[string]any
+	if recorder.Body.Len() > 0 {
+		if err := json.Unmarshal(recorder.Body.Bytes(), &decoded); err != nil {
+			c.t.Fatalf("%s %s: %v: %s", method, path, err, recorder.Body)
+		}
+	}
+	return recorder, decoded
+}
+
+// field walks the JSON objects of body along path and returns the value at
+// its end.
+func field(body map[string]any, path ...string) any {
+	var value any = body
+	for _, key := range path {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		value = object[key]
+	}
+	return value
+}
+
+func stringField(body map[string]any, path ...string) string {
+	s, _ := field(body, path...).(string)
+	return s
+}
+
+func listField(body map[string]any, path ...string) []any {
+	list, _ := field(body, path...).([]any)
+	return list
+}
+
+// TestGatewayRoutes calls every REST route in turn, as a client would,
+// with ids taken from earlier responses.
+func TestGatewayRoutes(t *testing.T) {
+	c := newGatewayClient(t)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer hook.Close()
+
+	var user, serverId, serverVersion, webhookId string
+
+	steps := []struct {
+		name   string
+		method string
+		path   func() string
+		token  func() string
+		body   func() string
+		check  func(body map[string]any) string
+	}{
+		{
+			"register", http.MethodPost, fixed("/v1/auth/register"), nil,
+			fixed(`{"email":"user@example.com","password":"correct horse"}`),
+			func(body map[string]any) string {
+				user = stringField(body, "token")
+				return stringField(body, "token")
+			},
+		},
+		{
+			"login", http.MethodPost, fixed("/v1/auth/login"), nil,
+			fixed(`{"email":"user@example.com","password":"correct horse"}`),
+			func(body map[string]any) string { return stringField(body, "token") },
+		},
+		{
+			"create server", http.MethodPost, fixed("/v1/servers"), c.adminToken,
+			fixed(`{"name":"eu-1","endpoint":"vpn.example.com:51820","region":"eu","maxClients":10}`),
+			func(body map[string]any) string {
+				serverId = stringField(body, "server", "id")
+				serverVersion = stringField(body, "server", "version")
+				return serverId
+			},
+		},
+		{
+			"list servers", http.MethodGet, fixed("/v1/servers"), func() string { return user }, nil,
+			func(body map[string]any) string {
+				if servers := listField(body, "servers"); len(servers) == 1 {
+					return "1"
+				}
+				return ""
+			},
+		},
+		{
+			"get server", http.MethodGet, func() string { return "/v1/servers/" + serverId }, func() string { return user }, nil,
+			func(body map[string]any) string { return stringField(body, "server", "name") },
+		},
+		{
+			"update server", http.MethodPut, func() string { return "/v1/servers/" + serverId }, c.adminToken,
+			func() string {
+				return `{"version":"` + serverVersion + `","name":"eu-2","endpoint":"vpn.example.com:51820","region":"eu","maxClients":20}`
+			},
+			func(body map[string]any) string {
+				if stringField(body, "server", "name") != "eu-2" {
+					return ""
+				}
+				return "renamed"
+			},
+		},
+		{
+			"generate config", http.MethodPost, func() string { return "/v1/servers/" + serverId + "/config" }, func() string { return user },
+			fixed(`{"format":"CONFIG_FORMAT_JSON"}`),
+			func(body map[string]any) string { return stringField(body, "configData", "clientIp") },
+		},
+		{
+			"rotate keys", http.MethodPost, func() string { return "/v1/servers/" + serverId + "/config:rotateKeys" }, func() string { return user },
+			fixed(`{}`),
+			func(body map[string]any) string { return stringField(body, "configData", "publicKey") },
+		},
+		{
+			"create share link", http.MethodPost, func() string { return "/v1/servers/" + serverId + "/share-links" }, func() string { return user },
+			fixed(`{"kind":"SHARE_LINK_KIND_CONFIG_FILE","ttlSeconds":"600"}`),
+			func(body map[string]any) string { return stringField(body, "url") },
+		},
+		{
+			"revoke peer", http.MethodDelete, func() string { return "/v1/servers/" + serverId + "/peer" }, func() string { return user }, nil,
+			func(body map[string]any) string { return "revoked" },
+		},
+		{
+			"list audit events", http.MethodGet, fixed("/v1/audit-events?pageSize=5&action=" + model.AuditActionKeysRevoke), c.adminToken, nil,
+			func(body map[string]any) string {
+				events := listField(body, "events")
+				if len(events) != 1 {
+					return ""
+				}
+				return stringField(events[0].(map[string]any), "action")
+			},
+		},
+		{
+			"list dead letter events", http.MethodGet, fixed("/v1/dead-letter-events"), c.adminToken, nil,
+			func(body map[string]any) string { return "listed" },
+		},
+		{
+			"create webhook", http.MethodPost, fixed("/v1/webhooks"), c.adminToken,
+			func() string { return `{"url":"` + hook.URL + `","eventTypes":["` + model.EventPeerCreated + `"]}` },
+			func(body map[string]any) string {
+				webhookId = stringField(body, "webhook", "id")
+				return stringField(body, "secret")
+			},
+		},
+		{
+			"list webhooks", http.MethodGet, fixed("/v1/webhooks"), c.adminToken, nil,
+			func(body map[string]any) string {
+				webhooks := listField(body, "webhooks")
+				if len(webhooks) != 1 {
+					return ""
+				}
+				return stringField(webhooks[0].(map[string]any), "id")
+			},
+		},
+		{
+			"test webhook", http.MethodPost, func() string { return "/v1/webhooks/" + webhookId + ":test" }, c.adminToken, nil,
+			func(body map[string]any) string { return "tested" },
+		},
+		{
+			"list webhook deliveries", http.MethodGet, func() string { return "/v1/webhooks/" + webhookId + "/deliveries" }, c.adminToken, nil,
+			func(body map[string]any) string {
+				if len(listField(body, "deliveries")) != 1 {
+					return ""
+				}
+				return "1"
+			},
+		},
+		{
+			"delete webhook", http.MethodDelete, func() string { return "/v1/webhooks/" + webhookId }, c.adminToken, nil,
+			func(body map[string]any) string { return "deleted" },
+		},
+	}
+
+	for _, step := range steps {
+		var token, body string
+		if step.token != nil {
+			token = step.token()
+		}
+		if step.body != nil {
+			body = step.body()
+		}
+		path := step.path()
+
+		recorder, decoded := c.do(step.method, path, token, body, nil)
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("%s: %s %s: %d: %s", step.name, step.method, path, recorder.Code, recorder.Body)
+		}
+		if got := step.check(decoded); got == "" {
+			t.Fatalf("%s: %s %s: unexpected response %s", step.name, step.method, path, recorder.Body)
+		}
+	}
+}
+
+// TestGatewayErrors checks that failures come back with the HTTP status of
+// their gRPC code and the status details in the body.
+func TestGatewayErrors(t *testing.T) {
+	c := newGatewayClient(t)
+
+	user, err := auth.GenerateToken(primitive.NewObjectID(), "user@example.com", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder, body := c.do(http.MethodPost, "/v1/servers", c.admin, `{"name":"eu-1","endpoint":"vpn.example.com:51820","region":"eu","maxClients":1}`, nil)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("create server: %d: %s", recorder.Code, recorder.Body)
+	}
+	serverId := stringField(body, "server", "id")
+	if recorder, _ := c.do(http.MethodPost, "/v1/auth/register", "", `{"email":"taken@example.com","password":"correct horse"}`, nil); recorder.Code != http.StatusOK {
+		t.Fatalf("register: %d: %s", recorder.Code, recorder.Body)
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		token      string
+		body       string
+		wantStatus int
+		wantReason string
+		wantField  string
+	}{
+		{"no credentials", http.MethodPost, "/v1/servers/" + serverId + "/config", "", `{}`, http.StatusUnauthorized, "", ""},
+		{"bad credentials", http.MethodGet, "/v1/servers", "not-a-token", "", http.StatusUnauthorized, "", ""},
+		{"not admin", http.MethodPost, "/v1/servers", user, `{"name":"eu-2","endpoint":"vpn.example.com:51820","region":"eu","maxClients":1}`, http.StatusForbidden, "", ""},
+		{"invalid field", http.MethodPost, "/v1/auth/register", "", `{"email":"user@example.com","password":"short"}`, http.StatusBadRequest, "INVALID_ARGUMENT", "password"},
+		{"invalid path parameter", http.MethodGet, "/v1/servers/not-an-id", user, "", http.StatusBadRequest, "INVALID_ARGUMENT", "server_id"},
+		{"malformed body", http.MethodPost, "/v1/auth/login", "", `{"email":`, http.StatusBadRequest, "", ""},
+		{"not found", http.MethodGet, "/v1/servers/" + primitive.NewObjectID().Hex(), user, "", http.StatusNotFound, "SERVER_NOT_FOUND", ""},
+		{"already exists", http.MethodPost, "/v1/auth/register", "", `{"email":"taken@example.com","password":"correct horse"}`, http.StatusConflict, "EMAIL_TAKEN", ""},
+		{"wrong password", http.MethodPost, "/v1/auth/login", "", `{"email":"taken@example.com","password":"wrong horse"}`, http.StatusUnauthorized, "INVALID_CREDENTIALS", ""},
+		{"stale version", http.MethodPut, "/v1/servers/" + serverId, c.admin, `{"version":"7","name":"eu-1","endpoint":"vpn.example.com:51820","region":"eu","maxClients":1}`, http.StatusBadRequest, "VERSION_MISMATCH", ""},
+		{"unknown route", http.MethodGet, "/v1/peers", user, "", http.StatusNotFound, "", ""},
+		{"unknown dead letter", http.MethodPost, "/v1/dead-letter-events/" + primitive.NewObjectID().Hex() + ":requeue", c.admin, "", http.StatusNotFound, "", ""},
+	}
+
+	for _, tt := range tests {
+		recorder, body := c.do(tt.method, tt.path, tt.token, tt.body, nil)
+		if recorder.Code != tt.wantStatus {
+			t.Errorf("%s: %s %s: %d, want %d: %s", tt.name, tt.method, tt.path, recorder.Code, tt.wantStatus, recorder.Body)
+			continue
+		}
+		if stringField(body, "message") == "" {
+			t.Errorf("%s: no message in %s", tt.name, recorder.Body)
+		}
+		if tt.wantReason != "" && !hasDetail(body, "reason", tt.wantReason) {
+			t.Errorf("%s: no ErrorInfo with reason %s in %s", tt.name, tt.wantReason, recorder.Body)
+		}
+		if tt.wantField != "" && !hasFieldViolation(body, tt.wantField) {
+			t.Errorf("%s: no BadRequest naming %s in %s", tt.name, tt.wantField, recorder.Body)
+		}
+	}
+}
+
+// TestGatewayHeaders checks the headers passed to the chain and back.
+func TestGatewayHeaders(t *testing.T) {
+	c := newGatewayClient(t)
+
+	recorder, body := c.do(http.MethodPost, "/v1/servers", c.admin, `{"name":"eu-1","endpoint":"vpn.example.com:51820","region":"eu","maxClients":1}`, http.Header{
+		"X-Request-Id": {"req-1234"},
+		"User-Agent":   {"gateway-test/1.0"},
+	})
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("create server: %d: %s", recorder.Code, recorder.Body)
+	}
+	if got := recorder.Header().Get("X-Request-Id"); got != "req-1234" {
+		t.Errorf("X-Request-Id is %q, want the caller's", got)
+	}
+
+	// The audit entry shows the caller the chain saw.
+	entries, err := c.repos.Audit.List(context.Background(), repository.AuditFilter{Action: model.AuditActionServerCreate}, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("got %d audit entries, want 1", len(entries))
+	}
+	entry := entries[0]
+	if entry.ActorEmail != "admin@example.com" || entry.UserAgent != "gateway-test/1.0" || entry.SourceIp != "203.0.113.7" {
+		t.Errorf("audit entry has actor %q, user agent %q and source %q", entry.ActorEmail, entry.UserAgent, entry.SourceIp)
+	}
+
+	serverId := stringField(body, "server", "id")
+	recorder, _ = c.do(http.MethodGet, "/v1/servers/"+serverId, c.admin, "", nil)
+	if recorder.Header().Get("Etag") == "" {
+		t.Errorf("no ETag on GET: %v", recorder.Header())
+	}
+	for key := range recorder.Header() {
+		if strings.HasPrefix(key, "Grpc-Metadata-Etag") || strings.HasPrefix(key, "Grpc-Metadata-X-Request-Id") {
+			t.Errorf("%s sent as metadata too", key)
+		}
+	}
+}
+
+func TestOpenAPIDocument(t *testing.T) {
+	c := newGatewayClient(t)
+
+	recorder, body := c.do(http.MethodGet, openAPIPath, "", "", nil)
+	if recorder.Code != http.StatusOK || recorder.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("%d %s", recorder.Code, recorder.Header().Get("Content-Type"))
+	}
+
+	routes := map[string][]string{
+		"/v1/auth/login":                           {"post"},
+		"/v1/auth/register":                        {"post"},
+		"/v1/servers":                              {"get", "post"},
+		"/v1/servers/{serverId}":                   {"get", "put"},
+		"/v1/servers/{serverId}/config":            {"post"},
+		"/v1/servers/{serverId}/config:rotateKeys": {"post"},
+		"/v1/servers/{serverId}/share-links":       {"post"},
+		"/v1/servers/{serverId}/peer":              {"delete"},
+		"/v1/audit-events":                         {"get"},
+		"/v1/dead-letter-events":                   {"get"},
+		"/v1/dead-letter-events/{eventId}:requeue": {"post"},
+		"/v1/webhooks":                             {"get", "post"},
+		"/v1/webhooks/{webhookId}":                 {"delete"},
+		"/v1/webhooks/{webhookId}/deliveries":      {"get"},
+		"/v1/webhooks/{webhookId}:test":            {"post"},
+	}
+	paths, _ := field(body, "paths").(map[string]any)
+	if len(paths) != len(routes) {
+		t.Errorf("document has %d paths, want %d", len(paths), len(routes))
+	}
+	for path, methods := range routes {
+		for _, method := range methods {
+			if field(body, "paths", path, method) == nil {
+				t.Errorf("document has no %s %s", method, path)
+			}
+		}
+	}
+}
+
+func (c *gatewayClient) adminToken() string {
+	return c.admin
+}
+
+func fixed(s string) func() string {
+	return func() string { return s }
+}
+
+func hasDetail(body map[string]any, key, value string) bool {
+	for _, detail := range listField(body, "details") {
+		if detail, ok := detail.(map[string]any); ok && detail[key] == value {
+			return true
+		}
+	}
+	return false
+}
+
+func hasFieldViolation(body map[string]any, name string) bool {
+	for _, detail := range listField(body, "details") {
+		detail, _ := detail.(map[string]any)
+		for _, violation := range listField(detail, "fieldViolations") {
+			if violation, ok := violation.(map[string]any); ok && violation["field"] == name {
+				return true
+			}
+		}
+	}
+	return false
+}
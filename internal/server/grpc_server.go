@@ -2,13 +2,20 @@ package server
 
 import (
 	"context"
-	"errors"
-	"log"
+	"log/slog"
+	"strconv"
+	"time"
 
+	"github.com/shivamp1998/vpn_backend/internal/audit"
 	"github.com/shivamp1998/vpn_backend/internal/auth"
+	"github.com/shivamp1998/vpn_backend/internal/events"
+	"github.com/shivamp1998/vpn_backend/internal/model"
 	"github.com/shivamp1998/vpn_backend/internal/service"
+	"github.com/shivamp1998/vpn_backend/internal/wireguard"
 	pb "github.com/shivamp1998/vpn_backend/proto/gen"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 )
 
@@ -16,16 +23,30 @@ type Server struct {
 	pb.UnimplementedUserServiceServer
 	pb.UnimplementedServerServiceServer
 	pb.UnimplementedConfigServiceServer
-	userService   *service.UserService
-	serverService *service.ServerService
-	configService *service.ConfigService
+	pb.UnimplementedAuditServiceServer
+	pb.UnimplementedEventServiceServer
+	pb.UnimplementedWebhookServiceServer
+	userService    *service.UserService
+	serverService  *service.ServerService
+	configService  *service.ConfigService
+	webhookService *service.WebhookService
+	peerWatcher    *service.PeerWatcher
+	auditLog       *audit.Logger
+	dispatcher     *events.Dispatcher
+	// publicBaseURL prefixes the share link URLs.
+	publicBaseURL string
 }
 
-func NewServer() *Server {
+func NewServer(userService *service.UserService, serverService *service.ServerService, configService *service.ConfigService, webhookService *service.WebhookService, peerWatcher *service.PeerWatcher, auditLog *audit.Logger, dispatcher *events.Dispatcher, publicBaseURL string) *Server {
 	return &Server{
-		userService:   service.NewUserService(),
-		serverService: service.NewServerService(),
-		configService: service.NewConfigService(),
+		userService:    userService,
+		serverService:  serverService,
+		configService:  configService,
+		webhookService: webhookService,
+		peerWatcher:    peerWatcher,
+		auditLog:       auditLog,
+		dispatcher:     dispatcher,
+		publicBaseURL:  publicBaseURL,
 	}
 }
 
@@ -35,16 +56,98 @@ func (s *Server) GenerateConfig(ctx context.Context, req *pb.GenerateConfigReque
 		return nil, status.Errorf(codes.Unauthenticated, "user not authenticated")
 	}
 
-	result, err := s.configService.GenerateConfig(ctx, userId, req.ServerId)
+	format, err := configFormatFromProto(req.Format)
+	if err != nil {
+		return nil, err
+	}
 
+	qrOptions, err := qrCodeOptionsFromProto(req.QrCodeOptions)
 	if err != nil {
-		return &pb.GenerateConfigResponse{
-			Message: "error",
-		}, err
+		return nil, err
 	}
+
+	result, err := s.configService.GenerateConfig(ctx, userId, req.ServerId, service.GenerateConfigOptions{
+		Format: format,
+		QRCode: qrOptions,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	setETag(ctx, result.KeysVersion)
 	return &pb.GenerateConfigResponse{
+		ConfigContent:     result.ConfigContent,
+		QrCodeBase64:      result.QRCodeBase64,
+		QrCodeContentType: result.QRCodeContentType,
+		QrCodeError:       result.QRCodeError,
+		RotationRequired:  result.RotationRequired,
+		KeysVersion:       result.KeysVersion,
+		ConfigData: &pb.ConfigData{
+			PrivateKey:      result.ConfigData.PrivateKey,
+			PublicKey:       result.ConfigData.PublicKey,
+			ServerPublicKey: result.ConfigData.ServerPublicKey,
+			ServerEndpoint:  result.ConfigData.ServerEndpoint,
+			ServerAddress:   result.ConfigData.ServerAddress,
+			ServerPort:      result.ConfigData.ServerPort,
+			ClientIp:        result.ConfigData.ClientIp,
+			Dns:             result.ConfigData.DNS,
+		},
+		Export: &pb.ConfigExport{
+			Format:      configFormatToProto(result.Export.Format),
+			Content:     result.Export.Content,
+			FileName:    result.Export.FileName,
+			ContentType: result.Export.ContentType,
+		},
+		Message: "success",
+	}, nil
+}
+
+func (s *Server) CreateConfigShareLink(ctx context.Context, req *pb.CreateConfigShareLinkRequest) (*pb.CreateConfigShareLinkResponse, error) {
+	userId, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "user not authenticated")
+	}
+
+	kind := model.ShareLinkKindConfig
+	switch req.Kind {
+	case pb.ShareLinkKind_SHARE_LINK_KIND_UNSPECIFIED, pb.ShareLinkKind_SHARE_LINK_KIND_CONFIG_FILE:
+		req.Kind = pb.ShareLinkKind_SHARE_LINK_KIND_CONFIG_FILE
+	case pb.ShareLinkKind_SHARE_LINK_KIND_QR_PAGE:
+		kind = model.ShareLinkKindQRCode
+	default:
+		return nil, service.InvalidArgument("kind", "unsupported share link kind %v", req.Kind)
+	}
+
+	link, err := s.configService.CreateShareLink(ctx, userId, req.ServerId, kind, time.Duration(req.TtlSeconds)*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.CreateConfigShareLinkResponse{
+		Token:     link.Token,
+		Url:       s.shareLinkURL(link.Token),
+		ExpiresAt: link.ExpiresAt.Unix(),
+		Kind:      req.Kind,
+	}, nil
+}
+
+func (s *Server) RotateKeys(ctx context.Context, req *pb.GenerateConfigRequest) (*pb.GetConfigResponse, error) {
+	userId, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "user not authenticated")
+	}
+
+	result, err := s.configService.RotateKeys(ctx, userId, req.ServerId, req.ExpectedKeysVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	setETag(ctx, result.KeysVersion)
+	return &pb.GetConfigResponse{
 		ConfigContent: result.ConfigContent,
 		QrCodeBase64:  result.QRCodeBase64,
+		KeysVersion:   result.KeysVersion,
 		ConfigData: &pb.ConfigData{
 			PrivateKey:      result.ConfigData.PrivateKey,
 			PublicKey:       result.ConfigData.PublicKey,
@@ -55,29 +158,110 @@ func (s *Server) GenerateConfig(ctx context.Context, req *pb.GenerateConfigReque
 			ClientIp:        result.ConfigData.ClientIp,
 			Dns:             result.ConfigData.DNS,
 		},
-		Message: "success",
 	}, nil
 }
 
+func (s *Server) RevokePeer(ctx context.Context, req *pb.RevokePeerRequest) (*pb.RevokePeerResponse, error) {
+	userId, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "user not authenticated")
+	}
+
+	err = s.configService.RevokePeer(ctx, userId, req.ServerId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.RevokePeerResponse{}, nil
+}
+
+func configFormatFromProto(format pb.ConfigFormat) (wireguard.ConfigFormat, error) {
+	switch format {
+	case pb.ConfigFormat_CONFIG_FORMAT_UNSPECIFIED, pb.ConfigFormat_CONFIG_FORMAT_WG_QUICK:
+		return wireguard.FormatWgQuick, nil
+	case pb.ConfigFormat_CONFIG_FORMAT_NETWORK_MANAGER:
+		return wireguard.FormatNetworkManager, nil
+	case pb.ConfigFormat_CONFIG_FORMAT_APPLE_MOBILECONFIG:
+		return wireguard.FormatMobileConfig, nil
+	case pb.ConfigFormat_CONFIG_FORMAT_OPENWRT_UCI:
+		return wireguard.FormatOpenWrt, nil
+	case pb.ConfigFormat_CONFIG_FORMAT_MIKROTIK:
+		return wireguard.FormatMikroTik, nil
+	case pb.ConfigFormat_CONFIG_FORMAT_JSON:
+		return wireguard.FormatJSON, nil
+	}
+	return 0, service.InvalidArgument("format", "unsupported config format %v", format)
+}
+
+func qrCodeOptionsFromProto(opts *pb.QRCodeOptions) (wireguard.QRCodeOptions, error) {
+	var result wireguard.QRCodeOptions
+	if opts == nil {
+		return result, nil
+	}
+
+	if opts.Size < 0 || opts.Size > wireguard.MaxQRCodeSize {
+		return result, service.InvalidArgument("qr_code_options.size", "qr code size must be between 0 and %d", wireguard.MaxQRCodeSize)
+	}
+	result.Size = int(opts.Size)
+
+	switch opts.RecoveryLevel {
+	case pb.QRRecoveryLevel_QR_RECOVERY_LEVEL_UNSPECIFIED:
+		result.RecoveryLevel = wireguard.QRRecoveryDefault
+	case pb.QRRecoveryLevel_QR_RECOVERY_LEVEL_LOW:
+		result.RecoveryLevel = wireguard.QRRecoveryLow
+	case pb.QRRecoveryLevel_QR_RECOVERY_LEVEL_MEDIUM:
+		result.RecoveryLevel = wireguard.QRRecoveryMedium
+	case pb.QRRecoveryLevel_QR_RECOVERY_LEVEL_QUARTILE:
+		result.RecoveryLevel = wireguard.QRRecoveryQuartile
+	case pb.QRRecoveryLevel_QR_RECOVERY_LEVEL_HIGH:
+		result.RecoveryLevel = wireguard.QRRecoveryHigh
+	default:
+		return result, service.InvalidArgument("qr_code_options.recovery_level", "unsupported QR recovery level %v", opts.RecoveryLevel)
+	}
+
+	switch opts.Format {
+	case pb.QRCodeFormat_QR_CODE_FORMAT_UNSPECIFIED, pb.QRCodeFormat_QR_CODE_FORMAT_PNG:
+		result.Format = wireguard.QRCodeFormatPNG
+	case pb.QRCodeFormat_QR_CODE_FORMAT_SVG:
+		result.Format = wireguard.QRCodeFormatSVG
+	case pb.QRCodeFormat_QR_CODE_FORMAT_TERMINAL:
+		result.Format = wireguard.QRCodeFormatTerminal
+	default:
+		return result, service.InvalidArgument("qr_code_options.format", "unsupported QR code format %v", opts.Format)
+	}
+
+	return result, nil
+}
+
+func configFormatToProto(format wireguard.ConfigFormat) pb.ConfigFormat {
+	switch format {
+	case wireguard.FormatNetworkManager:
+		return pb.ConfigFormat_CONFIG_FORMAT_NETWORK_MANAGER
+	case wireguard.FormatMobileConfig:
+		return pb.ConfigFormat_CONFIG_FORMAT_APPLE_MOBILECONFIG
+	case wireguard.FormatOpenWrt:
+		return pb.ConfigFormat_CONFIG_FORMAT_OPENWRT_UCI
+	case wireguard.FormatMikroTik:
+		return pb.ConfigFormat_CONFIG_FORMAT_MIKROTIK
+	case wireguard.FormatJSON:
+		return pb.ConfigFormat_CONFIG_FORMAT_JSON
+	}
+	return pb.ConfigFormat_CONFIG_FORMAT_WG_QUICK
+}
+
 func (s *Server) Register(ctx context.Context, req *pb.RegisterRequest) (*pb.AuthenticationResponse, error) {
-	log.Printf("Register request for email: %s", req.Email)
+	slog.DebugContext(ctx, "Register request", "email", req.Email)
 
 	user, err := s.userService.Register(ctx, req.Email, req.Password)
 
 	if err != nil {
-		return &pb.AuthenticationResponse{
-			Status: "error",
-			Token:  "",
-		}, err
+		return nil, err
 	}
 
-	token, err := auth.GenerateToken(user.Id, user.Email)
+	token, err := auth.GenerateToken(user.Id, user.Email, user.Role)
 
 	if err != nil {
-		return &pb.AuthenticationResponse{
-			Status: "error",
-			Token:  "",
-		}, err
+		return nil, err
 	}
 
 	return &pb.AuthenticationResponse{
@@ -90,19 +274,13 @@ func (s *Server) Login(ctx context.Context, req *pb.LoginRequest) (*pb.Authentic
 	user, err := s.userService.Login(ctx, req.Email, req.Password)
 
 	if err != nil {
-		return &pb.AuthenticationResponse{
-			Status: "Error",
-			Token:  "",
-		}, err
+		return nil, err
 	}
 
-	token, err := auth.GenerateToken(user.Id, user.Email)
+	token, err := auth.GenerateToken(user.Id, user.Email, user.Role)
 
 	if err != nil {
-		return &pb.AuthenticationResponse{
-			Status: "Error",
-			Token:  "",
-		}, err
+		return nil, err
 	}
 
 	return &pb.AuthenticationResponse{
@@ -112,28 +290,25 @@ func (s *Server) Login(ctx context.Context, req *pb.LoginRequest) (*pb.Authentic
 }
 
 func (s *Server) CreateServer(ctx context.Context, req *pb.CreateServerRequest) (*pb.CreateServerResponse, error) {
-	server, err := s.serverService.CreateServer(ctx, req.Name, req.Endpoint, req.Region, req.PublicKey, req.MaxClients)
+	rotation := service.KeyRotationPolicy{
+		MaxAge: time.Duration(req.KeyRotationMaxAgeSeconds) * time.Second,
+		Mode:   req.KeyRotationMode,
+	}
+
+	server, err := s.serverService.CreateServer(ctx, req.Name, req.Endpoint, req.Region, req.PublicKey, req.MaxClients, rotation)
 
 	if err != nil {
-		return nil, errors.New("error creating server")
+		return nil, err
 	}
 
 	return &pb.CreateServerResponse{
-		Server: &pb.Server{
-			Id:             server.Id.Hex(),
-			Name:           server.Name,
-			Endpoint:       server.Endpoint,
-			PublicKey:      server.PublicKey,
-			Region:         server.Region,
-			MaxClients:     server.MaxClients,
-			CurrentClients: server.CurrentClients,
-		},
+		Server:  serverToProto(server),
 		Message: "server created successfully!",
 	}, nil
 }
 
 func (s *Server) ListServers(ctx context.Context, req *pb.ListServerRequest) (*pb.ListServerResponse, error) {
-	log.Println("ListServer request")
+	slog.DebugContext(ctx, "ListServers request")
 
 	servers, err := s.serverService.ListServers(ctx)
 
@@ -144,15 +319,7 @@ func (s *Server) ListServers(ctx context.Context, req *pb.ListServerRequest) (*p
 	pbServers := make([]*pb.Server, len(servers))
 
 	for i, server := range servers {
-		pbServers[i] = &pb.Server{
-			Id:             server.Id.Hex(),
-			Name:           server.Name,
-			Endpoint:       server.Endpoint,
-			PublicKey:      server.PublicKey,
-			Region:         server.Region,
-			MaxClients:     server.MaxClients,
-			CurrentClients: server.CurrentClients,
-		}
+		pbServers[i] = serverToProto(server)
 	}
 
 	return &pb.ListServerResponse{
@@ -167,15 +334,58 @@ func (s *Server) GetServer(ctx context.Context, req *pb.GetServerRequest) (*pb.G
 		return nil, err
 	}
 
+	setETag(ctx, server.Version)
 	return &pb.GetServerResponse{
-		Server: &pb.Server{
-			Id:             server.Id.Hex(),
-			Name:           server.Name,
-			Endpoint:       server.Endpoint,
-			Region:         server.Region,
-			PublicKey:      server.PublicKey,
-			MaxClients:     server.MaxClients,
-			CurrentClients: server.CurrentClients,
+		Server: serverToProto(server),
+	}, nil
+}
+
+func (s *Server) UpdateServer(ctx context.Context, req *pb.UpdateServerRequest) (*pb.UpdateServerResponse, error) {
+	update := service.ServerUpdate{
+		Name:       req.Name,
+		Endpoint:   req.Endpoint,
+		Region:     req.Region,
+		MaxClients: req.MaxClients,
+		Rotation: service.KeyRotationPolicy{
+			MaxAge: time.Duration(req.KeyRotationMaxAgeSeconds) * time.Second,
+			Mode:   req.KeyRotationMode,
 		},
+		Status: req.Status,
+	}
+
+	server, err := s.serverService.UpdateServer(ctx, req.ServerId, req.Version, update)
+	if err != nil {
+		return nil, err
+	}
+
+	setETag(ctx, server.Version)
+	return &pb.UpdateServerResponse{
+		Server: serverToProto(server),
 	}, nil
 }
+
+func serverToProto(server *model.Server) *pb.Server {
+	return &pb.Server{
+		Id:                       server.Id.Hex(),
+		Name:                     server.Name,
+		Endpoint:                 server.Endpoint,
+		PublicKey:                server.PublicKey,
+		Region:                   server.Region,
+		MaxClients:               server.MaxClients,
+		CurrentClients:           server.CurrentClients,
+		KeyRotationMaxAgeSeconds: int64(server.KeyRotationMaxAge / time.Second),
+		KeyRotationMode:          server.KeyRotationMode,
+		Version:                  server.Version,
+		Status:                   server.Status,
+	}
+}
+
+func versionETag(version int64) string {
+	return strconv.Quote(strconv.FormatInt(version, 10))
+}
+
+// setETag sends the version as an "etag" header on gRPC calls. Connect
+// handlers set the HTTP ETag header from the response message instead.
+func setETag(ctx context.Context, version int64) {
+	grpc.SetHeader(ctx, metadata.Pairs("etag", versionETag(version)))
+}
@@ -1,98 +1,60 @@
 package server
 
 import (
-	"context"
-	"errors"
-	"strings"
-
-	"github.com/shivamp1998/vpn_backend/internal/auth"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/metadata"
-	"google.golang.org/grpc/status"
+	"buf.build/go/protovalidate"
+	"github.com/shivamp1998/vpn_backend/internal/audit"
+	"github.com/shivamp1998/vpn_backend/internal/middleware"
+	"github.com/shivamp1998/vpn_backend/internal/model"
+	"github.com/shivamp1998/vpn_backend/proto/gen/genconnect"
+	healthgrpc "google.golang.org/grpc/health/grpc_health_v1"
 )
 
-func AuthInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
-	if IsPublicEndpoint(info.FullMethod) {
-		return handler(ctx, req)
-	}
-
-	token, err := extractTokenFromMetadata(ctx)
-
-	if err != nil {
-		return nil, status.Errorf(codes.Unauthenticated, "authentication required: %v", err)
-	}
-
-	ctx, err = ValidateAndSetUserContext(ctx, token)
-
-	if err != nil {
-		return nil, status.Errorf(codes.Unauthenticated, "invalid token")
-	}
-	return handler(ctx, req)
-
-}
-
-func ValidateAndSetUserContext(ctx context.Context, token string) (context.Context, error) {
-	claims, err := auth.ValidateToken(token)
-
-	if err != nil {
-		return ctx, err
-	}
-
-	ctx = context.WithValue(ctx, auth.UserIdKey, claims.UserId)
-	ctx = context.WithValue(ctx, auth.UserEmailKey, claims.Email)
-	return ctx, nil
+// publicProcedures can be called without a token.
+var publicProcedures = []string{
+	genconnect.UserServiceRegisterProcedure,
+	genconnect.UserServiceLoginProcedure,
+	healthgrpc.Health_Check_FullMethodName,
+	healthgrpc.Health_List_FullMethodName,
+	healthgrpc.Health_Watch_FullMethodName,
 }
 
-func IsPublicEndpoint(method string) bool {
-	publicEndpoints := []string{
-		"/vpn.UserService/Register",
-		"/vpn.UserService/Login",
-	}
-
-	for _, endpoint := range publicEndpoints {
-		if method == endpoint {
-			return true
-		}
-	}
-
-	return false
+// roleRules lists the procedures and services that need a role.
+var roleRules = map[string]string{
+	"/vpn.AuditService/":                          model.RoleAdmin,
+	"/vpn.EventService/":                          model.RoleAdmin,
+	"/vpn.WebhookService/":                        model.RoleAdmin,
+	genconnect.ServerServiceCreateServerProcedure: model.RoleAdmin,
+	genconnect.ServerServiceUpdateServerProcedure: model.RoleAdmin,
+	genconnect.ServerServiceWatchPeersProcedure:   model.RoleAdmin,
 }
 
-func extractTokenFromMetadata(ctx context.Context) (string, error) {
-	md, ok := metadata.FromIncomingContext(ctx)
-
-	if !ok {
-		return "", errors.New("metadata not found")
-	}
-
-	authHeaders := md.Get("authorization")
-
-	if len(authHeaders) == 0 {
-		return "", errors.New("authorization headers missing")
-	}
-
-	authHeader := authHeaders[0]
-
-	parts := strings.Split(authHeader, " ")
-
-	if len(parts) != 2 || parts[0] != "Bearer" {
-		return "", errors.New("invalid authorization header fromat")
-	}
-
-	return parts[1], nil
+type MiddlewareConfig struct {
+	// RateLimiter limits calls per caller. Nil disables rate limiting.
+	RateLimiter *middleware.RateLimiter
+	// Observer receives the outcome of every call. Nil disables it.
+	Observer middleware.Observer
 }
 
-func ExtractTokenFromHeader(authHeader string) (string, error) {
-	if authHeader == "" {
-		return "", errors.New("authorization header missing")
+// NewMiddleware returns the chain every RPC runs through, on the gRPC
+// server and the Connect handlers alike.
+func NewMiddleware(auditLog *audit.Logger, config MiddlewareConfig) middleware.Chain {
+	return middleware.Chain{
+		middleware.RequestID(),
+		middleware.Tracing(),
+		middleware.Logging(),
+		middleware.Metrics(config.Observer),
+		// Errors sits inside logging and metrics so they see the mapped
+		// codes.
+		middleware.Errors(errorStatus),
+		// Everything after this point is covered, so a panic is logged and
+		// counted as an Internal error.
+		middleware.Recovery(),
+		// Audit runs before authentication so it sees the calls rejected
+		// there.
+		auditLog.Middleware(),
+		middleware.Authenticate(publicProcedures...),
+		middleware.RateLimit(config.RateLimiter),
+		middleware.Authorize(roleRules),
+		middleware.Validate(protovalidate.GlobalValidator),
 	}
-
-	parts := strings.Split(authHeader, " ")
-
-	if len(parts) != 2 || parts[0] != "Bearer" {
-		return "", errors.New("invalid authorization header format")
-	}
-
-	return parts[1], nil
 }
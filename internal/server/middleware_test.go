@@ -0,0 +1,49 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/shivamp1998/vpn_backend/internal/audit"
+	"github.com/shivamp1998/vpn_backend/internal/auth"
+	"github.com/shivamp1998/vpn_backend/internal/middleware"
+	"github.com/shivamp1998/vpn_backend/internal/model"
+	"github.com/shivamp1998/vpn_backend/internal/repository"
+	"github.com/shivamp1998/vpn_backend/proto/gen/genconnect"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/grpc/codes"
+)
+
+func TestServerEditsNeedAdmin(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	chain := NewMiddleware(audit.NewLogger(repos.Audit, audit.Options{}), MiddlewareConfig{})
+	handler := chain.Then(func(ctx context.Context, call *middleware.Call) error {
+		return nil
+	})
+
+	tests := []struct {
+		procedure string
+		role      string
+		want      codes.Code
+	}{
+		{genconnect.ServerServiceCreateServerProcedure, "", codes.PermissionDenied},
+		{genconnect.ServerServiceUpdateServerProcedure, "", codes.PermissionDenied},
+		{genconnect.ServerServiceCreateServerProcedure, model.RoleAdmin, codes.OK},
+		{genconnect.ServerServiceUpdateServerProcedure, model.RoleAdmin, codes.OK},
+		{genconnect.ServerServiceListServersProcedure, "", codes.OK},
+	}
+	for _, tt := range tests {
+		token, err := auth.GenerateToken(primitive.NewObjectID(), "user@example.com", tt.role)
+		if err != nil {
+			t.Fatal(err)
+		}
+		call := &middleware.Call{Procedure: tt.procedure, Header: http.Header{}}
+		call.Header.Set("Authorization", "Bearer "+token)
+
+		err = handler(context.Background(), call)
+		if got := middleware.Code(err); got != tt.want {
+			t.Errorf("%s as %q: got %s, want %s (%v)", tt.procedure, tt.role, got, tt.want, err)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package server
+
+import (
+	"context"
+
+	"github.com/shivamp1998/vpn_backend/internal/service"
+	pb "github.com/shivamp1998/vpn_backend/proto/gen"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+func (s *Server) WatchPeers(req *pb.WatchPeersRequest, stream grpc.ServerStreamingServer[pb.WatchPeersResponse]) error {
+	return s.watchPeers(stream.Context(), req, stream.Send)
+}
+
+// watchPeers serves WatchPeers for both the gRPC and the Connect handler.
+func (s *Server) watchPeers(ctx context.Context, req *pb.WatchPeersRequest, send func(*pb.WatchPeersResponse) error) error {
+	var sendErr error
+	err := s.peerWatcher.Watch(ctx, req.ServerId, req.ResumeToken, func(update *service.PeerWatchUpdate) error {
+		sendErr = send(peerWatchUpdateToProto(update))
+		return sendErr
+	})
+
+	switch {
+	case sendErr != nil:
+		return sendErr
+	case err != nil && ctx.Err() != nil:
+		return status.FromContextError(ctx.Err()).Err()
+	}
+	return err
+}
+
+func peerWatchUpdateToProto(update *service.PeerWatchUpdate) *pb.WatchPeersResponse {
+	resp := &pb.WatchPeersResponse{
+		Snapshot:    update.Snapshot,
+		ResumeToken: update.ResumeToken,
+	}
+
+	for _, peer := range update.Peers {
+		resp.Peers = append(resp.Peers, &pb.Peer{
+			PeerId:    peer.Id.Hex(),
+			UserId:    peer.UserId.Hex(),
+			PublicKey: peer.PublicKey,
+			IpAddress: peer.IpAddress,
+		})
+	}
+
+	for _, change := range update.Changes {
+		resp.Changes = append(resp.Changes, &pb.PeerChange{
+			Type:              peerChangeTypeToProto(change.Type),
+			PeerId:            change.PeerId,
+			UserId:            change.UserId,
+			PublicKey:         change.PublicKey,
+			PreviousPublicKey: change.PreviousPublicKey,
+			IpAddress:         change.IpAddress,
+			OccurredAt:        change.OccurredAt.UnixMilli(),
+		})
+	}
+	return resp
+}
+
+func peerChangeTypeToProto(changeType string) pb.PeerChange_Type {
+	switch changeType {
+	case service.PeerChangeAdded:
+		return pb.PeerChange_TYPE_ADDED
+	case service.PeerChangeRemoved:
+		return pb.PeerChange_TYPE_REMOVED
+	case service.PeerChangeRotated:
+		return pb.PeerChange_TYPE_ROTATED
+	}
+	return pb.PeerChange_TYPE_UNSPECIFIED
+}
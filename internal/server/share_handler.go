@@ -0,0 +1,127 @@
+package server
+
+import (
+	"errors"
+	"html/template"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/shivamp1998/vpn_backend/internal/audit"
+	"github.com/shivamp1998/vpn_backend/internal/model"
+	"github.com/shivamp1998/vpn_backend/internal/service"
+)
+
+const shareLinkPath = "/share/"
+
+func (s *Server) shareLinkURL(token string) string {
+	return strings.TrimSuffix(s.publicBaseURL, "/") + shareLinkPath + token
+}
+
+// The GET page only offers a button; the config is released on POST. Chat
+// apps and mail scanners fetch links to build previews, and would otherwise
+// burn the single-use token before the user ever opens it.
+var shareConfirmPage = template.Must(template.New("confirm").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><meta name="robots" content="noindex"><title>WireGuard configuration</title></head>
+<body>
+<h1>WireGuard configuration</h1>
+<p>This link can only be used once. Open it on the device you want to set up.</p>
+<form method="post" action="{{.}}"><button type="submit">Show configuration</button></form>
+</body>
+</html>
+`))
+
+var shareQRCodePage = template.Must(template.New("qr").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><meta name="robots" content="noindex"><title>WireGuard configuration</title></head>
+<body>
+<h1>Scan with the WireGuard app</h1>
+<img alt="WireGuard configuration QR code" src="{{.}}">
+<p>This page will not be shown again. Close it once the tunnel has been imported.</p>
+</body>
+</html>
+`))
+
+type shareLinkHandler struct {
+	server *Server
+}
+
+func newShareLinkHandler(mainServer *Server) http.Handler {
+	return &shareLinkHandler{server: mainServer}
+}
+
+func (h *shareLinkHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	token := strings.TrimPrefix(r.URL.Path, shareLinkPath)
+	if token == "" || strings.Contains(token, "/") {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.Header().Set("X-Robots-Tag", "noindex")
+
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		shareConfirmPage.Execute(w, r.URL.Path)
+	case http.MethodPost:
+		h.redeem(w, r, token)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *shareLinkHandler) redeem(w http.ResponseWriter, r *http.Request, token string) {
+	info := service.ShareRequestInfo{
+		SourceIp:  remoteIp(r),
+		UserAgent: r.UserAgent(),
+	}
+
+	ctx := audit.WithRequestInfo(r.Context(), audit.RequestInfo{
+		Procedure: r.Method + " " + shareLinkPath,
+		SourceIp:  info.SourceIp,
+		UserAgent: info.UserAgent,
+	})
+
+	link, result, err := h.server.configService.RedeemShareLink(ctx, token, info)
+
+	switch {
+	case err == nil:
+	case errors.Is(err, service.ErrShareLinkNotFound):
+		http.NotFound(w, r)
+		return
+	case errors.Is(err, service.ErrShareLinkUsed), errors.Is(err, service.ErrShareLinkExpired):
+		http.Error(w, err.Error(), http.StatusGone)
+		return
+	case errors.Is(err, service.ErrShareLinkQRCode):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	default:
+		slog.ErrorContext(ctx, "Share link retrieval failed", "error", err)
+		http.Error(w, "unable to load configuration", http.StatusInternalServerError)
+		return
+	}
+
+	if link.Kind == model.ShareLinkKindQRCode {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		// The image is generated by us, so the data URL is safe to embed.
+		shareQRCodePage.Execute(w, template.URL("data:"+result.QRCodeContentType+";base64,"+result.QRCodeBase64))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+result.Export.FileName+`"`)
+	w.Write([]byte(result.ConfigContent))
+}
+
+func remoteIp(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,67 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/shivamp1998/vpn_backend/internal/model"
+	"github.com/shivamp1998/vpn_backend/internal/repository"
+	"github.com/shivamp1998/vpn_backend/internal/service"
+	"github.com/shivamp1998/vpn_backend/internal/wireguard"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestShareLinkQRCodeFailureKeepsToken(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemoryRepositories()
+
+	_, publicKey, err := wireguard.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	vpnServer := &model.Server{Name: "test", Endpoint: "vpn.example.com:51820", PublicKey: publicKey, MaxClients: 10, Status: model.ServerStatusActive}
+	if err := repos.Servers.Create(ctx, vpnServer); err != nil {
+		t.Fatal(err)
+	}
+
+	// Both handlers share the repositories; the first one's configs are too
+	// large for a QR code.
+	handler := func(dns string) http.Handler {
+		configService := service.NewConfigService(repos.Users, repos.Servers, repos.Keys, repos.ShareLinks, nil, service.ClientNetworkConfig{DNS: dns})
+		return newShareLinkHandler(NewServer(nil, nil, configService, nil, nil, nil, nil, "https://vpn.example.com"))
+	}
+	oversized := handler(strings.Repeat("1.1.1.1, ", 300))
+	working := handler("1.1.1.1")
+
+	configService := service.NewConfigService(repos.Users, repos.Servers, repos.Keys, repos.ShareLinks, nil, service.ClientNetworkConfig{})
+	userId := primitive.NewObjectID()
+	if _, err := configService.GenerateConfig(ctx, userId, vpnServer.Id.Hex(), service.GenerateConfigOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	link, err := configService.CreateShareLink(ctx, userId, vpnServer.Id.Hex(), model.ShareLinkKindQRCode, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	redeem := func(h http.Handler) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		h.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, shareLinkPath+link.Token, nil))
+		return recorder
+	}
+
+	if recorder := redeem(oversized); recorder.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("oversized config: got %d, want %d: %s", recorder.Code, http.StatusUnprocessableEntity, recorder.Body)
+	}
+
+	recorder := redeem(working)
+	if recorder.Code != http.StatusOK || !strings.Contains(recorder.Body.String(), "data:image/png;base64,") {
+		t.Fatalf("redeem after the failure: got %d: %s", recorder.Code, recorder.Body)
+	}
+
+	if recorder := redeem(working); recorder.Code != http.StatusGone {
+		t.Errorf("second redeem: got %d, want %d", recorder.Code, http.StatusGone)
+	}
+}
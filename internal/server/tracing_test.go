@@ -0,0 +1,123 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/netip"
+	"testing"
+
+	"github.com/shivamp1998/vpn_backend/internal/audit"
+	"github.com/shivamp1998/vpn_backend/internal/auth"
+	"github.com/shivamp1998/vpn_backend/internal/middleware"
+	"github.com/shivamp1998/vpn_backend/internal/model"
+	"github.com/shivamp1998/vpn_backend/internal/repository"
+	"github.com/shivamp1998/vpn_backend/internal/service"
+	"github.com/shivamp1998/vpn_backend/internal/tracing"
+	"github.com/shivamp1998/vpn_backend/internal/wireguard"
+	"github.com/shivamp1998/vpn_backend/proto/gen/genconnect"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestGenerateConfigSpanTree(t *testing.T) {
+	provider, exporter := tracing.NewMemoryProvider()
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(noop.NewTracerProvider())
+
+	ctx := context.Background()
+	repos := repository.Traced(repository.NewMemoryRepositories(), "memory")
+	_, serverKey, err := wireguard.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &model.Server{Name: "test", Endpoint: "vpn.example.com:51820", PublicKey: serverKey, MaxClients: 10, Status: model.ServerStatusActive}
+	if err := repos.Servers.Create(ctx, server); err != nil {
+		t.Fatal(err)
+	}
+
+	auditLog := audit.NewLogger(repos.Audit, audit.Options{})
+	configService := service.NewConfigService(repos.Users, repos.Servers, repos.Keys, repos.ShareLinks, auditLog,
+		service.ClientNetworkConfig{Network: netip.MustParsePrefix("10.8.0.0/24")})
+
+	userId := primitive.NewObjectID()
+	handler := NewMiddleware(auditLog, MiddlewareConfig{}).Then(func(ctx context.Context, call *middleware.Call) error {
+		_, err := configService.GenerateConfig(ctx, userId, server.Id.Hex(), service.GenerateConfigOptions{})
+		return err
+	})
+
+	token, err := auth.GenerateToken(userId, "user@example.com", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	call := &middleware.Call{Procedure: genconnect.ConfigServiceGenerateConfigProcedure, Header: http.Header{}}
+	call.Header.Set("Authorization", "Bearer "+token)
+
+	exporter.Reset()
+	if err := handler(ctx, call); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := exporter.GetSpans()
+	byName := make(map[string]tracetest.SpanStub)
+	for _, span := range spans {
+		byName[span.Name] = span
+	}
+
+	root, ok := byName["vpn.ConfigService/GenerateConfig"]
+	if !ok {
+		t.Fatalf("no server span among %d spans", len(spans))
+	}
+	if root.SpanKind != trace.SpanKindServer || root.Parent.IsValid() {
+		t.Errorf("server span has kind %v and parent %v, want a server root span", root.SpanKind, root.Parent.SpanID())
+	}
+	if got := attr(root, tracing.ProcedureKey); got != genconnect.ConfigServiceGenerateConfigProcedure {
+		t.Errorf("server span procedure is %q", got)
+	}
+
+	parents := make(map[trace.SpanID]string)
+	for _, span := range spans {
+		parents[span.SpanContext.SpanID()] = span.Name
+		if span.SpanContext.TraceID() != root.SpanContext.TraceID() {
+			t.Errorf("%s is in another trace", span.Name)
+		}
+	}
+
+	// Each span and the span it should hang off.
+	tree := []struct {
+		name, parent string
+		serverId     bool
+	}{
+		{"ConfigService.GenerateConfig", "vpn.ConfigService/GenerateConfig", true},
+		{"ServerRepository.GetById", "ConfigService.GenerateConfig", true},
+		{"WireGuardKeysRepository.GetByUserAndServer", "ConfigService.GenerateConfig", true},
+		{"ConfigService.assignClientIp", "ConfigService.GenerateConfig", true},
+		{"WireGuardKeysRepository.Allocate", "ConfigService.GenerateConfig", true},
+		{"ConfigService.buildConfig", "ConfigService.GenerateConfig", false},
+	}
+	for _, tt := range tree {
+		span, ok := byName[tt.name]
+		if !ok {
+			t.Errorf("no %s span", tt.name)
+			continue
+		}
+		if got := parents[span.Parent.SpanID()]; got != tt.parent {
+			t.Errorf("%s is a child of %q, want %q", tt.name, got, tt.parent)
+		}
+		if tt.serverId && attr(span, tracing.ServerIDKey) != server.Id.Hex() {
+			t.Errorf("%s has server_id %q, want %s", tt.name, attr(span, tracing.ServerIDKey), server.Id.Hex())
+		}
+	}
+}
+
+func attr(span tracetest.SpanStub, key attribute.Key) string {
+	for _, kv := range span.Attributes {
+		if kv.Key == key {
+			return kv.Value.Emit()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,102 @@
+package server
+
+import (
+	"context"
+
+	"github.com/shivamp1998/vpn_backend/internal/model"
+	pb "github.com/shivamp1998/vpn_backend/proto/gen"
+)
+
+const (
+	defaultWebhookDeliveryPageSize = 50
+	maxWebhookDeliveryPageSize     = 500
+)
+
+func (s *Server) CreateWebhook(ctx context.Context, req *pb.CreateWebhookRequest) (*pb.CreateWebhookResponse, error) {
+	hook, err := s.webhookService.CreateWebhook(ctx, req.Url, req.EventTypes, req.Description)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.CreateWebhookResponse{
+		Webhook: webhookToProto(hook),
+		Secret:  hook.Secret,
+	}, nil
+}
+
+func (s *Server) ListWebhooks(ctx context.Context, req *pb.ListWebhooksRequest) (*pb.ListWebhooksResponse, error) {
+	webhooks, err := s.webhookService.ListWebhooks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &pb.ListWebhooksResponse{}
+	for _, hook := range webhooks {
+		resp.Webhooks = append(resp.Webhooks, webhookToProto(hook))
+	}
+	return resp, nil
+}
+
+func (s *Server) DeleteWebhook(ctx context.Context, req *pb.DeleteWebhookRequest) (*pb.DeleteWebhookResponse, error) {
+	if err := s.webhookService.DeleteWebhook(ctx, req.WebhookId); err != nil {
+		return nil, err
+	}
+	return &pb.DeleteWebhookResponse{}, nil
+}
+
+func (s *Server) ListWebhookDeliveries(ctx context.Context, req *pb.ListWebhookDeliveriesRequest) (*pb.ListWebhookDeliveriesResponse, error) {
+	limit := int(req.PageSize)
+	if limit <= 0 {
+		limit = defaultWebhookDeliveryPageSize
+	}
+	limit = min(limit, maxWebhookDeliveryPageSize)
+
+	deliveries, err := s.webhookService.ListDeliveries(ctx, req.WebhookId, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &pb.ListWebhookDeliveriesResponse{}
+	for _, delivery := range deliveries {
+		resp.Deliveries = append(resp.Deliveries, webhookDeliveryToProto(delivery))
+	}
+	return resp, nil
+}
+
+func (s *Server) TestWebhook(ctx context.Context, req *pb.TestWebhookRequest) (*pb.TestWebhookResponse, error) {
+	delivery, err := s.webhookService.TestWebhook(ctx, req.WebhookId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.TestWebhookResponse{
+		Delivery: webhookDeliveryToProto(delivery),
+	}, nil
+}
+
+func webhookToProto(hook *model.Webhook) *pb.Webhook {
+	return &pb.Webhook{
+		Id:          hook.Id.Hex(),
+		Url:         hook.Url,
+		EventTypes:  hook.EventTypes,
+		Description: hook.Description,
+		Enabled:     hook.Enabled,
+		CreatedAt:   hook.CreatedAt.UnixMilli(),
+	}
+}
+
+func webhookDeliveryToProto(delivery *model.WebhookDelivery) *pb.WebhookDelivery {
+	return &pb.WebhookDelivery{
+		Id:         delivery.Id.Hex(),
+		WebhookId:  delivery.WebhookId.Hex(),
+		EventId:    delivery.EventId.Hex(),
+		EventType:  delivery.EventType,
+		Attempt:    int32(delivery.Attempt),
+		Test:       delivery.Test,
+		Success:    delivery.Success,
+		StatusCode: int32(delivery.StatusCode),
+		Error:      delivery.Error,
+		DurationMs: delivery.DurationMs,
+		CreatedAt:  delivery.CreatedAt.UnixMilli(),
+	}
+}
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/shivamp1998/vpn_backend/internal/repository"
+)
+
+type ClientCountConfig struct {
+	// Interval is the time between recounts. It must be longer than any
+	// allocation or revoke takes.
+	Interval time.Duration
+}
+
+// ClientCountReconciler periodically gives back the client slots a backend
+// without transactions can leak. See ServerRepository.RecountClients.
+type ClientCountReconciler struct {
+	config     ClientCountConfig
+	serverRepo repository.ServerRepository
+}
+
+func NewClientCountReconciler(config ClientCountConfig, serverRepo repository.ServerRepository) *ClientCountReconciler {
+	if config.Interval <= 0 {
+		config.Interval = 5 * time.Minute
+	}
+
+	return &ClientCountReconciler{config: config, serverRepo: serverRepo}
+}
+
+// Run recounts every Interval until ctx is cancelled.
+func (r *ClientCountReconciler) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := r.serverRepo.RecountClients(ctx); err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "Client recount failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -2,139 +2,397 @@ package service
 
 import (
 	"context"
+	"encoding/base64"
 	"errors"
 	"fmt"
-	"strings"
+	"math"
+	"net/netip"
 
+	"github.com/shivamp1998/vpn_backend/internal/audit"
+	"github.com/shivamp1998/vpn_backend/internal/events"
+	"github.com/shivamp1998/vpn_backend/internal/metrics"
 	"github.com/shivamp1998/vpn_backend/internal/model"
 	"github.com/shivamp1998/vpn_backend/internal/repository"
+	"github.com/shivamp1998/vpn_backend/internal/tracing"
 	"github.com/shivamp1998/vpn_backend/internal/wireguard"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = otel.Tracer("github.com/shivamp1998/vpn_backend/internal/service")
+
+// startSpan starts a span for an operation on a user's peer on a server.
+func startSpan(ctx context.Context, name string, userId primitive.ObjectID, serverId string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name, trace.WithAttributes(
+		tracing.UserIDKey.String(userId.Hex()),
+		tracing.ServerIDKey.String(serverId),
+	))
+}
+
+// generateKeyPair is wireguard.GenerateKeyPair in a span of its own.
+func generateKeyPair(ctx context.Context) (privateKey, publicKey string, err error) {
+	_, span := tracer.Start(ctx, "wireguard.GenerateKeyPair")
+	defer func() { tracing.End(span, err) }()
+	return wireguard.GenerateKeyPair()
+}
+
+// ClientNetworkConfig is what clients are given on the tunnel. Peers get
+// addresses in Network from the second host address on; the first is the
+// server's.
+type ClientNetworkConfig struct {
+	Network netip.Prefix
+	DNS     string
+}
+
+// PoolSize is the number of addresses peers can be given: those in Network
+// less the network, server and broadcast addresses.
+func (c ClientNetworkConfig) PoolSize() int {
+	hostBits := c.Network.Addr().BitLen() - c.Network.Bits()
+	if hostBits >= 62 {
+		return math.MaxInt
+	}
+	return max(1<<hostBits-3, 0)
+}
+
 type ConfigService struct {
-	userRepo      *repository.UserRespository
-	serverRepo    *repository.ServerRepository
-	keysRepo      *repository.WireGuardKeysRepository
-	serverNetwork string
+	userRepo   repository.UserRepository
+	serverRepo repository.ServerRepository
+	keysRepo   repository.WireGuardKeysRepository
+	shareRepo  repository.ConfigShareLinkRepository
+	auditLog   *audit.Logger
+	network    ClientNetworkConfig
 }
 
-func NewConfigService() *ConfigService {
+func NewConfigService(
+	userRepo repository.UserRepository,
+	serverRepo repository.ServerRepository,
+	keysRepo repository.WireGuardKeysRepository,
+	shareRepo repository.ConfigShareLinkRepository,
+	auditLog *audit.Logger,
+	network ClientNetworkConfig,
+) *ConfigService {
+	if !network.Network.IsValid() {
+		network.Network = netip.MustParsePrefix("10.0.0.0/24")
+	}
+
 	return &ConfigService{
-		userRepo:      repository.NewUserRepository(),
-		serverRepo:    repository.NewServerRepository(),
-		keysRepo:      repository.NewWireGuardKeysRepository(),
-		serverNetwork: "10.0.0.0/24",
+		userRepo:   userRepo,
+		serverRepo: serverRepo,
+		keysRepo:   keysRepo,
+		shareRepo:  shareRepo,
+		auditLog:   auditLog,
+		network:    network,
 	}
 }
 
 type ConfigResult struct {
-	ConfigContent string
-	QRCodeBase64  string
-	ConfigData    ConfigData
+	ConfigContent     string
+	QRCodeBase64      string
+	QRCodeContentType string
+	QRCodeError       string
+	RotationRequired  bool
+	// KeysVersion identifies the key material in ConfigData. It changes on
+	// every rotation.
+	KeysVersion int64
+	ConfigData  ConfigData
+	Export      *wireguard.ExportedConfig
 }
 
-type ConfigData struct {
-	PrivateKey      string
-	PublicKey       string
-	ServerPublicKey string
-	ServerEndpoint  string
-	ServerAddress   string
-	ServerPort      string
-	ClientIp        string
-	DNS             string
+type GenerateConfigOptions struct {
+	Format wireguard.ConfigFormat
+	QRCode wireguard.QRCodeOptions
+}
+
+type ConfigData = wireguard.ConfigData
+
+func (s *ConfigService) GenerateConfig(ctx context.Context, userId primitive.ObjectID, serverId string, opts GenerateConfigOptions) (_ *ConfigResult, err error) {
+	ctx, span := startSpan(ctx, "ConfigService.GenerateConfig", userId, serverId)
+	defer func() { tracing.End(span, err) }()
+
+	result, err := s.generateConfig(ctx, userId, serverId, opts)
+	metrics.ConfigGenerated(metricsResult(err))
+
+	s.auditLog.Record(ctx, audit.Entry{
+		Action:     model.AuditActionConfigGenerate,
+		TargetType: model.AuditTargetServer,
+		TargetId:   serverId,
+		ActorId:    userId,
+		Err:        err,
+	})
+
+	return result, err
 }
 
-func (s *ConfigService) GenerateConfig(ctx context.Context, userId primitive.ObjectID, serverId string) (*ConfigResult, error) {
+func (s *ConfigService) generateConfig(ctx context.Context, userId primitive.ObjectID, serverId string, opts GenerateConfigOptions) (*ConfigResult, error) {
 	serverObjId, err := primitive.ObjectIDFromHex(serverId)
 	if err != nil {
-		return nil, errors.New("invalid server ID")
+		return nil, ErrInvalidServerId
 	}
 
 	server, err := s.serverRepo.GetById(ctx, serverObjId)
 	if err != nil {
-		return nil, errors.New("server not found")
+		return nil, err
 	}
 	existingKeys, err := s.keysRepo.GetByUserAndServer(ctx, userId, serverObjId)
-	fmt.Print(err)
 	var keys *model.WireGuardKeys
 
-	if err != nil {
-		privateKey, publicKey, err := wireguard.GenerateKeyPair()
-		if err != nil {
-			return nil, fmt.Errorf("failed to generate keys: %v", err)
-		}
-
-		clientIp, err := s.assignClientIp(ctx, serverObjId)
-		if err != nil {
-			return nil, fmt.Errorf("failed to generate keys: %v", err)
-		}
-
-		keys = &model.WireGuardKeys{
-			UserId:              userId,
-			ServerId:            serverObjId,
-			PrivateKeyEncrypted: privateKey,
-			PublicKey:           publicKey,
-			IpAddress:           clientIp,
-		}
-
-		err = s.keysRepo.Create(ctx, keys)
+	if err != nil && !errors.Is(err, repository.ErrKeysNotFound) {
+		return nil, err
+	}
 
+	if err != nil {
+		keys, err = s.allocatePeer(ctx, userId, server)
 		if err != nil {
-			return nil, fmt.Errorf("failed to save keys: %v", err)
+			return nil, err
 		}
-
 	} else {
 		keys = existingKeys
 	}
 
-	configContent := wireguard.GenerateClientConfig(keys.PrivateKeyEncrypted, server.PublicKey, server.Endpoint, keys.IpAddress, "8.8.8.8")
+	return s.buildConfig(ctx, server, keys, opts)
+}
+
+func (s *ConfigService) buildConfig(ctx context.Context, server *model.Server, keys *model.WireGuardKeys, opts GenerateConfigOptions) (_ *ConfigResult, err error) {
+	_, span := tracer.Start(ctx, "ConfigService.buildConfig")
+	defer func() { tracing.End(span, err) }()
 
-	qrCode, err := wireguard.GeneateQRCode(configContent)
+	// Endpoints are validated when a server is saved, but servers created
+	// before that may still hold a bad one.
+	host, port, err := wireguard.SplitEndpoint(server.Endpoint)
 	if err != nil {
-		qrCode = ""
+		return nil, ErrServerMisconfigured
 	}
 
+	configContent := wireguard.GenerateClientConfig(keys.PrivateKeyEncrypted, server.PublicKey, server.Endpoint, keys.IpAddress, s.network.DNS)
+
 	result := &ConfigResult{
-		ConfigContent: configContent,
-		QRCodeBase64:  qrCode,
+		ConfigContent:    configContent,
+		RotationRequired: keys.RotationRequired,
+		KeysVersion:      keys.Version,
 		ConfigData: ConfigData{
 			PrivateKey:      keys.PrivateKeyEncrypted,
 			PublicKey:       keys.PublicKey,
 			ServerPublicKey: server.PublicKey,
 			ServerEndpoint:  server.Endpoint,
-			ServerAddress:   strings.Split(server.Endpoint, ":")[0],
-			ServerPort:      strings.Split(server.Endpoint, ":")[1],
+			ServerAddress:   host,
+			ServerPort:      port,
 			ClientIp:        keys.IpAddress,
-			DNS:             "8.8.8.8",
+			DNS:             s.network.DNS,
 		},
 	}
 
+	qrCode, err := wireguard.GenerateQRCode(configContent, opts.QRCode)
+	if err != nil {
+		result.QRCodeError = err.Error()
+	} else {
+		result.QRCodeBase64 = base64.StdEncoding.EncodeToString(qrCode.Data)
+		result.QRCodeContentType = qrCode.ContentType
+	}
+
+	result.Export, err = wireguard.RenderConfig(opts.Format, result.ConfigData)
+	if err != nil {
+		return nil, err
+	}
+
 	return result, nil
 }
 
-func (s *ConfigService) assignClientIp(ctx context.Context, serverId primitive.ObjectID) (string, error) {
+// maxAllocationAttempts bounds how often allocatePeer retries after losing a
+// race for an address to a concurrent request.
+const maxAllocationAttempts = 10
+
+var (
+	ErrServerFull        = &Error{Kind: KindResourceExhausted, Reason: "SERVER_FULL", Message: "server is at capacity"}
+	ErrServerUnavailable = &Error{Kind: KindFailedPrecondition, Reason: "SERVER_UNAVAILABLE", Message: "server is not accepting new clients"}
+	// ErrAddressPoolExhausted means every address in the server's network is
+	// taken, even though it may have client slots left.
+	ErrAddressPoolExhausted = &Error{Kind: KindResourceExhausted, Reason: "ADDRESS_POOL_EXHAUSTED", Message: "no available IP addresses"}
+	ErrAddressConflict      = &Error{Kind: KindAborted, Reason: "ADDRESS_CONFLICT", Message: "failed to allocate an IP address, please retry"}
+	// ErrServerMisconfigured means a stored server cannot be turned into a
+	// client config; an admin has to fix it.
+	ErrServerMisconfigured = &Error{Kind: KindFailedPrecondition, Reason: "SERVER_MISCONFIGURED", Message: "server endpoint is invalid"}
+)
+
+// allocatePeer creates keys for a user's first connection to server. The
+// address is picked from a snapshot of the peers, so a concurrent request can
+// take it first; the unique (server_id, ip_address) index rejects the loser,
+// which then retries with a fresh snapshot.
+func (s *ConfigService) allocatePeer(ctx context.Context, userId primitive.ObjectID, server *model.Server) (*model.WireGuardKeys, error) {
+	// Servers created before statuses existed have none and are active.
+	if server.Status != "" && server.Status != model.ServerStatusActive {
+		return nil, ErrServerUnavailable
+	}
+
+	privateKey, publicKey, err := generateKeyPair(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate keys: %v", err)
+	}
+
+	for attempt := 0; attempt < maxAllocationAttempts; attempt++ {
+		clientIp, err := s.assignClientIp(ctx, server.Id)
+		if err != nil {
+			return nil, err
+		}
+
+		keys := &model.WireGuardKeys{
+			Id:                  primitive.NewObjectID(),
+			UserId:              userId,
+			ServerId:            server.Id,
+			PrivateKeyEncrypted: privateKey,
+			PublicKey:           publicKey,
+			IpAddress:           clientIp,
+		}
+
+		err = s.keysRepo.Allocate(ctx, keys, events.NewPeerCreated(keys))
+		switch {
+		case err == nil:
+			return keys, nil
+		case errors.Is(err, repository.ErrServerFull):
+			return nil, ErrServerFull
+		case !errors.Is(err, repository.ErrDuplicateKey):
+			return nil, fmt.Errorf("failed to save keys: %v", err)
+		}
+
+		// Either the address was taken or a concurrent request for the same
+		// user won; in the latter case use its peer.
+		if existing, err := s.keysRepo.GetByUserAndServer(ctx, userId, server.Id); err == nil {
+			return existing, nil
+		}
+	}
+
+	return nil, ErrAddressConflict
+}
+
+func (s *ConfigService) assignClientIp(ctx context.Context, serverId primitive.ObjectID) (_ string, err error) {
+	ctx, span := tracer.Start(ctx, "ConfigService.assignClientIp", trace.WithAttributes(tracing.ServerIDKey.String(serverId.Hex())))
+	defer func() { tracing.End(span, err) }()
+
 	existingKeys, err := s.keysRepo.GetAllByServer(ctx, serverId)
 
 	if err != nil {
 		return "", fmt.Errorf("failed to get existing keys: %v", err)
 	}
-	usedIps := make(map[int]bool)
+	span.SetAttributes(attribute.Int("peers", len(existingKeys)))
+	usedIps := make(map[netip.Addr]bool)
 
 	for _, key := range existingKeys {
-		if key.IpAddress != "" {
-			var lastOctet int
-			_, err := fmt.Sscanf(key.IpAddress, "10.0.0.%d/32", &lastOctet)
-			if err == nil {
-				usedIps[lastOctet] = true
-			}
+		if prefix, err := netip.ParsePrefix(key.IpAddress); err == nil {
+			usedIps[prefix.Addr()] = true
 		}
 	}
 
-	for i := 2; i <= 254; i++ {
-		if !usedIps[i] {
-			return fmt.Sprintf("10.0.0.%d/32", i), nil
+	// Skip the network address and the server's; stop before the broadcast
+	// address.
+	network := s.network.Network.Masked()
+	for ip := network.Addr().Next().Next(); network.Contains(ip.Next()); ip = ip.Next() {
+		if !usedIps[ip] {
+			return netip.PrefixFrom(ip, 32).String(), nil
 		}
 	}
-	return "", errors.New("no available IP addressess")
+	return "", ErrAddressPoolExhausted
+}
+
+// RotateKeys replaces the user's key pair on a server. If expectedVersion is
+// set and the keys have changed since, it fails with ErrVersionMismatch
+// rather than rotating keys the caller has not seen.
+func (s *ConfigService) RotateKeys(ctx context.Context, userId primitive.ObjectID, serverId string, expectedVersion int64) (_ *ConfigResult, err error) {
+	ctx, span := startSpan(ctx, "ConfigService.RotateKeys", userId, serverId)
+	defer func() { tracing.End(span, err) }()
+
+	result, err := s.rotateKeys(ctx, userId, serverId, expectedVersion)
+
+	s.auditLog.Record(ctx, audit.Entry{
+		Action:     model.AuditActionKeysRotate,
+		TargetType: model.AuditTargetServer,
+		TargetId:   serverId,
+		ActorId:    userId,
+		Err:        err,
+	})
+
+	return result, err
+}
+
+func (s *ConfigService) rotateKeys(ctx context.Context, userId primitive.ObjectID, serverId string, expectedVersion int64) (*ConfigResult, error) {
+	serverObjId, err := primitive.ObjectIDFromHex(serverId)
+	if err != nil {
+		return nil, ErrInvalidServerId
+	}
+
+	server, err := s.serverRepo.GetById(ctx, serverObjId)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := s.keysRepo.GetByUserAndServer(ctx, userId, serverObjId)
+	if err != nil {
+		return nil, err
+	}
+
+	if expectedVersion != 0 && keys.Version != expectedVersion {
+		return nil, ErrVersionMismatch.withDetail("keys are at version %d", keys.Version)
+	}
+
+	rotated, err := s.rotatePeerKeys(ctx, keys, events.TriggerUser)
+	if err != nil {
+		return nil, err
+	}
+
+	if !rotated {
+		return nil, &repository.VersionConflictError{Collection: "wireguard_keys", Id: keys.Id, Version: keys.Version}
+	}
+
+	return s.buildConfig(ctx, server, keys, GenerateConfigOptions{})
+}
+
+func (s *ConfigService) rotatePeerKeys(ctx context.Context, keys *model.WireGuardKeys, trigger string) (bool, error) {
+	privateKey, publicKey, err := generateKeyPair(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to generate keys: %v", err)
+	}
+
+	previousRotatedAt := keys.LastRotatedAt
+	previousPublicKey := keys.PublicKey
+	keys.PrivateKeyEncrypted = privateKey
+	keys.PublicKey = publicKey
+
+	rotated, err := s.keysRepo.Rotate(ctx, keys, previousRotatedAt, events.NewKeysRotated(keys, previousPublicKey, trigger))
+	if rotated {
+		metrics.KeysRotated(trigger)
+	}
+	return rotated, err
+}
+
+// RevokePeer removes the user's peer on a server and frees its slot. The
+// next GenerateConfig allocates a new peer with new keys and address.
+func (s *ConfigService) RevokePeer(ctx context.Context, userId primitive.ObjectID, serverId string) (err error) {
+	ctx, span := startSpan(ctx, "ConfigService.RevokePeer", userId, serverId)
+	defer func() { tracing.End(span, err) }()
+
+	err = s.revokePeer(ctx, userId, serverId)
+
+	s.auditLog.Record(ctx, audit.Entry{
+		Action:     model.AuditActionKeysRevoke,
+		TargetType: model.AuditTargetServer,
+		TargetId:   serverId,
+		ActorId:    userId,
+		Err:        err,
+	})
+
+	return err
+}
+
+func (s *ConfigService) revokePeer(ctx context.Context, userId primitive.ObjectID, serverId string) error {
+	serverObjId, err := primitive.ObjectIDFromHex(serverId)
+	if err != nil {
+		return ErrInvalidServerId
+	}
+
+	keys, err := s.keysRepo.GetByUserAndServer(ctx, userId, serverObjId)
+	if err != nil {
+		return err
+	}
+
+	return s.keysRepo.Revoke(ctx, keys, events.NewPeerRevoked(keys))
 }
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"sync"
+	"testing"
+
+	"github.com/shivamp1998/vpn_backend/internal/model"
+	"github.com/shivamp1998/vpn_backend/internal/repository"
+	"github.com/shivamp1998/vpn_backend/internal/wireguard"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func newTestConfigService(t *testing.T, maxClients int32) (*ConfigService, *repository.Repositories, *model.Server) {
+	t.Helper()
+
+	repos := repository.NewMemoryRepositories()
+	_, publicKey, err := wireguard.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := &model.Server{
+		Name:       "test",
+		Endpoint:   "vpn.example.com:51820",
+		PublicKey:  publicKey,
+		Region:     "eu",
+		MaxClients: maxClients,
+		Status:     model.ServerStatusActive,
+	}
+	if err := repos.Servers.Create(context.Background(), server); err != nil {
+		t.Fatal(err)
+	}
+
+	network := ClientNetworkConfig{Network: netip.MustParsePrefix("10.8.0.0/24"), DNS: "1.1.1.1"}
+	service := NewConfigService(repos.Users, repos.Servers, repos.Keys, repos.ShareLinks, nil, network)
+	return service, repos, server
+}
+
+func TestGenerateConfigConcurrently(t *testing.T) {
+	const users = 40
+	const maxClients = 12
+
+	ctx := context.Background()
+	service, repos, server := newTestConfigService(t, maxClients)
+
+	// Every user asks twice at once, so the same user races itself as well as
+	// the others.
+	userIds := make([]primitive.ObjectID, users)
+	for i := range userIds {
+		userIds[i] = primitive.NewObjectID()
+	}
+
+	var mu sync.Mutex
+	clientIps := make(map[primitive.ObjectID]map[string]bool)
+	var wg sync.WaitGroup
+	for i := 0; i < 2*users; i++ {
+		wg.Add(1)
+		go func(userId primitive.ObjectID) {
+			defer wg.Done()
+
+			result, err := service.GenerateConfig(ctx, userId, server.Id.Hex(), GenerateConfigOptions{})
+			if errors.Is(err, ErrServerFull) {
+				return
+			}
+			if err != nil {
+				t.Errorf("generate config: %v", err)
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if clientIps[userId] == nil {
+				clientIps[userId] = make(map[string]bool)
+			}
+			clientIps[userId][result.ConfigData.ClientIp] = true
+		}(userIds[i%users])
+	}
+	wg.Wait()
+
+	peers, err := repos.Keys.GetAllByServer(ctx, server.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(peers) != maxClients {
+		t.Errorf("allocated %d peers, want max_clients %d", len(peers), maxClients)
+	}
+
+	byIp := make(map[string]primitive.ObjectID)
+	for _, peer := range peers {
+		if other, ok := byIp[peer.IpAddress]; ok {
+			t.Errorf("address %s allocated to both %s and %s", peer.IpAddress, other.Hex(), peer.UserId.Hex())
+		}
+		byIp[peer.IpAddress] = peer.UserId
+	}
+
+	for userId, ips := range clientIps {
+		if len(ips) != 1 {
+			t.Errorf("user %s was given %d addresses", userId.Hex(), len(ips))
+		}
+		for ip := range ips {
+			if byIp[ip] != userId {
+				t.Errorf("user %s was given %s, which is stored for %s", userId.Hex(), ip, byIp[ip].Hex())
+			}
+		}
+	}
+
+	stored, err := repos.Servers.GetById(ctx, server.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if int(stored.CurrentClients) != len(peers) {
+		t.Errorf("current_clients is %d, want %d", stored.CurrentClients, len(peers))
+	}
+}
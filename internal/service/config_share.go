@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/shivamp1998/vpn_backend/internal/audit"
+	"github.com/shivamp1998/vpn_backend/internal/model"
+	"github.com/shivamp1998/vpn_backend/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	DefaultShareLinkTTL = 24 * time.Hour
+	MaxShareLinkTTL     = 7 * 24 * time.Hour
+	minShareLinkTTL     = time.Minute
+)
+
+var (
+	ErrShareLinkNotFound = &Error{Kind: KindNotFound, Reason: "SHARE_LINK_NOT_FOUND", Message: "share link not found"}
+	ErrShareLinkExpired  = &Error{Kind: KindFailedPrecondition, Reason: "SHARE_LINK_EXPIRED", Message: "share link has expired"}
+	ErrShareLinkUsed     = &Error{Kind: KindFailedPrecondition, Reason: "SHARE_LINK_USED", Message: "share link has already been used"}
+	ErrShareLinkQRCode   = &Error{Kind: KindFailedPrecondition, Reason: "SHARE_LINK_QR_CODE_FAILED", Message: "unable to render the QR code"}
+)
+
+type ShareLink struct {
+	Token     string
+	Kind      string
+	ExpiresAt time.Time
+}
+
+type ShareRequestInfo struct {
+	SourceIp  string
+	UserAgent string
+}
+
+// CreateShareLink mints a single-use token that lets the holder download the
+// user's config for a server once without authenticating. The user must
+// already have a peer on the server; a link never allocates one. Only a hash
+// of the token is stored.
+func (s *ConfigService) CreateShareLink(ctx context.Context, userId primitive.ObjectID, serverId, kind string, ttl time.Duration) (*ShareLink, error) {
+	link, err := s.createShareLink(ctx, userId, serverId, kind, ttl)
+
+	s.auditLog.Record(ctx, audit.Entry{
+		Action:     model.AuditActionShareLinkCreate,
+		TargetType: model.AuditTargetServer,
+		TargetId:   serverId,
+		ActorId:    userId,
+		Err:        err,
+	})
+
+	return link, err
+}
+
+func (s *ConfigService) createShareLink(ctx context.Context, userId primitive.ObjectID, serverId, kind string, ttl time.Duration) (*ShareLink, error) {
+	if kind != model.ShareLinkKindConfig && kind != model.ShareLinkKindQRCode {
+		return nil, InvalidArgument("kind", "invalid share link kind %q", kind)
+	}
+
+	if ttl == 0 {
+		ttl = DefaultShareLinkTTL
+	}
+
+	if ttl < minShareLinkTTL || ttl > MaxShareLinkTTL {
+		return nil, InvalidArgument("ttl_seconds", "share link ttl must be between %v and %v", minShareLinkTTL, MaxShareLinkTTL)
+	}
+
+	serverObjId, err := primitive.ObjectIDFromHex(serverId)
+	if err != nil {
+		return nil, ErrInvalidServerId
+	}
+
+	// Make sure the peer exists so the link always has something to serve.
+	_, err = s.keysRepo.GetByUserAndServer(ctx, userId, serverObjId)
+	if errors.Is(err, repository.ErrKeysNotFound) {
+		return nil, ErrPeerNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := newShareToken()
+	if err != nil {
+		return nil, err
+	}
+
+	link := &model.ConfigShareLink{
+		TokenHash: hashShareToken(token),
+		UserId:    userId,
+		ServerId:  serverObjId,
+		Kind:      kind,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+
+	if err := s.shareRepo.Create(ctx, link); err != nil {
+		return nil, fmt.Errorf("failed to save share link: %v", err)
+	}
+
+	return &ShareLink{
+		Token:     token,
+		Kind:      kind,
+		ExpiresAt: link.ExpiresAt,
+	}, nil
+}
+
+// RedeemShareLink consumes token and returns the config it points to. Every
+// attempt, successful or not, is recorded as a retrieval.
+func (s *ConfigService) RedeemShareLink(ctx context.Context, token string, info ShareRequestInfo) (*model.ConfigShareLink, *ConfigResult, error) {
+	tokenHash := hashShareToken(token)
+	retrieval := &model.ConfigShareRetrieval{
+		SourceIp:  info.SourceIp,
+		UserAgent: info.UserAgent,
+	}
+
+	link, result, err := s.redeemShareLink(ctx, tokenHash, retrieval)
+
+	switch {
+	case err == nil:
+		retrieval.Outcome = model.ShareRetrievalSuccess
+	case errors.Is(err, ErrShareLinkNotFound):
+		retrieval.Outcome = model.ShareRetrievalNotFound
+	case errors.Is(err, ErrShareLinkExpired):
+		retrieval.Outcome = model.ShareRetrievalExpired
+	case errors.Is(err, ErrShareLinkUsed):
+		retrieval.Outcome = model.ShareRetrievalAlreadyUsed
+	default:
+		retrieval.Outcome = model.ShareRetrievalFailed
+	}
+
+	// The link holder is anonymous, so the entry is attributed to the owner
+	// of the link.
+	entry := audit.Entry{
+		Action:     model.AuditActionShareLinkRedeem,
+		TargetType: model.AuditTargetShareLink,
+		ActorId:    retrieval.UserId,
+		Err:        err,
+	}
+	if !retrieval.LinkId.IsZero() {
+		entry.TargetId = retrieval.LinkId.Hex()
+	}
+	s.auditLog.Record(ctx, entry)
+
+	if recordErr := s.shareRepo.RecordRetrieval(ctx, retrieval); recordErr != nil && err == nil {
+		return nil, nil, fmt.Errorf("failed to record share link retrieval: %v", recordErr)
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+	return link, result, nil
+}
+
+// redeemShareLink builds the config, and the QR code for a QR link, before
+// consuming the token, so a link whose content cannot be built stays usable.
+// Consume still decides which of two concurrent redeems gets the config.
+func (s *ConfigService) redeemShareLink(ctx context.Context, tokenHash string, retrieval *model.ConfigShareRetrieval) (*model.ConfigShareLink, *ConfigResult, error) {
+	now := time.Now()
+
+	link, err := s.shareRepo.GetByTokenHash(ctx, tokenHash)
+	if err != nil {
+		return nil, nil, ErrShareLinkNotFound
+	}
+
+	retrieval.LinkId = link.Id
+	retrieval.UserId = link.UserId
+
+	if err := shareLinkUsable(link, now); err != nil {
+		return nil, nil, err
+	}
+
+	server, err := s.serverRepo.GetById(ctx, link.ServerId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keys, err := s.keysRepo.GetByUserAndServer(ctx, link.UserId, link.ServerId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result, err := s.buildConfig(ctx, server, keys, GenerateConfigOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+	if link.Kind == model.ShareLinkKindQRCode && result.QRCodeBase64 == "" {
+		return nil, nil, ErrShareLinkQRCode.withDetail("%s", result.QRCodeError)
+	}
+
+	consumed, err := s.shareRepo.Consume(ctx, tokenHash, now)
+	if err != nil {
+		// Someone else redeemed the link since it was looked up.
+		if existing, lookupErr := s.shareRepo.GetByTokenHash(ctx, tokenHash); lookupErr == nil {
+			if usableErr := shareLinkUsable(existing, now); usableErr != nil {
+				return nil, nil, usableErr
+			}
+		}
+		return nil, nil, err
+	}
+
+	return consumed, result, nil
+}
+
+func shareLinkUsable(link *model.ConfigShareLink, now time.Time) error {
+	if link.UsedAt != nil {
+		return ErrShareLinkUsed
+	}
+	if !link.ExpiresAt.After(now) {
+		return ErrShareLinkExpired
+	}
+	return nil
+}
+
+func newShareToken() (string, error) {
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(token), nil
+}
+
+func hashShareToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/shivamp1998/vpn_backend/internal/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCreateShareLinkNeedsPeer(t *testing.T) {
+	ctx := context.Background()
+	service, repos, server := newTestConfigService(t, 10)
+	userId := primitive.NewObjectID()
+
+	_, err := service.CreateShareLink(ctx, userId, server.Id.Hex(), model.ShareLinkKindConfig, 0)
+	if !errors.Is(err, ErrPeerNotFound) {
+		t.Fatalf("got error %v, want %v", err, ErrPeerNotFound)
+	}
+
+	peers, err := repos.Keys.GetAllByServer(ctx, server.Id)
+	if err != nil || len(peers) != 0 {
+		t.Fatalf("creating a link allocated %d peers, %v", len(peers), err)
+	}
+}
+
+func TestRedeemShareLinkKeepsTokenWhenBuildFails(t *testing.T) {
+	ctx := context.Background()
+	service, repos, server := newTestConfigService(t, 10)
+	userId := primitive.NewObjectID()
+
+	if _, err := service.GenerateConfig(ctx, userId, server.Id.Hex(), GenerateConfigOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	link, err := service.CreateShareLink(ctx, userId, server.Id.Hex(), model.ShareLinkKindConfig, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	setEndpoint := func(endpoint string) {
+		t.Helper()
+		stored, err := repos.Servers.GetById(ctx, server.Id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stored.Endpoint = endpoint
+		if err := repos.Servers.Update(ctx, stored); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	setEndpoint("no port")
+	_, _, err = service.RedeemShareLink(ctx, link.Token, ShareRequestInfo{})
+	if !errors.Is(err, ErrServerMisconfigured) {
+		t.Fatalf("redeem with a broken server: got error %v, want %v", err, ErrServerMisconfigured)
+	}
+
+	setEndpoint(server.Endpoint)
+	_, result, err := service.RedeemShareLink(ctx, link.Token, ShareRequestInfo{})
+	if err != nil {
+		t.Fatalf("redeem after the server was fixed: %v", err)
+	}
+	if result.ConfigContent == "" {
+		t.Error("redeem returned an empty config")
+	}
+
+	_, _, err = service.RedeemShareLink(ctx, link.Token, ShareRequestInfo{})
+	if !errors.Is(err, ErrShareLinkUsed) {
+		t.Errorf("second redeem: got error %v, want %v", err, ErrShareLinkUsed)
+	}
+}
+
+func TestRedeemShareLinkKeepsTokenWhenQRCodeFails(t *testing.T) {
+	ctx := context.Background()
+	service, _, server := newTestConfigService(t, 10)
+	userId := primitive.NewObjectID()
+
+	if _, err := service.GenerateConfig(ctx, userId, server.Id.Hex(), GenerateConfigOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	link, err := service.CreateShareLink(ctx, userId, server.Id.Hex(), model.ShareLinkKindQRCode, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A config too large for a QR code still builds as a file.
+	dns := service.network.DNS
+	service.network.DNS = strings.Repeat("1.1.1.1, ", 300)
+	_, _, err = service.RedeemShareLink(ctx, link.Token, ShareRequestInfo{})
+	if !errors.Is(err, ErrShareLinkQRCode) {
+		t.Fatalf("redeem with an oversized config: got error %v, want %v", err, ErrShareLinkQRCode)
+	}
+
+	service.network.DNS = dns
+	_, result, err := service.RedeemShareLink(ctx, link.Token, ShareRequestInfo{})
+	if err != nil {
+		t.Fatalf("redeem after the config shrank: %v", err)
+	}
+	if result.QRCodeBase64 == "" {
+		t.Error("redeem returned no QR code")
+	}
+
+	_, _, err = service.RedeemShareLink(ctx, link.Token, ShareRequestInfo{})
+	if !errors.Is(err, ErrShareLinkUsed) {
+		t.Errorf("second redeem: got error %v, want %v", err, ErrShareLinkUsed)
+	}
+}
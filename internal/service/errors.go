@@ -0,0 +1,122 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/shivamp1998/vpn_backend/internal/metrics"
+	"github.com/shivamp1998/vpn_backend/internal/repository"
+)
+
+// Kind says what went wrong in terms the caller can act on. The transports
+// map each kind to a status code.
+type Kind int
+
+const (
+	KindInternal Kind = iota
+	KindInvalidArgument
+	KindNotFound
+	KindAlreadyExists
+	KindUnauthenticated
+	KindPermissionDenied
+	KindResourceExhausted
+	KindFailedPrecondition
+	KindAborted
+	KindUnavailable
+)
+
+// Error is a domain error. Message is written for the caller and is sent to
+// them as is; Err is only for logs and errors.Is.
+type Error struct {
+	Kind Kind
+	// Reason is a stable identifier such as "SERVER_NOT_FOUND" that clients
+	// can switch on instead of parsing the message.
+	Reason  string
+	Message string
+	// Field names the request field at fault, if there is one.
+	Field string
+	Err   error
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// withDetail returns a copy of e with detail appended to the message. The
+// copy still matches e with errors.Is.
+func (e *Error) withDetail(format string, args ...any) *Error {
+	detailed := *e
+	detailed.Message = e.Message + ": " + fmt.Sprintf(format, args...)
+	detailed.Err = e
+	return &detailed
+}
+
+// InvalidArgument reports a bad value in a request field.
+func InvalidArgument(field, format string, args ...any) *Error {
+	return &Error{
+		Kind:    KindInvalidArgument,
+		Reason:  "INVALID_ARGUMENT",
+		Message: fmt.Sprintf(format, args...),
+		Field:   field,
+	}
+}
+
+var (
+	ErrInvalidCredentials = &Error{Kind: KindUnauthenticated, Reason: "INVALID_CREDENTIALS", Message: "invalid email or password"}
+	ErrEmailTaken         = &Error{Kind: KindAlreadyExists, Reason: "EMAIL_TAKEN", Message: "email already registered"}
+	ErrAlreadyExists      = &Error{Kind: KindAlreadyExists, Reason: "ALREADY_EXISTS", Message: "resource already exists"}
+	ErrUserNotFound       = &Error{Kind: KindNotFound, Reason: "USER_NOT_FOUND", Message: "user not found"}
+	ErrServerNotFound     = &Error{Kind: KindNotFound, Reason: "SERVER_NOT_FOUND", Message: "server not found"}
+	ErrPeerNotFound       = &Error{Kind: KindNotFound, Reason: "PEER_NOT_FOUND", Message: "no peer on this server"}
+	ErrWebhookNotFound    = &Error{Kind: KindNotFound, Reason: "WEBHOOK_NOT_FOUND", Message: "webhook not found"}
+	// ErrConcurrentUpdate means a write lost a race with another one; the
+	// request can be retried as is.
+	ErrConcurrentUpdate = &Error{Kind: KindAborted, Reason: "CONCURRENT_UPDATE", Message: "the resource was modified concurrently, please retry"}
+)
+
+// repositoryErrors gives the domain error for each repository error that
+// can reach a caller.
+var repositoryErrors = []struct {
+	err    error
+	domain *Error
+}{
+	{repository.ErrUserNotFound, ErrUserNotFound},
+	{repository.ErrServerNotFound, ErrServerNotFound},
+	{repository.ErrKeysNotFound, ErrPeerNotFound},
+	{repository.ErrWebhookNotFound, ErrWebhookNotFound},
+	{repository.ErrServerFull, ErrServerFull},
+	{repository.ErrVersionConflict, ErrConcurrentUpdate},
+	{repository.ErrDuplicateKey, ErrAlreadyExists},
+}
+
+// AsError returns the domain error in err's chain, translating repository
+// errors on the way. It returns nil for anything else, which is an internal
+// error the caller must not see.
+func AsError(err error) *Error {
+	var domainErr *Error
+	if errors.As(err, &domainErr) {
+		return domainErr
+	}
+	for _, e := range repositoryErrors {
+		if errors.Is(err, e.err) {
+			return e.domain
+		}
+	}
+	return nil
+}
+
+// metricsResult classifies the outcome of a call for the result label of
+// the domain metrics.
+func metricsResult(err error) string {
+	if err == nil {
+		return metrics.ResultSuccess
+	}
+	if domainErr := AsError(err); domainErr != nil && domainErr.Kind != KindInternal && domainErr.Kind != KindUnavailable {
+		return metrics.ResultFailure
+	}
+	return metrics.ResultError
+}
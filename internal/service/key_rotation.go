@@ -0,0 +1,236 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/shivamp1998/vpn_backend/internal/audit"
+	"github.com/shivamp1998/vpn_backend/internal/events"
+	"github.com/shivamp1998/vpn_backend/internal/model"
+	"github.com/shivamp1998/vpn_backend/internal/repository"
+	"github.com/shivamp1998/vpn_backend/internal/tracing"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const keyRotationLockName = "key_rotation"
+
+// keyRotationActor identifies the scheduler in the audit log.
+const keyRotationActor = "system:key_rotation"
+
+// KeyRotationPolicy decides when a peer's keys are too old. A zero MaxAge
+// disables rotation. Mode is model.KeyRotationModeServer to rotate the keys
+// in the backend, or model.KeyRotationModeClient to flag the peer so the
+// client rotates on its next sync.
+type KeyRotationPolicy struct {
+	MaxAge time.Duration
+	Mode   string
+}
+
+type KeyRotationConfig struct {
+	Interval time.Duration
+	Default  KeyRotationPolicy
+	Plans    map[string]KeyRotationPolicy
+}
+
+type KeyRotationScheduler struct {
+	config        KeyRotationConfig
+	configService *ConfigService
+	serverRepo    repository.ServerRepository
+	userRepo      repository.UserRepository
+	keysRepo      repository.WireGuardKeysRepository
+	notifyRepo    repository.NotificationRepository
+	lockRepo      repository.LockRepository
+	owner         string
+	now           func() time.Time
+}
+
+func NewKeyRotationScheduler(config KeyRotationConfig, configService *ConfigService, repos *repository.Repositories) *KeyRotationScheduler {
+	if config.Interval <= 0 {
+		config.Interval = time.Hour
+	}
+
+	return &KeyRotationScheduler{
+		config:        config,
+		configService: configService,
+		serverRepo:    repos.Servers,
+		userRepo:      repos.Users,
+		keysRepo:      repos.Keys,
+		notifyRepo:    repos.Notifications,
+		lockRepo:      repos.Locks,
+		owner:         repository.NewLockOwner(),
+		now:           time.Now,
+	}
+}
+
+// Run checks for stale keys every Interval until ctx is cancelled.
+func (s *KeyRotationScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.RunOnce(ctx); err != nil {
+			slog.ErrorContext(ctx, "Key rotation run failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce performs a single pass over all peers. Only the replica holding the
+// rotation lease does any work; the others return immediately.
+func (s *KeyRotationScheduler) RunOnce(ctx context.Context) (err error) {
+	ctx, span := tracer.Start(ctx, "KeyRotationScheduler.RunOnce")
+	defer func() { tracing.End(span, err) }()
+
+	acquired, err := s.lockRepo.Acquire(ctx, keyRotationLockName, s.owner, 2*s.config.Interval)
+	if err != nil {
+		return fmt.Errorf("failed to acquire rotation lock: %v", err)
+	}
+
+	if !acquired {
+		return nil
+	}
+	defer s.lockRepo.Release(context.WithoutCancel(ctx), keyRotationLockName, s.owner)
+
+	servers, err := s.serverRepo.ListAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	plans := make(map[primitive.ObjectID]string)
+
+	for _, server := range servers {
+		if err := s.rotateServer(ctx, server, plans); err != nil {
+			slog.ErrorContext(ctx, "Key rotation failed for server", "server_id", server.Id.Hex(), "error", err)
+		}
+	}
+
+	return nil
+}
+
+func (s *KeyRotationScheduler) rotateServer(ctx context.Context, server *model.Server, plans map[primitive.ObjectID]string) (err error) {
+	ctx, span := tracer.Start(ctx, "KeyRotationScheduler.rotateServer", trace.WithAttributes(tracing.ServerIDKey.String(server.Id.Hex())))
+	defer func() { tracing.End(span, err) }()
+
+	now := s.now()
+	minAge := s.minMaxAge(server)
+	if minAge == 0 {
+		return nil
+	}
+
+	peers, err := s.keysRepo.GetAllByServerRotatedBefore(ctx, server.Id, now.Add(-minAge))
+	if err != nil {
+		return err
+	}
+
+	for _, keys := range peers {
+		plan, ok := plans[keys.UserId]
+		if !ok {
+			if user, err := s.userRepo.GetById(ctx, keys.UserId); err == nil {
+				plan = user.Plan
+			}
+			plans[keys.UserId] = plan
+		}
+
+		policy := s.policyFor(server, plan)
+		if policy.MaxAge == 0 || now.Sub(keys.LastRotatedAt) < policy.MaxAge {
+			continue
+		}
+
+		if err := s.applyPolicy(ctx, server, keys, policy); err != nil {
+			slog.ErrorContext(ctx, "Key rotation failed for peer", "peer_id", keys.Id.Hex(), "error", err)
+		}
+	}
+
+	return nil
+}
+
+func (s *KeyRotationScheduler) applyPolicy(ctx context.Context, server *model.Server, keys *model.WireGuardKeys, policy KeyRotationPolicy) error {
+	entry := audit.Entry{
+		TargetType: model.AuditTargetKeys,
+		TargetId:   keys.Id.Hex(),
+		ActorEmail: keyRotationActor,
+	}
+
+	notification := &model.Notification{
+		UserId:   keys.UserId,
+		ServerId: server.Id,
+	}
+
+	switch policy.Mode {
+	case model.KeyRotationModeServer:
+		rotated, err := s.configService.rotatePeerKeys(ctx, keys, events.TriggerScheduler)
+		if err != nil || !rotated {
+			return err
+		}
+		entry.Action = model.AuditActionKeysRotate
+
+		notification.Type = model.NotificationKeysRotated
+		notification.Message = fmt.Sprintf("Your keys for %s were rotated. Download the new configuration to stay connected.", server.Name)
+	case model.KeyRotationModeClient:
+		flagged, err := s.keysRepo.MarkRotationRequired(ctx, keys.Id)
+		if err != nil || !flagged {
+			return err
+		}
+		entry.Action = model.AuditActionKeysFlagRotation
+
+		notification.Type = model.NotificationKeyRotationRequired
+		notification.Message = fmt.Sprintf("Your keys for %s are due for rotation. Your app will rotate them on its next sync.", server.Name)
+	default:
+		return fmt.Errorf("unknown key rotation mode %q", policy.Mode)
+	}
+
+	s.configService.auditLog.Record(ctx, entry)
+
+	return s.notifyRepo.Create(ctx, notification)
+}
+
+// policyFor resolves the policy for a peer. Server settings take precedence
+// over the user's plan, which takes precedence over the default.
+func (s *KeyRotationScheduler) policyFor(server *model.Server, plan string) KeyRotationPolicy {
+	policy := s.config.Default
+
+	if planPolicy, ok := s.config.Plans[plan]; ok && plan != "" {
+		policy.MaxAge = planPolicy.MaxAge
+		if planPolicy.Mode != "" {
+			policy.Mode = planPolicy.Mode
+		}
+	}
+
+	if server.KeyRotationMaxAge > 0 {
+		policy.MaxAge = server.KeyRotationMaxAge
+	}
+
+	if server.KeyRotationMode != "" {
+		policy.Mode = server.KeyRotationMode
+	}
+
+	if policy.Mode == "" {
+		policy.Mode = model.KeyRotationModeClient
+	}
+
+	return policy
+}
+
+// minMaxAge is the shortest max age any peer on server can have, used to
+// narrow the query before per-peer policies are applied.
+func (s *KeyRotationScheduler) minMaxAge(server *model.Server) time.Duration {
+	if server.KeyRotationMaxAge > 0 {
+		return server.KeyRotationMaxAge
+	}
+
+	minAge := s.config.Default.MaxAge
+	for _, policy := range s.config.Plans {
+		if policy.MaxAge > 0 && (minAge == 0 || policy.MaxAge < minAge) {
+			minAge = policy.MaxAge
+		}
+	}
+	return minAge
+}
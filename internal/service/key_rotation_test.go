@@ -0,0 +1,331 @@
+package service
+
+import (
+	"context"
+	"net/netip"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shivamp1998/vpn_backend/internal/model"
+	"github.com/shivamp1998/vpn_backend/internal/repository"
+	"github.com/shivamp1998/vpn_backend/internal/wireguard"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const day = 24 * time.Hour
+
+type rotationFixture struct {
+	store     *repository.MemoryStore
+	repos     *repository.Repositories
+	service   *ConfigService
+	scheduler *KeyRotationScheduler
+	server    *model.Server
+}
+
+func newRotationFixture(t *testing.T, config KeyRotationConfig, server model.Server) *rotationFixture {
+	t.Helper()
+
+	store := repository.NewMemoryStore()
+	repos := store.Repositories()
+
+	_, publicKey, err := wireguard.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.Name = "test"
+	server.Endpoint = "vpn.example.com:51820"
+	server.PublicKey = publicKey
+	server.MaxClients = 10
+	server.Status = model.ServerStatusActive
+	if err := repos.Servers.Create(context.Background(), &server); err != nil {
+		t.Fatal(err)
+	}
+
+	network := ClientNetworkConfig{Network: netip.MustParsePrefix("10.8.0.0/24"), DNS: "1.1.1.1"}
+	service := NewConfigService(repos.Users, repos.Servers, repos.Keys, repos.ShareLinks, nil, network)
+
+	return &rotationFixture{
+		store:     store,
+		repos:     repos,
+		service:   service,
+		scheduler: NewKeyRotationScheduler(config, service, repos),
+		server:    &server,
+	}
+}
+
+// addPeer creates a user on plan with a peer on the fixture's server.
+func (f *rotationFixture) addPeer(t *testing.T, plan string) *model.WireGuardKeys {
+	t.Helper()
+	ctx := context.Background()
+
+	user := &model.User{Email: plan + "@example.com", Plan: plan}
+	if err := f.repos.Users.Create(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.service.GenerateConfig(ctx, user.Id, f.server.Id.Hex(), GenerateConfigOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	return f.peer(t, user.Id)
+}
+
+func (f *rotationFixture) peer(t *testing.T, userId primitive.ObjectID) *model.WireGuardKeys {
+	t.Helper()
+
+	peers, err := f.repos.Keys.GetAllByServer(context.Background(), f.server.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, peer := range peers {
+		if peer.UserId == userId {
+			return peer
+		}
+	}
+	t.Fatalf("no peer for user %s", userId.Hex())
+	return nil
+}
+
+// setClock fixes the scheduler's clock at age past the rotation of keys.
+func (f *rotationFixture) setClock(keys *model.WireGuardKeys, age time.Duration) {
+	now := keys.LastRotatedAt.Add(age)
+	f.scheduler.now = func() time.Time { return now }
+}
+
+// outcome reports what the scheduler did to the peer of keys.
+func (f *rotationFixture) outcome(t *testing.T, keys *model.WireGuardKeys) string {
+	t.Helper()
+
+	peer := f.peer(t, keys.UserId)
+	switch {
+	case peer.PublicKey != keys.PublicKey:
+		return model.KeyRotationModeServer
+	case peer.RotationRequired:
+		return model.KeyRotationModeClient
+	}
+	return ""
+}
+
+func TestKeyRotationRunOnce(t *testing.T) {
+	plans := map[string]KeyRotationPolicy{
+		"pro":   {MaxAge: 7 * day, Mode: model.KeyRotationModeServer},
+		"trial": {MaxAge: 2 * day},
+	}
+
+	tests := []struct {
+		name    string
+		policy  KeyRotationPolicy
+		server  model.Server
+		plan    string
+		age     time.Duration
+		outcome string
+	}{
+		{"default flags", KeyRotationPolicy{MaxAge: 30 * day, Mode: model.KeyRotationModeClient}, model.Server{}, "", 31 * day, model.KeyRotationModeClient},
+		{"default rotates", KeyRotationPolicy{MaxAge: 30 * day, Mode: model.KeyRotationModeServer}, model.Server{}, "", 31 * day, model.KeyRotationModeServer},
+		{"default mode is client", KeyRotationPolicy{MaxAge: 30 * day}, model.Server{}, "", 31 * day, model.KeyRotationModeClient},
+		{"too young", KeyRotationPolicy{MaxAge: 30 * day, Mode: model.KeyRotationModeServer}, model.Server{}, "", 29 * day, ""},
+		{"disabled", KeyRotationPolicy{}, model.Server{}, "", 365 * day, ""},
+		{"plan max age and mode", KeyRotationPolicy{MaxAge: 30 * day, Mode: model.KeyRotationModeClient}, model.Server{}, "pro", 8 * day, model.KeyRotationModeServer},
+		{"plan keeps default mode", KeyRotationPolicy{MaxAge: 30 * day, Mode: model.KeyRotationModeServer}, model.Server{}, "trial", 3 * day, model.KeyRotationModeServer},
+		{"plan too young", KeyRotationPolicy{MaxAge: 30 * day}, model.Server{}, "pro", 6 * day, ""},
+		{"unknown plan uses default", KeyRotationPolicy{MaxAge: 30 * day}, model.Server{}, "gold", 8 * day, ""},
+		{"server max age beats plan", KeyRotationPolicy{}, model.Server{KeyRotationMaxAge: day}, "pro", 2 * day, model.KeyRotationModeServer},
+		{"server max age beats older plan", KeyRotationPolicy{}, model.Server{KeyRotationMaxAge: 10 * day}, "pro", 8 * day, ""},
+		{"server mode beats plan", KeyRotationPolicy{}, model.Server{KeyRotationMode: model.KeyRotationModeClient}, "pro", 8 * day, model.KeyRotationModeClient},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newRotationFixture(t, KeyRotationConfig{Default: tt.policy, Plans: plans}, tt.server)
+			keys := f.addPeer(t, tt.plan)
+			f.setClock(keys, tt.age)
+
+			if err := f.scheduler.RunOnce(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			if got := f.outcome(t, keys); got != tt.outcome {
+				t.Errorf("outcome is %q, want %q", got, tt.outcome)
+			}
+
+			notifications := f.store.Notifications()
+			if tt.outcome == "" {
+				if len(notifications) != 0 {
+					t.Errorf("got notifications %v, want none", notifications)
+				}
+				return
+			}
+
+			want := model.NotificationKeysRotated
+			if tt.outcome == model.KeyRotationModeClient {
+				want = model.NotificationKeyRotationRequired
+			}
+			if len(notifications) != 1 || notifications[0].Type != want || notifications[0].UserId != keys.UserId || notifications[0].ServerId != f.server.Id {
+				t.Errorf("got notifications %v, want one %s for the peer", notifications, want)
+			}
+		})
+	}
+}
+
+func TestKeyRotationRunOnceIsIdempotent(t *testing.T) {
+	for _, mode := range []string{model.KeyRotationModeServer, model.KeyRotationModeClient} {
+		t.Run(mode, func(t *testing.T) {
+			f := newRotationFixture(t, KeyRotationConfig{Default: KeyRotationPolicy{MaxAge: 30 * day, Mode: mode}}, model.Server{})
+			keys := f.addPeer(t, "")
+			f.setClock(keys, 31*day)
+
+			ctx := context.Background()
+			if err := f.scheduler.RunOnce(ctx); err != nil {
+				t.Fatal(err)
+			}
+
+			// The memory repositories stamp rotations with the real time, so
+			// the clock follows a rotated peer, which is a day old on the
+			// second run. A flagged peer is still too old but is not flagged
+			// twice.
+			if mode == model.KeyRotationModeServer {
+				f.setClock(f.peer(t, keys.UserId), day)
+			}
+			if err := f.scheduler.RunOnce(ctx); err != nil {
+				t.Fatal(err)
+			}
+
+			if n := len(f.store.Notifications()); n != 1 {
+				t.Errorf("got %d notifications, want 1", n)
+			}
+		})
+	}
+}
+
+func TestKeyRotationRunOnceWithoutLease(t *testing.T) {
+	f := newRotationFixture(t, KeyRotationConfig{Default: KeyRotationPolicy{MaxAge: day}}, model.Server{})
+	keys := f.addPeer(t, "")
+	f.setClock(keys, 2*day)
+
+	ctx := context.Background()
+	if _, err := f.repos.Locks.Acquire(ctx, keyRotationLockName, "other replica", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := f.scheduler.RunOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := f.outcome(t, keys); got != "" {
+		t.Errorf("outcome is %q without the lease, want none", got)
+	}
+}
+
+func TestKeyRotationLosesRace(t *testing.T) {
+	const rotators = 8
+
+	for _, mode := range []string{model.KeyRotationModeServer, model.KeyRotationModeClient} {
+		t.Run(mode, func(t *testing.T) {
+			f := newRotationFixture(t, KeyRotationConfig{}, model.Server{})
+			keys := f.addPeer(t, "")
+			policy := KeyRotationPolicy{MaxAge: day, Mode: mode}
+
+			// Every rotator read the same peer, as replicas racing after a
+			// lease change would, so only one may win the compare-and-swap.
+			var wg sync.WaitGroup
+			for i := 0; i < rotators; i++ {
+				stale := *keys
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if err := f.scheduler.applyPolicy(context.Background(), f.server, &stale, policy); err != nil {
+						t.Error(err)
+					}
+				}()
+			}
+			wg.Wait()
+
+			if got := f.outcome(t, keys); got != mode {
+				t.Errorf("outcome is %q, want %q", got, mode)
+			}
+			if n := len(f.store.Notifications()); n != 1 {
+				t.Errorf("got %d notifications, want 1 from the winner", n)
+			}
+		})
+	}
+}
+
+func TestKeyRotationApplyPolicyUnknownMode(t *testing.T) {
+	f := newRotationFixture(t, KeyRotationConfig{}, model.Server{})
+	keys := f.addPeer(t, "")
+
+	if err := f.scheduler.applyPolicy(context.Background(), f.server, keys, KeyRotationPolicy{MaxAge: day, Mode: "later"}); err == nil {
+		t.Error("applied unknown mode")
+	}
+	if got := f.outcome(t, keys); got != "" {
+		t.Errorf("outcome is %q, want none", got)
+	}
+}
+
+func TestKeyRotationPolicyFor(t *testing.T) {
+	scheduler := NewKeyRotationScheduler(KeyRotationConfig{
+		Default: KeyRotationPolicy{MaxAge: 30 * day, Mode: model.KeyRotationModeServer},
+		Plans: map[string]KeyRotationPolicy{
+			"pro":      {MaxAge: 7 * day, Mode: model.KeyRotationModeClient},
+			"trial":    {MaxAge: 2 * day},
+			"archived": {},
+		},
+	}, nil, repository.NewMemoryRepositories())
+
+	tests := []struct {
+		name   string
+		server model.Server
+		plan   string
+		want   KeyRotationPolicy
+	}{
+		{"default", model.Server{}, "", KeyRotationPolicy{30 * day, model.KeyRotationModeServer}},
+		{"unknown plan", model.Server{}, "gold", KeyRotationPolicy{30 * day, model.KeyRotationModeServer}},
+		{"plan", model.Server{}, "pro", KeyRotationPolicy{7 * day, model.KeyRotationModeClient}},
+		{"plan without mode", model.Server{}, "trial", KeyRotationPolicy{2 * day, model.KeyRotationModeServer}},
+		{"plan disables rotation", model.Server{}, "archived", KeyRotationPolicy{0, model.KeyRotationModeServer}},
+		{"server max age", model.Server{KeyRotationMaxAge: day}, "pro", KeyRotationPolicy{day, model.KeyRotationModeClient}},
+		{"server mode", model.Server{KeyRotationMode: model.KeyRotationModeServer}, "pro", KeyRotationPolicy{7 * day, model.KeyRotationModeServer}},
+		{"server max age and mode", model.Server{KeyRotationMaxAge: day, KeyRotationMode: model.KeyRotationModeClient}, "", KeyRotationPolicy{day, model.KeyRotationModeClient}},
+	}
+
+	for _, tt := range tests {
+		if got := scheduler.policyFor(&tt.server, tt.plan); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	empty := NewKeyRotationScheduler(KeyRotationConfig{}, nil, repository.NewMemoryRepositories())
+	if got := empty.policyFor(&model.Server{}, ""); got.Mode != model.KeyRotationModeClient {
+		t.Errorf("mode without any setting is %q, want client", got.Mode)
+	}
+}
+
+func TestKeyRotationMinMaxAge(t *testing.T) {
+	tests := []struct {
+		name   string
+		config KeyRotationConfig
+		server model.Server
+		want   time.Duration
+	}{
+		{"nothing", KeyRotationConfig{}, model.Server{}, 0},
+		{"default", KeyRotationConfig{Default: KeyRotationPolicy{MaxAge: 30 * day}}, model.Server{}, 30 * day},
+		{"shorter plan", KeyRotationConfig{
+			Default: KeyRotationPolicy{MaxAge: 30 * day},
+			Plans:   map[string]KeyRotationPolicy{"pro": {MaxAge: 7 * day}, "trial": {MaxAge: 14 * day}},
+		}, model.Server{}, 7 * day},
+		{"longer plan", KeyRotationConfig{
+			Default: KeyRotationPolicy{MaxAge: 30 * day},
+			Plans:   map[string]KeyRotationPolicy{"basic": {MaxAge: 60 * day}},
+		}, model.Server{}, 30 * day},
+		{"plan without default", KeyRotationConfig{
+			Plans: map[string]KeyRotationPolicy{"pro": {MaxAge: 7 * day}, "archived": {}},
+		}, model.Server{}, 7 * day},
+		{"server", KeyRotationConfig{
+			Plans: map[string]KeyRotationPolicy{"pro": {MaxAge: day}},
+		}, model.Server{KeyRotationMaxAge: 10 * day}, 10 * day},
+	}
+
+	for _, tt := range tests {
+		scheduler := NewKeyRotationScheduler(tt.config, nil, repository.NewMemoryRepositories())
+		if got := scheduler.minMaxAge(&tt.server); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
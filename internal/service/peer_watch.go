@@ -0,0 +1,315 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/shivamp1998/vpn_backend/internal/events"
+	"github.com/shivamp1998/vpn_backend/internal/model"
+	"github.com/shivamp1998/vpn_backend/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	PeerChangeAdded   = "added"
+	PeerChangeRemoved = "removed"
+	PeerChangeRotated = "rotated"
+)
+
+var ErrInvalidResumeToken = InvalidArgument("resume_token", "invalid resume token")
+
+// PeerChange is one change to the peers of a server. Removals carry the
+// removed key and address; rotations carry the previous key so an agent can
+// tell if it has missed one.
+type PeerChange struct {
+	Type              string
+	PeerId            string
+	UserId            string
+	PublicKey         string
+	PreviousPublicKey string
+	IpAddress         string
+	OccurredAt        time.Time
+}
+
+// PeerWatchUpdate is one message of a watch. A snapshot replaces everything
+// the agent knew; otherwise Changes apply in order. An update without either
+// is a heartbeat that only moves the resume token forward.
+type PeerWatchUpdate struct {
+	Snapshot    bool
+	Peers       []*model.WireGuardKeys
+	Changes     []PeerChange
+	ResumeToken string
+}
+
+type PeerWatchConfig struct {
+	PollInterval time.Duration
+	// Overlap is how far back before the previous poll each poll looks
+	// again, to pick up changes that were committed late or stamped by a
+	// replica whose clock is behind.
+	Overlap   time.Duration
+	Heartbeat time.Duration
+	// MaxResumeAge is the oldest resume token honoured; older ones get a
+	// snapshot.
+	MaxResumeAge time.Duration
+	// MaxChanges is the most changes sent as a catch-up. If more were missed
+	// a snapshot is sent instead.
+	MaxChanges int
+}
+
+// PeerWatcher streams the peers of a server to its node agent. Changes are
+// read from the outbox, so every replica can serve a watch and a resume
+// token from one replica works on another.
+//
+// Delivery is at least once: a resumed watch, or the changes following a
+// snapshot, can repeat changes the agent has already applied. Applying them
+// in order always ends in the current state.
+type PeerWatcher struct {
+	config     PeerWatchConfig
+	serverRepo repository.ServerRepository
+	keysRepo   repository.WireGuardKeysRepository
+	outbox     repository.OutboxRepository
+
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// ErrWatchClosed ends the watches of a replica that is shutting down. The
+// agent should resume with its last token, which any replica accepts.
+var ErrWatchClosed = &Error{Kind: KindUnavailable, Reason: "SHUTTING_DOWN", Message: "server is shutting down, resume the watch"}
+
+func NewPeerWatcher(config PeerWatchConfig, serverRepo repository.ServerRepository, keysRepo repository.WireGuardKeysRepository, outbox repository.OutboxRepository) *PeerWatcher {
+	if config.PollInterval <= 0 {
+		config.PollInterval = 2 * time.Second
+	}
+	if config.Overlap <= 0 {
+		config.Overlap = 10 * time.Second
+	}
+	if config.Heartbeat <= 0 {
+		config.Heartbeat = 30 * time.Second
+	}
+	if config.MaxResumeAge <= 0 {
+		config.MaxResumeAge = 24 * time.Hour
+	}
+	if config.MaxChanges <= 0 {
+		config.MaxChanges = 1000
+	}
+
+	return &PeerWatcher{
+		config:     config,
+		serverRepo: serverRepo,
+		keysRepo:   keysRepo,
+		outbox:     outbox,
+		closed:     make(chan struct{}),
+	}
+}
+
+// Close ends every watch, and every watch started later, with
+// ErrWatchClosed. Watches never finish on their own, so the servers could
+// not drain otherwise.
+func (w *PeerWatcher) Close(ctx context.Context) error {
+	w.closeOnce.Do(func() {
+		close(w.closed)
+	})
+	return nil
+}
+
+var peerEventTypes = []string{model.EventPeerCreated, model.EventPeerRevoked, model.EventKeysRotated}
+
+// Watch sends the peers of a server to send until ctx is done or send
+// fails. Without a resume token, or with one older than MaxResumeAge, it
+// starts with a snapshot; otherwise it starts with the changes since the
+// token was issued.
+func (w *PeerWatcher) Watch(ctx context.Context, serverId, resumeToken string, send func(*PeerWatchUpdate) error) error {
+	id, err := primitive.ObjectIDFromHex(serverId)
+	if err != nil {
+		return ErrInvalidServerId
+	}
+
+	select {
+	case <-w.closed:
+		return ErrWatchClosed
+	default:
+	}
+
+	if _, err := w.serverRepo.GetById(ctx, id); err != nil {
+		return err
+	}
+
+	watch := &peerWatch{watcher: w, serverId: id, seen: make(map[primitive.ObjectID]time.Time)}
+	if resumeToken != "" {
+		watermark, err := decodePeerWatchToken(resumeToken, id)
+		if err != nil {
+			return err
+		}
+		if time.Since(watermark) <= w.config.MaxResumeAge {
+			watch.watermark = watermark
+		}
+	}
+
+	ticker := time.NewTicker(w.config.PollInterval)
+	defer ticker.Stop()
+
+	lastSent := time.Now()
+	for {
+		update, err := watch.next(ctx)
+		if err != nil {
+			return err
+		}
+
+		if update.Snapshot || len(update.Changes) > 0 || time.Since(lastSent) >= w.config.Heartbeat {
+			if err := send(update); err != nil {
+				return err
+			}
+			lastSent = time.Now()
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-w.closed:
+			return ErrWatchClosed
+		case <-ticker.C:
+		}
+	}
+}
+
+type peerWatch struct {
+	watcher   *PeerWatcher
+	serverId  primitive.ObjectID
+	watermark time.Time
+	// seen holds the changes sent within the overlap window, so a change
+	// read by two polls is only sent once.
+	seen map[primitive.ObjectID]time.Time
+}
+
+// next returns the changes since the previous call, or a snapshot if there
+// is no watermark yet or too much has changed to send as changes.
+func (p *peerWatch) next(ctx context.Context) (*PeerWatchUpdate, error) {
+	if p.watermark.IsZero() {
+		return p.snapshot(ctx)
+	}
+
+	config := p.watcher.config
+	started := time.Now()
+
+	changes, err := p.watcher.outbox.ListSince(ctx, p.serverId, peerEventTypes, p.watermark.Add(-config.Overlap), config.MaxChanges)
+	if err != nil {
+		return nil, err
+	}
+	if len(changes) >= config.MaxChanges {
+		return p.snapshot(ctx)
+	}
+
+	update := &PeerWatchUpdate{}
+	for _, event := range changes {
+		if _, seen := p.seen[event.Id]; seen {
+			continue
+		}
+
+		change, ok, err := p.peerChange(event)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			p.seen[event.Id] = event.OccurredAt
+			update.Changes = append(update.Changes, change)
+		}
+	}
+
+	p.advance(started)
+	update.ResumeToken = encodePeerWatchToken(p.serverId, p.watermark)
+	return update, nil
+}
+
+func (p *peerWatch) snapshot(ctx context.Context) (*PeerWatchUpdate, error) {
+	started := time.Now()
+
+	peers, err := p.watcher.keysRepo.GetAllByServer(ctx, p.serverId)
+	if err != nil {
+		return nil, err
+	}
+
+	p.seen = make(map[primitive.ObjectID]time.Time)
+	p.advance(started)
+
+	return &PeerWatchUpdate{
+		Snapshot:    true,
+		Peers:       peers,
+		ResumeToken: encodePeerWatchToken(p.serverId, p.watermark),
+	}, nil
+}
+
+func (p *peerWatch) advance(watermark time.Time) {
+	p.watermark = watermark
+
+	horizon := watermark.Add(-p.watcher.config.Overlap)
+	for id, occurredAt := range p.seen {
+		if occurredAt.Before(horizon) {
+			delete(p.seen, id)
+		}
+	}
+}
+
+// peerChange converts event to a change, reporting false if it is about
+// another server.
+func (p *peerWatch) peerChange(event *model.OutboxEvent) (PeerChange, bool, error) {
+	change := PeerChange{OccurredAt: event.OccurredAt}
+	var serverId string
+
+	switch event.Type {
+	case model.EventPeerCreated:
+		var payload events.PeerCreated
+		if err := events.Decode(event, &payload); err != nil {
+			return change, false, err
+		}
+		change.Type = PeerChangeAdded
+		change.PeerId, change.UserId, serverId = payload.PeerId, payload.UserId, payload.ServerId
+		change.PublicKey, change.IpAddress = payload.PublicKey, payload.IpAddress
+
+	case model.EventPeerRevoked:
+		var payload events.PeerRevoked
+		if err := events.Decode(event, &payload); err != nil {
+			return change, false, err
+		}
+		change.Type = PeerChangeRemoved
+		change.PeerId, change.UserId, serverId = payload.PeerId, payload.UserId, payload.ServerId
+		change.PublicKey, change.IpAddress = payload.PublicKey, payload.IpAddress
+
+	case model.EventKeysRotated:
+		var payload events.KeysRotated
+		if err := events.Decode(event, &payload); err != nil {
+			return change, false, err
+		}
+		change.Type = PeerChangeRotated
+		change.PeerId, change.UserId, serverId = payload.PeerId, payload.UserId, payload.ServerId
+		change.PublicKey, change.PreviousPublicKey = payload.PublicKey, payload.PreviousPublicKey
+	}
+
+	return change, serverId == p.serverId.Hex(), nil
+}
+
+type peerWatchToken struct {
+	ServerId  string `json:"s"`
+	Watermark int64  `json:"w"`
+}
+
+func encodePeerWatchToken(serverId primitive.ObjectID, watermark time.Time) string {
+	token, _ := json.Marshal(peerWatchToken{ServerId: serverId.Hex(), Watermark: watermark.UnixNano()})
+	return base64.RawURLEncoding.EncodeToString(token)
+}
+
+func decodePeerWatchToken(encoded string, serverId primitive.ObjectID) (time.Time, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return time.Time{}, ErrInvalidResumeToken
+	}
+
+	var token peerWatchToken
+	if err := json.Unmarshal(raw, &token); err != nil || token.ServerId != serverId.Hex() || token.Watermark <= 0 {
+		return time.Time{}, ErrInvalidResumeToken
+	}
+	return time.Unix(0, token.Watermark), nil
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/shivamp1998/vpn_backend/internal/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TestPeerWatchIgnoresOtherServers checks that changes to other servers
+// neither reach a watch nor count towards its MaxChanges.
+func TestPeerWatchIgnoresOtherServers(t *testing.T) {
+	ctx := context.Background()
+	service, repos, server := newTestConfigService(t, 10)
+
+	busy := &model.Server{Name: "busy", Endpoint: "busy.example.com:51820", Region: "eu", MaxClients: 10, Status: model.ServerStatusActive}
+	if err := repos.Servers.Create(ctx, busy); err != nil {
+		t.Fatal(err)
+	}
+
+	watcher := NewPeerWatcher(PeerWatchConfig{MaxChanges: 3}, repos.Servers, repos.Keys, repos.Outbox)
+	watch := &peerWatch{watcher: watcher, serverId: server.Id, seen: make(map[primitive.ObjectID]time.Time)}
+
+	update, err := watch.next(ctx)
+	if err != nil || !update.Snapshot {
+		t.Fatalf("first update: %+v, %v", update, err)
+	}
+
+	for i := 0; i < 5; i++ {
+		if _, err := service.GenerateConfig(ctx, primitive.NewObjectID(), busy.Id.Hex(), GenerateConfigOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	userId := primitive.NewObjectID()
+	if _, err := service.GenerateConfig(ctx, userId, server.Id.Hex(), GenerateConfigOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	update, err = watch.next(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if update.Snapshot {
+		t.Fatal("changes to another server forced a snapshot")
+	}
+	if len(update.Changes) != 1 || update.Changes[0].Type != PeerChangeAdded || update.Changes[0].UserId != userId.Hex() {
+		t.Errorf("changes: %+v", update.Changes)
+	}
+}
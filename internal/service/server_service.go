@@ -2,8 +2,10 @@ package service
 
 import (
 	"context"
-	"errors"
+	"fmt"
 
+	"github.com/shivamp1998/vpn_backend/internal/audit"
+	"github.com/shivamp1998/vpn_backend/internal/events"
 	"github.com/shivamp1998/vpn_backend/internal/model"
 	"github.com/shivamp1998/vpn_backend/internal/repository"
 	"github.com/shivamp1998/vpn_backend/internal/wireguard"
@@ -11,37 +13,73 @@ import (
 )
 
 type ServerService struct {
-	serverRepo *repository.ServerRepository
+	serverRepo repository.ServerRepository
+	auditLog   *audit.Logger
 }
 
-func NewServerService() *ServerService {
+func NewServerService(serverRepo repository.ServerRepository, auditLog *audit.Logger) *ServerService {
 	return &ServerService{
-		serverRepo: repository.NewServerRepository(),
+		serverRepo: serverRepo,
+		auditLog:   auditLog,
 	}
 }
 
-func (s *ServerService) CreateServer(ctx context.Context, name, endpoint, region, publicKey string, maxClients int32) (*model.Server, error) {
+// ErrVersionMismatch is returned when the version a caller sends is not the
+// stored one, meaning the caller is editing an out-of-date copy.
+var ErrVersionMismatch = &Error{Kind: KindFailedPrecondition, Reason: "VERSION_MISMATCH", Message: "version does not match the current version"}
+
+var ErrInvalidServerId = InvalidArgument("server_id", "invalid server id")
+
+// ServerUpdate holds the editable fields of a server. Every field is written,
+// so callers send the full desired state, except that an empty Status keeps
+// the current one.
+type ServerUpdate struct {
+	Name       string
+	Endpoint   string
+	Region     string
+	MaxClients int32
+	Rotation   KeyRotationPolicy
+	Status     string
+}
+
+func (s *ServerService) CreateServer(ctx context.Context, name, endpoint, region, publicKey string, maxClients int32, rotation KeyRotationPolicy) (*model.Server, error) {
+	server, err := s.createServer(ctx, name, endpoint, region, publicKey, maxClients, rotation)
 
-	if name == "" || endpoint == "" || region == "" {
-		return nil, errors.New("name, endpoint, region is required")
+	entry := audit.Entry{Action: model.AuditActionServerCreate, TargetType: model.AuditTargetServer, Err: err}
+	if server != nil {
+		entry.TargetId = server.Id.Hex()
 	}
+	s.auditLog.Record(ctx, entry)
 
-	if maxClients <= 0 {
-		return nil, errors.New("max_clients must not be greater than 0")
+	return server, err
+}
+
+func (s *ServerService) createServer(ctx context.Context, name, endpoint, region, publicKey string, maxClients int32, rotation KeyRotationPolicy) (*model.Server, error) {
+	if err := validateServer(name, endpoint, region, maxClients, rotation); err != nil {
+		return nil, err
+	}
+
+	if publicKey != "" {
+		if err := wireguard.ValidateKey(publicKey); err != nil {
+			return nil, InvalidArgument("public_key", "invalid public key: %v", err)
+		}
 	}
 
 	server := &model.Server{
-		Name:       name,
-		Endpoint:   endpoint,
-		Region:     region,
-		MaxClients: maxClients,
+		Name:              name,
+		Endpoint:          endpoint,
+		Region:            region,
+		MaxClients:        maxClients,
+		KeyRotationMaxAge: rotation.MaxAge,
+		KeyRotationMode:   rotation.Mode,
+		Status:            model.ServerStatusActive,
 	}
 
-	if publicKey == "" {
+	// A server that brings its own key keeps the private half to itself.
+	if publicKey != "" {
 		server.PublicKey = publicKey
-		server.PrivateKeyEncrypted = ""
 	} else {
-		privateKey, publicKey, err := wireguard.GenerateKeyPair()
+		privateKey, publicKey, err := generateKeyPair(ctx)
 		if err != nil {
 			return nil, err
 		}
@@ -62,7 +100,7 @@ func (s *ServerService) GetServer(ctx context.Context, serverId string) (*model.
 	id, err := primitive.ObjectIDFromHex(serverId)
 
 	if err != nil {
-		return nil, errors.New("invalid server id")
+		return nil, ErrInvalidServerId
 	}
 
 	return s.serverRepo.GetById(ctx, id)
@@ -72,7 +110,113 @@ func (s *ServerService) ListServers(ctx context.Context) ([]*model.Server, error
 	return s.serverRepo.ListAll(ctx)
 }
 
-func (s *ServerService) UpdateServer(ctx context.Context, server *model.Server) error {
-	err := s.serverRepo.Update(ctx, server)
-	return err
+// UpdateServer applies update to the server if it is still at version. A
+// version that is already out of date fails with ErrVersionMismatch; losing a
+// race to a concurrent edit fails with repository.ErrVersionConflict.
+func (s *ServerService) UpdateServer(ctx context.Context, serverId string, version int64, update ServerUpdate) (*model.Server, error) {
+	server, err := s.updateServer(ctx, serverId, version, update)
+
+	s.auditLog.Record(ctx, audit.Entry{
+		Action:     model.AuditActionServerUpdate,
+		TargetType: model.AuditTargetServer,
+		TargetId:   serverId,
+		Err:        err,
+	})
+
+	return server, err
+}
+
+func (s *ServerService) updateServer(ctx context.Context, serverId string, version int64, update ServerUpdate) (*model.Server, error) {
+	id, err := primitive.ObjectIDFromHex(serverId)
+	if err != nil {
+		return nil, ErrInvalidServerId
+	}
+
+	if version <= 0 {
+		return nil, InvalidArgument("version", "version is required")
+	}
+
+	if err := validateServer(update.Name, update.Endpoint, update.Region, update.MaxClients, update.Rotation); err != nil {
+		return nil, err
+	}
+
+	server, err := s.serverRepo.GetById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if server.Version != version {
+		return nil, ErrVersionMismatch.withDetail("server is at version %d", server.Version)
+	}
+
+	if update.MaxClients < server.CurrentClients {
+		return nil, &Error{
+			Kind:    KindFailedPrecondition,
+			Reason:  "MAX_CLIENTS_BELOW_CURRENT",
+			Message: fmt.Sprintf("max_clients cannot be lower than the %d clients already on the server", server.CurrentClients),
+			Field:   "max_clients",
+		}
+	}
+
+	var changes []*model.OutboxEvent
+	if update.Status != "" && update.Status != server.Status {
+		if !validServerStatus(update.Status) {
+			return nil, InvalidArgument("status", "invalid server status %q", update.Status)
+		}
+		changes = append(changes, events.NewServerStatusChanged(server.Id, server.Status, update.Status))
+		server.Status = update.Status
+	}
+
+	server.Name = update.Name
+	server.Endpoint = update.Endpoint
+	server.Region = update.Region
+	server.MaxClients = update.MaxClients
+	server.KeyRotationMaxAge = update.Rotation.MaxAge
+	server.KeyRotationMode = update.Rotation.Mode
+
+	if err := s.serverRepo.Update(ctx, server, changes...); err != nil {
+		return nil, err
+	}
+
+	return server, nil
+}
+
+func validServerStatus(status string) bool {
+	switch status {
+	case model.ServerStatusActive, model.ServerStatusDraining, model.ServerStatusDisabled:
+		return true
+	}
+	return false
+}
+
+func validateServer(name, endpoint, region string, maxClients int32, rotation KeyRotationPolicy) error {
+	if name == "" {
+		return InvalidArgument("name", "name is required")
+	}
+
+	if endpoint == "" {
+		return InvalidArgument("endpoint", "endpoint is required")
+	}
+
+	if err := wireguard.ValidateEndpoint(endpoint); err != nil {
+		return InvalidArgument("endpoint", "%v", err)
+	}
+
+	if region == "" {
+		return InvalidArgument("region", "region is required")
+	}
+
+	if maxClients <= 0 {
+		return InvalidArgument("max_clients", "max_clients must be greater than 0")
+	}
+
+	if rotation.MaxAge < 0 {
+		return InvalidArgument("key_rotation_max_age_seconds", "key rotation max age must not be negative")
+	}
+
+	if rotation.Mode != "" && rotation.Mode != model.KeyRotationModeServer && rotation.Mode != model.KeyRotationModeClient {
+		return InvalidArgument("key_rotation_mode", "key rotation mode must be server or client")
+	}
+
+	return nil
 }
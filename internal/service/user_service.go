@@ -3,27 +3,61 @@ package service
 import (
 	"context"
 	"errors"
+	"net/mail"
 
+	"github.com/shivamp1998/vpn_backend/internal/audit"
 	"github.com/shivamp1998/vpn_backend/internal/auth"
+	"github.com/shivamp1998/vpn_backend/internal/events"
+	"github.com/shivamp1998/vpn_backend/internal/metrics"
 	"github.com/shivamp1998/vpn_backend/internal/model"
 	"github.com/shivamp1998/vpn_backend/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type UserService struct {
-	userRepo *repository.UserRespository
+	userRepo repository.UserRepository
+	auditLog *audit.Logger
 }
 
-func NewUserService() *UserService {
+func NewUserService(userRepo repository.UserRepository, auditLog *audit.Logger) *UserService {
 	return &UserService{
-		userRepo: repository.NewUserRepository(),
+		userRepo: userRepo,
+		auditLog: auditLog,
 	}
 }
 
 func (s *UserService) Register(ctx context.Context, email, password string) (*model.User, error) {
-	existing, _ := s.userRepo.GetByEmail(ctx, email)
+	user, err := s.register(ctx, email, password)
+
+	entry := audit.Entry{Action: model.AuditActionUserRegister, TargetType: model.AuditTargetUser, ActorEmail: email, Err: err}
+	if user != nil {
+		entry.ActorId = user.Id
+		entry.TargetId = user.Id.Hex()
+	}
+	s.auditLog.Record(ctx, entry)
+
+	return user, err
+}
+
+// minPasswordLength matches the rule on RegisterRequest.password.
+const minPasswordLength = 8
+
+func (s *UserService) register(ctx context.Context, email, password string) (*model.User, error) {
+	if address, err := mail.ParseAddress(email); err != nil || address.Address != email {
+		return nil, InvalidArgument("email", "invalid email address")
+	}
+
+	if len(password) < minPasswordLength {
+		return nil, InvalidArgument("password", "password must be at least %d characters", minPasswordLength)
+	}
+
+	existing, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil && !errors.Is(err, repository.ErrUserNotFound) {
+		return nil, err
+	}
 
 	if existing != nil {
-		return nil, errors.New("email already registered")
+		return nil, ErrEmailTaken
 	}
 
 	hashedPassword, err := auth.HashPassword(password)
@@ -33,11 +67,16 @@ func (s *UserService) Register(ctx context.Context, email, password string) (*mo
 	}
 
 	user := &model.User{
+		Id:           primitive.NewObjectID(),
 		Email:        email,
 		PasswordHash: hashedPassword,
 	}
 
-	err = s.userRepo.Create(ctx, user)
+	err = s.userRepo.Create(ctx, user, events.NewUserRegistered(user))
+
+	if errors.Is(err, repository.ErrDuplicateKey) {
+		return nil, ErrEmailTaken
+	}
 
 	if err != nil {
 		return nil, err
@@ -47,14 +86,38 @@ func (s *UserService) Register(ctx context.Context, email, password string) (*mo
 }
 
 func (s *UserService) Login(ctx context.Context, email, password string) (*model.User, error) {
-	user, _ := s.userRepo.GetByEmail(ctx, email)
+	user, err := s.login(ctx, email, password)
+	metrics.LoginAttempted(metricsResult(err))
 
-	if user == nil {
-		return nil, errors.New("invalid email or password")
+	entry := audit.Entry{Action: model.AuditActionUserLogin, TargetType: model.AuditTargetUser, ActorEmail: email, Err: err}
+	if user != nil {
+		entry.ActorId = user.Id
+		entry.TargetId = user.Id.Hex()
+	}
+	s.auditLog.Record(ctx, entry)
+
+	return user, err
+}
+
+// dummyPasswordHash is checked against when the email is unknown, so that
+// answer takes as long as a wrong password does. It is a bcrypt hash, at the
+// default cost, of a random password nobody knows.
+const dummyPasswordHash = "$2a$10$NlyGpORER0jxbY1dO5qDiOta8LsM5dAD8Xb6vbOnejJpDVPqHe0c2"
+
+func (s *UserService) login(ctx context.Context, email, password string) (*model.User, error) {
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		auth.CheckPasswordHash(dummyPasswordHash, password)
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
 	}
 
+	// The same error as for an unknown email, so the response does not tell
+	// whether the account exists.
 	if !auth.CheckPasswordHash(user.PasswordHash, password) {
-		return nil, errors.New("passwords do not match")
+		return nil, ErrInvalidCredentials
 	}
 
 	return user, nil
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/shivamp1998/vpn_backend/internal/repository"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestLogin(t *testing.T) {
+	ctx := context.Background()
+	service := NewUserService(repository.NewMemoryRepositories().Users, nil)
+	if _, err := service.Register(ctx, "user@example.com", "correct horse"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		email    string
+		password string
+		wantErr  error
+	}{
+		{"user@example.com", "correct horse", nil},
+		{"user@example.com", "wrong horse", ErrInvalidCredentials},
+		{"nobody@example.com", "correct horse", ErrInvalidCredentials},
+	}
+
+	for _, tt := range tests {
+		user, err := service.Login(ctx, tt.email, tt.password)
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s with %q: got error %v, want %v", tt.email, tt.password, err, tt.wantErr)
+		}
+		if (user != nil) != (tt.wantErr == nil) {
+			t.Errorf("%s with %q: got user %v", tt.email, tt.password, user)
+		}
+	}
+}
+
+// TestDummyPasswordHash checks that an unknown email costs as much bcrypt
+// work as a wrong password does.
+func TestDummyPasswordHash(t *testing.T) {
+	cost, err := bcrypt.Cost([]byte(dummyPasswordHash))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cost != bcrypt.DefaultCost {
+		t.Errorf("dummy hash has cost %d, want the default %d", cost, bcrypt.DefaultCost)
+	}
+}
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"slices"
+
+	"github.com/shivamp1998/vpn_backend/internal/audit"
+	"github.com/shivamp1998/vpn_backend/internal/auth"
+	"github.com/shivamp1998/vpn_backend/internal/model"
+	"github.com/shivamp1998/vpn_backend/internal/repository"
+	"github.com/shivamp1998/vpn_backend/internal/webhook"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type WebhookService struct {
+	webhookRepo repository.WebhookRepository
+	notifier    *webhook.Notifier
+	auditLog    *audit.Logger
+}
+
+func NewWebhookService(webhookRepo repository.WebhookRepository, notifier *webhook.Notifier, auditLog *audit.Logger) *WebhookService {
+	return &WebhookService{
+		webhookRepo: webhookRepo,
+		notifier:    notifier,
+		auditLog:    auditLog,
+	}
+}
+
+var ErrInvalidWebhookId = InvalidArgument("webhook_id", "invalid webhook id")
+
+// CreateWebhook subscribes targetUrl to eventTypes. The returned webhook
+// holds the signing secret; it is not shown again.
+func (s *WebhookService) CreateWebhook(ctx context.Context, targetUrl string, eventTypes []string, description string) (*model.Webhook, error) {
+	hook, err := s.createWebhook(ctx, targetUrl, eventTypes, description)
+
+	entry := audit.Entry{Action: model.AuditActionWebhookCreate, TargetType: model.AuditTargetWebhook, Err: err}
+	if hook != nil {
+		entry.TargetId = hook.Id.Hex()
+	}
+	s.auditLog.Record(ctx, entry)
+
+	return hook, err
+}
+
+func (s *WebhookService) createWebhook(ctx context.Context, targetUrl string, eventTypes []string, description string) (*model.Webhook, error) {
+	if err := validateWebhookUrl(targetUrl); err != nil {
+		return nil, err
+	}
+
+	if len(eventTypes) == 0 {
+		return nil, InvalidArgument("event_types", "at least one event type is required")
+	}
+	for _, eventType := range eventTypes {
+		if !slices.Contains(webhook.EventTypes, eventType) {
+			return nil, InvalidArgument("event_types", "unknown event type %q", eventType)
+		}
+	}
+
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	hook := &model.Webhook{
+		Url:         targetUrl,
+		Secret:      secret,
+		EventTypes:  slices.Compact(slices.Sorted(slices.Values(eventTypes))),
+		Description: description,
+		Enabled:     true,
+	}
+	hook.CreatedBy, _ = auth.GetUserIDFromContext(ctx)
+
+	if err := s.webhookRepo.Create(ctx, hook); err != nil {
+		return nil, err
+	}
+	return hook, nil
+}
+
+func (s *WebhookService) ListWebhooks(ctx context.Context) ([]*model.Webhook, error) {
+	return s.webhookRepo.List(ctx)
+}
+
+func (s *WebhookService) DeleteWebhook(ctx context.Context, webhookId string) error {
+	err := s.deleteWebhook(ctx, webhookId)
+
+	s.auditLog.Record(ctx, audit.Entry{
+		Action:     model.AuditActionWebhookDelete,
+		TargetType: model.AuditTargetWebhook,
+		TargetId:   webhookId,
+		Err:        err,
+	})
+
+	return err
+}
+
+func (s *WebhookService) deleteWebhook(ctx context.Context, webhookId string) error {
+	id, err := primitive.ObjectIDFromHex(webhookId)
+	if err != nil {
+		return ErrInvalidWebhookId
+	}
+
+	return s.webhookRepo.Delete(ctx, id)
+}
+
+// ListDeliveries returns up to limit deliveries to a webhook, newest first.
+func (s *WebhookService) ListDeliveries(ctx context.Context, webhookId string, limit int) ([]*model.WebhookDelivery, error) {
+	id, err := primitive.ObjectIDFromHex(webhookId)
+	if err != nil {
+		return nil, ErrInvalidWebhookId
+	}
+
+	if _, err := s.webhookRepo.GetById(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return s.webhookRepo.ListDeliveries(ctx, id, limit)
+}
+
+// TestWebhook sends a test event to a webhook and returns the delivery. A
+// receiver that rejects it is reported in the delivery, not as an error.
+func (s *WebhookService) TestWebhook(ctx context.Context, webhookId string) (*model.WebhookDelivery, error) {
+	delivery, err := s.testWebhook(ctx, webhookId)
+
+	entry := audit.Entry{
+		Action:     model.AuditActionWebhookTest,
+		TargetType: model.AuditTargetWebhook,
+		TargetId:   webhookId,
+		Err:        err,
+	}
+	if delivery != nil && !delivery.Success {
+		entry.Err = errors.New(delivery.Error)
+	}
+	s.auditLog.Record(ctx, entry)
+
+	return delivery, err
+}
+
+func (s *WebhookService) testWebhook(ctx context.Context, webhookId string) (*model.WebhookDelivery, error) {
+	id, err := primitive.ObjectIDFromHex(webhookId)
+	if err != nil {
+		return nil, ErrInvalidWebhookId
+	}
+
+	hook, err := s.webhookRepo.GetById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.notifier.TestFire(ctx, hook)
+}
+
+func validateWebhookUrl(targetUrl string) error {
+	parsed, err := url.Parse(targetUrl)
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return InvalidArgument("url", "webhook url must be an absolute http or https url")
+	}
+
+	if parsed.User != nil {
+		return InvalidArgument("url", "webhook url must not contain credentials")
+	}
+	return nil
+}
+
+func newWebhookSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return "whsec_" + base64.RawURLEncoding.EncodeToString(secret), nil
+}
@@ -0,0 +1,119 @@
+// Package storage opens the repositories of the configured backend for the
+// server and the command line tools.
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/shivamp1998/vpn_backend/internal/config"
+	"github.com/shivamp1998/vpn_backend/internal/database"
+	"github.com/shivamp1998/vpn_backend/internal/health"
+	"github.com/shivamp1998/vpn_backend/internal/metrics"
+	"github.com/shivamp1998/vpn_backend/internal/repository"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Open connects to the storage backend named in cfg ("mongo", "postgres" or
+// "sqlite") and returns its repositories together with a function that
+// closes the connection. The backend's readiness checks are added to checker
+// unless it is nil.
+func Open(cfg config.StorageConfig, checker *health.Checker) (*repository.Repositories, func(), error) {
+	switch cfg.Backend {
+	case "mongo":
+		return openMongoRepositories(cfg, checker)
+	case "postgres":
+		return openPostgresRepositories(cfg, checker)
+	case "sqlite":
+		return openSQLiteRepositories(cfg, checker)
+	}
+
+	return nil, nil, fmt.Errorf("unknown storage backend %q", cfg.Backend)
+}
+
+func openMongoRepositories(cfg config.StorageConfig, checker *health.Checker) (*repository.Repositories, func(), error) {
+	monitor := options.Client().SetMonitor(metrics.MongoCommandMonitor())
+	if err := database.Connect(cfg.MongoURI, cfg.MongoDatabase, monitor); err != nil {
+		return nil, nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	// Migrations normally run out of band with cmd/migrate; migrate_on_start
+	// is for single-replica installs that want the old apply-on-boot behaviour.
+	if cfg.MigrateOnStart {
+		if _, err := database.MigrateMongo(ctx, database.DB, false); err != nil {
+			database.Disconnect()
+			return nil, nil, err
+		}
+	}
+
+	if err := database.CheckMongoSchema(ctx, database.DB); err != nil {
+		database.Disconnect()
+		return nil, nil, fmt.Errorf("%v; run `go run ./cmd/migrate run` or set storage.migrate_on_start", err)
+	}
+
+	if checker != nil {
+		checker.Add("mongo", func(ctx context.Context) error {
+			return database.Client.Ping(ctx, nil)
+		})
+		checker.Add("migrations", func(ctx context.Context) error {
+			return database.CheckMongoSchema(ctx, database.DB)
+		})
+	}
+
+	closeStorage := func() {
+		database.Disconnect()
+	}
+	return repository.Traced(repository.NewMongoRepositories(database.DB), "mongodb"), closeStorage, nil
+}
+
+func openPostgresRepositories(cfg config.StorageConfig, checker *health.Checker) (*repository.Repositories, func(), error) {
+	db, err := database.OpenPostgres(cfg.PostgresDSN)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	if err := database.MigrateSQL(ctx, db, "postgres"); err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+
+	if checker != nil {
+		checker.Add("postgres", db.PingContext)
+	}
+
+	closeStorage := func() {
+		db.Close()
+	}
+	return repository.Traced(repository.NewPostgresRepositories(db), "postgresql"), closeStorage, nil
+}
+
+func openSQLiteRepositories(cfg config.StorageConfig, checker *health.Checker) (*repository.Repositories, func(), error) {
+	db, err := database.OpenSQLite(cfg.SQLitePath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	if err := database.MigrateSQL(ctx, db, "sqlite"); err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+
+	if checker != nil {
+		checker.Add("sqlite", db.PingContext)
+	}
+
+	closeStorage := func() {
+		db.Close()
+	}
+	return repository.Traced(repository.NewSQLiteRepositories(db), "sqlite"), closeStorage, nil
+}
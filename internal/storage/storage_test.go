@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/shivamp1998/vpn_backend/internal/config"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TestOpenMongoChecksSchema runs against the server in MONGODB_TEST_URI,
+// in a database of its own.
+func TestOpenMongoChecksSchema(t *testing.T) {
+	uri := os.Getenv("MONGODB_TEST_URI")
+	if uri == "" {
+		t.Skip("MONGODB_TEST_URI is not set")
+	}
+
+	ctx := context.Background()
+	cfg := config.StorageConfig{
+		Backend:       "mongo",
+		MongoURI:      uri,
+		MongoDatabase: "vpn_test_" + primitive.NewObjectID().Hex(),
+	}
+	t.Cleanup(func() {
+		client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		client.Database(cfg.MongoDatabase).Drop(ctx)
+		client.Disconnect(ctx)
+	})
+
+	// The server refuses to start on a schema cmd/migrate has not brought up
+	// to date.
+	_, _, err := Open(cfg, nil)
+	if err == nil || !strings.Contains(err.Error(), "out of date") || !strings.Contains(err.Error(), "cmd/migrate") {
+		t.Fatalf("unmigrated database: got %v, want a schema error pointing at cmd/migrate", err)
+	}
+
+	cfg.MigrateOnStart = true
+	_, closeStorage, err := Open(cfg, nil)
+	if err != nil {
+		t.Fatalf("with migrate_on_start: %v", err)
+	}
+	closeStorage()
+
+	cfg.MigrateOnStart = false
+	_, closeStorage, err = Open(cfg, nil)
+	if err != nil {
+		t.Fatalf("migrated database: %v", err)
+	}
+	closeStorage()
+}
@@ -0,0 +1,118 @@
+// Package tracing sets up OpenTelemetry tracing. Spans are started in the
+// middleware chain for every call, whichever transport it came in on, and
+// continued in the services and repositories through the context.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Attributes set on our spans, alongside the OpenTelemetry semantic ones.
+const (
+	ProcedureKey = attribute.Key("procedure")
+	RequestIDKey = attribute.Key("request_id")
+	ServerIDKey  = attribute.Key("server_id")
+	UserIDKey    = attribute.Key("user_id")
+)
+
+// Exporters.
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+type Config struct {
+	// Exporter is ExporterNone, ExporterStdout or ExporterOTLP.
+	Exporter string
+	// Endpoint is the URL of the OTLP/gRPC collector; an http:// URL
+	// connects without TLS. Empty uses the exporter's default.
+	Endpoint    string
+	ServiceName string
+	// SampleRatio is the share of new traces recorded. Calls that arrive
+	// with a sampled parent are always recorded.
+	SampleRatio float64
+}
+
+// Setup installs the tracer provider and W3C propagators described by
+// config as the global ones. The returned function flushes the spans not
+// yet exported and must be called before the process exits.
+func Setup(ctx context.Context, config Config) (func(ctx context.Context) error, error) {
+	// Propagation works with the exporter off too, so traces started by a
+	// caller still reach the services we call.
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch config.Exporter {
+	case ExporterNone, "":
+		return func(ctx context.Context) error { return nil }, nil
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case ExporterOTLP:
+		var opts []otlptracegrpc.Option
+		if config.Endpoint != "" {
+			opts = append(opts, otlptracegrpc.WithEndpointURL(config.Endpoint))
+		}
+		exporter, err = otlptracegrpc.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", config.Exporter)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	provider := NewProvider(config, sdktrace.WithBatcher(exporter))
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// NewProvider returns a tracer provider for config that sends its spans
+// as opts say, e.g. sdktrace.WithBatcher(exporter).
+func NewProvider(config Config, opts ...sdktrace.TracerProviderOption) *sdktrace.TracerProvider {
+	serviceName := config.ServiceName
+	if serviceName == "" {
+		serviceName = "vpn-backend"
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(attribute.String("service.name", serviceName)))
+	if err != nil {
+		res = resource.Default()
+	}
+
+	opts = append([]sdktrace.TracerProviderOption{
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.SampleRatio))),
+	}, opts...)
+	return sdktrace.NewTracerProvider(opts...)
+}
+
+// NewMemoryProvider returns a provider that records every span, as soon as
+// it ends, in the returned exporter. It is meant for tests, which can make
+// it the global provider with otel.SetTracerProvider.
+func NewMemoryProvider() (*sdktrace.TracerProvider, *tracetest.InMemoryExporter) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := NewProvider(Config{SampleRatio: 1}, sdktrace.WithSyncer(exporter))
+	return provider, exporter
+}
+
+// End records err on span, if there is one, and ends it.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,192 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shivamp1998/vpn_backend/internal/events"
+	"github.com/shivamp1998/vpn_backend/internal/model"
+	"github.com/shivamp1998/vpn_backend/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const subscriberName = "webhooks"
+
+// TestEventType is the type of the event sent by a test fire.
+const TestEventType = "WebhookTest"
+
+// EventTypes lists the events a webhook can subscribe to.
+var EventTypes = []string{
+	model.EventUserRegistered,
+	model.EventPeerCreated,
+	model.EventPeerRevoked,
+	model.EventKeysRotated,
+	model.EventServerStatusChanged,
+}
+
+// Payload is the JSON body of a delivery.
+type Payload struct {
+	EventId     string          `json:"event_id"`
+	Type        string          `json:"type"`
+	AggregateId string          `json:"aggregate_id"`
+	OccurredAt  time.Time       `json:"occurred_at"`
+	Data        json.RawMessage `json:"data"`
+}
+
+// Notifier posts outbox events to the webhooks subscribed to them.
+type Notifier struct {
+	repo   repository.WebhookRepository
+	client *http.Client
+}
+
+// NewNotifier returns a Notifier sending with client, or with a client that
+// has a 10 second timeout and does not follow redirects if client is nil.
+func NewNotifier(repo repository.WebhookRepository, client *http.Client) *Notifier {
+	if client == nil {
+		client = &http.Client{
+			Timeout: 10 * time.Second,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		}
+	}
+
+	return &Notifier{repo: repo, client: client}
+}
+
+// Subscribe registers the notifier with dispatcher for every event type.
+// Retries come from the dispatcher, which backs off exponentially between
+// attempts.
+func (n *Notifier) Subscribe(dispatcher *events.Dispatcher) {
+	dispatcher.Subscribe(subscriberName, n.Handle)
+}
+
+// Handle delivers event to every subscribed webhook that has not received it
+// yet. It fails if any delivery failed, so the dispatcher retries the event;
+// webhooks that already succeeded are skipped on the retry.
+func (n *Notifier) Handle(ctx context.Context, event *model.OutboxEvent) error {
+	webhooks, err := n.repo.ListByEventType(ctx, event.Type)
+	if err != nil {
+		return err
+	}
+
+	var failures []string
+	for _, webhook := range webhooks {
+		delivered, err := n.repo.Delivered(ctx, webhook.Id, event.Id)
+		if err != nil {
+			return err
+		}
+		if delivered {
+			continue
+		}
+
+		delivery := n.send(ctx, webhook, event)
+		delivery.Attempt = event.Attempts + 1
+		if err := n.repo.RecordDelivery(ctx, delivery); err != nil {
+			return err
+		}
+
+		if !delivery.Success {
+			failures = append(failures, fmt.Sprintf("%s: %s", webhook.Id.Hex(), delivery.Error))
+		}
+	}
+
+	if len(failures) > 0 {
+		return errors.New(strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// TestFire sends a WebhookTest event to webhook straight away, whatever
+// events it subscribes to, and records the delivery.
+func (n *Notifier) TestFire(ctx context.Context, webhook *model.Webhook) (*model.WebhookDelivery, error) {
+	data, err := json.Marshal(map[string]string{"webhook_id": webhook.Id.Hex()})
+	if err != nil {
+		return nil, err
+	}
+
+	event := &model.OutboxEvent{
+		Id:          primitive.NewObjectID(),
+		Type:        TestEventType,
+		AggregateId: webhook.Id.Hex(),
+		Payload:     string(data),
+		OccurredAt:  time.Now(),
+	}
+
+	delivery := n.send(ctx, webhook, event)
+	delivery.Attempt = 1
+	delivery.Test = true
+	if err := n.repo.RecordDelivery(ctx, delivery); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// send posts event to webhook. Failures are reported in the returned
+// delivery rather than as an error.
+func (n *Notifier) send(ctx context.Context, webhook *model.Webhook, event *model.OutboxEvent) *model.WebhookDelivery {
+	delivery := &model.WebhookDelivery{
+		WebhookId: webhook.Id,
+		EventId:   event.Id,
+		EventType: event.Type,
+	}
+
+	started := time.Now()
+	statusCode, err := n.post(ctx, webhook, event)
+	delivery.DurationMs = time.Since(started).Milliseconds()
+	delivery.StatusCode = statusCode
+
+	if err != nil {
+		delivery.Error = err.Error()
+	} else {
+		delivery.Success = true
+	}
+	return delivery
+}
+
+func (n *Notifier) post(ctx context.Context, webhook *model.Webhook, event *model.OutboxEvent) (int, error) {
+	body, err := json.Marshal(Payload{
+		EventId:     event.Id.Hex(),
+		Type:        event.Type,
+		AggregateId: event.AggregateId,
+		OccurredAt:  event.OccurredAt.UTC(),
+		Data:        json.RawMessage(event.Payload),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.Url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, event.Type)
+	req.Header.Set(EventIdHeader, event.Id.Hex())
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, Sign(webhook.Secret, timestamp, body))
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	// Drain a bounded amount so the connection can be reused.
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,99 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/shivamp1998/vpn_backend/internal/events"
+	"github.com/shivamp1998/vpn_backend/internal/model"
+	"github.com/shivamp1998/vpn_backend/internal/repository"
+)
+
+// receiver is a webhook endpoint that checks signatures and fails the first
+// failFirst deliveries.
+type receiver struct {
+	*httptest.Server
+	secret    string
+	failFirst int32
+	calls     atomic.Int32
+	verified  atomic.Int32
+}
+
+func newReceiver(t *testing.T, secret string, failFirst int32) *receiver {
+	r := &receiver{secret: secret, failFirst: failFirst}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		if Verify(r.secret, req.Header.Get(SignatureHeader), req.Header.Get(TimestampHeader), body, time.Minute, time.Now()) == nil {
+			r.verified.Add(1)
+		}
+
+		if r.calls.Add(1) <= r.failFirst {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+func TestHandleRetriesFailedDeliveries(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemoryRepositories()
+
+	healthy := newReceiver(t, "healthy-secret", 0)
+	flaky := newReceiver(t, "flaky-secret", 1)
+	for _, r := range []*receiver{healthy, flaky} {
+		hook := &model.Webhook{Url: r.URL, Secret: r.secret, EventTypes: []string{model.EventUserRegistered}, Enabled: true}
+		if err := repos.Webhooks.Create(ctx, hook); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dispatcher := events.NewDispatcher(events.DispatcherConfig{MinBackoff: time.Nanosecond}, repos.Outbox, repos.Locks)
+	NewNotifier(repos.Webhooks, nil).Subscribe(dispatcher)
+
+	user := &model.User{Email: "user@example.com"}
+	if err := repos.Users.Create(ctx, user, events.NewUserRegistered(user)); err != nil {
+		t.Fatal(err)
+	}
+
+	// The flaky receiver fails, so the event stays pending.
+	if err := dispatcher.RunOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if healthy.calls.Load() != 1 || flaky.calls.Load() != 1 {
+		t.Fatalf("first run: healthy got %d calls, flaky %d; want 1 each", healthy.calls.Load(), flaky.calls.Load())
+	}
+	if delivered, _ := repos.Outbox.ListByStatus(ctx, model.OutboxStatusDelivered, 10); len(delivered) != 0 {
+		t.Fatal("event was marked delivered although a webhook failed")
+	}
+
+	// The retry only goes to the receiver that failed.
+	time.Sleep(time.Millisecond)
+	if err := dispatcher.RunOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if healthy.calls.Load() != 1 {
+		t.Errorf("healthy receiver got %d calls, want 1: it was retried after succeeding", healthy.calls.Load())
+	}
+	if flaky.calls.Load() != 2 {
+		t.Errorf("flaky receiver got %d calls, want 2", flaky.calls.Load())
+	}
+	if healthy.verified.Load() != healthy.calls.Load() || flaky.verified.Load() != flaky.calls.Load() {
+		t.Error("a delivery was not signed with the webhook's secret")
+	}
+
+	delivered, err := repos.Outbox.ListByStatus(ctx, model.OutboxStatusDelivered, 10)
+	if err != nil || len(delivered) != 1 {
+		t.Fatalf("got %d delivered events, %v; want 1", len(delivered), err)
+	}
+	if delivered[0].Attempts != 1 {
+		t.Errorf("event took %d failed attempts, want 1", delivered[0].Attempts)
+	}
+}
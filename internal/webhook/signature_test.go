@@ -0,0 +1,44 @@
+package webhook
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestVerify(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	body := []byte(`{"type":"PeerCreated"}`)
+	signature := Sign("secret", now.Unix(), body)
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+
+	tests := []struct {
+		name      string
+		secret    string
+		signature string
+		timestamp string
+		body      []byte
+		now       time.Time
+		want      error
+	}{
+		{"valid", "secret", signature, timestamp, body, now, nil},
+		{"within tolerance", "secret", signature, timestamp, body, now.Add(4 * time.Minute), nil},
+		{"wrong secret", "other", signature, timestamp, body, now, ErrInvalidSignature},
+		{"edited body", "secret", signature, timestamp, []byte(`{"type":"PeerRevoked"}`), now, ErrInvalidSignature},
+		{"missing prefix", "secret", signature[len(signaturePrefix):], timestamp, body, now, ErrInvalidSignature},
+		{"replayed timestamp", "secret", Sign("secret", now.Unix()-1, body), timestamp, body, now, ErrInvalidSignature},
+		{"stale", "secret", signature, timestamp, body, now.Add(6 * time.Minute), ErrStaleTimestamp},
+		{"from the future", "secret", signature, timestamp, body, now.Add(-6 * time.Minute), ErrStaleTimestamp},
+		{"malformed timestamp", "secret", signature, "yesterday", body, now, ErrStaleTimestamp},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify(tt.secret, tt.signature, tt.timestamp, tt.body, 5*time.Minute, tt.now)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
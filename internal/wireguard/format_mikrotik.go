@@ -0,0 +1,32 @@
+package wireguard
+
+import (
+	"fmt"
+	"strings"
+)
+
+// renderMikroTik renders a RouterOS v7 script that can be imported with
+// "/import file-name=<name>.rsc".
+func renderMikroTik(data ConfigData) (string, error) {
+	name := configFileBaseName(data)
+	var config strings.Builder
+
+	config.WriteString("/interface wireguard\n")
+	config.WriteString(fmt.Sprintf("add name=%s private-key=\"%s\"\n", name, data.PrivateKey))
+
+	config.WriteString("/interface wireguard peers\n")
+	config.WriteString(fmt.Sprintf(
+		"add interface=%s public-key=\"%s\" endpoint-address=%s endpoint-port=%s allowed-address=%s persistent-keepalive=%ds\n",
+		name, data.ServerPublicKey, data.ServerAddress, data.ServerPort, clientAllowedIps, clientPersistentKeepalive,
+	))
+
+	config.WriteString("/ip address\n")
+	config.WriteString(fmt.Sprintf("add address=%s interface=%s\n", data.ClientIp, name))
+
+	if data.DNS != "" {
+		config.WriteString("/ip dns\n")
+		config.WriteString(fmt.Sprintf("set servers=%s\n", data.DNS))
+	}
+
+	return config.String(), nil
+}
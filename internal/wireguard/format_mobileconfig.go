@@ -0,0 +1,87 @@
+package wireguard
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"strings"
+)
+
+// renderMobileConfig renders an Apple configuration profile that installs the
+// tunnel into the official WireGuard app on iOS and macOS.
+func renderMobileConfig(data ConfigData) (string, error) {
+	name := configFileBaseName(data)
+	wgQuick, err := renderWgQuick(data)
+	if err != nil {
+		return "", err
+	}
+
+	var config strings.Builder
+
+	config.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	config.WriteString(`<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">` + "\n")
+	config.WriteString(`<plist version="1.0">` + "\n")
+	config.WriteString("<dict>\n")
+	config.WriteString("  <key>PayloadDisplayName</key>\n")
+	config.WriteString(fmt.Sprintf("  <string>%s</string>\n", xmlEscape(name)))
+	config.WriteString("  <key>PayloadType</key>\n")
+	config.WriteString("  <string>Configuration</string>\n")
+	config.WriteString("  <key>PayloadVersion</key>\n")
+	config.WriteString("  <integer>1</integer>\n")
+	config.WriteString("  <key>PayloadIdentifier</key>\n")
+	config.WriteString(fmt.Sprintf("  <string>com.wireguard.%s</string>\n", xmlEscape(name)))
+	config.WriteString("  <key>PayloadUUID</key>\n")
+	config.WriteString(fmt.Sprintf("  <string>%s</string>\n", stableUUID("mobileconfig", data.PublicKey)))
+	config.WriteString("  <key>PayloadContent</key>\n")
+	config.WriteString("  <array>\n")
+	config.WriteString("    <dict>\n")
+	config.WriteString("      <key>PayloadDisplayName</key>\n")
+	config.WriteString("      <string>VPN</string>\n")
+	config.WriteString("      <key>PayloadType</key>\n")
+	config.WriteString("      <string>com.apple.vpn.managed</string>\n")
+	config.WriteString("      <key>PayloadVersion</key>\n")
+	config.WriteString("      <integer>1</integer>\n")
+	config.WriteString("      <key>PayloadIdentifier</key>\n")
+	config.WriteString(fmt.Sprintf("      <string>com.wireguard.%s.vpn</string>\n", xmlEscape(name)))
+	config.WriteString("      <key>PayloadUUID</key>\n")
+	config.WriteString(fmt.Sprintf("      <string>%s</string>\n", stableUUID("mobileconfig-vpn", data.PublicKey)))
+	config.WriteString("      <key>UserDefinedName</key>\n")
+	config.WriteString(fmt.Sprintf("      <string>%s</string>\n", xmlEscape(name)))
+	config.WriteString("      <key>VPNType</key>\n")
+	config.WriteString("      <string>VPN</string>\n")
+	config.WriteString("      <key>VPNSubType</key>\n")
+	config.WriteString("      <string>com.wireguard.ios</string>\n")
+	config.WriteString("      <key>VendorConfig</key>\n")
+	config.WriteString("      <dict>\n")
+	config.WriteString("        <key>WgQuickConfig</key>\n")
+	config.WriteString(fmt.Sprintf("        <string>%s</string>\n", xmlEscape(wgQuick)))
+	config.WriteString("      </dict>\n")
+	config.WriteString("      <key>VPN</key>\n")
+	config.WriteString("      <dict>\n")
+	config.WriteString("        <key>RemoteAddress</key>\n")
+	config.WriteString(fmt.Sprintf("        <string>%s</string>\n", xmlEscape(data.ServerEndpoint)))
+	config.WriteString("        <key>AuthenticationMethod</key>\n")
+	config.WriteString("        <string>Password</string>\n")
+	config.WriteString("      </dict>\n")
+	config.WriteString("    </dict>\n")
+	config.WriteString("  </array>\n")
+	config.WriteString("</dict>\n")
+	config.WriteString("</plist>\n")
+
+	return config.String(), nil
+}
+
+var xmlEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;", "'", "&apos;")
+
+func xmlEscape(value string) string {
+	return xmlEscaper.Replace(value)
+}
+
+// stableUUID derives a name-based UUID so that re-exporting the same peer
+// replaces the existing profile instead of installing a duplicate.
+func stableUUID(namespace, name string) string {
+	sum := sha1.Sum([]byte(namespace + ":" + name))
+	sum[6] = (sum[6] & 0x0f) | 0x50
+	sum[8] = (sum[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%X-%X-%X-%X-%X", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
@@ -0,0 +1,42 @@
+package wireguard
+
+import (
+	"fmt"
+	"strings"
+)
+
+// renderNetworkManager renders a NetworkManager keyfile, suitable for
+// /etc/NetworkManager/system-connections/<name>.nmconnection.
+func renderNetworkManager(data ConfigData) (string, error) {
+	var config strings.Builder
+
+	config.WriteString("[connection]\n")
+	config.WriteString(fmt.Sprintf("id=%s\n", configFileBaseName(data)))
+	config.WriteString(fmt.Sprintf("uuid=%s\n", stableUUID("networkmanager", data.PublicKey)))
+	config.WriteString("type=wireguard\n")
+	config.WriteString(fmt.Sprintf("interface-name=%s\n", clientInterfaceName))
+	config.WriteString("\n")
+
+	config.WriteString("[wireguard]\n")
+	config.WriteString(fmt.Sprintf("private-key=%s\n", data.PrivateKey))
+	config.WriteString("\n")
+
+	config.WriteString(fmt.Sprintf("[wireguard-peer.%s]\n", data.ServerPublicKey))
+	config.WriteString(fmt.Sprintf("endpoint=%s\n", data.ServerEndpoint))
+	config.WriteString(fmt.Sprintf("persistent-keepalive=%d\n", clientPersistentKeepalive))
+	config.WriteString(fmt.Sprintf("allowed-ips=%s;\n", clientAllowedIps))
+	config.WriteString("\n")
+
+	config.WriteString("[ipv4]\n")
+	config.WriteString(fmt.Sprintf("address1=%s\n", data.ClientIp))
+	if data.DNS != "" {
+		config.WriteString(fmt.Sprintf("dns=%s;\n", data.DNS))
+	}
+	config.WriteString("method=manual\n")
+	config.WriteString("\n")
+
+	config.WriteString("[ipv6]\n")
+	config.WriteString("method=disabled\n")
+
+	return config.String(), nil
+}
@@ -0,0 +1,31 @@
+package wireguard
+
+import (
+	"fmt"
+	"strings"
+)
+
+// renderOpenWrt renders a section for /etc/config/network on OpenWrt.
+func renderOpenWrt(data ConfigData) (string, error) {
+	var config strings.Builder
+
+	config.WriteString(fmt.Sprintf("config interface '%s'\n", clientInterfaceName))
+	config.WriteString("\toption proto 'wireguard'\n")
+	config.WriteString(fmt.Sprintf("\toption private_key '%s'\n", data.PrivateKey))
+	config.WriteString(fmt.Sprintf("\tlist addresses '%s'\n", data.ClientIp))
+	if data.DNS != "" {
+		config.WriteString(fmt.Sprintf("\tlist dns '%s'\n", data.DNS))
+	}
+	config.WriteString("\n")
+
+	config.WriteString(fmt.Sprintf("config wireguard_%s\n", clientInterfaceName))
+	config.WriteString(fmt.Sprintf("\toption description '%s'\n", configFileBaseName(data)))
+	config.WriteString(fmt.Sprintf("\toption public_key '%s'\n", data.ServerPublicKey))
+	config.WriteString(fmt.Sprintf("\toption endpoint_host '%s'\n", data.ServerAddress))
+	config.WriteString(fmt.Sprintf("\toption endpoint_port '%s'\n", data.ServerPort))
+	config.WriteString(fmt.Sprintf("\toption persistent_keepalive '%d'\n", clientPersistentKeepalive))
+	config.WriteString("\toption route_allowed_ips '1'\n")
+	config.WriteString(fmt.Sprintf("\tlist allowed_ips '%s'\n", clientAllowedIps))
+
+	return config.String(), nil
+}
@@ -0,0 +1,148 @@
+package wireguard
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	clientInterfaceName       = "wg0"
+	clientAllowedIps          = "0.0.0.0/0"
+	clientPersistentKeepalive = 25
+)
+
+type ConfigData struct {
+	PrivateKey      string
+	PublicKey       string
+	ServerPublicKey string
+	ServerEndpoint  string
+	ServerAddress   string
+	ServerPort      string
+	ClientIp        string
+	DNS             string
+}
+
+type ConfigFormat int
+
+const (
+	FormatWgQuick ConfigFormat = iota
+	FormatNetworkManager
+	FormatMobileConfig
+	FormatOpenWrt
+	FormatMikroTik
+	FormatJSON
+)
+
+type ExportedConfig struct {
+	Format      ConfigFormat
+	Content     string
+	FileName    string
+	ContentType string
+}
+
+type configRenderer struct {
+	extension   string
+	contentType string
+	render      func(data ConfigData) (string, error)
+}
+
+var configRenderers = map[ConfigFormat]configRenderer{
+	FormatWgQuick:        {extension: "conf", contentType: "text/plain", render: renderWgQuick},
+	FormatNetworkManager: {extension: "nmconnection", contentType: "text/plain", render: renderNetworkManager},
+	FormatMobileConfig:   {extension: "mobileconfig", contentType: "application/x-apple-aspen-config", render: renderMobileConfig},
+	FormatOpenWrt:        {extension: "uci", contentType: "text/plain", render: renderOpenWrt},
+	FormatMikroTik:       {extension: "rsc", contentType: "text/plain", render: renderMikroTik},
+	FormatJSON:           {extension: "json", contentType: "application/json", render: renderJSON},
+}
+
+// RenderConfig renders the client config described by data in the requested
+// client format.
+func RenderConfig(format ConfigFormat, data ConfigData) (*ExportedConfig, error) {
+	renderer, ok := configRenderers[format]
+	if !ok {
+		return nil, fmt.Errorf("unsupported config format %d", format)
+	}
+
+	content, err := renderer.render(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ExportedConfig{
+		Format:      format,
+		Content:     content,
+		FileName:    fmt.Sprintf("%s.%s", configFileBaseName(data), renderer.extension),
+		ContentType: renderer.contentType,
+	}, nil
+}
+
+func configFileBaseName(data ConfigData) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-':
+			return r
+		case r == '.' || r == '_':
+			return '-'
+		}
+		return -1
+	}, data.ServerAddress)
+
+	if name == "" {
+		return "wireguard"
+	}
+	return "wg-" + name
+}
+
+func renderWgQuick(data ConfigData) (string, error) {
+	return GenerateClientConfig(data.PrivateKey, data.ServerPublicKey, data.ServerEndpoint, data.ClientIp, data.DNS), nil
+}
+
+type jsonConfig struct {
+	Interface jsonInterface `json:"interface"`
+	Peer      jsonPeer      `json:"peer"`
+}
+
+type jsonInterface struct {
+	PrivateKey string   `json:"private_key"`
+	PublicKey  string   `json:"public_key"`
+	Addresses  []string `json:"addresses"`
+	DNS        []string `json:"dns,omitempty"`
+}
+
+type jsonPeer struct {
+	PublicKey           string   `json:"public_key"`
+	Endpoint            string   `json:"endpoint"`
+	EndpointHost        string   `json:"endpoint_host"`
+	EndpointPort        string   `json:"endpoint_port"`
+	AllowedIps          []string `json:"allowed_ips"`
+	PersistentKeepalive int      `json:"persistent_keepalive"`
+}
+
+func renderJSON(data ConfigData) (string, error) {
+	config := jsonConfig{
+		Interface: jsonInterface{
+			PrivateKey: data.PrivateKey,
+			PublicKey:  data.PublicKey,
+			Addresses:  []string{data.ClientIp},
+		},
+		Peer: jsonPeer{
+			PublicKey:           data.ServerPublicKey,
+			Endpoint:            data.ServerEndpoint,
+			EndpointHost:        data.ServerAddress,
+			EndpointPort:        data.ServerPort,
+			AllowedIps:          []string{clientAllowedIps},
+			PersistentKeepalive: clientPersistentKeepalive,
+		},
+	}
+
+	if data.DNS != "" {
+		config.Interface.DNS = []string{data.DNS}
+	}
+
+	content, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(content) + "\n", nil
+}
@@ -0,0 +1,162 @@
+package wireguard
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func testConfigData(t *testing.T) ConfigData {
+	t.Helper()
+	privateKey, publicKey := mustKeyPair(t)
+	_, serverKey := mustKeyPair(t)
+
+	return ConfigData{
+		PrivateKey:      privateKey,
+		PublicKey:       publicKey,
+		ServerPublicKey: serverKey,
+		ServerEndpoint:  "vpn.example.com:51820",
+		ServerAddress:   "vpn.example.com",
+		ServerPort:      "51820",
+		ClientIp:        "10.0.0.2/32",
+		DNS:             "1.1.1.1",
+	}
+}
+
+func TestRenderConfig(t *testing.T) {
+	data := testConfigData(t)
+
+	tests := []struct {
+		format      ConfigFormat
+		fileName    string
+		contentType string
+		contains    []string
+		// check parses the output the way its consumer would.
+		check func(t *testing.T, content string)
+	}{
+		{
+			format:      FormatWgQuick,
+			fileName:    "wg-vpn-example-com.conf",
+			contentType: "text/plain",
+			contains:    []string{"PrivateKey = " + data.PrivateKey, "PublicKey = " + data.ServerPublicKey, "Endpoint = vpn.example.com:51820", "Address = 10.0.0.2/32", "DNS = 1.1.1.1"},
+			check: func(t *testing.T, content string) {
+				if _, err := ParseConfigString(content); err != nil {
+					t.Error(err)
+				}
+			},
+		},
+		{
+			format:      FormatNetworkManager,
+			fileName:    "wg-vpn-example-com.nmconnection",
+			contentType: "text/plain",
+			contains:    []string{"type=wireguard", "private-key=" + data.PrivateKey, "[wireguard-peer." + data.ServerPublicKey + "]", "endpoint=vpn.example.com:51820", "address1=10.0.0.2/32", "dns=1.1.1.1;"},
+		},
+		{
+			format:      FormatMobileConfig,
+			fileName:    "wg-vpn-example-com.mobileconfig",
+			contentType: "application/x-apple-aspen-config",
+			contains:    []string{"<string>com.wireguard.ios</string>", "PrivateKey = " + data.PrivateKey, "<string>vpn.example.com:51820</string>"},
+			check: func(t *testing.T, content string) {
+				decoder := xml.NewDecoder(strings.NewReader(content))
+				for {
+					_, err := decoder.Token()
+					if errors.Is(err, io.EOF) {
+						return
+					}
+					if err != nil {
+						t.Fatalf("profile is not well-formed XML: %v", err)
+					}
+				}
+			},
+		},
+		{
+			format:      FormatOpenWrt,
+			fileName:    "wg-vpn-example-com.uci",
+			contentType: "text/plain",
+			contains:    []string{"option proto 'wireguard'", "option private_key '" + data.PrivateKey + "'", "option endpoint_host 'vpn.example.com'", "option endpoint_port '51820'", "list addresses '10.0.0.2/32'"},
+		},
+		{
+			format:      FormatMikroTik,
+			fileName:    "wg-vpn-example-com.rsc",
+			contentType: "text/plain",
+			contains:    []string{"private-key=\"" + data.PrivateKey + "\"", "endpoint-address=vpn.example.com endpoint-port=51820", "add address=10.0.0.2/32", "set servers=1.1.1.1"},
+		},
+		{
+			format:      FormatJSON,
+			fileName:    "wg-vpn-example-com.json",
+			contentType: "application/json",
+			check: func(t *testing.T, content string) {
+				var config jsonConfig
+				if err := json.Unmarshal([]byte(content), &config); err != nil {
+					t.Fatal(err)
+				}
+				if config.Interface.PrivateKey != data.PrivateKey || config.Peer.EndpointPort != "51820" || config.Interface.DNS[0] != "1.1.1.1" {
+					t.Errorf("decoded %+v", config)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fileName, func(t *testing.T) {
+			exported, err := RenderConfig(tt.format, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if exported.FileName != tt.fileName || exported.ContentType != tt.contentType || exported.Format != tt.format {
+				t.Errorf("got %s (%s, format %d), want %s (%s, format %d)",
+					exported.FileName, exported.ContentType, exported.Format, tt.fileName, tt.contentType, tt.format)
+			}
+			for _, want := range tt.contains {
+				if !strings.Contains(exported.Content, want) {
+					t.Errorf("content does not contain %q:\n%s", want, exported.Content)
+				}
+			}
+			if tt.check != nil {
+				tt.check(t, exported.Content)
+			}
+		})
+	}
+}
+
+func TestRenderConfigWithoutDNS(t *testing.T) {
+	data := testConfigData(t)
+	data.DNS = ""
+
+	for format := range configRenderers {
+		exported, err := RenderConfig(format, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(strings.ToLower(exported.Content), "dns") {
+			t.Errorf("%s mentions DNS although none is set:\n%s", exported.FileName, exported.Content)
+		}
+	}
+}
+
+func TestRenderConfigUnknownFormat(t *testing.T) {
+	if _, err := RenderConfig(ConfigFormat(99), testConfigData(t)); err == nil {
+		t.Error("rendered an unknown format")
+	}
+}
+
+func TestConfigFileBaseName(t *testing.T) {
+	tests := []struct{ address, want string }{
+		{"vpn.example.com", "wg-vpn-example-com"},
+		{"203.0.113.7", "wg-203-0-113-7"},
+		{"2001:db8::1", "wg-2001db81"},
+		{"under_score", "wg-under-score"},
+		{"", "wireguard"},
+		{"::", "wireguard"},
+	}
+
+	for _, tt := range tests {
+		if got := configFileBaseName(ConfigData{ServerAddress: tt.address}); got != tt.want {
+			t.Errorf("%q: got %q, want %q", tt.address, got, tt.want)
+		}
+	}
+}
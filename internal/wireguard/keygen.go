@@ -58,3 +58,15 @@ func ValidateKeyPair(privateKeyBase64, publicKeyBase64 string) (bool, error) {
 	}
 	return true, nil
 }
+
+func ValidateKey(keyBase64 string) error {
+	key, err := base64.StdEncoding.DecodeString(keyBase64)
+	if err != nil {
+		return errors.New("key is not valid base64")
+	}
+
+	if len(key) != 32 {
+		return errors.New("invalid key length")
+	}
+	return nil
+}
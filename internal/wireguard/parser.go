@@ -0,0 +1,448 @@
+package wireguard
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+// Config is a parsed wg-quick configuration file.
+type Config struct {
+	Interface InterfaceSection
+	Peers     []PeerSection
+}
+
+type InterfaceSection struct {
+	PrivateKey string
+	Address    []string
+	ListenPort int
+	DNS        []string
+	MTU        int
+	Table      string
+	FwMark     string
+	PreUp      []string
+	PostUp     []string
+	PreDown    []string
+	PostDown   []string
+	SaveConfig bool
+}
+
+type PeerSection struct {
+	PublicKey           string
+	PresharedKey        string
+	AllowedIPs          []string
+	Endpoint            string
+	PersistentKeepalive int
+}
+
+// ParseError reports the line of the config that could not be parsed.
+type ParseError struct {
+	Line int
+	Err  error
+}
+
+func (e *ParseError) Error() string {
+	if e.Line == 0 {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+const (
+	sectionNone = iota
+	sectionInterface
+	sectionPeer
+)
+
+// ParseConfig reads a wg-quick config. Section and key names are matched
+// case-insensitively, "#" starts a comment, and list keys (Address, DNS,
+// AllowedIPs) may be comma separated and/or repeated.
+func ParseConfig(r io.Reader) (*Config, error) {
+	config := &Config{}
+	section := sectionNone
+	interfaceLine := 0
+	var peer *PeerSection
+	peerLine := 0
+	var peerLines []int
+	seen := make(map[string]bool)
+
+	finishPeer := func() error {
+		if peer == nil {
+			return nil
+		}
+		if peer.PublicKey == "" {
+			return &ParseError{Line: peerLine, Err: errors.New("peer is missing PublicKey")}
+		}
+		config.Peers = append(config.Peers, *peer)
+		peerLines = append(peerLines, peerLine)
+		peer = nil
+		return nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+
+	for scanner.Scan() {
+		lineNumber++
+		line := scanner.Text()
+
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				return nil, &ParseError{Line: lineNumber, Err: fmt.Errorf("malformed section header %q", line)}
+			}
+
+			if err := finishPeer(); err != nil {
+				return nil, err
+			}
+			seen = make(map[string]bool)
+
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			switch strings.ToLower(name) {
+			case "interface":
+				if interfaceLine != 0 {
+					return nil, &ParseError{Line: lineNumber, Err: fmt.Errorf("duplicate [Interface] section, first defined on line %d", interfaceLine)}
+				}
+				section = sectionInterface
+				interfaceLine = lineNumber
+			case "peer":
+				section = sectionPeer
+				peer = &PeerSection{}
+				peerLine = lineNumber
+			default:
+				return nil, &ParseError{Line: lineNumber, Err: fmt.Errorf("unknown section [%s]", name)}
+			}
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, &ParseError{Line: lineNumber, Err: fmt.Errorf("expected key = value, got %q", line)}
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		if key == "" {
+			return nil, &ParseError{Line: lineNumber, Err: errors.New("missing key name")}
+		}
+
+		var err error
+		switch section {
+		case sectionInterface:
+			err = setInterfaceKey(&config.Interface, key, value, seen)
+		case sectionPeer:
+			err = setPeerKey(peer, key, value, seen)
+		default:
+			err = fmt.Errorf("key %q outside of a section", key)
+		}
+
+		if err != nil {
+			return nil, &ParseError{Line: lineNumber, Err: err}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := finishPeer(); err != nil {
+		return nil, err
+	}
+
+	if interfaceLine == 0 {
+		return nil, &ParseError{Err: errors.New("missing [Interface] section")}
+	}
+
+	if config.Interface.PrivateKey == "" {
+		return nil, &ParseError{Line: interfaceLine, Err: errors.New("interface is missing PrivateKey")}
+	}
+
+	// Peers are keyed by line of their [Peer] header.
+	publicKeys := make(map[string]int)
+	for i, p := range config.Peers {
+		if first, ok := publicKeys[p.PublicKey]; ok {
+			return nil, &ParseError{Line: peerLines[i], Err: fmt.Errorf("duplicate peer with PublicKey %s, first defined on line %d", p.PublicKey, first)}
+		}
+		publicKeys[p.PublicKey] = peerLines[i]
+	}
+
+	return config, nil
+}
+
+func ParseConfigString(config string) (*Config, error) {
+	return ParseConfig(strings.NewReader(config))
+}
+
+func setInterfaceKey(iface *InterfaceSection, key, value string, seen map[string]bool) error {
+	if isSingleValueKey(key) {
+		if seen[key] {
+			return fmt.Errorf("duplicate key %s", keyNames[key])
+		}
+		seen[key] = true
+	}
+
+	var err error
+	switch key {
+	case "privatekey":
+		if err = ValidateKey(value); err == nil {
+			iface.PrivateKey = value
+		}
+	case "address":
+		iface.Address, err = appendList(iface.Address, value, validatePrefix)
+	case "dns":
+		iface.DNS, err = appendList(iface.DNS, value, validateDNS)
+	case "listenport":
+		iface.ListenPort, err = parsePort(value)
+	case "mtu":
+		iface.MTU, err = parseIntRange(value, 576, 65535)
+	case "table":
+		iface.Table = value
+	case "fwmark":
+		iface.FwMark = value
+	case "preup":
+		iface.PreUp = append(iface.PreUp, value)
+	case "postup":
+		iface.PostUp = append(iface.PostUp, value)
+	case "predown":
+		iface.PreDown = append(iface.PreDown, value)
+	case "postdown":
+		iface.PostDown = append(iface.PostDown, value)
+	case "saveconfig":
+		iface.SaveConfig, err = strconv.ParseBool(value)
+	default:
+		return fmt.Errorf("unknown key %q in [Interface]", key)
+	}
+
+	if err != nil {
+		return fmt.Errorf("%s: %v", keyNames[key], err)
+	}
+	return nil
+}
+
+func setPeerKey(peer *PeerSection, key, value string, seen map[string]bool) error {
+	if isSingleValueKey(key) {
+		if seen[key] {
+			return fmt.Errorf("duplicate key %s", keyNames[key])
+		}
+		seen[key] = true
+	}
+
+	var err error
+	switch key {
+	case "publickey":
+		if err = ValidateKey(value); err == nil {
+			peer.PublicKey = value
+		}
+	case "presharedkey":
+		if err = ValidateKey(value); err == nil {
+			peer.PresharedKey = value
+		}
+	case "allowedips":
+		peer.AllowedIPs, err = appendList(peer.AllowedIPs, value, validatePrefix)
+	case "endpoint":
+		if err = ValidateEndpoint(value); err == nil {
+			peer.Endpoint = value
+		}
+	case "persistentkeepalive":
+		if strings.EqualFold(value, "off") {
+			peer.PersistentKeepalive = 0
+		} else {
+			peer.PersistentKeepalive, err = parseIntRange(value, 0, 65535)
+		}
+	default:
+		return fmt.Errorf("unknown key %q in [Peer]", key)
+	}
+
+	if err != nil {
+		return fmt.Errorf("%s: %v", keyNames[key], err)
+	}
+	return nil
+}
+
+var keyNames = map[string]string{
+	"privatekey":          "PrivateKey",
+	"address":             "Address",
+	"dns":                 "DNS",
+	"listenport":          "ListenPort",
+	"mtu":                 "MTU",
+	"table":               "Table",
+	"fwmark":              "FwMark",
+	"preup":               "PreUp",
+	"postup":              "PostUp",
+	"predown":             "PreDown",
+	"postdown":            "PostDown",
+	"saveconfig":          "SaveConfig",
+	"publickey":           "PublicKey",
+	"presharedkey":        "PresharedKey",
+	"allowedips":          "AllowedIPs",
+	"endpoint":            "Endpoint",
+	"persistentkeepalive": "PersistentKeepalive",
+}
+
+func isSingleValueKey(key string) bool {
+	switch key {
+	case "address", "dns", "allowedips", "preup", "postup", "predown", "postdown":
+		return false
+	}
+	return true
+}
+
+func appendList(list []string, value string, validate func(string) error) ([]string, error) {
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if err := validate(item); err != nil {
+			return nil, err
+		}
+		list = append(list, item)
+	}
+	return list, nil
+}
+
+func validatePrefix(value string) error {
+	if _, err := netip.ParsePrefix(value); err == nil {
+		return nil
+	}
+
+	// wg-quick accepts a bare address and treats it as a host route.
+	if _, err := netip.ParseAddr(value); err == nil {
+		return nil
+	}
+	return fmt.Errorf("invalid CIDR %q", value)
+}
+
+func validateDNS(value string) error {
+	if _, err := netip.ParseAddr(value); err == nil {
+		return nil
+	}
+
+	// Anything that is not an address is used by wg-quick as a search domain.
+	if strings.ContainsAny(value, " \t/:") {
+		return fmt.Errorf("invalid DNS server or search domain %q", value)
+	}
+	return nil
+}
+
+// ValidateEndpoint checks that endpoint is a host:port pair with a valid
+// port. IPv6 hosts must be written in brackets.
+func ValidateEndpoint(endpoint string) error {
+	_, _, err := SplitEndpoint(endpoint)
+	return err
+}
+
+// SplitEndpoint checks endpoint like ValidateEndpoint and splits it. An IPv6
+// host is returned without brackets.
+func SplitEndpoint(endpoint string) (host, port string, err error) {
+	host, port, err = net.SplitHostPort(endpoint)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid endpoint %q: %v", endpoint, err)
+	}
+
+	if host == "" {
+		return "", "", fmt.Errorf("invalid endpoint %q: missing host", endpoint)
+	}
+
+	if _, err := parsePort(port); err != nil {
+		return "", "", fmt.Errorf("invalid endpoint %q: %v", endpoint, err)
+	}
+	return host, port, nil
+}
+
+func parsePort(value string) (int, error) {
+	return parseIntRange(value, 1, 65535)
+}
+
+func parseIntRange(value string, min, max int) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a number", value)
+	}
+
+	if n < min || n > max {
+		return 0, fmt.Errorf("%d is out of range [%d, %d]", n, min, max)
+	}
+	return n, nil
+}
+
+// String renders the config in wg-quick format. The output parses back to an
+// identical Config.
+func (c *Config) String() string {
+	var config strings.Builder
+
+	iface := c.Interface
+	config.WriteString("[Interface]\n")
+	config.WriteString(fmt.Sprintf("PrivateKey = %s\n", iface.PrivateKey))
+
+	if len(iface.Address) > 0 {
+		config.WriteString(fmt.Sprintf("Address = %s\n", strings.Join(iface.Address, ", ")))
+	}
+	if iface.ListenPort != 0 {
+		config.WriteString(fmt.Sprintf("ListenPort = %d\n", iface.ListenPort))
+	}
+	if len(iface.DNS) > 0 {
+		config.WriteString(fmt.Sprintf("DNS = %s\n", strings.Join(iface.DNS, ", ")))
+	}
+	if iface.MTU != 0 {
+		config.WriteString(fmt.Sprintf("MTU = %d\n", iface.MTU))
+	}
+	if iface.Table != "" {
+		config.WriteString(fmt.Sprintf("Table = %s\n", iface.Table))
+	}
+	if iface.FwMark != "" {
+		config.WriteString(fmt.Sprintf("FwMark = %s\n", iface.FwMark))
+	}
+	for _, cmd := range iface.PreUp {
+		config.WriteString(fmt.Sprintf("PreUp = %s\n", cmd))
+	}
+	for _, cmd := range iface.PostUp {
+		config.WriteString(fmt.Sprintf("PostUp = %s\n", cmd))
+	}
+	for _, cmd := range iface.PreDown {
+		config.WriteString(fmt.Sprintf("PreDown = %s\n", cmd))
+	}
+	for _, cmd := range iface.PostDown {
+		config.WriteString(fmt.Sprintf("PostDown = %s\n", cmd))
+	}
+	if iface.SaveConfig {
+		config.WriteString("SaveConfig = true\n")
+	}
+
+	for _, peer := range c.Peers {
+		config.WriteString("\n[Peer]\n")
+		config.WriteString(fmt.Sprintf("PublicKey = %s\n", peer.PublicKey))
+
+		if peer.PresharedKey != "" {
+			config.WriteString(fmt.Sprintf("PresharedKey = %s\n", peer.PresharedKey))
+		}
+		if len(peer.AllowedIPs) > 0 {
+			config.WriteString(fmt.Sprintf("AllowedIPs = %s\n", strings.Join(peer.AllowedIPs, ", ")))
+		}
+		if peer.Endpoint != "" {
+			config.WriteString(fmt.Sprintf("Endpoint = %s\n", peer.Endpoint))
+		}
+		if peer.PersistentKeepalive != 0 {
+			config.WriteString(fmt.Sprintf("PersistentKeepalive = %d\n", peer.PersistentKeepalive))
+		}
+	}
+
+	return config.String()
+}
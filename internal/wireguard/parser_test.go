@@ -0,0 +1,146 @@
+package wireguard
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func mustKeyPair(t testing.TB) (string, string) {
+	t.Helper()
+	privateKey, publicKey, err := GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return privateKey, publicKey
+}
+
+// roundTrip parses config, renders the result and parses that again, and
+// fails unless both parses agree.
+func roundTrip(t *testing.T, config string) *Config {
+	t.Helper()
+
+	parsed, err := ParseConfigString(config)
+	if err != nil {
+		t.Fatalf("parse: %v\n%s", err, config)
+	}
+
+	reparsed, err := ParseConfigString(parsed.String())
+	if err != nil {
+		t.Fatalf("parse of String(): %v\n%s", err, parsed.String())
+	}
+	if !reflect.DeepEqual(parsed, reparsed) {
+		t.Fatalf("round trip changed the config:\n%+v\n%+v", parsed, reparsed)
+	}
+	return parsed
+}
+
+func TestClientConfigRoundTrip(t *testing.T) {
+	clientKey, _ := mustKeyPair(t)
+	_, serverKey := mustKeyPair(t)
+
+	config := roundTrip(t, GenerateClientConfig(clientKey, serverKey, "vpn.example.com:51820", "10.0.0.2/32", "1.1.1.1"))
+
+	want := &Config{
+		Interface: InterfaceSection{PrivateKey: clientKey, Address: []string{"10.0.0.2/32"}, DNS: []string{"1.1.1.1"}},
+		Peers: []PeerSection{{
+			PublicKey:           serverKey,
+			AllowedIPs:          []string{"0.0.0.0/0"},
+			Endpoint:            "vpn.example.com:51820",
+			PersistentKeepalive: 25,
+		}},
+	}
+	if !reflect.DeepEqual(config, want) {
+		t.Errorf("got %+v, want %+v", config, want)
+	}
+}
+
+func TestServerConfigRoundTrip(t *testing.T) {
+	serverKey, _ := mustKeyPair(t)
+	_, firstKey := mustKeyPair(t)
+	_, secondKey := mustKeyPair(t)
+
+	config := roundTrip(t, GenerateServerConfig(serverKey, "10.0.0.1/24", 51820, []PeerConfig{
+		{PublicKey: firstKey, AllowedIps: "10.0.0.2/32"},
+		{PublicKey: secondKey, AllowedIps: "10.0.0.3/32"},
+	}))
+
+	if config.Interface.PrivateKey != serverKey || config.Interface.ListenPort != 51820 {
+		t.Errorf("interface is %+v", config.Interface)
+	}
+	if len(config.Interface.PostUp) != 1 || len(config.Interface.PostDown) != 1 {
+		t.Errorf("got %d PostUp and %d PostDown commands, want 1 each", len(config.Interface.PostUp), len(config.Interface.PostDown))
+	}
+	if len(config.Peers) != 2 || config.Peers[0].PublicKey != firstKey || config.Peers[1].AllowedIPs[0] != "10.0.0.3/32" {
+		t.Errorf("peers are %+v", config.Peers)
+	}
+}
+
+func TestParseConfigErrors(t *testing.T) {
+	privateKey, publicKey := mustKeyPair(t)
+
+	tests := []struct {
+		name   string
+		config string
+		line   int
+		want   string
+	}{
+		{"missing interface", "[Peer]\nPublicKey = " + publicKey + "\n", 0, "missing [Interface] section"},
+		{"unknown section", "[Interface]\nPrivateKey = " + privateKey + "\n[Foo]\n", 3, "unknown section [Foo]"},
+		{"bad key", "[Interface]\nPrivateKey = nope\n", 2, "PrivateKey"},
+		{"duplicate key", "[Interface]\nPrivateKey = " + privateKey + "\nListenPort = 1\nListenPort = 2\n", 4, "duplicate key ListenPort"},
+		{"peer without key", "[Interface]\nPrivateKey = " + privateKey + "\n\n[Peer]\nEndpoint = a:1\n", 4, "peer is missing PublicKey"},
+		{
+			"duplicate peer",
+			"[Interface]\nPrivateKey = " + privateKey + "\n\n[Peer]\nPublicKey = " + publicKey + "\n\n[Peer]\nPublicKey = " + publicKey + "\n",
+			7,
+			"first defined on line 4",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseConfigString(tt.config)
+
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("got error %v, want a ParseError", err)
+			}
+			if parseErr.Line != tt.line {
+				t.Errorf("error is on line %d, want %d: %v", parseErr.Line, tt.line, err)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error %q does not mention %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func FuzzParseConfig(f *testing.F) {
+	clientKey, clientPublicKey := mustKeyPair(f)
+	serverKey, serverPublicKey := mustKeyPair(f)
+
+	f.Add(GenerateClientConfig(clientKey, serverPublicKey, "vpn.example.com:51820", "10.0.0.2/32", "1.1.1.1"))
+	f.Add(GenerateServerConfig(serverKey, "10.0.0.1/24", 51820, []PeerConfig{{PublicKey: clientPublicKey, AllowedIps: "10.0.0.2/32"}}))
+	f.Add("[interface]\nprivatekey=" + clientKey + " # comment\nAddress = 10.0.0.2, fd00::2/128\nAddress = 10.1.0.2\nDNS = 1.1.1.1, example.com\nMTU = 1420\nTable = off\nSaveConfig = true\n")
+	f.Add("[Interface]\nPrivateKey = " + clientKey + "\n[Peer]\nPublicKey = " + serverPublicKey + "\nEndpoint = [::1]:51820\nPersistentKeepalive = off\nPresharedKey = " + serverKey + "\n")
+	f.Add("[Interface\n")
+	f.Add("Key = value\n")
+	f.Add("")
+
+	f.Fuzz(func(t *testing.T, config string) {
+		parsed, err := ParseConfigString(config)
+		if err != nil {
+			return
+		}
+
+		reparsed, err := ParseConfigString(parsed.String())
+		if err != nil {
+			t.Fatalf("parse of String(): %v\n%s", err, parsed.String())
+		}
+		if !reflect.DeepEqual(parsed, reparsed) {
+			t.Fatalf("round trip changed the config:\n%+v\n%+v", parsed, reparsed)
+		}
+	})
+}
@@ -2,22 +2,193 @@ package wireguard
 
 import (
 	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
 
 	"github.com/skip2/go-qrcode"
 )
 
-func GeneateQRCode(config string) (string, error) {
-	qr, err := qrcode.New(config, qrcode.Medium)
+type QRCodeFormat int
+
+const (
+	QRCodeFormatPNG QRCodeFormat = iota
+	QRCodeFormatSVG
+	QRCodeFormatTerminal
+)
+
+type QRRecoveryLevel int
+
+const (
+	QRRecoveryDefault QRRecoveryLevel = iota
+	QRRecoveryLow
+	QRRecoveryMedium
+	QRRecoveryQuartile
+	QRRecoveryHigh
+)
+
+const (
+	defaultQRCodeSize = 256
+	MaxQRCodeSize     = 4096
+
+	// Below this many pixels per module phone cameras start to misread
+	// dense codes, so the image is enlarged instead.
+	minQRModulePixels = 4
+)
+
+var ErrQRCodeTooLarge = errors.New("config is too large to encode as a QR code")
+
+type QRCodeOptions struct {
+	// Size is the width and height in pixels. Zero means 256. It is
+	// increased automatically when the code has too many modules to be
+	// readable at the requested size. Ignored for terminal output.
+	Size          int
+	RecoveryLevel QRRecoveryLevel
+	Format        QRCodeFormat
+}
+
+type QRCode struct {
+	Data        []byte
+	ContentType string
+	Format      QRCodeFormat
+	Size        int
+	Version     int
+}
+
+var qrRecoveryLevels = map[QRRecoveryLevel]qrcode.RecoveryLevel{
+	QRRecoveryDefault:  qrcode.Medium,
+	QRRecoveryLow:      qrcode.Low,
+	QRRecoveryMedium:   qrcode.Medium,
+	QRRecoveryQuartile: qrcode.High,
+	QRRecoveryHigh:     qrcode.Highest,
+}
+
+// Byte mode capacity of a version 40 symbol for each recovery level.
+var qrByteCapacity = map[qrcode.RecoveryLevel]int{
+	qrcode.Low:     2953,
+	qrcode.Medium:  2331,
+	qrcode.High:    1663,
+	qrcode.Highest: 1273,
+}
+
+var qrRecoveryNames = map[qrcode.RecoveryLevel]string{
+	qrcode.Low:     "low",
+	qrcode.Medium:  "medium",
+	qrcode.High:    "quartile",
+	qrcode.Highest: "high",
+}
 
+func GeneateQRCode(config string) (string, error) {
+	qr, err := GenerateQRCode(config, QRCodeOptions{})
 	if err != nil {
 		return "", err
 	}
 
-	pngBytes, err := qr.PNG(256)
+	return base64.StdEncoding.EncodeToString(qr.Data), nil
+}
+
+func GenerateQRCode(content string, opts QRCodeOptions) (*QRCode, error) {
+	level, ok := qrRecoveryLevels[opts.RecoveryLevel]
+	if !ok {
+		return nil, fmt.Errorf("unsupported QR recovery level %d", opts.RecoveryLevel)
+	}
+
+	if opts.Size < 0 || opts.Size > MaxQRCodeSize {
+		return nil, fmt.Errorf("QR code size must be between 0 and %d pixels", MaxQRCodeSize)
+	}
+
+	if capacity := qrByteCapacity[level]; len(content) > capacity {
+		return nil, fmt.Errorf("%w: %d bytes exceeds the %d byte limit at %s recovery",
+			ErrQRCodeTooLarge, len(content), capacity, qrRecoveryNames[level])
+	}
+
+	qr, err := qrcode.New(content, level)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+
+	modules := len(qr.Bitmap())
+	size := opts.Size
+	if size == 0 {
+		size = defaultQRCodeSize
+	}
+	if size < modules*minQRModulePixels {
+		size = modules * minQRModulePixels
+	}
+
+	result := &QRCode{
+		Format:  opts.Format,
+		Size:    size,
+		Version: qr.VersionNumber,
+	}
+
+	switch opts.Format {
+	case QRCodeFormatPNG:
+		result.Data, err = qr.PNG(size)
+		result.ContentType = "image/png"
+	case QRCodeFormatSVG:
+		result.Data = renderQRCodeSVG(qr.Bitmap(), size)
+		result.ContentType = "image/svg+xml"
+	case QRCodeFormatTerminal:
+		result.Data = renderQRCodeTerminal(qr.Bitmap())
+		result.ContentType = "text/plain; charset=utf-8"
+		result.Size = modules
+	default:
+		return nil, fmt.Errorf("unsupported QR code format %d", opts.Format)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func renderQRCodeSVG(bitmap [][]bool, size int) []byte {
+	var svg strings.Builder
+	modules := len(bitmap)
+
+	svg.WriteString(fmt.Sprintf(
+		`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		size, size, modules, modules,
+	))
+	svg.WriteString(`<rect width="100%" height="100%" fill="#ffffff"/>`)
+	svg.WriteString(`<path fill="#000000" d="`)
+
+	for y, row := range bitmap {
+		for x, dark := range row {
+			if dark {
+				svg.WriteString(fmt.Sprintf("M%d %dh1v1h-1z", x, y))
+			}
+		}
+	}
+
+	svg.WriteString(`"/></svg>`)
+	svg.WriteString("\n")
+	return []byte(svg.String())
+}
+
+// renderQRCodeTerminal draws two character cells per module using ANSI
+// background colours, which keeps the code square in most terminal fonts.
+func renderQRCodeTerminal(bitmap [][]bool) []byte {
+	const (
+		black = "\x1b[40m  "
+		white = "\x1b[47m  "
+		reset = "\x1b[0m"
+	)
+
+	var text strings.Builder
+
+	for _, row := range bitmap {
+		for _, dark := range row {
+			if dark {
+				text.WriteString(black)
+			} else {
+				text.WriteString(white)
+			}
+		}
+		text.WriteString(reset)
+		text.WriteString("\n")
 	}
 
-	base64String := base64.StdEncoding.EncodeToString(pngBytes)
-	return base64String, nil
+	return []byte(text.String())
 }
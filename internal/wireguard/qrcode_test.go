@@ -0,0 +1,112 @@
+package wireguard
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestGenerateQRCodeFormats(t *testing.T) {
+	config := GenerateClientConfig("private", "public", "vpn.example.com:51820", "10.0.0.2/32", "1.1.1.1")
+
+	tests := []struct {
+		format      QRCodeFormat
+		contentType string
+		prefix      []byte
+	}{
+		{QRCodeFormatPNG, "image/png", []byte("\x89PNG\r\n\x1a\n")},
+		{QRCodeFormatSVG, "image/svg+xml", []byte("<svg ")},
+		{QRCodeFormatTerminal, "text/plain; charset=utf-8", []byte("\x1b[4")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.contentType, func(t *testing.T) {
+			qr, err := GenerateQRCode(config, QRCodeOptions{Format: tt.format})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if qr.ContentType != tt.contentType || qr.Format != tt.format {
+				t.Errorf("got %s format %d, want %s format %d", qr.ContentType, qr.Format, tt.contentType, tt.format)
+			}
+			if !bytes.HasPrefix(qr.Data, tt.prefix) {
+				t.Errorf("data starts with %q, want %q", qr.Data[:min(len(qr.Data), 16)], tt.prefix)
+			}
+		})
+	}
+}
+
+func TestGenerateQRCodeSize(t *testing.T) {
+	small := strings.Repeat("a", 20)
+	large := strings.Repeat("a", 2000)
+
+	qr, err := GenerateQRCode(small, QRCodeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if qr.Size != defaultQRCodeSize {
+		t.Errorf("default size is %d, want %d", qr.Size, defaultQRCodeSize)
+	}
+
+	// A dense code is enlarged so every module gets enough pixels.
+	qr, err = GenerateQRCode(large, QRCodeOptions{Size: 100, RecoveryLevel: QRRecoveryLow})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if modules := 17 + 4*qr.Version; qr.Size < modules*minQRModulePixels {
+		t.Errorf("size %d gives version %d fewer than %d pixels per module", qr.Size, qr.Version, minQRModulePixels)
+	}
+
+	qr, err = GenerateQRCode(small, QRCodeOptions{Format: QRCodeFormatTerminal})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := bytes.Count(qr.Data, []byte("\n")); lines != qr.Size {
+		t.Errorf("terminal code has %d lines, want one per module (%d)", lines, qr.Size)
+	}
+
+	for _, size := range []int{-1, MaxQRCodeSize + 1} {
+		if _, err := GenerateQRCode(small, QRCodeOptions{Size: size}); err == nil {
+			t.Errorf("size %d was accepted", size)
+		}
+	}
+}
+
+func TestGenerateQRCodeCapacity(t *testing.T) {
+	tests := []struct {
+		level    QRRecoveryLevel
+		capacity int
+	}{
+		{QRRecoveryLow, 2953},
+		{QRRecoveryDefault, 2331},
+		{QRRecoveryMedium, 2331},
+		{QRRecoveryQuartile, 1663},
+		{QRRecoveryHigh, 1273},
+	}
+
+	for _, tt := range tests {
+		// Lower case letters force byte mode, where the limits apply.
+		full := strings.Repeat("a", tt.capacity)
+
+		qr, err := GenerateQRCode(full, QRCodeOptions{RecoveryLevel: tt.level})
+		if err != nil {
+			t.Errorf("level %d: %d bytes, at capacity: %v", tt.level, tt.capacity, err)
+		} else if qr.Version != 40 {
+			t.Errorf("level %d: %d bytes used version %d, want 40", tt.level, tt.capacity, qr.Version)
+		}
+
+		_, err = GenerateQRCode(full+"a", QRCodeOptions{RecoveryLevel: tt.level})
+		if !errors.Is(err, ErrQRCodeTooLarge) {
+			t.Errorf("level %d: %d bytes: got error %v, want %v", tt.level, tt.capacity+1, err, ErrQRCodeTooLarge)
+		}
+	}
+}
+
+func TestGenerateQRCodeUnknownOptions(t *testing.T) {
+	if _, err := GenerateQRCode("config", QRCodeOptions{RecoveryLevel: 99}); err == nil {
+		t.Error("unknown recovery level was accepted")
+	}
+	if _, err := GenerateQRCode("config", QRCodeOptions{Format: 99}); err == nil {
+		t.Error("unknown format was accepted")
+	}
+}
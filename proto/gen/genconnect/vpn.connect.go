@@ -27,6 +27,12 @@ const (
 	ServerServiceName = "vpn.ServerService"
 	// ConfigServiceName is the fully-qualified name of the ConfigService service.
 	ConfigServiceName = "vpn.ConfigService"
+	// AuditServiceName is the fully-qualified name of the AuditService service.
+	AuditServiceName = "vpn.AuditService"
+	// EventServiceName is the fully-qualified name of the EventService service.
+	EventServiceName = "vpn.EventService"
+	// WebhookServiceName is the fully-qualified name of the WebhookService service.
+	WebhookServiceName = "vpn.WebhookService"
 )
 
 // These constants are the fully-qualified names of the RPCs defined in this package. They're
@@ -49,6 +55,12 @@ const (
 	ServerServiceListServersProcedure = "/vpn.ServerService/ListServers"
 	// ServerServiceGetServerProcedure is the fully-qualified name of the ServerService's GetServer RPC.
 	ServerServiceGetServerProcedure = "/vpn.ServerService/GetServer"
+	// ServerServiceUpdateServerProcedure is the fully-qualified name of the ServerService's
+	// UpdateServer RPC.
+	ServerServiceUpdateServerProcedure = "/vpn.ServerService/UpdateServer"
+	// ServerServiceWatchPeersProcedure is the fully-qualified name of the ServerService's WatchPeers
+	// RPC.
+	ServerServiceWatchPeersProcedure = "/vpn.ServerService/WatchPeers"
 	// ConfigServiceGenerateConfigProcedure is the fully-qualified name of the ConfigService's
 	// GenerateConfig RPC.
 	ConfigServiceGenerateConfigProcedure = "/vpn.ConfigService/GenerateConfig"
@@ -57,6 +69,36 @@ const (
 	// ConfigServiceRotateKeysProcedure is the fully-qualified name of the ConfigService's RotateKeys
 	// RPC.
 	ConfigServiceRotateKeysProcedure = "/vpn.ConfigService/RotateKeys"
+	// ConfigServiceCreateConfigShareLinkProcedure is the fully-qualified name of the ConfigService's
+	// CreateConfigShareLink RPC.
+	ConfigServiceCreateConfigShareLinkProcedure = "/vpn.ConfigService/CreateConfigShareLink"
+	// ConfigServiceRevokePeerProcedure is the fully-qualified name of the ConfigService's RevokePeer
+	// RPC.
+	ConfigServiceRevokePeerProcedure = "/vpn.ConfigService/RevokePeer"
+	// AuditServiceListAuditEventsProcedure is the fully-qualified name of the AuditService's
+	// ListAuditEvents RPC.
+	AuditServiceListAuditEventsProcedure = "/vpn.AuditService/ListAuditEvents"
+	// EventServiceListDeadLetterEventsProcedure is the fully-qualified name of the EventService's
+	// ListDeadLetterEvents RPC.
+	EventServiceListDeadLetterEventsProcedure = "/vpn.EventService/ListDeadLetterEvents"
+	// EventServiceRequeueDeadLetterEventProcedure is the fully-qualified name of the EventService's
+	// RequeueDeadLetterEvent RPC.
+	EventServiceRequeueDeadLetterEventProcedure = "/vpn.EventService/RequeueDeadLetterEvent"
+	// WebhookServiceCreateWebhookProcedure is the fully-qualified name of the WebhookService's
+	// CreateWebhook RPC.
+	WebhookServiceCreateWebhookProcedure = "/vpn.WebhookService/CreateWebhook"
+	// WebhookServiceListWebhooksProcedure is the fully-qualified name of the WebhookService's
+	// ListWebhooks RPC.
+	WebhookServiceListWebhooksProcedure = "/vpn.WebhookService/ListWebhooks"
+	// WebhookServiceDeleteWebhookProcedure is the fully-qualified name of the WebhookService's
+	// DeleteWebhook RPC.
+	WebhookServiceDeleteWebhookProcedure = "/vpn.WebhookService/DeleteWebhook"
+	// WebhookServiceListWebhookDeliveriesProcedure is the fully-qualified name of the WebhookService's
+	// ListWebhookDeliveries RPC.
+	WebhookServiceListWebhookDeliveriesProcedure = "/vpn.WebhookService/ListWebhookDeliveries"
+	// WebhookServiceTestWebhookProcedure is the fully-qualified name of the WebhookService's
+	// TestWebhook RPC.
+	WebhookServiceTestWebhookProcedure = "/vpn.WebhookService/TestWebhook"
 )
 
 // UserServiceClient is a client for the vpn.UserService service.
@@ -160,6 +202,10 @@ type ServerServiceClient interface {
 	CreateServer(context.Context, *connect.Request[gen.CreateServerRequest]) (*connect.Response[gen.CreateServerResponse], error)
 	ListServers(context.Context, *connect.Request[gen.ListServerRequest]) (*connect.Response[gen.ListServerResponse], error)
 	GetServer(context.Context, *connect.Request[gen.GetServerRequest]) (*connect.Response[gen.GetServerResponse], error)
+	UpdateServer(context.Context, *connect.Request[gen.UpdateServerRequest]) (*connect.Response[gen.UpdateServerResponse], error)
+	// WatchPeers streams the peers of a server to its node agent: a snapshot,
+	// then changes as they happen. Requires the admin role.
+	WatchPeers(context.Context, *connect.Request[gen.WatchPeersRequest]) (*connect.ServerStreamForClient[gen.WatchPeersResponse], error)
 }
 
 // NewServerServiceClient constructs a client for the vpn.ServerService service. By default, it uses
@@ -191,6 +237,18 @@ func NewServerServiceClient(httpClient connect.HTTPClient, baseURL string, opts
 			connect.WithSchema(serverServiceMethods.ByName("GetServer")),
 			connect.WithClientOptions(opts...),
 		),
+		updateServer: connect.NewClient[gen.UpdateServerRequest, gen.UpdateServerResponse](
+			httpClient,
+			baseURL+ServerServiceUpdateServerProcedure,
+			connect.WithSchema(serverServiceMethods.ByName("UpdateServer")),
+			connect.WithClientOptions(opts...),
+		),
+		watchPeers: connect.NewClient[gen.WatchPeersRequest, gen.WatchPeersResponse](
+			httpClient,
+			baseURL+ServerServiceWatchPeersProcedure,
+			connect.WithSchema(serverServiceMethods.ByName("WatchPeers")),
+			connect.WithClientOptions(opts...),
+		),
 	}
 }
 
@@ -199,6 +257,8 @@ type serverServiceClient struct {
 	createServer *connect.Client[gen.CreateServerRequest, gen.CreateServerResponse]
 	listServers  *connect.Client[gen.ListServerRequest, gen.ListServerResponse]
 	getServer    *connect.Client[gen.GetServerRequest, gen.GetServerResponse]
+	updateServer *connect.Client[gen.UpdateServerRequest, gen.UpdateServerResponse]
+	watchPeers   *connect.Client[gen.WatchPeersRequest, gen.WatchPeersResponse]
 }
 
 // CreateServer calls vpn.ServerService.CreateServer.
@@ -216,11 +276,25 @@ func (c *serverServiceClient) GetServer(ctx context.Context, req *connect.Reques
 	return c.getServer.CallUnary(ctx, req)
 }
 
+// UpdateServer calls vpn.ServerService.UpdateServer.
+func (c *serverServiceClient) UpdateServer(ctx context.Context, req *connect.Request[gen.UpdateServerRequest]) (*connect.Response[gen.UpdateServerResponse], error) {
+	return c.updateServer.CallUnary(ctx, req)
+}
+
+// WatchPeers calls vpn.ServerService.WatchPeers.
+func (c *serverServiceClient) WatchPeers(ctx context.Context, req *connect.Request[gen.WatchPeersRequest]) (*connect.ServerStreamForClient[gen.WatchPeersResponse], error) {
+	return c.watchPeers.CallServerStream(ctx, req)
+}
+
 // ServerServiceHandler is an implementation of the vpn.ServerService service.
 type ServerServiceHandler interface {
 	CreateServer(context.Context, *connect.Request[gen.CreateServerRequest]) (*connect.Response[gen.CreateServerResponse], error)
 	ListServers(context.Context, *connect.Request[gen.ListServerRequest]) (*connect.Response[gen.ListServerResponse], error)
 	GetServer(context.Context, *connect.Request[gen.GetServerRequest]) (*connect.Response[gen.GetServerResponse], error)
+	UpdateServer(context.Context, *connect.Request[gen.UpdateServerRequest]) (*connect.Response[gen.UpdateServerResponse], error)
+	// WatchPeers streams the peers of a server to its node agent: a snapshot,
+	// then changes as they happen. Requires the admin role.
+	WatchPeers(context.Context, *connect.Request[gen.WatchPeersRequest], *connect.ServerStream[gen.WatchPeersResponse]) error
 }
 
 // NewServerServiceHandler builds an HTTP handler from the service implementation. It returns the
@@ -248,6 +322,18 @@ func NewServerServiceHandler(svc ServerServiceHandler, opts ...connect.HandlerOp
 		connect.WithSchema(serverServiceMethods.ByName("GetServer")),
 		connect.WithHandlerOptions(opts...),
 	)
+	serverServiceUpdateServerHandler := connect.NewUnaryHandler(
+		ServerServiceUpdateServerProcedure,
+		svc.UpdateServer,
+		connect.WithSchema(serverServiceMethods.ByName("UpdateServer")),
+		connect.WithHandlerOptions(opts...),
+	)
+	serverServiceWatchPeersHandler := connect.NewServerStreamHandler(
+		ServerServiceWatchPeersProcedure,
+		svc.WatchPeers,
+		connect.WithSchema(serverServiceMethods.ByName("WatchPeers")),
+		connect.WithHandlerOptions(opts...),
+	)
 	return "/vpn.ServerService/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.URL.Path {
 		case ServerServiceCreateServerProcedure:
@@ -256,6 +342,10 @@ func NewServerServiceHandler(svc ServerServiceHandler, opts ...connect.HandlerOp
 			serverServiceListServersHandler.ServeHTTP(w, r)
 		case ServerServiceGetServerProcedure:
 			serverServiceGetServerHandler.ServeHTTP(w, r)
+		case ServerServiceUpdateServerProcedure:
+			serverServiceUpdateServerHandler.ServeHTTP(w, r)
+		case ServerServiceWatchPeersProcedure:
+			serverServiceWatchPeersHandler.ServeHTTP(w, r)
 		default:
 			http.NotFound(w, r)
 		}
@@ -277,11 +367,21 @@ func (UnimplementedServerServiceHandler) GetServer(context.Context, *connect.Req
 	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("vpn.ServerService.GetServer is not implemented"))
 }
 
+func (UnimplementedServerServiceHandler) UpdateServer(context.Context, *connect.Request[gen.UpdateServerRequest]) (*connect.Response[gen.UpdateServerResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("vpn.ServerService.UpdateServer is not implemented"))
+}
+
+func (UnimplementedServerServiceHandler) WatchPeers(context.Context, *connect.Request[gen.WatchPeersRequest], *connect.ServerStream[gen.WatchPeersResponse]) error {
+	return connect.NewError(connect.CodeUnimplemented, errors.New("vpn.ServerService.WatchPeers is not implemented"))
+}
+
 // ConfigServiceClient is a client for the vpn.ConfigService service.
 type ConfigServiceClient interface {
 	GenerateConfig(context.Context, *connect.Request[gen.GenerateConfigRequest]) (*connect.Response[gen.GenerateConfigResponse], error)
 	GetConfig(context.Context, *connect.Request[gen.GetConfigRequest]) (*connect.Response[gen.GetConfigResponse], error)
 	RotateKeys(context.Context, *connect.Request[gen.GenerateConfigRequest]) (*connect.Response[gen.GetConfigResponse], error)
+	CreateConfigShareLink(context.Context, *connect.Request[gen.CreateConfigShareLinkRequest]) (*connect.Response[gen.CreateConfigShareLinkResponse], error)
+	RevokePeer(context.Context, *connect.Request[gen.RevokePeerRequest]) (*connect.Response[gen.RevokePeerResponse], error)
 }
 
 // NewConfigServiceClient constructs a client for the vpn.ConfigService service. By default, it uses
@@ -313,14 +413,28 @@ func NewConfigServiceClient(httpClient connect.HTTPClient, baseURL string, opts
 			connect.WithSchema(configServiceMethods.ByName("RotateKeys")),
 			connect.WithClientOptions(opts...),
 		),
+		createConfigShareLink: connect.NewClient[gen.CreateConfigShareLinkRequest, gen.CreateConfigShareLinkResponse](
+			httpClient,
+			baseURL+ConfigServiceCreateConfigShareLinkProcedure,
+			connect.WithSchema(configServiceMethods.ByName("CreateConfigShareLink")),
+			connect.WithClientOptions(opts...),
+		),
+		revokePeer: connect.NewClient[gen.RevokePeerRequest, gen.RevokePeerResponse](
+			httpClient,
+			baseURL+ConfigServiceRevokePeerProcedure,
+			connect.WithSchema(configServiceMethods.ByName("RevokePeer")),
+			connect.WithClientOptions(opts...),
+		),
 	}
 }
 
 // configServiceClient implements ConfigServiceClient.
 type configServiceClient struct {
-	generateConfig *connect.Client[gen.GenerateConfigRequest, gen.GenerateConfigResponse]
-	getConfig      *connect.Client[gen.GetConfigRequest, gen.GetConfigResponse]
-	rotateKeys     *connect.Client[gen.GenerateConfigRequest, gen.GetConfigResponse]
+	generateConfig        *connect.Client[gen.GenerateConfigRequest, gen.GenerateConfigResponse]
+	getConfig             *connect.Client[gen.GetConfigRequest, gen.GetConfigResponse]
+	rotateKeys            *connect.Client[gen.GenerateConfigRequest, gen.GetConfigResponse]
+	createConfigShareLink *connect.Client[gen.CreateConfigShareLinkRequest, gen.CreateConfigShareLinkResponse]
+	revokePeer            *connect.Client[gen.RevokePeerRequest, gen.RevokePeerResponse]
 }
 
 // GenerateConfig calls vpn.ConfigService.GenerateConfig.
@@ -338,11 +452,23 @@ func (c *configServiceClient) RotateKeys(ctx context.Context, req *connect.Reque
 	return c.rotateKeys.CallUnary(ctx, req)
 }
 
+// CreateConfigShareLink calls vpn.ConfigService.CreateConfigShareLink.
+func (c *configServiceClient) CreateConfigShareLink(ctx context.Context, req *connect.Request[gen.CreateConfigShareLinkRequest]) (*connect.Response[gen.CreateConfigShareLinkResponse], error) {
+	return c.createConfigShareLink.CallUnary(ctx, req)
+}
+
+// RevokePeer calls vpn.ConfigService.RevokePeer.
+func (c *configServiceClient) RevokePeer(ctx context.Context, req *connect.Request[gen.RevokePeerRequest]) (*connect.Response[gen.RevokePeerResponse], error) {
+	return c.revokePeer.CallUnary(ctx, req)
+}
+
 // ConfigServiceHandler is an implementation of the vpn.ConfigService service.
 type ConfigServiceHandler interface {
 	GenerateConfig(context.Context, *connect.Request[gen.GenerateConfigRequest]) (*connect.Response[gen.GenerateConfigResponse], error)
 	GetConfig(context.Context, *connect.Request[gen.GetConfigRequest]) (*connect.Response[gen.GetConfigResponse], error)
 	RotateKeys(context.Context, *connect.Request[gen.GenerateConfigRequest]) (*connect.Response[gen.GetConfigResponse], error)
+	CreateConfigShareLink(context.Context, *connect.Request[gen.CreateConfigShareLinkRequest]) (*connect.Response[gen.CreateConfigShareLinkResponse], error)
+	RevokePeer(context.Context, *connect.Request[gen.RevokePeerRequest]) (*connect.Response[gen.RevokePeerResponse], error)
 }
 
 // NewConfigServiceHandler builds an HTTP handler from the service implementation. It returns the
@@ -370,6 +496,18 @@ func NewConfigServiceHandler(svc ConfigServiceHandler, opts ...connect.HandlerOp
 		connect.WithSchema(configServiceMethods.ByName("RotateKeys")),
 		connect.WithHandlerOptions(opts...),
 	)
+	configServiceCreateConfigShareLinkHandler := connect.NewUnaryHandler(
+		ConfigServiceCreateConfigShareLinkProcedure,
+		svc.CreateConfigShareLink,
+		connect.WithSchema(configServiceMethods.ByName("CreateConfigShareLink")),
+		connect.WithHandlerOptions(opts...),
+	)
+	configServiceRevokePeerHandler := connect.NewUnaryHandler(
+		ConfigServiceRevokePeerProcedure,
+		svc.RevokePeer,
+		connect.WithSchema(configServiceMethods.ByName("RevokePeer")),
+		connect.WithHandlerOptions(opts...),
+	)
 	return "/vpn.ConfigService/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.URL.Path {
 		case ConfigServiceGenerateConfigProcedure:
@@ -378,6 +516,10 @@ func NewConfigServiceHandler(svc ConfigServiceHandler, opts ...connect.HandlerOp
 			configServiceGetConfigHandler.ServeHTTP(w, r)
 		case ConfigServiceRotateKeysProcedure:
 			configServiceRotateKeysHandler.ServeHTTP(w, r)
+		case ConfigServiceCreateConfigShareLinkProcedure:
+			configServiceCreateConfigShareLinkHandler.ServeHTTP(w, r)
+		case ConfigServiceRevokePeerProcedure:
+			configServiceRevokePeerHandler.ServeHTTP(w, r)
 		default:
 			http.NotFound(w, r)
 		}
@@ -398,3 +540,353 @@ func (UnimplementedConfigServiceHandler) GetConfig(context.Context, *connect.Req
 func (UnimplementedConfigServiceHandler) RotateKeys(context.Context, *connect.Request[gen.GenerateConfigRequest]) (*connect.Response[gen.GetConfigResponse], error) {
 	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("vpn.ConfigService.RotateKeys is not implemented"))
 }
+
+func (UnimplementedConfigServiceHandler) CreateConfigShareLink(context.Context, *connect.Request[gen.CreateConfigShareLinkRequest]) (*connect.Response[gen.CreateConfigShareLinkResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("vpn.ConfigService.CreateConfigShareLink is not implemented"))
+}
+
+func (UnimplementedConfigServiceHandler) RevokePeer(context.Context, *connect.Request[gen.RevokePeerRequest]) (*connect.Response[gen.RevokePeerResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("vpn.ConfigService.RevokePeer is not implemented"))
+}
+
+// AuditServiceClient is a client for the vpn.AuditService service.
+type AuditServiceClient interface {
+	ListAuditEvents(context.Context, *connect.Request[gen.ListAuditEventsRequest]) (*connect.Response[gen.ListAuditEventsResponse], error)
+}
+
+// NewAuditServiceClient constructs a client for the vpn.AuditService service. By default, it uses
+// the Connect protocol with the binary Protobuf Codec, asks for gzipped responses, and sends
+// uncompressed requests. To use the gRPC or gRPC-Web protocols, supply the connect.WithGRPC() or
+// connect.WithGRPCWeb() options.
+//
+// The URL supplied here should be the base URL for the Connect or gRPC server (for example,
+// http://api.acme.com or https://acme.com/grpc).
+func NewAuditServiceClient(httpClient connect.HTTPClient, baseURL string, opts ...connect.ClientOption) AuditServiceClient {
+	baseURL = strings.TrimRight(baseURL, "/")
+	auditServiceMethods := gen.File_vpn_proto.Services().ByName("AuditService").Methods()
+	return &auditServiceClient{
+		listAuditEvents: connect.NewClient[gen.ListAuditEventsRequest, gen.ListAuditEventsResponse](
+			httpClient,
+			baseURL+AuditServiceListAuditEventsProcedure,
+			connect.WithSchema(auditServiceMethods.ByName("ListAuditEvents")),
+			connect.WithClientOptions(opts...),
+		),
+	}
+}
+
+// auditServiceClient implements AuditServiceClient.
+type auditServiceClient struct {
+	listAuditEvents *connect.Client[gen.ListAuditEventsRequest, gen.ListAuditEventsResponse]
+}
+
+// ListAuditEvents calls vpn.AuditService.ListAuditEvents.
+func (c *auditServiceClient) ListAuditEvents(ctx context.Context, req *connect.Request[gen.ListAuditEventsRequest]) (*connect.Response[gen.ListAuditEventsResponse], error) {
+	return c.listAuditEvents.CallUnary(ctx, req)
+}
+
+// AuditServiceHandler is an implementation of the vpn.AuditService service.
+type AuditServiceHandler interface {
+	ListAuditEvents(context.Context, *connect.Request[gen.ListAuditEventsRequest]) (*connect.Response[gen.ListAuditEventsResponse], error)
+}
+
+// NewAuditServiceHandler builds an HTTP handler from the service implementation. It returns the
+// path on which to mount the handler and the handler itself.
+//
+// By default, handlers support the Connect, gRPC, and gRPC-Web protocols with the binary Protobuf
+// and JSON codecs. They also support gzip compression.
+func NewAuditServiceHandler(svc AuditServiceHandler, opts ...connect.HandlerOption) (string, http.Handler) {
+	auditServiceMethods := gen.File_vpn_proto.Services().ByName("AuditService").Methods()
+	auditServiceListAuditEventsHandler := connect.NewUnaryHandler(
+		AuditServiceListAuditEventsProcedure,
+		svc.ListAuditEvents,
+		connect.WithSchema(auditServiceMethods.ByName("ListAuditEvents")),
+		connect.WithHandlerOptions(opts...),
+	)
+	return "/vpn.AuditService/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case AuditServiceListAuditEventsProcedure:
+			auditServiceListAuditEventsHandler.ServeHTTP(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+// UnimplementedAuditServiceHandler returns CodeUnimplemented from all methods.
+type UnimplementedAuditServiceHandler struct{}
+
+func (UnimplementedAuditServiceHandler) ListAuditEvents(context.Context, *connect.Request[gen.ListAuditEventsRequest]) (*connect.Response[gen.ListAuditEventsResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("vpn.AuditService.ListAuditEvents is not implemented"))
+}
+
+// EventServiceClient is a client for the vpn.EventService service.
+type EventServiceClient interface {
+	ListDeadLetterEvents(context.Context, *connect.Request[gen.ListDeadLetterEventsRequest]) (*connect.Response[gen.ListDeadLetterEventsResponse], error)
+	RequeueDeadLetterEvent(context.Context, *connect.Request[gen.RequeueDeadLetterEventRequest]) (*connect.Response[gen.RequeueDeadLetterEventResponse], error)
+}
+
+// NewEventServiceClient constructs a client for the vpn.EventService service. By default, it uses
+// the Connect protocol with the binary Protobuf Codec, asks for gzipped responses, and sends
+// uncompressed requests. To use the gRPC or gRPC-Web protocols, supply the connect.WithGRPC() or
+// connect.WithGRPCWeb() options.
+//
+// The URL supplied here should be the base URL for the Connect or gRPC server (for example,
+// http://api.acme.com or https://acme.com/grpc).
+func NewEventServiceClient(httpClient connect.HTTPClient, baseURL string, opts ...connect.ClientOption) EventServiceClient {
+	baseURL = strings.TrimRight(baseURL, "/")
+	eventServiceMethods := gen.File_vpn_proto.Services().ByName("EventService").Methods()
+	return &eventServiceClient{
+		listDeadLetterEvents: connect.NewClient[gen.ListDeadLetterEventsRequest, gen.ListDeadLetterEventsResponse](
+			httpClient,
+			baseURL+EventServiceListDeadLetterEventsProcedure,
+			connect.WithSchema(eventServiceMethods.ByName("ListDeadLetterEvents")),
+			connect.WithClientOptions(opts...),
+		),
+		requeueDeadLetterEvent: connect.NewClient[gen.RequeueDeadLetterEventRequest, gen.RequeueDeadLetterEventResponse](
+			httpClient,
+			baseURL+EventServiceRequeueDeadLetterEventProcedure,
+			connect.WithSchema(eventServiceMethods.ByName("RequeueDeadLetterEvent")),
+			connect.WithClientOptions(opts...),
+		),
+	}
+}
+
+// eventServiceClient implements EventServiceClient.
+type eventServiceClient struct {
+	listDeadLetterEvents   *connect.Client[gen.ListDeadLetterEventsRequest, gen.ListDeadLetterEventsResponse]
+	requeueDeadLetterEvent *connect.Client[gen.RequeueDeadLetterEventRequest, gen.RequeueDeadLetterEventResponse]
+}
+
+// ListDeadLetterEvents calls vpn.EventService.ListDeadLetterEvents.
+func (c *eventServiceClient) ListDeadLetterEvents(ctx context.Context, req *connect.Request[gen.ListDeadLetterEventsRequest]) (*connect.Response[gen.ListDeadLetterEventsResponse], error) {
+	return c.listDeadLetterEvents.CallUnary(ctx, req)
+}
+
+// RequeueDeadLetterEvent calls vpn.EventService.RequeueDeadLetterEvent.
+func (c *eventServiceClient) RequeueDeadLetterEvent(ctx context.Context, req *connect.Request[gen.RequeueDeadLetterEventRequest]) (*connect.Response[gen.RequeueDeadLetterEventResponse], error) {
+	return c.requeueDeadLetterEvent.CallUnary(ctx, req)
+}
+
+// EventServiceHandler is an implementation of the vpn.EventService service.
+type EventServiceHandler interface {
+	ListDeadLetterEvents(context.Context, *connect.Request[gen.ListDeadLetterEventsRequest]) (*connect.Response[gen.ListDeadLetterEventsResponse], error)
+	RequeueDeadLetterEvent(context.Context, *connect.Request[gen.RequeueDeadLetterEventRequest]) (*connect.Response[gen.RequeueDeadLetterEventResponse], error)
+}
+
+// NewEventServiceHandler builds an HTTP handler from the service implementation. It returns the
+// path on which to mount the handler and the handler itself.
+//
+// By default, handlers support the Connect, gRPC, and gRPC-Web protocols with the binary Protobuf
+// and JSON codecs. They also support gzip compression.
+func NewEventServiceHandler(svc EventServiceHandler, opts ...connect.HandlerOption) (string, http.Handler) {
+	eventServiceMethods := gen.File_vpn_proto.Services().ByName("EventService").Methods()
+	eventServiceListDeadLetterEventsHandler := connect.NewUnaryHandler(
+		EventServiceListDeadLetterEventsProcedure,
+		svc.ListDeadLetterEvents,
+		connect.WithSchema(eventServiceMethods.ByName("ListDeadLetterEvents")),
+		connect.WithHandlerOptions(opts...),
+	)
+	eventServiceRequeueDeadLetterEventHandler := connect.NewUnaryHandler(
+		EventServiceRequeueDeadLetterEventProcedure,
+		svc.RequeueDeadLetterEvent,
+		connect.WithSchema(eventServiceMethods.ByName("RequeueDeadLetterEvent")),
+		connect.WithHandlerOptions(opts...),
+	)
+	return "/vpn.EventService/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case EventServiceListDeadLetterEventsProcedure:
+			eventServiceListDeadLetterEventsHandler.ServeHTTP(w, r)
+		case EventServiceRequeueDeadLetterEventProcedure:
+			eventServiceRequeueDeadLetterEventHandler.ServeHTTP(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+// UnimplementedEventServiceHandler returns CodeUnimplemented from all methods.
+type UnimplementedEventServiceHandler struct{}
+
+func (UnimplementedEventServiceHandler) ListDeadLetterEvents(context.Context, *connect.Request[gen.ListDeadLetterEventsRequest]) (*connect.Response[gen.ListDeadLetterEventsResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("vpn.EventService.ListDeadLetterEvents is not implemented"))
+}
+
+func (UnimplementedEventServiceHandler) RequeueDeadLetterEvent(context.Context, *connect.Request[gen.RequeueDeadLetterEventRequest]) (*connect.Response[gen.RequeueDeadLetterEventResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("vpn.EventService.RequeueDeadLetterEvent is not implemented"))
+}
+
+// WebhookServiceClient is a client for the vpn.WebhookService service.
+type WebhookServiceClient interface {
+	CreateWebhook(context.Context, *connect.Request[gen.CreateWebhookRequest]) (*connect.Response[gen.CreateWebhookResponse], error)
+	ListWebhooks(context.Context, *connect.Request[gen.ListWebhooksRequest]) (*connect.Response[gen.ListWebhooksResponse], error)
+	DeleteWebhook(context.Context, *connect.Request[gen.DeleteWebhookRequest]) (*connect.Response[gen.DeleteWebhookResponse], error)
+	ListWebhookDeliveries(context.Context, *connect.Request[gen.ListWebhookDeliveriesRequest]) (*connect.Response[gen.ListWebhookDeliveriesResponse], error)
+	// TestWebhook sends a WebhookTest event to the webhook right away.
+	TestWebhook(context.Context, *connect.Request[gen.TestWebhookRequest]) (*connect.Response[gen.TestWebhookResponse], error)
+}
+
+// NewWebhookServiceClient constructs a client for the vpn.WebhookService service. By default, it
+// uses the Connect protocol with the binary Protobuf Codec, asks for gzipped responses, and sends
+// uncompressed requests. To use the gRPC or gRPC-Web protocols, supply the connect.WithGRPC() or
+// connect.WithGRPCWeb() options.
+//
+// The URL supplied here should be the base URL for the Connect or gRPC server (for example,
+// http://api.acme.com or https://acme.com/grpc).
+func NewWebhookServiceClient(httpClient connect.HTTPClient, baseURL string, opts ...connect.ClientOption) WebhookServiceClient {
+	baseURL = strings.TrimRight(baseURL, "/")
+	webhookServiceMethods := gen.File_vpn_proto.Services().ByName("WebhookService").Methods()
+	return &webhookServiceClient{
+		createWebhook: connect.NewClient[gen.CreateWebhookRequest, gen.CreateWebhookResponse](
+			httpClient,
+			baseURL+WebhookServiceCreateWebhookProcedure,
+			connect.WithSchema(webhookServiceMethods.ByName("CreateWebhook")),
+			connect.WithClientOptions(opts...),
+		),
+		listWebhooks: connect.NewClient[gen.ListWebhooksRequest, gen.ListWebhooksResponse](
+			httpClient,
+			baseURL+WebhookServiceListWebhooksProcedure,
+			connect.WithSchema(webhookServiceMethods.ByName("ListWebhooks")),
+			connect.WithClientOptions(opts...),
+		),
+		deleteWebhook: connect.NewClient[gen.DeleteWebhookRequest, gen.DeleteWebhookResponse](
+			httpClient,
+			baseURL+WebhookServiceDeleteWebhookProcedure,
+			connect.WithSchema(webhookServiceMethods.ByName("DeleteWebhook")),
+			connect.WithClientOptions(opts...),
+		),
+		listWebhookDeliveries: connect.NewClient[gen.ListWebhookDeliveriesRequest, gen.ListWebhookDeliveriesResponse](
+			httpClient,
+			baseURL+WebhookServiceListWebhookDeliveriesProcedure,
+			connect.WithSchema(webhookServiceMethods.ByName("ListWebhookDeliveries")),
+			connect.WithClientOptions(opts...),
+		),
+		testWebhook: connect.NewClient[gen.TestWebhookRequest, gen.TestWebhookResponse](
+			httpClient,
+			baseURL+WebhookServiceTestWebhookProcedure,
+			connect.WithSchema(webhookServiceMethods.ByName("TestWebhook")),
+			connect.WithClientOptions(opts...),
+		),
+	}
+}
+
+// webhookServiceClient implements WebhookServiceClient.
+type webhookServiceClient struct {
+	createWebhook         *connect.Client[gen.CreateWebhookRequest, gen.CreateWebhookResponse]
+	listWebhooks          *connect.Client[gen.ListWebhooksRequest, gen.ListWebhooksResponse]
+	deleteWebhook         *connect.Client[gen.DeleteWebhookRequest, gen.DeleteWebhookResponse]
+	listWebhookDeliveries *connect.Client[gen.ListWebhookDeliveriesRequest, gen.ListWebhookDeliveriesResponse]
+	testWebhook           *connect.Client[gen.TestWebhookRequest, gen.TestWebhookResponse]
+}
+
+// CreateWebhook calls vpn.WebhookService.CreateWebhook.
+func (c *webhookServiceClient) CreateWebhook(ctx context.Context, req *connect.Request[gen.CreateWebhookRequest]) (*connect.Response[gen.CreateWebhookResponse], error) {
+	return c.createWebhook.CallUnary(ctx, req)
+}
+
+// ListWebhooks calls vpn.WebhookService.ListWebhooks.
+func (c *webhookServiceClient) ListWebhooks(ctx context.Context, req *connect.Request[gen.ListWebhooksRequest]) (*connect.Response[gen.ListWebhooksResponse], error) {
+	return c.listWebhooks.CallUnary(ctx, req)
+}
+
+// DeleteWebhook calls vpn.WebhookService.DeleteWebhook.
+func (c *webhookServiceClient) DeleteWebhook(ctx context.Context, req *connect.Request[gen.DeleteWebhookRequest]) (*connect.Response[gen.DeleteWebhookResponse], error) {
+	return c.deleteWebhook.CallUnary(ctx, req)
+}
+
+// ListWebhookDeliveries calls vpn.WebhookService.ListWebhookDeliveries.
+func (c *webhookServiceClient) ListWebhookDeliveries(ctx context.Context, req *connect.Request[gen.ListWebhookDeliveriesRequest]) (*connect.Response[gen.ListWebhookDeliveriesResponse], error) {
+	return c.listWebhookDeliveries.CallUnary(ctx, req)
+}
+
+// TestWebhook calls vpn.WebhookService.TestWebhook.
+func (c *webhookServiceClient) TestWebhook(ctx context.Context, req *connect.Request[gen.TestWebhookRequest]) (*connect.Response[gen.TestWebhookResponse], error) {
+	return c.testWebhook.CallUnary(ctx, req)
+}
+
+// WebhookServiceHandler is an implementation of the vpn.WebhookService service.
+type WebhookServiceHandler interface {
+	CreateWebhook(context.Context, *connect.Request[gen.CreateWebhookRequest]) (*connect.Response[gen.CreateWebhookResponse], error)
+	ListWebhooks(context.Context, *connect.Request[gen.ListWebhooksRequest]) (*connect.Response[gen.ListWebhooksResponse], error)
+	DeleteWebhook(context.Context, *connect.Request[gen.DeleteWebhookRequest]) (*connect.Response[gen.DeleteWebhookResponse], error)
+	ListWebhookDeliveries(context.Context, *connect.Request[gen.ListWebhookDeliveriesRequest]) (*connect.Response[gen.ListWebhookDeliveriesResponse], error)
+	// TestWebhook sends a WebhookTest event to the webhook right away.
+	TestWebhook(context.Context, *connect.Request[gen.TestWebhookRequest]) (*connect.Response[gen.TestWebhookResponse], error)
+}
+
+// NewWebhookServiceHandler builds an HTTP handler from the service implementation. It returns the
+// path on which to mount the handler and the handler itself.
+//
+// By default, handlers support the Connect, gRPC, and gRPC-Web protocols with the binary Protobuf
+// and JSON codecs. They also support gzip compression.
+func NewWebhookServiceHandler(svc WebhookServiceHandler, opts ...connect.HandlerOption) (string, http.Handler) {
+	webhookServiceMethods := gen.File_vpn_proto.Services().ByName("WebhookService").Methods()
+	webhookServiceCreateWebhookHandler := connect.NewUnaryHandler(
+		WebhookServiceCreateWebhookProcedure,
+		svc.CreateWebhook,
+		connect.WithSchema(webhookServiceMethods.ByName("CreateWebhook")),
+		connect.WithHandlerOptions(opts...),
+	)
+	webhookServiceListWebhooksHandler := connect.NewUnaryHandler(
+		WebhookServiceListWebhooksProcedure,
+		svc.ListWebhooks,
+		connect.WithSchema(webhookServiceMethods.ByName("ListWebhooks")),
+		connect.WithHandlerOptions(opts...),
+	)
+	webhookServiceDeleteWebhookHandler := connect.NewUnaryHandler(
+		WebhookServiceDeleteWebhookProcedure,
+		svc.DeleteWebhook,
+		connect.WithSchema(webhookServiceMethods.ByName("DeleteWebhook")),
+		connect.WithHandlerOptions(opts...),
+	)
+	webhookServiceListWebhookDeliveriesHandler := connect.NewUnaryHandler(
+		WebhookServiceListWebhookDeliveriesProcedure,
+		svc.ListWebhookDeliveries,
+		connect.WithSchema(webhookServiceMethods.ByName("ListWebhookDeliveries")),
+		connect.WithHandlerOptions(opts...),
+	)
+	webhookServiceTestWebhookHandler := connect.NewUnaryHandler(
+		WebhookServiceTestWebhookProcedure,
+		svc.TestWebhook,
+		connect.WithSchema(webhookServiceMethods.ByName("TestWebhook")),
+		connect.WithHandlerOptions(opts...),
+	)
+	return "/vpn.WebhookService/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case WebhookServiceCreateWebhookProcedure:
+			webhookServiceCreateWebhookHandler.ServeHTTP(w, r)
+		case WebhookServiceListWebhooksProcedure:
+			webhookServiceListWebhooksHandler.ServeHTTP(w, r)
+		case WebhookServiceDeleteWebhookProcedure:
+			webhookServiceDeleteWebhookHandler.ServeHTTP(w, r)
+		case WebhookServiceListWebhookDeliveriesProcedure:
+			webhookServiceListWebhookDeliveriesHandler.ServeHTTP(w, r)
+		case WebhookServiceTestWebhookProcedure:
+			webhookServiceTestWebhookHandler.ServeHTTP(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+// UnimplementedWebhookServiceHandler returns CodeUnimplemented from all methods.
+type UnimplementedWebhookServiceHandler struct{}
+
+func (UnimplementedWebhookServiceHandler) CreateWebhook(context.Context, *connect.Request[gen.CreateWebhookRequest]) (*connect.Response[gen.CreateWebhookResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("vpn.WebhookService.CreateWebhook is not implemented"))
+}
+
+func (UnimplementedWebhookServiceHandler) ListWebhooks(context.Context, *connect.Request[gen.ListWebhooksRequest]) (*connect.Response[gen.ListWebhooksResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("vpn.WebhookService.ListWebhooks is not implemented"))
+}
+
+func (UnimplementedWebhookServiceHandler) DeleteWebhook(context.Context, *connect.Request[gen.DeleteWebhookRequest]) (*connect.Response[gen.DeleteWebhookResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("vpn.WebhookService.DeleteWebhook is not implemented"))
+}
+
+func (UnimplementedWebhookServiceHandler) ListWebhookDeliveries(context.Context, *connect.Request[gen.ListWebhookDeliveriesRequest]) (*connect.Response[gen.ListWebhookDeliveriesResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("vpn.WebhookService.ListWebhookDeliveries is not implemented"))
+}
+
+func (UnimplementedWebhookServiceHandler) TestWebhook(context.Context, *connect.Request[gen.TestWebhookRequest]) (*connect.Response[gen.TestWebhookResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("vpn.WebhookService.TestWebhook is not implemented"))
+}
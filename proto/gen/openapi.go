@@ -0,0 +1,9 @@
+package gen
+
+import _ "embed"
+
+// OpenAPI is the OpenAPI v3 document for the REST gateway, in YAML. It is
+// generated by protoc-gen-openapi along with the rest of this package.
+//
+//go:embed openapi.yaml
+var OpenAPI []byte
@@ -7,6 +7,8 @@
 package gen
 
 import (
+	_ "buf.build/gen/go/bufbuild/protovalidate/protocolbuffers/go/buf/validate"
+	_ "google.golang.org/genproto/googleapis/api/annotations"
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
 	reflect "reflect"
@@ -21,6 +23,275 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type ConfigFormat int32
+
+const (
+	ConfigFormat_CONFIG_FORMAT_UNSPECIFIED        ConfigFormat = 0
+	ConfigFormat_CONFIG_FORMAT_WG_QUICK           ConfigFormat = 1
+	ConfigFormat_CONFIG_FORMAT_NETWORK_MANAGER    ConfigFormat = 2
+	ConfigFormat_CONFIG_FORMAT_APPLE_MOBILECONFIG ConfigFormat = 3
+	ConfigFormat_CONFIG_FORMAT_OPENWRT_UCI        ConfigFormat = 4
+	ConfigFormat_CONFIG_FORMAT_MIKROTIK           ConfigFormat = 5
+	ConfigFormat_CONFIG_FORMAT_JSON               ConfigFormat = 6
+)
+
+// Enum value maps for ConfigFormat.
+var (
+	ConfigFormat_name = map[int32]string{
+		0: "CONFIG_FORMAT_UNSPECIFIED",
+		1: "CONFIG_FORMAT_WG_QUICK",
+		2: "CONFIG_FORMAT_NETWORK_MANAGER",
+		3: "CONFIG_FORMAT_APPLE_MOBILECONFIG",
+		4: "CONFIG_FORMAT_OPENWRT_UCI",
+		5: "CONFIG_FORMAT_MIKROTIK",
+		6: "CONFIG_FORMAT_JSON",
+	}
+	ConfigFormat_value = map[string]int32{
+		"CONFIG_FORMAT_UNSPECIFIED":        0,
+		"CONFIG_FORMAT_WG_QUICK":           1,
+		"CONFIG_FORMAT_NETWORK_MANAGER":    2,
+		"CONFIG_FORMAT_APPLE_MOBILECONFIG": 3,
+		"CONFIG_FORMAT_OPENWRT_UCI":        4,
+		"CONFIG_FORMAT_MIKROTIK":           5,
+		"CONFIG_FORMAT_JSON":               6,
+	}
+)
+
+func (x ConfigFormat) Enum() *ConfigFormat {
+	p := new(ConfigFormat)
+	*p = x
+	return p
+}
+
+func (x ConfigFormat) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ConfigFormat) Descriptor() protoreflect.EnumDescriptor {
+	return file_vpn_proto_enumTypes[0].Descriptor()
+}
+
+func (ConfigFormat) Type() protoreflect.EnumType {
+	return &file_vpn_proto_enumTypes[0]
+}
+
+func (x ConfigFormat) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ConfigFormat.Descriptor instead.
+func (ConfigFormat) EnumDescriptor() ([]byte, []int) {
+	return file_vpn_proto_rawDescGZIP(), []int{0}
+}
+
+type QRCodeFormat int32
+
+const (
+	QRCodeFormat_QR_CODE_FORMAT_UNSPECIFIED QRCodeFormat = 0
+	QRCodeFormat_QR_CODE_FORMAT_PNG         QRCodeFormat = 1
+	QRCodeFormat_QR_CODE_FORMAT_SVG         QRCodeFormat = 2
+	QRCodeFormat_QR_CODE_FORMAT_TERMINAL    QRCodeFormat = 3
+)
+
+// Enum value maps for QRCodeFormat.
+var (
+	QRCodeFormat_name = map[int32]string{
+		0: "QR_CODE_FORMAT_UNSPECIFIED",
+		1: "QR_CODE_FORMAT_PNG",
+		2: "QR_CODE_FORMAT_SVG",
+		3: "QR_CODE_FORMAT_TERMINAL",
+	}
+	QRCodeFormat_value = map[string]int32{
+		"QR_CODE_FORMAT_UNSPECIFIED": 0,
+		"QR_CODE_FORMAT_PNG":         1,
+		"QR_CODE_FORMAT_SVG":         2,
+		"QR_CODE_FORMAT_TERMINAL":    3,
+	}
+)
+
+func (x QRCodeFormat) Enum() *QRCodeFormat {
+	p := new(QRCodeFormat)
+	*p = x
+	return p
+}
+
+func (x QRCodeFormat) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (QRCodeFormat) Descriptor() protoreflect.EnumDescriptor {
+	return file_vpn_proto_enumTypes[1].Descriptor()
+}
+
+func (QRCodeFormat) Type() protoreflect.EnumType {
+	return &file_vpn_proto_enumTypes[1]
+}
+
+func (x QRCodeFormat) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use QRCodeFormat.Descriptor instead.
+func (QRCodeFormat) EnumDescriptor() ([]byte, []int) {
+	return file_vpn_proto_rawDescGZIP(), []int{1}
+}
+
+type QRRecoveryLevel int32
+
+const (
+	QRRecoveryLevel_QR_RECOVERY_LEVEL_UNSPECIFIED QRRecoveryLevel = 0
+	QRRecoveryLevel_QR_RECOVERY_LEVEL_LOW         QRRecoveryLevel = 1
+	QRRecoveryLevel_QR_RECOVERY_LEVEL_MEDIUM      QRRecoveryLevel = 2
+	QRRecoveryLevel_QR_RECOVERY_LEVEL_QUARTILE    QRRecoveryLevel = 3
+	QRRecoveryLevel_QR_RECOVERY_LEVEL_HIGH        QRRecoveryLevel = 4
+)
+
+// Enum value maps for QRRecoveryLevel.
+var (
+	QRRecoveryLevel_name = map[int32]string{
+		0: "QR_RECOVERY_LEVEL_UNSPECIFIED",
+		1: "QR_RECOVERY_LEVEL_LOW",
+		2: "QR_RECOVERY_LEVEL_MEDIUM",
+		3: "QR_RECOVERY_LEVEL_QUARTILE",
+		4: "QR_RECOVERY_LEVEL_HIGH",
+	}
+	QRRecoveryLevel_value = map[string]int32{
+		"QR_RECOVERY_LEVEL_UNSPECIFIED": 0,
+		"QR_RECOVERY_LEVEL_LOW":         1,
+		"QR_RECOVERY_LEVEL_MEDIUM":      2,
+		"QR_RECOVERY_LEVEL_QUARTILE":    3,
+		"QR_RECOVERY_LEVEL_HIGH":        4,
+	}
+)
+
+func (x QRRecoveryLevel) Enum() *QRRecoveryLevel {
+	p := new(QRRecoveryLevel)
+	*p = x
+	return p
+}
+
+func (x QRRecoveryLevel) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (QRRecoveryLevel) Descriptor() protoreflect.EnumDescriptor {
+	return file_vpn_proto_enumTypes[2].Descriptor()
+}
+
+func (QRRecoveryLevel) Type() protoreflect.EnumType {
+	return &file_vpn_proto_enumTypes[2]
+}
+
+func (x QRRecoveryLevel) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use QRRecoveryLevel.Descriptor instead.
+func (QRRecoveryLevel) EnumDescriptor() ([]byte, []int) {
+	return file_vpn_proto_rawDescGZIP(), []int{2}
+}
+
+type ShareLinkKind int32
+
+const (
+	ShareLinkKind_SHARE_LINK_KIND_UNSPECIFIED ShareLinkKind = 0
+	ShareLinkKind_SHARE_LINK_KIND_CONFIG_FILE ShareLinkKind = 1
+	ShareLinkKind_SHARE_LINK_KIND_QR_PAGE     ShareLinkKind = 2
+)
+
+// Enum value maps for ShareLinkKind.
+var (
+	ShareLinkKind_name = map[int32]string{
+		0: "SHARE_LINK_KIND_UNSPECIFIED",
+		1: "SHARE_LINK_KIND_CONFIG_FILE",
+		2: "SHARE_LINK_KIND_QR_PAGE",
+	}
+	ShareLinkKind_value = map[string]int32{
+		"SHARE_LINK_KIND_UNSPECIFIED": 0,
+		"SHARE_LINK_KIND_CONFIG_FILE": 1,
+		"SHARE_LINK_KIND_QR_PAGE":     2,
+	}
+)
+
+func (x ShareLinkKind) Enum() *ShareLinkKind {
+	p := new(ShareLinkKind)
+	*p = x
+	return p
+}
+
+func (x ShareLinkKind) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ShareLinkKind) Descriptor() protoreflect.EnumDescriptor {
+	return file_vpn_proto_enumTypes[3].Descriptor()
+}
+
+func (ShareLinkKind) Type() protoreflect.EnumType {
+	return &file_vpn_proto_enumTypes[3]
+}
+
+func (x ShareLinkKind) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ShareLinkKind.Descriptor instead.
+func (ShareLinkKind) EnumDescriptor() ([]byte, []int) {
+	return file_vpn_proto_rawDescGZIP(), []int{3}
+}
+
+type PeerChange_Type int32
+
+const (
+	PeerChange_TYPE_UNSPECIFIED PeerChange_Type = 0
+	PeerChange_TYPE_ADDED       PeerChange_Type = 1
+	PeerChange_TYPE_REMOVED     PeerChange_Type = 2
+	PeerChange_TYPE_ROTATED     PeerChange_Type = 3
+)
+
+// Enum value maps for PeerChange_Type.
+var (
+	PeerChange_Type_name = map[int32]string{
+		0: "TYPE_UNSPECIFIED",
+		1: "TYPE_ADDED",
+		2: "TYPE_REMOVED",
+		3: "TYPE_ROTATED",
+	}
+	PeerChange_Type_value = map[string]int32{
+		"TYPE_UNSPECIFIED": 0,
+		"TYPE_ADDED":       1,
+		"TYPE_REMOVED":     2,
+		"TYPE_ROTATED":     3,
+	}
+)
+
+func (x PeerChange_Type) Enum() *PeerChange_Type {
+	p := new(PeerChange_Type)
+	*p = x
+	return p
+}
+
+func (x PeerChange_Type) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (PeerChange_Type) Descriptor() protoreflect.EnumDescriptor {
+	return file_vpn_proto_enumTypes[4].Descriptor()
+}
+
+func (PeerChange_Type) Type() protoreflect.EnumType {
+	return &file_vpn_proto_enumTypes[4]
+}
+
+func (x PeerChange_Type) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use PeerChange_Type.Descriptor instead.
+func (PeerChange_Type) EnumDescriptor() ([]byte, []int) {
+	return file_vpn_proto_rawDescGZIP(), []int{14, 0}
+}
+
 type LoginRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Email         string                 `protobuf:"bytes,1,opt,name=email,proto3" json:"email,omitempty"`
@@ -74,9 +345,10 @@ func (x *LoginRequest) GetPassword() string {
 }
 
 type RegisterRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Email         string                 `protobuf:"bytes,1,opt,name=email,proto3" json:"email,omitempty"`
-	Password      string                 `protobuf:"bytes,2,opt,name=password,proto3" json:"password,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Email string                 `protobuf:"bytes,1,opt,name=email,proto3" json:"email,omitempty"`
+	// bcrypt only uses the first 72 bytes.
+	Password      string `protobuf:"bytes,2,opt,name=password,proto3" json:"password,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -178,16 +450,22 @@ func (x *AuthenticationResponse) GetToken() string {
 }
 
 type Server struct {
-	state          protoimpl.MessageState `protogen:"open.v1"`
-	Id             string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	Name           string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
-	Endpoint       string                 `protobuf:"bytes,3,opt,name=endpoint,proto3" json:"endpoint,omitempty"`
-	PublicKey      string                 `protobuf:"bytes,4,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
-	Region         string                 `protobuf:"bytes,5,opt,name=region,proto3" json:"region,omitempty"`
-	MaxClients     int32                  `protobuf:"varint,6,opt,name=max_clients,json=maxClients,proto3" json:"max_clients,omitempty"`
-	CurrentClients int32                  `protobuf:"varint,7,opt,name=current_clients,json=currentClients,proto3" json:"current_clients,omitempty"`
-	unknownFields  protoimpl.UnknownFields
-	sizeCache      protoimpl.SizeCache
+	state                    protoimpl.MessageState `protogen:"open.v1"`
+	Id                       string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name                     string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Endpoint                 string                 `protobuf:"bytes,3,opt,name=endpoint,proto3" json:"endpoint,omitempty"`
+	PublicKey                string                 `protobuf:"bytes,4,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
+	Region                   string                 `protobuf:"bytes,5,opt,name=region,proto3" json:"region,omitempty"`
+	MaxClients               int32                  `protobuf:"varint,6,opt,name=max_clients,json=maxClients,proto3" json:"max_clients,omitempty"`
+	CurrentClients           int32                  `protobuf:"varint,7,opt,name=current_clients,json=currentClients,proto3" json:"current_clients,omitempty"`
+	KeyRotationMaxAgeSeconds int64                  `protobuf:"varint,8,opt,name=key_rotation_max_age_seconds,json=keyRotationMaxAgeSeconds,proto3" json:"key_rotation_max_age_seconds,omitempty"`
+	KeyRotationMode          string                 `protobuf:"bytes,9,opt,name=key_rotation_mode,json=keyRotationMode,proto3" json:"key_rotation_mode,omitempty"`
+	// Changes on every edit. Send it back in UpdateServerRequest.version.
+	Version int64 `protobuf:"varint,10,opt,name=version,proto3" json:"version,omitempty"`
+	// "active", "draining" (no new peers) or "disabled".
+	Status        string `protobuf:"bytes,11,opt,name=status,proto3" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
 func (x *Server) Reset() {
@@ -269,15 +547,47 @@ func (x *Server) GetCurrentClients() int32 {
 	return 0
 }
 
+func (x *Server) GetKeyRotationMaxAgeSeconds() int64 {
+	if x != nil {
+		return x.KeyRotationMaxAgeSeconds
+	}
+	return 0
+}
+
+func (x *Server) GetKeyRotationMode() string {
+	if x != nil {
+		return x.KeyRotationMode
+	}
+	return ""
+}
+
+func (x *Server) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *Server) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
 type CreateServerRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
-	Endpoint      string                 `protobuf:"bytes,2,opt,name=endpoint,proto3" json:"endpoint,omitempty"`
-	Region        string                 `protobuf:"bytes,3,opt,name=region,proto3" json:"region,omitempty"`
-	MaxClients    int32                  `protobuf:"varint,4,opt,name=max_clients,json=maxClients,proto3" json:"max_clients,omitempty"`
-	PublicKey     string                 `protobuf:"bytes,5,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// host:port the clients connect to.
+	Endpoint   string `protobuf:"bytes,2,opt,name=endpoint,proto3" json:"endpoint,omitempty"`
+	Region     string `protobuf:"bytes,3,opt,name=region,proto3" json:"region,omitempty"`
+	MaxClients int32  `protobuf:"varint,4,opt,name=max_clients,json=maxClients,proto3" json:"max_clients,omitempty"`
+	// The server's WireGuard public key. Empty generates a key pair.
+	PublicKey                string `protobuf:"bytes,5,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
+	KeyRotationMaxAgeSeconds int64  `protobuf:"varint,6,opt,name=key_rotation_max_age_seconds,json=keyRotationMaxAgeSeconds,proto3" json:"key_rotation_max_age_seconds,omitempty"`
+	KeyRotationMode          string `protobuf:"bytes,7,opt,name=key_rotation_mode,json=keyRotationMode,proto3" json:"key_rotation_mode,omitempty"`
+	unknownFields            protoimpl.UnknownFields
+	sizeCache                protoimpl.SizeCache
 }
 
 func (x *CreateServerRequest) Reset() {
@@ -345,6 +655,20 @@ func (x *CreateServerRequest) GetPublicKey() string {
 	return ""
 }
 
+func (x *CreateServerRequest) GetKeyRotationMaxAgeSeconds() int64 {
+	if x != nil {
+		return x.KeyRotationMaxAgeSeconds
+	}
+	return 0
+}
+
+func (x *CreateServerRequest) GetKeyRotationMode() string {
+	if x != nil {
+		return x.KeyRotationMode
+	}
+	return ""
+}
+
 type CreateServerResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Server        *Server                `protobuf:"bytes,1,opt,name=server,proto3" json:"server,omitempty"`
@@ -565,27 +889,39 @@ func (x *GetServerResponse) GetServer() *Server {
 	return nil
 }
 
-type GenerateConfigRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	ServerId      string                 `protobuf:"bytes,1,opt,name=server_id,json=serverId,proto3" json:"server_id,omitempty"`
+// UpdateServerRequest replaces the editable fields of a server. It fails
+// with FAILED_PRECONDITION if version is not the server's current version,
+// and with ABORTED if another edit lands while it is being applied.
+type UpdateServerRequest struct {
+	state                    protoimpl.MessageState `protogen:"open.v1"`
+	ServerId                 string                 `protobuf:"bytes,1,opt,name=server_id,json=serverId,proto3" json:"server_id,omitempty"`
+	Version                  int64                  `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	Name                     string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	Endpoint                 string                 `protobuf:"bytes,4,opt,name=endpoint,proto3" json:"endpoint,omitempty"`
+	Region                   string                 `protobuf:"bytes,5,opt,name=region,proto3" json:"region,omitempty"`
+	MaxClients               int32                  `protobuf:"varint,6,opt,name=max_clients,json=maxClients,proto3" json:"max_clients,omitempty"`
+	KeyRotationMaxAgeSeconds int64                  `protobuf:"varint,7,opt,name=key_rotation_max_age_seconds,json=keyRotationMaxAgeSeconds,proto3" json:"key_rotation_max_age_seconds,omitempty"`
+	KeyRotationMode          string                 `protobuf:"bytes,8,opt,name=key_rotation_mode,json=keyRotationMode,proto3" json:"key_rotation_mode,omitempty"`
+	// Empty keeps the current status.
+	Status        string `protobuf:"bytes,9,opt,name=status,proto3" json:"status,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GenerateConfigRequest) Reset() {
-	*x = GenerateConfigRequest{}
+func (x *UpdateServerRequest) Reset() {
+	*x = UpdateServerRequest{}
 	mi := &file_vpn_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GenerateConfigRequest) String() string {
+func (x *UpdateServerRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GenerateConfigRequest) ProtoMessage() {}
+func (*UpdateServerRequest) ProtoMessage() {}
 
-func (x *GenerateConfigRequest) ProtoReflect() protoreflect.Message {
+func (x *UpdateServerRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_vpn_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -597,115 +933,96 @@ func (x *GenerateConfigRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GenerateConfigRequest.ProtoReflect.Descriptor instead.
-func (*GenerateConfigRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use UpdateServerRequest.ProtoReflect.Descriptor instead.
+func (*UpdateServerRequest) Descriptor() ([]byte, []int) {
 	return file_vpn_proto_rawDescGZIP(), []int{10}
 }
 
-func (x *GenerateConfigRequest) GetServerId() string {
+func (x *UpdateServerRequest) GetServerId() string {
 	if x != nil {
 		return x.ServerId
 	}
 	return ""
 }
 
-type GenerateConfigResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	ConfigContent string                 `protobuf:"bytes,1,opt,name=config_content,json=configContent,proto3" json:"config_content,omitempty"`
-	QrCodeBase64  string                 `protobuf:"bytes,2,opt,name=qr_code_base64,json=qrCodeBase64,proto3" json:"qr_code_base64,omitempty"`
-	ConfigData    *ConfigData            `protobuf:"bytes,3,opt,name=config_data,json=configData,proto3" json:"config_data,omitempty"`
-	Message       string                 `protobuf:"bytes,4,opt,name=message,proto3" json:"message,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
-}
-
-func (x *GenerateConfigResponse) Reset() {
-	*x = GenerateConfigResponse{}
-	mi := &file_vpn_proto_msgTypes[11]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
+func (x *UpdateServerRequest) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
 }
 
-func (x *GenerateConfigResponse) String() string {
-	return protoimpl.X.MessageStringOf(x)
+func (x *UpdateServerRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
 }
 
-func (*GenerateConfigResponse) ProtoMessage() {}
-
-func (x *GenerateConfigResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_vpn_proto_msgTypes[11]
+func (x *UpdateServerRequest) GetEndpoint() string {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.Endpoint
 	}
-	return mi.MessageOf(x)
+	return ""
 }
 
-// Deprecated: Use GenerateConfigResponse.ProtoReflect.Descriptor instead.
-func (*GenerateConfigResponse) Descriptor() ([]byte, []int) {
-	return file_vpn_proto_rawDescGZIP(), []int{11}
+func (x *UpdateServerRequest) GetRegion() string {
+	if x != nil {
+		return x.Region
+	}
+	return ""
 }
 
-func (x *GenerateConfigResponse) GetConfigContent() string {
+func (x *UpdateServerRequest) GetMaxClients() int32 {
 	if x != nil {
-		return x.ConfigContent
+		return x.MaxClients
 	}
-	return ""
+	return 0
 }
 
-func (x *GenerateConfigResponse) GetQrCodeBase64() string {
+func (x *UpdateServerRequest) GetKeyRotationMaxAgeSeconds() int64 {
 	if x != nil {
-		return x.QrCodeBase64
+		return x.KeyRotationMaxAgeSeconds
 	}
-	return ""
+	return 0
 }
 
-func (x *GenerateConfigResponse) GetConfigData() *ConfigData {
+func (x *UpdateServerRequest) GetKeyRotationMode() string {
 	if x != nil {
-		return x.ConfigData
+		return x.KeyRotationMode
 	}
-	return nil
+	return ""
 }
 
-func (x *GenerateConfigResponse) GetMessage() string {
+func (x *UpdateServerRequest) GetStatus() string {
 	if x != nil {
-		return x.Message
+		return x.Status
 	}
 	return ""
 }
 
-type ConfigData struct {
-	state           protoimpl.MessageState `protogen:"open.v1"`
-	PrivateKey      string                 `protobuf:"bytes,1,opt,name=private_key,json=privateKey,proto3" json:"private_key,omitempty"`
-	PublicKey       string                 `protobuf:"bytes,2,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
-	ServerPublicKey string                 `protobuf:"bytes,3,opt,name=server_public_key,json=serverPublicKey,proto3" json:"server_public_key,omitempty"`
-	ServerEndpoint  string                 `protobuf:"bytes,4,opt,name=server_endpoint,json=serverEndpoint,proto3" json:"server_endpoint,omitempty"`
-	ServerAddress   string                 `protobuf:"bytes,5,opt,name=server_address,json=serverAddress,proto3" json:"server_address,omitempty"`
-	ServerPort      string                 `protobuf:"bytes,6,opt,name=server_port,json=serverPort,proto3" json:"server_port,omitempty"`
-	ClientIp        string                 `protobuf:"bytes,7,opt,name=client_ip,json=clientIp,proto3" json:"client_ip,omitempty"`
-	Dns             string                 `protobuf:"bytes,8,opt,name=dns,proto3" json:"dns,omitempty"`
-	unknownFields   protoimpl.UnknownFields
-	sizeCache       protoimpl.SizeCache
+type UpdateServerResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Server        *Server                `protobuf:"bytes,1,opt,name=server,proto3" json:"server,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ConfigData) Reset() {
-	*x = ConfigData{}
-	mi := &file_vpn_proto_msgTypes[12]
+func (x *UpdateServerResponse) Reset() {
+	*x = UpdateServerResponse{}
+	mi := &file_vpn_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ConfigData) String() string {
+func (x *UpdateServerResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ConfigData) ProtoMessage() {}
+func (*UpdateServerResponse) ProtoMessage() {}
 
-func (x *ConfigData) ProtoReflect() protoreflect.Message {
-	mi := &file_vpn_proto_msgTypes[12]
+func (x *UpdateServerResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_vpn_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -716,90 +1033,1986 @@ func (x *ConfigData) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ConfigData.ProtoReflect.Descriptor instead.
-func (*ConfigData) Descriptor() ([]byte, []int) {
-	return file_vpn_proto_rawDescGZIP(), []int{12}
+// Deprecated: Use UpdateServerResponse.ProtoReflect.Descriptor instead.
+func (*UpdateServerResponse) Descriptor() ([]byte, []int) {
+	return file_vpn_proto_rawDescGZIP(), []int{11}
 }
 
-func (x *ConfigData) GetPrivateKey() string {
+func (x *UpdateServerResponse) GetServer() *Server {
 	if x != nil {
-		return x.PrivateKey
+		return x.Server
 	}
-	return ""
+	return nil
 }
 
-func (x *ConfigData) GetPublicKey() string {
-	if x != nil {
-		return x.PublicKey
-	}
-	return ""
+type WatchPeersRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	ServerId string                 `protobuf:"bytes,1,opt,name=server_id,json=serverId,proto3" json:"server_id,omitempty"`
+	// resume_token from the last response received. The watch then starts
+	// with the changes missed since, or with a snapshot if the token is too
+	// old. Empty starts with a snapshot.
+	ResumeToken   string `protobuf:"bytes,2,opt,name=resume_token,json=resumeToken,proto3" json:"resume_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ConfigData) GetServerPublicKey() string {
+func (x *WatchPeersRequest) Reset() {
+	*x = WatchPeersRequest{}
+	mi := &file_vpn_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchPeersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchPeersRequest) ProtoMessage() {}
+
+func (x *WatchPeersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_vpn_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchPeersRequest.ProtoReflect.Descriptor instead.
+func (*WatchPeersRequest) Descriptor() ([]byte, []int) {
+	return file_vpn_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *WatchPeersRequest) GetServerId() string {
+	if x != nil {
+		return x.ServerId
+	}
+	return ""
+}
+
+func (x *WatchPeersRequest) GetResumeToken() string {
+	if x != nil {
+		return x.ResumeToken
+	}
+	return ""
+}
+
+type Peer struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PeerId        string                 `protobuf:"bytes,1,opt,name=peer_id,json=peerId,proto3" json:"peer_id,omitempty"`
+	UserId        string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	PublicKey     string                 `protobuf:"bytes,3,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
+	IpAddress     string                 `protobuf:"bytes,4,opt,name=ip_address,json=ipAddress,proto3" json:"ip_address,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Peer) Reset() {
+	*x = Peer{}
+	mi := &file_vpn_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Peer) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Peer) ProtoMessage() {}
+
+func (x *Peer) ProtoReflect() protoreflect.Message {
+	mi := &file_vpn_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Peer.ProtoReflect.Descriptor instead.
+func (*Peer) Descriptor() ([]byte, []int) {
+	return file_vpn_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *Peer) GetPeerId() string {
+	if x != nil {
+		return x.PeerId
+	}
+	return ""
+}
+
+func (x *Peer) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *Peer) GetPublicKey() string {
+	if x != nil {
+		return x.PublicKey
+	}
+	return ""
+}
+
+func (x *Peer) GetIpAddress() string {
+	if x != nil {
+		return x.IpAddress
+	}
+	return ""
+}
+
+type PeerChange struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Type      PeerChange_Type        `protobuf:"varint,1,opt,name=type,proto3,enum=vpn.PeerChange_Type" json:"type,omitempty"`
+	PeerId    string                 `protobuf:"bytes,2,opt,name=peer_id,json=peerId,proto3" json:"peer_id,omitempty"`
+	UserId    string                 `protobuf:"bytes,3,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	PublicKey string                 `protobuf:"bytes,4,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
+	// Set for rotations.
+	PreviousPublicKey string `protobuf:"bytes,5,opt,name=previous_public_key,json=previousPublicKey,proto3" json:"previous_public_key,omitempty"`
+	// Set for additions and removals.
+	IpAddress string `protobuf:"bytes,6,opt,name=ip_address,json=ipAddress,proto3" json:"ip_address,omitempty"`
+	// Unix milliseconds.
+	OccurredAt    int64 `protobuf:"varint,7,opt,name=occurred_at,json=occurredAt,proto3" json:"occurred_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PeerChange) Reset() {
+	*x = PeerChange{}
+	mi := &file_vpn_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PeerChange) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PeerChange) ProtoMessage() {}
+
+func (x *PeerChange) ProtoReflect() protoreflect.Message {
+	mi := &file_vpn_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PeerChange.ProtoReflect.Descriptor instead.
+func (*PeerChange) Descriptor() ([]byte, []int) {
+	return file_vpn_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *PeerChange) GetType() PeerChange_Type {
+	if x != nil {
+		return x.Type
+	}
+	return PeerChange_TYPE_UNSPECIFIED
+}
+
+func (x *PeerChange) GetPeerId() string {
+	if x != nil {
+		return x.PeerId
+	}
+	return ""
+}
+
+func (x *PeerChange) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *PeerChange) GetPublicKey() string {
+	if x != nil {
+		return x.PublicKey
+	}
+	return ""
+}
+
+func (x *PeerChange) GetPreviousPublicKey() string {
+	if x != nil {
+		return x.PreviousPublicKey
+	}
+	return ""
+}
+
+func (x *PeerChange) GetIpAddress() string {
+	if x != nil {
+		return x.IpAddress
+	}
+	return ""
+}
+
+func (x *PeerChange) GetOccurredAt() int64 {
+	if x != nil {
+		return x.OccurredAt
+	}
+	return 0
+}
+
+// Changes can repeat ones already applied after a resume or a snapshot;
+// applying them in order always ends in the current state. A response
+// with neither a snapshot nor changes is a heartbeat.
+type WatchPeersResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// If set, peers replaces every peer the agent knows.
+	Snapshot      bool          `protobuf:"varint,1,opt,name=snapshot,proto3" json:"snapshot,omitempty"`
+	Peers         []*Peer       `protobuf:"bytes,2,rep,name=peers,proto3" json:"peers,omitempty"`
+	Changes       []*PeerChange `protobuf:"bytes,3,rep,name=changes,proto3" json:"changes,omitempty"`
+	ResumeToken   string        `protobuf:"bytes,4,opt,name=resume_token,json=resumeToken,proto3" json:"resume_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchPeersResponse) Reset() {
+	*x = WatchPeersResponse{}
+	mi := &file_vpn_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchPeersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchPeersResponse) ProtoMessage() {}
+
+func (x *WatchPeersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_vpn_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchPeersResponse.ProtoReflect.Descriptor instead.
+func (*WatchPeersResponse) Descriptor() ([]byte, []int) {
+	return file_vpn_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *WatchPeersResponse) GetSnapshot() bool {
+	if x != nil {
+		return x.Snapshot
+	}
+	return false
+}
+
+func (x *WatchPeersResponse) GetPeers() []*Peer {
+	if x != nil {
+		return x.Peers
+	}
+	return nil
+}
+
+func (x *WatchPeersResponse) GetChanges() []*PeerChange {
+	if x != nil {
+		return x.Changes
+	}
+	return nil
+}
+
+func (x *WatchPeersResponse) GetResumeToken() string {
+	if x != nil {
+		return x.ResumeToken
+	}
+	return ""
+}
+
+type RevokePeerRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ServerId      string                 `protobuf:"bytes,1,opt,name=server_id,json=serverId,proto3" json:"server_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RevokePeerRequest) Reset() {
+	*x = RevokePeerRequest{}
+	mi := &file_vpn_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RevokePeerRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RevokePeerRequest) ProtoMessage() {}
+
+func (x *RevokePeerRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_vpn_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RevokePeerRequest.ProtoReflect.Descriptor instead.
+func (*RevokePeerRequest) Descriptor() ([]byte, []int) {
+	return file_vpn_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *RevokePeerRequest) GetServerId() string {
+	if x != nil {
+		return x.ServerId
+	}
+	return ""
+}
+
+type RevokePeerResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RevokePeerResponse) Reset() {
+	*x = RevokePeerResponse{}
+	mi := &file_vpn_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RevokePeerResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RevokePeerResponse) ProtoMessage() {}
+
+func (x *RevokePeerResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_vpn_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RevokePeerResponse.ProtoReflect.Descriptor instead.
+func (*RevokePeerResponse) Descriptor() ([]byte, []int) {
+	return file_vpn_proto_rawDescGZIP(), []int{17}
+}
+
+type QRCodeOptions struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Pixels; zero picks the default.
+	Size          int32           `protobuf:"varint,1,opt,name=size,proto3" json:"size,omitempty"`
+	RecoveryLevel QRRecoveryLevel `protobuf:"varint,2,opt,name=recovery_level,json=recoveryLevel,proto3,enum=vpn.QRRecoveryLevel" json:"recovery_level,omitempty"`
+	Format        QRCodeFormat    `protobuf:"varint,3,opt,name=format,proto3,enum=vpn.QRCodeFormat" json:"format,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QRCodeOptions) Reset() {
+	*x = QRCodeOptions{}
+	mi := &file_vpn_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QRCodeOptions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QRCodeOptions) ProtoMessage() {}
+
+func (x *QRCodeOptions) ProtoReflect() protoreflect.Message {
+	mi := &file_vpn_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QRCodeOptions.ProtoReflect.Descriptor instead.
+func (*QRCodeOptions) Descriptor() ([]byte, []int) {
+	return file_vpn_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *QRCodeOptions) GetSize() int32 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *QRCodeOptions) GetRecoveryLevel() QRRecoveryLevel {
+	if x != nil {
+		return x.RecoveryLevel
+	}
+	return QRRecoveryLevel_QR_RECOVERY_LEVEL_UNSPECIFIED
+}
+
+func (x *QRCodeOptions) GetFormat() QRCodeFormat {
+	if x != nil {
+		return x.Format
+	}
+	return QRCodeFormat_QR_CODE_FORMAT_UNSPECIFIED
+}
+
+type GenerateConfigRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ServerId      string                 `protobuf:"bytes,1,opt,name=server_id,json=serverId,proto3" json:"server_id,omitempty"`
+	Format        ConfigFormat           `protobuf:"varint,2,opt,name=format,proto3,enum=vpn.ConfigFormat" json:"format,omitempty"`
+	QrCodeOptions *QRCodeOptions         `protobuf:"bytes,3,opt,name=qr_code_options,json=qrCodeOptions,proto3" json:"qr_code_options,omitempty"`
+	// RotateKeys only: fail with FAILED_PRECONDITION unless the keys are
+	// still at this version. Zero skips the check.
+	ExpectedKeysVersion int64 `protobuf:"varint,4,opt,name=expected_keys_version,json=expectedKeysVersion,proto3" json:"expected_keys_version,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *GenerateConfigRequest) Reset() {
+	*x = GenerateConfigRequest{}
+	mi := &file_vpn_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GenerateConfigRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GenerateConfigRequest) ProtoMessage() {}
+
+func (x *GenerateConfigRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_vpn_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GenerateConfigRequest.ProtoReflect.Descriptor instead.
+func (*GenerateConfigRequest) Descriptor() ([]byte, []int) {
+	return file_vpn_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *GenerateConfigRequest) GetServerId() string {
+	if x != nil {
+		return x.ServerId
+	}
+	return ""
+}
+
+func (x *GenerateConfigRequest) GetFormat() ConfigFormat {
+	if x != nil {
+		return x.Format
+	}
+	return ConfigFormat_CONFIG_FORMAT_UNSPECIFIED
+}
+
+func (x *GenerateConfigRequest) GetQrCodeOptions() *QRCodeOptions {
+	if x != nil {
+		return x.QrCodeOptions
+	}
+	return nil
+}
+
+func (x *GenerateConfigRequest) GetExpectedKeysVersion() int64 {
+	if x != nil {
+		return x.ExpectedKeysVersion
+	}
+	return 0
+}
+
+type GenerateConfigResponse struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	ConfigContent     string                 `protobuf:"bytes,1,opt,name=config_content,json=configContent,proto3" json:"config_content,omitempty"`
+	QrCodeBase64      string                 `protobuf:"bytes,2,opt,name=qr_code_base64,json=qrCodeBase64,proto3" json:"qr_code_base64,omitempty"`
+	ConfigData        *ConfigData            `protobuf:"bytes,3,opt,name=config_data,json=configData,proto3" json:"config_data,omitempty"`
+	Message           string                 `protobuf:"bytes,4,opt,name=message,proto3" json:"message,omitempty"`
+	Export            *ConfigExport          `protobuf:"bytes,5,opt,name=export,proto3" json:"export,omitempty"`
+	QrCodeContentType string                 `protobuf:"bytes,6,opt,name=qr_code_content_type,json=qrCodeContentType,proto3" json:"qr_code_content_type,omitempty"`
+	QrCodeError       string                 `protobuf:"bytes,7,opt,name=qr_code_error,json=qrCodeError,proto3" json:"qr_code_error,omitempty"`
+	RotationRequired  bool                   `protobuf:"varint,8,opt,name=rotation_required,json=rotationRequired,proto3" json:"rotation_required,omitempty"`
+	KeysVersion       int64                  `protobuf:"varint,9,opt,name=keys_version,json=keysVersion,proto3" json:"keys_version,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *GenerateConfigResponse) Reset() {
+	*x = GenerateConfigResponse{}
+	mi := &file_vpn_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GenerateConfigResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GenerateConfigResponse) ProtoMessage() {}
+
+func (x *GenerateConfigResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_vpn_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GenerateConfigResponse.ProtoReflect.Descriptor instead.
+func (*GenerateConfigResponse) Descriptor() ([]byte, []int) {
+	return file_vpn_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *GenerateConfigResponse) GetConfigContent() string {
+	if x != nil {
+		return x.ConfigContent
+	}
+	return ""
+}
+
+func (x *GenerateConfigResponse) GetQrCodeBase64() string {
+	if x != nil {
+		return x.QrCodeBase64
+	}
+	return ""
+}
+
+func (x *GenerateConfigResponse) GetConfigData() *ConfigData {
+	if x != nil {
+		return x.ConfigData
+	}
+	return nil
+}
+
+func (x *GenerateConfigResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *GenerateConfigResponse) GetExport() *ConfigExport {
+	if x != nil {
+		return x.Export
+	}
+	return nil
+}
+
+func (x *GenerateConfigResponse) GetQrCodeContentType() string {
+	if x != nil {
+		return x.QrCodeContentType
+	}
+	return ""
+}
+
+func (x *GenerateConfigResponse) GetQrCodeError() string {
+	if x != nil {
+		return x.QrCodeError
+	}
+	return ""
+}
+
+func (x *GenerateConfigResponse) GetRotationRequired() bool {
+	if x != nil {
+		return x.RotationRequired
+	}
+	return false
+}
+
+func (x *GenerateConfigResponse) GetKeysVersion() int64 {
+	if x != nil {
+		return x.KeysVersion
+	}
+	return 0
+}
+
+type ConfigExport struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Format        ConfigFormat           `protobuf:"varint,1,opt,name=format,proto3,enum=vpn.ConfigFormat" json:"format,omitempty"`
+	Content       string                 `protobuf:"bytes,2,opt,name=content,proto3" json:"content,omitempty"`
+	FileName      string                 `protobuf:"bytes,3,opt,name=file_name,json=fileName,proto3" json:"file_name,omitempty"`
+	ContentType   string                 `protobuf:"bytes,4,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConfigExport) Reset() {
+	*x = ConfigExport{}
+	mi := &file_vpn_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConfigExport) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConfigExport) ProtoMessage() {}
+
+func (x *ConfigExport) ProtoReflect() protoreflect.Message {
+	mi := &file_vpn_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConfigExport.ProtoReflect.Descriptor instead.
+func (*ConfigExport) Descriptor() ([]byte, []int) {
+	return file_vpn_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *ConfigExport) GetFormat() ConfigFormat {
+	if x != nil {
+		return x.Format
+	}
+	return ConfigFormat_CONFIG_FORMAT_UNSPECIFIED
+}
+
+func (x *ConfigExport) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *ConfigExport) GetFileName() string {
+	if x != nil {
+		return x.FileName
+	}
+	return ""
+}
+
+func (x *ConfigExport) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+type ConfigData struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	PrivateKey      string                 `protobuf:"bytes,1,opt,name=private_key,json=privateKey,proto3" json:"private_key,omitempty"`
+	PublicKey       string                 `protobuf:"bytes,2,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
+	ServerPublicKey string                 `protobuf:"bytes,3,opt,name=server_public_key,json=serverPublicKey,proto3" json:"server_public_key,omitempty"`
+	ServerEndpoint  string                 `protobuf:"bytes,4,opt,name=server_endpoint,json=serverEndpoint,proto3" json:"server_endpoint,omitempty"`
+	ServerAddress   string                 `protobuf:"bytes,5,opt,name=server_address,json=serverAddress,proto3" json:"server_address,omitempty"`
+	ServerPort      string                 `protobuf:"bytes,6,opt,name=server_port,json=serverPort,proto3" json:"server_port,omitempty"`
+	ClientIp        string                 `protobuf:"bytes,7,opt,name=client_ip,json=clientIp,proto3" json:"client_ip,omitempty"`
+	Dns             string                 `protobuf:"bytes,8,opt,name=dns,proto3" json:"dns,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *ConfigData) Reset() {
+	*x = ConfigData{}
+	mi := &file_vpn_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConfigData) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConfigData) ProtoMessage() {}
+
+func (x *ConfigData) ProtoReflect() protoreflect.Message {
+	mi := &file_vpn_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConfigData.ProtoReflect.Descriptor instead.
+func (*ConfigData) Descriptor() ([]byte, []int) {
+	return file_vpn_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *ConfigData) GetPrivateKey() string {
+	if x != nil {
+		return x.PrivateKey
+	}
+	return ""
+}
+
+func (x *ConfigData) GetPublicKey() string {
+	if x != nil {
+		return x.PublicKey
+	}
+	return ""
+}
+
+func (x *ConfigData) GetServerPublicKey() string {
+	if x != nil {
+		return x.ServerPublicKey
+	}
+	return ""
+}
+
+func (x *ConfigData) GetServerEndpoint() string {
+	if x != nil {
+		return x.ServerEndpoint
+	}
+	return ""
+}
+
+func (x *ConfigData) GetServerAddress() string {
+	if x != nil {
+		return x.ServerAddress
+	}
+	return ""
+}
+
+func (x *ConfigData) GetServerPort() string {
+	if x != nil {
+		return x.ServerPort
+	}
+	return ""
+}
+
+func (x *ConfigData) GetClientIp() string {
+	if x != nil {
+		return x.ClientIp
+	}
+	return ""
+}
+
+func (x *ConfigData) GetDns() string {
+	if x != nil {
+		return x.Dns
+	}
+	return ""
+}
+
+type GetConfigRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ServerId      string                 `protobuf:"bytes,1,opt,name=server_id,json=serverId,proto3" json:"server_id,omitempty"`
+	UserId        string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetConfigRequest) Reset() {
+	*x = GetConfigRequest{}
+	mi := &file_vpn_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetConfigRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetConfigRequest) ProtoMessage() {}
+
+func (x *GetConfigRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_vpn_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetConfigRequest.ProtoReflect.Descriptor instead.
+func (*GetConfigRequest) Descriptor() ([]byte, []int) {
+	return file_vpn_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *GetConfigRequest) GetServerId() string {
+	if x != nil {
+		return x.ServerId
+	}
+	return ""
+}
+
+func (x *GetConfigRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+type GetConfigResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ConfigData    *ConfigData            `protobuf:"bytes,1,opt,name=config_data,json=configData,proto3" json:"config_data,omitempty"`
+	ConfigContent string                 `protobuf:"bytes,2,opt,name=config_content,json=configContent,proto3" json:"config_content,omitempty"`
+	QrCodeBase64  string                 `protobuf:"bytes,3,opt,name=qr_code_base64,json=qrCodeBase64,proto3" json:"qr_code_base64,omitempty"`
+	KeysVersion   int64                  `protobuf:"varint,4,opt,name=keys_version,json=keysVersion,proto3" json:"keys_version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetConfigResponse) Reset() {
+	*x = GetConfigResponse{}
+	mi := &file_vpn_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetConfigResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetConfigResponse) ProtoMessage() {}
+
+func (x *GetConfigResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_vpn_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetConfigResponse.ProtoReflect.Descriptor instead.
+func (*GetConfigResponse) Descriptor() ([]byte, []int) {
+	return file_vpn_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *GetConfigResponse) GetConfigData() *ConfigData {
+	if x != nil {
+		return x.ConfigData
+	}
+	return nil
+}
+
+func (x *GetConfigResponse) GetConfigContent() string {
+	if x != nil {
+		return x.ConfigContent
+	}
+	return ""
+}
+
+func (x *GetConfigResponse) GetQrCodeBase64() string {
+	if x != nil {
+		return x.QrCodeBase64
+	}
+	return ""
+}
+
+func (x *GetConfigResponse) GetKeysVersion() int64 {
+	if x != nil {
+		return x.KeysVersion
+	}
+	return 0
+}
+
+type CreateConfigShareLinkRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	ServerId string                 `protobuf:"bytes,1,opt,name=server_id,json=serverId,proto3" json:"server_id,omitempty"`
+	Kind     ShareLinkKind          `protobuf:"varint,2,opt,name=kind,proto3,enum=vpn.ShareLinkKind" json:"kind,omitempty"`
+	// Zero means a day; at most a week.
+	TtlSeconds    int64 `protobuf:"varint,3,opt,name=ttl_seconds,json=ttlSeconds,proto3" json:"ttl_seconds,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateConfigShareLinkRequest) Reset() {
+	*x = CreateConfigShareLinkRequest{}
+	mi := &file_vpn_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateConfigShareLinkRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateConfigShareLinkRequest) ProtoMessage() {}
+
+func (x *CreateConfigShareLinkRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_vpn_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateConfigShareLinkRequest.ProtoReflect.Descriptor instead.
+func (*CreateConfigShareLinkRequest) Descriptor() ([]byte, []int) {
+	return file_vpn_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *CreateConfigShareLinkRequest) GetServerId() string {
+	if x != nil {
+		return x.ServerId
+	}
+	return ""
+}
+
+func (x *CreateConfigShareLinkRequest) GetKind() ShareLinkKind {
+	if x != nil {
+		return x.Kind
+	}
+	return ShareLinkKind_SHARE_LINK_KIND_UNSPECIFIED
+}
+
+func (x *CreateConfigShareLinkRequest) GetTtlSeconds() int64 {
+	if x != nil {
+		return x.TtlSeconds
+	}
+	return 0
+}
+
+type CreateConfigShareLinkResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Token         string                 `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	Url           string                 `protobuf:"bytes,2,opt,name=url,proto3" json:"url,omitempty"`
+	ExpiresAt     int64                  `protobuf:"varint,3,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	Kind          ShareLinkKind          `protobuf:"varint,4,opt,name=kind,proto3,enum=vpn.ShareLinkKind" json:"kind,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateConfigShareLinkResponse) Reset() {
+	*x = CreateConfigShareLinkResponse{}
+	mi := &file_vpn_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateConfigShareLinkResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateConfigShareLinkResponse) ProtoMessage() {}
+
+func (x *CreateConfigShareLinkResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_vpn_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateConfigShareLinkResponse.ProtoReflect.Descriptor instead.
+func (*CreateConfigShareLinkResponse) Descriptor() ([]byte, []int) {
+	return file_vpn_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *CreateConfigShareLinkResponse) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *CreateConfigShareLinkResponse) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *CreateConfigShareLinkResponse) GetExpiresAt() int64 {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return 0
+}
+
+func (x *CreateConfigShareLinkResponse) GetKind() ShareLinkKind {
+	if x != nil {
+		return x.Kind
+	}
+	return ShareLinkKind_SHARE_LINK_KIND_UNSPECIFIED
+}
+
+type AuditEvent struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Id       string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Sequence int64                  `protobuf:"varint,2,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	// Unix milliseconds.
+	OccurredAt    int64  `protobuf:"varint,3,opt,name=occurred_at,json=occurredAt,proto3" json:"occurred_at,omitempty"`
+	ActorId       string `protobuf:"bytes,4,opt,name=actor_id,json=actorId,proto3" json:"actor_id,omitempty"`
+	ActorEmail    string `protobuf:"bytes,5,opt,name=actor_email,json=actorEmail,proto3" json:"actor_email,omitempty"`
+	Action        string `protobuf:"bytes,6,opt,name=action,proto3" json:"action,omitempty"`
+	TargetType    string `protobuf:"bytes,7,opt,name=target_type,json=targetType,proto3" json:"target_type,omitempty"`
+	TargetId      string `protobuf:"bytes,8,opt,name=target_id,json=targetId,proto3" json:"target_id,omitempty"`
+	Procedure     string `protobuf:"bytes,9,opt,name=procedure,proto3" json:"procedure,omitempty"`
+	SourceIp      string `protobuf:"bytes,10,opt,name=source_ip,json=sourceIp,proto3" json:"source_ip,omitempty"`
+	UserAgent     string `protobuf:"bytes,11,opt,name=user_agent,json=userAgent,proto3" json:"user_agent,omitempty"`
+	Outcome       string `protobuf:"bytes,12,opt,name=outcome,proto3" json:"outcome,omitempty"`
+	Reason        string `protobuf:"bytes,13,opt,name=reason,proto3" json:"reason,omitempty"`
+	PrevHash      string `protobuf:"bytes,14,opt,name=prev_hash,json=prevHash,proto3" json:"prev_hash,omitempty"`
+	Hash          string `protobuf:"bytes,15,opt,name=hash,proto3" json:"hash,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AuditEvent) Reset() {
+	*x = AuditEvent{}
+	mi := &file_vpn_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AuditEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AuditEvent) ProtoMessage() {}
+
+func (x *AuditEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_vpn_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AuditEvent.ProtoReflect.Descriptor instead.
+func (*AuditEvent) Descriptor() ([]byte, []int) {
+	return file_vpn_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *AuditEvent) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *AuditEvent) GetSequence() int64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+func (x *AuditEvent) GetOccurredAt() int64 {
+	if x != nil {
+		return x.OccurredAt
+	}
+	return 0
+}
+
+func (x *AuditEvent) GetActorId() string {
+	if x != nil {
+		return x.ActorId
+	}
+	return ""
+}
+
+func (x *AuditEvent) GetActorEmail() string {
+	if x != nil {
+		return x.ActorEmail
+	}
+	return ""
+}
+
+func (x *AuditEvent) GetAction() string {
+	if x != nil {
+		return x.Action
+	}
+	return ""
+}
+
+func (x *AuditEvent) GetTargetType() string {
+	if x != nil {
+		return x.TargetType
+	}
+	return ""
+}
+
+func (x *AuditEvent) GetTargetId() string {
+	if x != nil {
+		return x.TargetId
+	}
+	return ""
+}
+
+func (x *AuditEvent) GetProcedure() string {
+	if x != nil {
+		return x.Procedure
+	}
+	return ""
+}
+
+func (x *AuditEvent) GetSourceIp() string {
+	if x != nil {
+		return x.SourceIp
+	}
+	return ""
+}
+
+func (x *AuditEvent) GetUserAgent() string {
+	if x != nil {
+		return x.UserAgent
+	}
+	return ""
+}
+
+func (x *AuditEvent) GetOutcome() string {
+	if x != nil {
+		return x.Outcome
+	}
+	return ""
+}
+
+func (x *AuditEvent) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *AuditEvent) GetPrevHash() string {
+	if x != nil {
+		return x.PrevHash
+	}
+	return ""
+}
+
+func (x *AuditEvent) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+type ListAuditEventsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Defaults to 50, at most 500.
+	PageSize   int32  `protobuf:"varint,1,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	PageToken  string `protobuf:"bytes,2,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	ActorId    string `protobuf:"bytes,3,opt,name=actor_id,json=actorId,proto3" json:"actor_id,omitempty"`
+	Action     string `protobuf:"bytes,4,opt,name=action,proto3" json:"action,omitempty"`
+	TargetType string `protobuf:"bytes,5,opt,name=target_type,json=targetType,proto3" json:"target_type,omitempty"`
+	TargetId   string `protobuf:"bytes,6,opt,name=target_id,json=targetId,proto3" json:"target_id,omitempty"`
+	Outcome    string `protobuf:"bytes,7,opt,name=outcome,proto3" json:"outcome,omitempty"`
+	// Unix seconds; since is inclusive, until exclusive.
+	Since         int64 `protobuf:"varint,8,opt,name=since,proto3" json:"since,omitempty"`
+	Until         int64 `protobuf:"varint,9,opt,name=until,proto3" json:"until,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListAuditEventsRequest) Reset() {
+	*x = ListAuditEventsRequest{}
+	mi := &file_vpn_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListAuditEventsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAuditEventsRequest) ProtoMessage() {}
+
+func (x *ListAuditEventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_vpn_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAuditEventsRequest.ProtoReflect.Descriptor instead.
+func (*ListAuditEventsRequest) Descriptor() ([]byte, []int) {
+	return file_vpn_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *ListAuditEventsRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListAuditEventsRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+func (x *ListAuditEventsRequest) GetActorId() string {
+	if x != nil {
+		return x.ActorId
+	}
+	return ""
+}
+
+func (x *ListAuditEventsRequest) GetAction() string {
+	if x != nil {
+		return x.Action
+	}
+	return ""
+}
+
+func (x *ListAuditEventsRequest) GetTargetType() string {
+	if x != nil {
+		return x.TargetType
+	}
+	return ""
+}
+
+func (x *ListAuditEventsRequest) GetTargetId() string {
+	if x != nil {
+		return x.TargetId
+	}
+	return ""
+}
+
+func (x *ListAuditEventsRequest) GetOutcome() string {
+	if x != nil {
+		return x.Outcome
+	}
+	return ""
+}
+
+func (x *ListAuditEventsRequest) GetSince() int64 {
+	if x != nil {
+		return x.Since
+	}
+	return 0
+}
+
+func (x *ListAuditEventsRequest) GetUntil() int64 {
+	if x != nil {
+		return x.Until
+	}
+	return 0
+}
+
+type ListAuditEventsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Newest first.
+	Events        []*AuditEvent `protobuf:"bytes,1,rep,name=events,proto3" json:"events,omitempty"`
+	NextPageToken string        `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListAuditEventsResponse) Reset() {
+	*x = ListAuditEventsResponse{}
+	mi := &file_vpn_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListAuditEventsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAuditEventsResponse) ProtoMessage() {}
+
+func (x *ListAuditEventsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_vpn_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAuditEventsResponse.ProtoReflect.Descriptor instead.
+func (*ListAuditEventsResponse) Descriptor() ([]byte, []int) {
+	return file_vpn_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *ListAuditEventsResponse) GetEvents() []*AuditEvent {
+	if x != nil {
+		return x.Events
+	}
+	return nil
+}
+
+func (x *ListAuditEventsResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+type OutboxEvent struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Id          string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Type        string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	AggregateId string                 `protobuf:"bytes,3,opt,name=aggregate_id,json=aggregateId,proto3" json:"aggregate_id,omitempty"`
+	// JSON encoded event fields.
+	Payload string `protobuf:"bytes,4,opt,name=payload,proto3" json:"payload,omitempty"`
+	// Unix milliseconds.
+	OccurredAt int64  `protobuf:"varint,5,opt,name=occurred_at,json=occurredAt,proto3" json:"occurred_at,omitempty"`
+	Attempts   int32  `protobuf:"varint,6,opt,name=attempts,proto3" json:"attempts,omitempty"`
+	LastError  string `protobuf:"bytes,7,opt,name=last_error,json=lastError,proto3" json:"last_error,omitempty"`
+	// Subscribers that handled the event before it was given up on.
+	Delivered     []string `protobuf:"bytes,8,rep,name=delivered,proto3" json:"delivered,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OutboxEvent) Reset() {
+	*x = OutboxEvent{}
+	mi := &file_vpn_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OutboxEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OutboxEvent) ProtoMessage() {}
+
+func (x *OutboxEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_vpn_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OutboxEvent.ProtoReflect.Descriptor instead.
+func (*OutboxEvent) Descriptor() ([]byte, []int) {
+	return file_vpn_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *OutboxEvent) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *OutboxEvent) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *OutboxEvent) GetAggregateId() string {
+	if x != nil {
+		return x.AggregateId
+	}
+	return ""
+}
+
+func (x *OutboxEvent) GetPayload() string {
+	if x != nil {
+		return x.Payload
+	}
+	return ""
+}
+
+func (x *OutboxEvent) GetOccurredAt() int64 {
+	if x != nil {
+		return x.OccurredAt
+	}
+	return 0
+}
+
+func (x *OutboxEvent) GetAttempts() int32 {
+	if x != nil {
+		return x.Attempts
+	}
+	return 0
+}
+
+func (x *OutboxEvent) GetLastError() string {
+	if x != nil {
+		return x.LastError
+	}
+	return ""
+}
+
+func (x *OutboxEvent) GetDelivered() []string {
+	if x != nil {
+		return x.Delivered
+	}
+	return nil
+}
+
+type ListDeadLetterEventsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Defaults to 50, at most 500.
+	PageSize      int32 `protobuf:"varint,1,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListDeadLetterEventsRequest) Reset() {
+	*x = ListDeadLetterEventsRequest{}
+	mi := &file_vpn_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDeadLetterEventsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDeadLetterEventsRequest) ProtoMessage() {}
+
+func (x *ListDeadLetterEventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_vpn_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDeadLetterEventsRequest.ProtoReflect.Descriptor instead.
+func (*ListDeadLetterEventsRequest) Descriptor() ([]byte, []int) {
+	return file_vpn_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *ListDeadLetterEventsRequest) GetPageSize() int32 {
 	if x != nil {
-		return x.ServerPublicKey
+		return x.PageSize
+	}
+	return 0
+}
+
+type ListDeadLetterEventsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Newest first.
+	Events        []*OutboxEvent `protobuf:"bytes,1,rep,name=events,proto3" json:"events,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListDeadLetterEventsResponse) Reset() {
+	*x = ListDeadLetterEventsResponse{}
+	mi := &file_vpn_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDeadLetterEventsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDeadLetterEventsResponse) ProtoMessage() {}
+
+func (x *ListDeadLetterEventsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_vpn_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDeadLetterEventsResponse.ProtoReflect.Descriptor instead.
+func (*ListDeadLetterEventsResponse) Descriptor() ([]byte, []int) {
+	return file_vpn_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *ListDeadLetterEventsResponse) GetEvents() []*OutboxEvent {
+	if x != nil {
+		return x.Events
+	}
+	return nil
+}
+
+type RequeueDeadLetterEventRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	EventId       string                 `protobuf:"bytes,1,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RequeueDeadLetterEventRequest) Reset() {
+	*x = RequeueDeadLetterEventRequest{}
+	mi := &file_vpn_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RequeueDeadLetterEventRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RequeueDeadLetterEventRequest) ProtoMessage() {}
+
+func (x *RequeueDeadLetterEventRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_vpn_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RequeueDeadLetterEventRequest.ProtoReflect.Descriptor instead.
+func (*RequeueDeadLetterEventRequest) Descriptor() ([]byte, []int) {
+	return file_vpn_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *RequeueDeadLetterEventRequest) GetEventId() string {
+	if x != nil {
+		return x.EventId
+	}
+	return ""
+}
+
+type RequeueDeadLetterEventResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RequeueDeadLetterEventResponse) Reset() {
+	*x = RequeueDeadLetterEventResponse{}
+	mi := &file_vpn_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RequeueDeadLetterEventResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RequeueDeadLetterEventResponse) ProtoMessage() {}
+
+func (x *RequeueDeadLetterEventResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_vpn_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RequeueDeadLetterEventResponse.ProtoReflect.Descriptor instead.
+func (*RequeueDeadLetterEventResponse) Descriptor() ([]byte, []int) {
+	return file_vpn_proto_rawDescGZIP(), []int{34}
+}
+
+type Webhook struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Id          string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Url         string                 `protobuf:"bytes,2,opt,name=url,proto3" json:"url,omitempty"`
+	EventTypes  []string               `protobuf:"bytes,3,rep,name=event_types,json=eventTypes,proto3" json:"event_types,omitempty"`
+	Description string                 `protobuf:"bytes,4,opt,name=description,proto3" json:"description,omitempty"`
+	Enabled     bool                   `protobuf:"varint,5,opt,name=enabled,proto3" json:"enabled,omitempty"`
+	// Unix milliseconds.
+	CreatedAt     int64 `protobuf:"varint,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Webhook) Reset() {
+	*x = Webhook{}
+	mi := &file_vpn_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Webhook) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Webhook) ProtoMessage() {}
+
+func (x *Webhook) ProtoReflect() protoreflect.Message {
+	mi := &file_vpn_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Webhook.ProtoReflect.Descriptor instead.
+func (*Webhook) Descriptor() ([]byte, []int) {
+	return file_vpn_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *Webhook) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Webhook) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *Webhook) GetEventTypes() []string {
+	if x != nil {
+		return x.EventTypes
+	}
+	return nil
+}
+
+func (x *Webhook) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Webhook) GetEnabled() bool {
+	if x != nil {
+		return x.Enabled
+	}
+	return false
+}
+
+func (x *Webhook) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+type WebhookDelivery struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Id        string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	WebhookId string                 `protobuf:"bytes,2,opt,name=webhook_id,json=webhookId,proto3" json:"webhook_id,omitempty"`
+	EventId   string                 `protobuf:"bytes,3,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
+	EventType string                 `protobuf:"bytes,4,opt,name=event_type,json=eventType,proto3" json:"event_type,omitempty"`
+	Attempt   int32                  `protobuf:"varint,5,opt,name=attempt,proto3" json:"attempt,omitempty"`
+	Test      bool                   `protobuf:"varint,6,opt,name=test,proto3" json:"test,omitempty"`
+	Success   bool                   `protobuf:"varint,7,opt,name=success,proto3" json:"success,omitempty"`
+	// Zero if no response was received.
+	StatusCode int32  `protobuf:"varint,8,opt,name=status_code,json=statusCode,proto3" json:"status_code,omitempty"`
+	Error      string `protobuf:"bytes,9,opt,name=error,proto3" json:"error,omitempty"`
+	DurationMs int64  `protobuf:"varint,10,opt,name=duration_ms,json=durationMs,proto3" json:"duration_ms,omitempty"`
+	// Unix milliseconds.
+	CreatedAt     int64 `protobuf:"varint,11,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WebhookDelivery) Reset() {
+	*x = WebhookDelivery{}
+	mi := &file_vpn_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WebhookDelivery) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WebhookDelivery) ProtoMessage() {}
+
+func (x *WebhookDelivery) ProtoReflect() protoreflect.Message {
+	mi := &file_vpn_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WebhookDelivery.ProtoReflect.Descriptor instead.
+func (*WebhookDelivery) Descriptor() ([]byte, []int) {
+	return file_vpn_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *WebhookDelivery) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *WebhookDelivery) GetWebhookId() string {
+	if x != nil {
+		return x.WebhookId
+	}
+	return ""
+}
+
+func (x *WebhookDelivery) GetEventId() string {
+	if x != nil {
+		return x.EventId
+	}
+	return ""
+}
+
+func (x *WebhookDelivery) GetEventType() string {
+	if x != nil {
+		return x.EventType
+	}
+	return ""
+}
+
+func (x *WebhookDelivery) GetAttempt() int32 {
+	if x != nil {
+		return x.Attempt
+	}
+	return 0
+}
+
+func (x *WebhookDelivery) GetTest() bool {
+	if x != nil {
+		return x.Test
+	}
+	return false
+}
+
+func (x *WebhookDelivery) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *WebhookDelivery) GetStatusCode() int32 {
+	if x != nil {
+		return x.StatusCode
+	}
+	return 0
+}
+
+func (x *WebhookDelivery) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *WebhookDelivery) GetDurationMs() int64 {
+	if x != nil {
+		return x.DurationMs
+	}
+	return 0
+}
+
+func (x *WebhookDelivery) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+type CreateWebhookRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Absolute http or https URL.
+	Url string `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+	// One or more of UserRegistered, PeerCreated, PeerRevoked, KeysRotated,
+	// ServerStatusChanged.
+	EventTypes    []string `protobuf:"bytes,2,rep,name=event_types,json=eventTypes,proto3" json:"event_types,omitempty"`
+	Description   string   `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateWebhookRequest) Reset() {
+	*x = CreateWebhookRequest{}
+	mi := &file_vpn_proto_msgTypes[37]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateWebhookRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateWebhookRequest) ProtoMessage() {}
+
+func (x *CreateWebhookRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_vpn_proto_msgTypes[37]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateWebhookRequest.ProtoReflect.Descriptor instead.
+func (*CreateWebhookRequest) Descriptor() ([]byte, []int) {
+	return file_vpn_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *CreateWebhookRequest) GetUrl() string {
+	if x != nil {
+		return x.Url
 	}
 	return ""
 }
 
-func (x *ConfigData) GetServerEndpoint() string {
+func (x *CreateWebhookRequest) GetEventTypes() []string {
 	if x != nil {
-		return x.ServerEndpoint
+		return x.EventTypes
 	}
-	return ""
+	return nil
 }
 
-func (x *ConfigData) GetServerAddress() string {
+func (x *CreateWebhookRequest) GetDescription() string {
 	if x != nil {
-		return x.ServerAddress
+		return x.Description
 	}
 	return ""
 }
 
-func (x *ConfigData) GetServerPort() string {
+type CreateWebhookResponse struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Webhook *Webhook               `protobuf:"bytes,1,opt,name=webhook,proto3" json:"webhook,omitempty"`
+	// Key for the HMAC-SHA256 signature in the X-Webhook-Signature header.
+	// It is only returned here.
+	Secret        string `protobuf:"bytes,2,opt,name=secret,proto3" json:"secret,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateWebhookResponse) Reset() {
+	*x = CreateWebhookResponse{}
+	mi := &file_vpn_proto_msgTypes[38]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateWebhookResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateWebhookResponse) ProtoMessage() {}
+
+func (x *CreateWebhookResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_vpn_proto_msgTypes[38]
 	if x != nil {
-		return x.ServerPort
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return ""
+	return mi.MessageOf(x)
 }
 
-func (x *ConfigData) GetClientIp() string {
+// Deprecated: Use CreateWebhookResponse.ProtoReflect.Descriptor instead.
+func (*CreateWebhookResponse) Descriptor() ([]byte, []int) {
+	return file_vpn_proto_rawDescGZIP(), []int{38}
+}
+
+func (x *CreateWebhookResponse) GetWebhook() *Webhook {
 	if x != nil {
-		return x.ClientIp
+		return x.Webhook
 	}
-	return ""
+	return nil
 }
 
-func (x *ConfigData) GetDns() string {
+func (x *CreateWebhookResponse) GetSecret() string {
 	if x != nil {
-		return x.Dns
+		return x.Secret
 	}
 	return ""
 }
 
-type GetConfigRequest struct {
+type ListWebhooksRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	ServerId      string                 `protobuf:"bytes,1,opt,name=server_id,json=serverId,proto3" json:"server_id,omitempty"`
-	UserId        string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetConfigRequest) Reset() {
-	*x = GetConfigRequest{}
-	mi := &file_vpn_proto_msgTypes[13]
+func (x *ListWebhooksRequest) Reset() {
+	*x = ListWebhooksRequest{}
+	mi := &file_vpn_proto_msgTypes[39]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetConfigRequest) String() string {
+func (x *ListWebhooksRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetConfigRequest) ProtoMessage() {}
+func (*ListWebhooksRequest) ProtoMessage() {}
 
-func (x *GetConfigRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_vpn_proto_msgTypes[13]
+func (x *ListWebhooksRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_vpn_proto_msgTypes[39]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -810,49 +3023,120 @@ func (x *GetConfigRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetConfigRequest.ProtoReflect.Descriptor instead.
-func (*GetConfigRequest) Descriptor() ([]byte, []int) {
-	return file_vpn_proto_rawDescGZIP(), []int{13}
+// Deprecated: Use ListWebhooksRequest.ProtoReflect.Descriptor instead.
+func (*ListWebhooksRequest) Descriptor() ([]byte, []int) {
+	return file_vpn_proto_rawDescGZIP(), []int{39}
 }
 
-func (x *GetConfigRequest) GetServerId() string {
+type ListWebhooksResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Webhooks      []*Webhook             `protobuf:"bytes,1,rep,name=webhooks,proto3" json:"webhooks,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListWebhooksResponse) Reset() {
+	*x = ListWebhooksResponse{}
+	mi := &file_vpn_proto_msgTypes[40]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListWebhooksResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListWebhooksResponse) ProtoMessage() {}
+
+func (x *ListWebhooksResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_vpn_proto_msgTypes[40]
 	if x != nil {
-		return x.ServerId
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return ""
+	return mi.MessageOf(x)
 }
 
-func (x *GetConfigRequest) GetUserId() string {
+// Deprecated: Use ListWebhooksResponse.ProtoReflect.Descriptor instead.
+func (*ListWebhooksResponse) Descriptor() ([]byte, []int) {
+	return file_vpn_proto_rawDescGZIP(), []int{40}
+}
+
+func (x *ListWebhooksResponse) GetWebhooks() []*Webhook {
 	if x != nil {
-		return x.UserId
+		return x.Webhooks
+	}
+	return nil
+}
+
+type DeleteWebhookRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	WebhookId     string                 `protobuf:"bytes,1,opt,name=webhook_id,json=webhookId,proto3" json:"webhook_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteWebhookRequest) Reset() {
+	*x = DeleteWebhookRequest{}
+	mi := &file_vpn_proto_msgTypes[41]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteWebhookRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteWebhookRequest) ProtoMessage() {}
+
+func (x *DeleteWebhookRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_vpn_proto_msgTypes[41]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteWebhookRequest.ProtoReflect.Descriptor instead.
+func (*DeleteWebhookRequest) Descriptor() ([]byte, []int) {
+	return file_vpn_proto_rawDescGZIP(), []int{41}
+}
+
+func (x *DeleteWebhookRequest) GetWebhookId() string {
+	if x != nil {
+		return x.WebhookId
 	}
 	return ""
 }
 
-type GetConfigResponse struct {
+type DeleteWebhookResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	ConfigData    *ConfigData            `protobuf:"bytes,1,opt,name=config_data,json=configData,proto3" json:"config_data,omitempty"`
-	ConfigContent string                 `protobuf:"bytes,2,opt,name=config_content,json=configContent,proto3" json:"config_content,omitempty"`
-	QrCodeBase64  string                 `protobuf:"bytes,3,opt,name=qr_code_base64,json=qrCodeBase64,proto3" json:"qr_code_base64,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetConfigResponse) Reset() {
-	*x = GetConfigResponse{}
-	mi := &file_vpn_proto_msgTypes[14]
+func (x *DeleteWebhookResponse) Reset() {
+	*x = DeleteWebhookResponse{}
+	mi := &file_vpn_proto_msgTypes[42]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetConfigResponse) String() string {
+func (x *DeleteWebhookResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetConfigResponse) ProtoMessage() {}
+func (*DeleteWebhookResponse) ProtoMessage() {}
 
-func (x *GetConfigResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_vpn_proto_msgTypes[14]
+func (x *DeleteWebhookResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_vpn_proto_msgTypes[42]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -863,46 +3147,212 @@ func (x *GetConfigResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetConfigResponse.ProtoReflect.Descriptor instead.
-func (*GetConfigResponse) Descriptor() ([]byte, []int) {
-	return file_vpn_proto_rawDescGZIP(), []int{14}
+// Deprecated: Use DeleteWebhookResponse.ProtoReflect.Descriptor instead.
+func (*DeleteWebhookResponse) Descriptor() ([]byte, []int) {
+	return file_vpn_proto_rawDescGZIP(), []int{42}
 }
 
-func (x *GetConfigResponse) GetConfigData() *ConfigData {
+type ListWebhookDeliveriesRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	WebhookId string                 `protobuf:"bytes,1,opt,name=webhook_id,json=webhookId,proto3" json:"webhook_id,omitempty"`
+	// Defaults to 50, at most 500.
+	PageSize      int32 `protobuf:"varint,2,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListWebhookDeliveriesRequest) Reset() {
+	*x = ListWebhookDeliveriesRequest{}
+	mi := &file_vpn_proto_msgTypes[43]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListWebhookDeliveriesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListWebhookDeliveriesRequest) ProtoMessage() {}
+
+func (x *ListWebhookDeliveriesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_vpn_proto_msgTypes[43]
 	if x != nil {
-		return x.ConfigData
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return nil
+	return mi.MessageOf(x)
 }
 
-func (x *GetConfigResponse) GetConfigContent() string {
+// Deprecated: Use ListWebhookDeliveriesRequest.ProtoReflect.Descriptor instead.
+func (*ListWebhookDeliveriesRequest) Descriptor() ([]byte, []int) {
+	return file_vpn_proto_rawDescGZIP(), []int{43}
+}
+
+func (x *ListWebhookDeliveriesRequest) GetWebhookId() string {
 	if x != nil {
-		return x.ConfigContent
+		return x.WebhookId
 	}
 	return ""
 }
 
-func (x *GetConfigResponse) GetQrCodeBase64() string {
+func (x *ListWebhookDeliveriesRequest) GetPageSize() int32 {
 	if x != nil {
-		return x.QrCodeBase64
+		return x.PageSize
+	}
+	return 0
+}
+
+type ListWebhookDeliveriesResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Newest first.
+	Deliveries    []*WebhookDelivery `protobuf:"bytes,1,rep,name=deliveries,proto3" json:"deliveries,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListWebhookDeliveriesResponse) Reset() {
+	*x = ListWebhookDeliveriesResponse{}
+	mi := &file_vpn_proto_msgTypes[44]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListWebhookDeliveriesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListWebhookDeliveriesResponse) ProtoMessage() {}
+
+func (x *ListWebhookDeliveriesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_vpn_proto_msgTypes[44]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListWebhookDeliveriesResponse.ProtoReflect.Descriptor instead.
+func (*ListWebhookDeliveriesResponse) Descriptor() ([]byte, []int) {
+	return file_vpn_proto_rawDescGZIP(), []int{44}
+}
+
+func (x *ListWebhookDeliveriesResponse) GetDeliveries() []*WebhookDelivery {
+	if x != nil {
+		return x.Deliveries
+	}
+	return nil
+}
+
+type TestWebhookRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	WebhookId     string                 `protobuf:"bytes,1,opt,name=webhook_id,json=webhookId,proto3" json:"webhook_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TestWebhookRequest) Reset() {
+	*x = TestWebhookRequest{}
+	mi := &file_vpn_proto_msgTypes[45]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TestWebhookRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TestWebhookRequest) ProtoMessage() {}
+
+func (x *TestWebhookRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_vpn_proto_msgTypes[45]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TestWebhookRequest.ProtoReflect.Descriptor instead.
+func (*TestWebhookRequest) Descriptor() ([]byte, []int) {
+	return file_vpn_proto_rawDescGZIP(), []int{45}
+}
+
+func (x *TestWebhookRequest) GetWebhookId() string {
+	if x != nil {
+		return x.WebhookId
 	}
 	return ""
 }
 
+type TestWebhookResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Delivery      *WebhookDelivery       `protobuf:"bytes,1,opt,name=delivery,proto3" json:"delivery,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TestWebhookResponse) Reset() {
+	*x = TestWebhookResponse{}
+	mi := &file_vpn_proto_msgTypes[46]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TestWebhookResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TestWebhookResponse) ProtoMessage() {}
+
+func (x *TestWebhookResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_vpn_proto_msgTypes[46]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TestWebhookResponse.ProtoReflect.Descriptor instead.
+func (*TestWebhookResponse) Descriptor() ([]byte, []int) {
+	return file_vpn_proto_rawDescGZIP(), []int{46}
+}
+
+func (x *TestWebhookResponse) GetDelivery() *WebhookDelivery {
+	if x != nil {
+		return x.Delivery
+	}
+	return nil
+}
+
 var File_vpn_proto protoreflect.FileDescriptor
 
 const file_vpn_proto_rawDesc = "" +
 	"\n" +
-	"\tvpn.proto\x12\x03vpn\"@\n" +
-	"\fLoginRequest\x12\x14\n" +
-	"\x05email\x18\x01 \x01(\tR\x05email\x12\x1a\n" +
-	"\bpassword\x18\x02 \x01(\tR\bpassword\"C\n" +
-	"\x0fRegisterRequest\x12\x14\n" +
-	"\x05email\x18\x01 \x01(\tR\x05email\x12\x1a\n" +
-	"\bpassword\x18\x02 \x01(\tR\bpassword\"F\n" +
+	"\tvpn.proto\x12\x03vpn\x1a\x1bbuf/validate/validate.proto\x1a\x1cgoogle/api/annotations.proto\"T\n" +
+	"\fLoginRequest\x12\x1d\n" +
+	"\x05email\x18\x01 \x01(\tB\a\xbaH\x04r\x02`\x01R\x05email\x12%\n" +
+	"\bpassword\x18\x02 \x01(\tB\t\xbaH\x06r\x04\x10\x01(HR\bpassword\"Z\n" +
+	"\x0fRegisterRequest\x12 \n" +
+	"\x05email\x18\x01 \x01(\tB\n" +
+	"\xbaH\ar\x05\x18\xfe\x01`\x01R\x05email\x12%\n" +
+	"\bpassword\x18\x02 \x01(\tB\t\xbaH\x06r\x04\x10\b(HR\bpassword\"F\n" +
 	"\x16AuthenticationResponse\x12\x16\n" +
 	"\x06status\x18\x01 \x01(\tR\x06status\x12\x14\n" +
-	"\x05token\x18\x02 \x01(\tR\x05token\"\xc9\x01\n" +
+	"\x05token\x18\x02 \x01(\tR\x05token\"\xe7\x02\n" +
 	"\x06Server\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
 	"\x04name\x18\x02 \x01(\tR\x04name\x12\x1a\n" +
@@ -912,33 +3362,107 @@ const file_vpn_proto_rawDesc = "" +
 	"\x06region\x18\x05 \x01(\tR\x06region\x12\x1f\n" +
 	"\vmax_clients\x18\x06 \x01(\x05R\n" +
 	"maxClients\x12'\n" +
-	"\x0fcurrent_clients\x18\a \x01(\x05R\x0ecurrentClients\"\x9d\x01\n" +
-	"\x13CreateServerRequest\x12\x12\n" +
-	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1a\n" +
-	"\bendpoint\x18\x02 \x01(\tR\bendpoint\x12\x16\n" +
-	"\x06region\x18\x03 \x01(\tR\x06region\x12\x1f\n" +
-	"\vmax_clients\x18\x04 \x01(\x05R\n" +
-	"maxClients\x12\x1d\n" +
+	"\x0fcurrent_clients\x18\a \x01(\x05R\x0ecurrentClients\x12>\n" +
+	"\x1ckey_rotation_max_age_seconds\x18\b \x01(\x03R\x18keyRotationMaxAgeSeconds\x12*\n" +
+	"\x11key_rotation_mode\x18\t \x01(\tR\x0fkeyRotationMode\x12\x18\n" +
+	"\aversion\x18\n" +
+	" \x01(\x03R\aversion\x12\x16\n" +
+	"\x06status\x18\v \x01(\tR\x06status\"\xe1\x02\n" +
+	"\x13CreateServerRequest\x12\x1d\n" +
+	"\x04name\x18\x01 \x01(\tB\t\xbaH\x06r\x04\x10\x01\x18dR\x04name\x12$\n" +
+	"\bendpoint\x18\x02 \x01(\tB\b\xbaH\x05r\x03\x80\x02\x01R\bendpoint\x12!\n" +
+	"\x06region\x18\x03 \x01(\tB\t\xbaH\x06r\x04\x10\x01\x18@R\x06region\x12(\n" +
+	"\vmax_clients\x18\x04 \x01(\x05B\a\xbaH\x04\x1a\x02 \x00R\n" +
+	"maxClients\x12*\n" +
 	"\n" +
-	"public_key\x18\x05 \x01(\tR\tpublicKey\"U\n" +
+	"public_key\x18\x05 \x01(\tB\v\xbaH\b\xd8\x01\x01r\x03\x98\x01,R\tpublicKey\x12G\n" +
+	"\x1ckey_rotation_max_age_seconds\x18\x06 \x01(\x03B\a\xbaH\x04\"\x02(\x00R\x18keyRotationMaxAgeSeconds\x12C\n" +
+	"\x11key_rotation_mode\x18\a \x01(\tB\x17\xbaH\x14r\x12R\x00R\x06serverR\x06clientR\x0fkeyRotationMode\"U\n" +
 	"\x14CreateServerResponse\x12#\n" +
 	"\x06server\x18\x01 \x01(\v2\v.vpn.ServerR\x06server\x12\x18\n" +
 	"\amessage\x18\x02 \x01(\tR\amessage\"\x13\n" +
 	"\x11ListServerRequest\";\n" +
 	"\x12ListServerResponse\x12%\n" +
-	"\aservers\x18\x01 \x03(\v2\v.vpn.ServerR\aservers\"/\n" +
-	"\x10GetServerRequest\x12\x1b\n" +
-	"\tserver_id\x18\x01 \x01(\tR\bserverId\"8\n" +
+	"\aservers\x18\x01 \x03(\v2\v.vpn.ServerR\aservers\"I\n" +
+	"\x10GetServerRequest\x125\n" +
+	"\tserver_id\x18\x01 \x01(\tB\x18\xbaH\x15r\x132\x11^[0-9a-fA-F]{24}$R\bserverId\"8\n" +
 	"\x11GetServerResponse\x12#\n" +
-	"\x06server\x18\x01 \x01(\v2\v.vpn.ServerR\x06server\"4\n" +
-	"\x15GenerateConfigRequest\x12\x1b\n" +
-	"\tserver_id\x18\x01 \x01(\tR\bserverId\"\xb1\x01\n" +
+	"\x06server\x18\x01 \x01(\v2\v.vpn.ServerR\x06server\"\xcc\x03\n" +
+	"\x13UpdateServerRequest\x125\n" +
+	"\tserver_id\x18\x01 \x01(\tB\x18\xbaH\x15r\x132\x11^[0-9a-fA-F]{24}$R\bserverId\x12!\n" +
+	"\aversion\x18\x02 \x01(\x03B\a\xbaH\x04\"\x02 \x00R\aversion\x12\x1d\n" +
+	"\x04name\x18\x03 \x01(\tB\t\xbaH\x06r\x04\x10\x01\x18dR\x04name\x12$\n" +
+	"\bendpoint\x18\x04 \x01(\tB\b\xbaH\x05r\x03\x80\x02\x01R\bendpoint\x12!\n" +
+	"\x06region\x18\x05 \x01(\tB\t\xbaH\x06r\x04\x10\x01\x18@R\x06region\x12(\n" +
+	"\vmax_clients\x18\x06 \x01(\x05B\a\xbaH\x04\x1a\x02 \x00R\n" +
+	"maxClients\x12G\n" +
+	"\x1ckey_rotation_max_age_seconds\x18\a \x01(\x03B\a\xbaH\x04\"\x02(\x00R\x18keyRotationMaxAgeSeconds\x12C\n" +
+	"\x11key_rotation_mode\x18\b \x01(\tB\x17\xbaH\x14r\x12R\x00R\x06serverR\x06clientR\x0fkeyRotationMode\x12;\n" +
+	"\x06status\x18\t \x01(\tB#\xbaH r\x1eR\x00R\x06activeR\bdrainingR\bdisabledR\x06status\";\n" +
+	"\x14UpdateServerResponse\x12#\n" +
+	"\x06server\x18\x01 \x01(\v2\v.vpn.ServerR\x06server\"w\n" +
+	"\x11WatchPeersRequest\x125\n" +
+	"\tserver_id\x18\x01 \x01(\tB\x18\xbaH\x15r\x132\x11^[0-9a-fA-F]{24}$R\bserverId\x12+\n" +
+	"\fresume_token\x18\x02 \x01(\tB\b\xbaH\x05r\x03\x18\x80\x04R\vresumeToken\"v\n" +
+	"\x04Peer\x12\x17\n" +
+	"\apeer_id\x18\x01 \x01(\tR\x06peerId\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x1d\n" +
+	"\n" +
+	"public_key\x18\x03 \x01(\tR\tpublicKey\x12\x1d\n" +
+	"\n" +
+	"ip_address\x18\x04 \x01(\tR\tipAddress\"\xc9\x02\n" +
+	"\n" +
+	"PeerChange\x12(\n" +
+	"\x04type\x18\x01 \x01(\x0e2\x14.vpn.PeerChange.TypeR\x04type\x12\x17\n" +
+	"\apeer_id\x18\x02 \x01(\tR\x06peerId\x12\x17\n" +
+	"\auser_id\x18\x03 \x01(\tR\x06userId\x12\x1d\n" +
+	"\n" +
+	"public_key\x18\x04 \x01(\tR\tpublicKey\x12.\n" +
+	"\x13previous_public_key\x18\x05 \x01(\tR\x11previousPublicKey\x12\x1d\n" +
+	"\n" +
+	"ip_address\x18\x06 \x01(\tR\tipAddress\x12\x1f\n" +
+	"\voccurred_at\x18\a \x01(\x03R\n" +
+	"occurredAt\"P\n" +
+	"\x04Type\x12\x14\n" +
+	"\x10TYPE_UNSPECIFIED\x10\x00\x12\x0e\n" +
+	"\n" +
+	"TYPE_ADDED\x10\x01\x12\x10\n" +
+	"\fTYPE_REMOVED\x10\x02\x12\x10\n" +
+	"\fTYPE_ROTATED\x10\x03\"\x9f\x01\n" +
+	"\x12WatchPeersResponse\x12\x1a\n" +
+	"\bsnapshot\x18\x01 \x01(\bR\bsnapshot\x12\x1f\n" +
+	"\x05peers\x18\x02 \x03(\v2\t.vpn.PeerR\x05peers\x12)\n" +
+	"\achanges\x18\x03 \x03(\v2\x0f.vpn.PeerChangeR\achanges\x12!\n" +
+	"\fresume_token\x18\x04 \x01(\tR\vresumeToken\"J\n" +
+	"\x11RevokePeerRequest\x125\n" +
+	"\tserver_id\x18\x01 \x01(\tB\x18\xbaH\x15r\x132\x11^[0-9a-fA-F]{24}$R\bserverId\"\x14\n" +
+	"\x12RevokePeerResponse\"\xab\x01\n" +
+	"\rQRCodeOptions\x12\x1e\n" +
+	"\x04size\x18\x01 \x01(\x05B\n" +
+	"\xbaH\a\x1a\x05\x18\x80 (\x00R\x04size\x12E\n" +
+	"\x0erecovery_level\x18\x02 \x01(\x0e2\x14.vpn.QRRecoveryLevelB\b\xbaH\x05\x82\x01\x02\x10\x01R\rrecoveryLevel\x123\n" +
+	"\x06format\x18\x03 \x01(\x0e2\x11.vpn.QRCodeFormatB\b\xbaH\x05\x82\x01\x02\x10\x01R\x06format\"\xfc\x01\n" +
+	"\x15GenerateConfigRequest\x125\n" +
+	"\tserver_id\x18\x01 \x01(\tB\x18\xbaH\x15r\x132\x11^[0-9a-fA-F]{24}$R\bserverId\x123\n" +
+	"\x06format\x18\x02 \x01(\x0e2\x11.vpn.ConfigFormatB\b\xbaH\x05\x82\x01\x02\x10\x01R\x06format\x12:\n" +
+	"\x0fqr_code_options\x18\x03 \x01(\v2\x12.vpn.QRCodeOptionsR\rqrCodeOptions\x12;\n" +
+	"\x15expected_keys_version\x18\x04 \x01(\x03B\a\xbaH\x04\"\x02(\x00R\x13expectedKeysVersion\"\x81\x03\n" +
 	"\x16GenerateConfigResponse\x12%\n" +
 	"\x0econfig_content\x18\x01 \x01(\tR\rconfigContent\x12$\n" +
 	"\x0eqr_code_base64\x18\x02 \x01(\tR\fqrCodeBase64\x120\n" +
 	"\vconfig_data\x18\x03 \x01(\v2\x0f.vpn.ConfigDataR\n" +
 	"configData\x12\x18\n" +
-	"\amessage\x18\x04 \x01(\tR\amessage\"\x98\x02\n" +
+	"\amessage\x18\x04 \x01(\tR\amessage\x12)\n" +
+	"\x06export\x18\x05 \x01(\v2\x11.vpn.ConfigExportR\x06export\x12/\n" +
+	"\x14qr_code_content_type\x18\x06 \x01(\tR\x11qrCodeContentType\x12\"\n" +
+	"\rqr_code_error\x18\a \x01(\tR\vqrCodeError\x12+\n" +
+	"\x11rotation_required\x18\b \x01(\bR\x10rotationRequired\x12!\n" +
+	"\fkeys_version\x18\t \x01(\x03R\vkeysVersion\"\x93\x01\n" +
+	"\fConfigExport\x12)\n" +
+	"\x06format\x18\x01 \x01(\x0e2\x11.vpn.ConfigFormatR\x06format\x12\x18\n" +
+	"\acontent\x18\x02 \x01(\tR\acontent\x12\x1b\n" +
+	"\tfile_name\x18\x03 \x01(\tR\bfileName\x12!\n" +
+	"\fcontent_type\x18\x04 \x01(\tR\vcontentType\"\x98\x02\n" +
 	"\n" +
 	"ConfigData\x12\x1f\n" +
 	"\vprivate_key\x18\x01 \x01(\tR\n" +
@@ -954,24 +3478,186 @@ const file_vpn_proto_rawDesc = "" +
 	"\x03dns\x18\b \x01(\tR\x03dns\"H\n" +
 	"\x10GetConfigRequest\x12\x1b\n" +
 	"\tserver_id\x18\x01 \x01(\tR\bserverId\x12\x17\n" +
-	"\auser_id\x18\x02 \x01(\tR\x06userId\"\x92\x01\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\"\xb5\x01\n" +
 	"\x11GetConfigResponse\x120\n" +
 	"\vconfig_data\x18\x01 \x01(\v2\x0f.vpn.ConfigDataR\n" +
 	"configData\x12%\n" +
 	"\x0econfig_content\x18\x02 \x01(\tR\rconfigContent\x12$\n" +
-	"\x0eqr_code_base64\x18\x03 \x01(\tR\fqrCodeBase642\x85\x01\n" +
-	"\vUserService\x127\n" +
-	"\x05Login\x12\x11.vpn.LoginRequest\x1a\x1b.vpn.AuthenticationResponse\x12=\n" +
-	"\bRegister\x12\x14.vpn.RegisterRequest\x1a\x1b.vpn.AuthenticationResponse2\xd0\x01\n" +
-	"\rServerService\x12C\n" +
-	"\fCreateServer\x12\x18.vpn.CreateServerRequest\x1a\x19.vpn.CreateServerResponse\x12>\n" +
-	"\vListServers\x12\x16.vpn.ListServerRequest\x1a\x17.vpn.ListServerResponse\x12:\n" +
-	"\tGetServer\x12\x15.vpn.GetServerRequest\x1a\x16.vpn.GetServerResponse2\xd8\x01\n" +
-	"\rConfigService\x12I\n" +
-	"\x0eGenerateConfig\x12\x1a.vpn.GenerateConfigRequest\x1a\x1b.vpn.GenerateConfigResponse\x12:\n" +
-	"\tGetConfig\x12\x15.vpn.GetConfigRequest\x1a\x16.vpn.GetConfigResponse\x12@\n" +
+	"\x0eqr_code_base64\x18\x03 \x01(\tR\fqrCodeBase64\x12!\n" +
+	"\fkeys_version\x18\x04 \x01(\x03R\vkeysVersion\"\xb5\x01\n" +
+	"\x1cCreateConfigShareLinkRequest\x125\n" +
+	"\tserver_id\x18\x01 \x01(\tB\x18\xbaH\x15r\x132\x11^[0-9a-fA-F]{24}$R\bserverId\x120\n" +
+	"\x04kind\x18\x02 \x01(\x0e2\x12.vpn.ShareLinkKindB\b\xbaH\x05\x82\x01\x02\x10\x01R\x04kind\x12,\n" +
+	"\vttl_seconds\x18\x03 \x01(\x03B\v\xbaH\b\"\x06\x18\x80\xf5$(\x00R\n" +
+	"ttlSeconds\"\x8e\x01\n" +
+	"\x1dCreateConfigShareLinkResponse\x12\x14\n" +
+	"\x05token\x18\x01 \x01(\tR\x05token\x12\x10\n" +
+	"\x03url\x18\x02 \x01(\tR\x03url\x12\x1d\n" +
+	"\n" +
+	"expires_at\x18\x03 \x01(\x03R\texpiresAt\x12&\n" +
+	"\x04kind\x18\x04 \x01(\x0e2\x12.vpn.ShareLinkKindR\x04kind\"\xa8\x03\n" +
+	"\n" +
+	"AuditEvent\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1a\n" +
+	"\bsequence\x18\x02 \x01(\x03R\bsequence\x12\x1f\n" +
+	"\voccurred_at\x18\x03 \x01(\x03R\n" +
+	"occurredAt\x12\x19\n" +
+	"\bactor_id\x18\x04 \x01(\tR\aactorId\x12\x1f\n" +
+	"\vactor_email\x18\x05 \x01(\tR\n" +
+	"actorEmail\x12\x16\n" +
+	"\x06action\x18\x06 \x01(\tR\x06action\x12\x1f\n" +
+	"\vtarget_type\x18\a \x01(\tR\n" +
+	"targetType\x12\x1b\n" +
+	"\ttarget_id\x18\b \x01(\tR\btargetId\x12\x1c\n" +
+	"\tprocedure\x18\t \x01(\tR\tprocedure\x12\x1b\n" +
+	"\tsource_ip\x18\n" +
+	" \x01(\tR\bsourceIp\x12\x1d\n" +
+	"\n" +
+	"user_agent\x18\v \x01(\tR\tuserAgent\x12\x18\n" +
+	"\aoutcome\x18\f \x01(\tR\aoutcome\x12\x16\n" +
+	"\x06reason\x18\r \x01(\tR\x06reason\x12\x1b\n" +
+	"\tprev_hash\x18\x0e \x01(\tR\bprevHash\x12\x12\n" +
+	"\x04hash\x18\x0f \x01(\tR\x04hash\"\xc3\x02\n" +
+	"\x16ListAuditEventsRequest\x12$\n" +
+	"\tpage_size\x18\x01 \x01(\x05B\a\xbaH\x04\x1a\x02(\x00R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x02 \x01(\tR\tpageToken\x126\n" +
+	"\bactor_id\x18\x03 \x01(\tB\x1b\xbaH\x18\xd8\x01\x01r\x132\x11^[0-9a-fA-F]{24}$R\aactorId\x12\x16\n" +
+	"\x06action\x18\x04 \x01(\tR\x06action\x12\x1f\n" +
+	"\vtarget_type\x18\x05 \x01(\tR\n" +
+	"targetType\x12\x1b\n" +
+	"\ttarget_id\x18\x06 \x01(\tR\btargetId\x12\x18\n" +
+	"\aoutcome\x18\a \x01(\tR\aoutcome\x12\x1d\n" +
+	"\x05since\x18\b \x01(\x03B\a\xbaH\x04\"\x02(\x00R\x05since\x12\x1d\n" +
+	"\x05until\x18\t \x01(\x03B\a\xbaH\x04\"\x02(\x00R\x05until\"j\n" +
+	"\x17ListAuditEventsResponse\x12'\n" +
+	"\x06events\x18\x01 \x03(\v2\x0f.vpn.AuditEventR\x06events\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\"\xe8\x01\n" +
+	"\vOutboxEvent\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12!\n" +
+	"\faggregate_id\x18\x03 \x01(\tR\vaggregateId\x12\x18\n" +
+	"\apayload\x18\x04 \x01(\tR\apayload\x12\x1f\n" +
+	"\voccurred_at\x18\x05 \x01(\x03R\n" +
+	"occurredAt\x12\x1a\n" +
+	"\battempts\x18\x06 \x01(\x05R\battempts\x12\x1d\n" +
+	"\n" +
+	"last_error\x18\a \x01(\tR\tlastError\x12\x1c\n" +
+	"\tdelivered\x18\b \x03(\tR\tdelivered\"C\n" +
+	"\x1bListDeadLetterEventsRequest\x12$\n" +
+	"\tpage_size\x18\x01 \x01(\x05B\a\xbaH\x04\x1a\x02(\x00R\bpageSize\"H\n" +
+	"\x1cListDeadLetterEventsResponse\x12(\n" +
+	"\x06events\x18\x01 \x03(\v2\x10.vpn.OutboxEventR\x06events\"T\n" +
+	"\x1dRequeueDeadLetterEventRequest\x123\n" +
+	"\bevent_id\x18\x01 \x01(\tB\x18\xbaH\x15r\x132\x11^[0-9a-fA-F]{24}$R\aeventId\" \n" +
+	"\x1eRequeueDeadLetterEventResponse\"\xa7\x01\n" +
+	"\aWebhook\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x10\n" +
+	"\x03url\x18\x02 \x01(\tR\x03url\x12\x1f\n" +
+	"\vevent_types\x18\x03 \x03(\tR\n" +
+	"eventTypes\x12 \n" +
+	"\vdescription\x18\x04 \x01(\tR\vdescription\x12\x18\n" +
+	"\aenabled\x18\x05 \x01(\bR\aenabled\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\x06 \x01(\x03R\tcreatedAt\"\xb9\x02\n" +
+	"\x0fWebhookDelivery\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1d\n" +
+	"\n" +
+	"webhook_id\x18\x02 \x01(\tR\twebhookId\x12\x19\n" +
+	"\bevent_id\x18\x03 \x01(\tR\aeventId\x12\x1d\n" +
+	"\n" +
+	"event_type\x18\x04 \x01(\tR\teventType\x12\x18\n" +
+	"\aattempt\x18\x05 \x01(\x05R\aattempt\x12\x12\n" +
+	"\x04test\x18\x06 \x01(\bR\x04test\x12\x18\n" +
+	"\asuccess\x18\a \x01(\bR\asuccess\x12\x1f\n" +
+	"\vstatus_code\x18\b \x01(\x05R\n" +
+	"statusCode\x12\x14\n" +
+	"\x05error\x18\t \x01(\tR\x05error\x12\x1f\n" +
+	"\vduration_ms\x18\n" +
+	" \x01(\x03R\n" +
+	"durationMs\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\v \x01(\x03R\tcreatedAt\"\x92\x01\n" +
+	"\x14CreateWebhookRequest\x12\x1d\n" +
+	"\x03url\x18\x01 \x01(\tB\v\xbaH\br\x06\x18\x80\x10\x88\x01\x01R\x03url\x12/\n" +
+	"\vevent_types\x18\x02 \x03(\tB\x0e\xbaH\v\x92\x01\b\b\x01\"\x04r\x02\x10\x01R\n" +
+	"eventTypes\x12*\n" +
+	"\vdescription\x18\x03 \x01(\tB\b\xbaH\x05r\x03\x18\xf4\x03R\vdescription\"W\n" +
+	"\x15CreateWebhookResponse\x12&\n" +
+	"\awebhook\x18\x01 \x01(\v2\f.vpn.WebhookR\awebhook\x12\x16\n" +
+	"\x06secret\x18\x02 \x01(\tR\x06secret\"\x15\n" +
+	"\x13ListWebhooksRequest\"@\n" +
+	"\x14ListWebhooksResponse\x12(\n" +
+	"\bwebhooks\x18\x01 \x03(\v2\f.vpn.WebhookR\bwebhooks\"O\n" +
+	"\x14DeleteWebhookRequest\x127\n" +
+	"\n" +
+	"webhook_id\x18\x01 \x01(\tB\x18\xbaH\x15r\x132\x11^[0-9a-fA-F]{24}$R\twebhookId\"\x17\n" +
+	"\x15DeleteWebhookResponse\"}\n" +
+	"\x1cListWebhookDeliveriesRequest\x127\n" +
+	"\n" +
+	"webhook_id\x18\x01 \x01(\tB\x18\xbaH\x15r\x132\x11^[0-9a-fA-F]{24}$R\twebhookId\x12$\n" +
+	"\tpage_size\x18\x02 \x01(\x05B\a\xbaH\x04\x1a\x02(\x00R\bpageSize\"U\n" +
+	"\x1dListWebhookDeliveriesResponse\x124\n" +
+	"\n" +
+	"deliveries\x18\x01 \x03(\v2\x14.vpn.WebhookDeliveryR\n" +
+	"deliveries\"M\n" +
+	"\x12TestWebhookRequest\x127\n" +
+	"\n" +
+	"webhook_id\x18\x01 \x01(\tB\x18\xbaH\x15r\x132\x11^[0-9a-fA-F]{24}$R\twebhookId\"G\n" +
+	"\x13TestWebhookResponse\x120\n" +
+	"\bdelivery\x18\x01 \x01(\v2\x14.vpn.WebhookDeliveryR\bdelivery*\xe5\x01\n" +
+	"\fConfigFormat\x12\x1d\n" +
+	"\x19CONFIG_FORMAT_UNSPECIFIED\x10\x00\x12\x1a\n" +
+	"\x16CONFIG_FORMAT_WG_QUICK\x10\x01\x12!\n" +
+	"\x1dCONFIG_FORMAT_NETWORK_MANAGER\x10\x02\x12$\n" +
+	" CONFIG_FORMAT_APPLE_MOBILECONFIG\x10\x03\x12\x1d\n" +
+	"\x19CONFIG_FORMAT_OPENWRT_UCI\x10\x04\x12\x1a\n" +
+	"\x16CONFIG_FORMAT_MIKROTIK\x10\x05\x12\x16\n" +
+	"\x12CONFIG_FORMAT_JSON\x10\x06*{\n" +
+	"\fQRCodeFormat\x12\x1e\n" +
+	"\x1aQR_CODE_FORMAT_UNSPECIFIED\x10\x00\x12\x16\n" +
+	"\x12QR_CODE_FORMAT_PNG\x10\x01\x12\x16\n" +
+	"\x12QR_CODE_FORMAT_SVG\x10\x02\x12\x1b\n" +
+	"\x17QR_CODE_FORMAT_TERMINAL\x10\x03*\xa9\x01\n" +
+	"\x0fQRRecoveryLevel\x12!\n" +
+	"\x1dQR_RECOVERY_LEVEL_UNSPECIFIED\x10\x00\x12\x19\n" +
+	"\x15QR_RECOVERY_LEVEL_LOW\x10\x01\x12\x1c\n" +
+	"\x18QR_RECOVERY_LEVEL_MEDIUM\x10\x02\x12\x1e\n" +
+	"\x1aQR_RECOVERY_LEVEL_QUARTILE\x10\x03\x12\x1a\n" +
+	"\x16QR_RECOVERY_LEVEL_HIGH\x10\x04*n\n" +
+	"\rShareLinkKind\x12\x1f\n" +
+	"\x1bSHARE_LINK_KIND_UNSPECIFIED\x10\x00\x12\x1f\n" +
+	"\x1bSHARE_LINK_KIND_CONFIG_FILE\x10\x01\x12\x1b\n" +
+	"\x17SHARE_LINK_KIND_QR_PAGE\x10\x022\xbe\x01\n" +
+	"\vUserService\x12R\n" +
+	"\x05Login\x12\x11.vpn.LoginRequest\x1a\x1b.vpn.AuthenticationResponse\"\x19\x82\xd3\xe4\x93\x02\x13:\x01*\"\x0e/v1/auth/login\x12[\n" +
+	"\bRegister\x12\x14.vpn.RegisterRequest\x1a\x1b.vpn.AuthenticationResponse\"\x1c\x82\xd3\xe4\x93\x02\x16:\x01*\"\x11/v1/auth/register2\xc8\x03\n" +
+	"\rServerService\x12[\n" +
+	"\fCreateServer\x12\x18.vpn.CreateServerRequest\x1a\x19.vpn.CreateServerResponse\"\x16\x82\xd3\xe4\x93\x02\x10:\x01*\"\v/v1/servers\x12S\n" +
+	"\vListServers\x12\x16.vpn.ListServerRequest\x1a\x17.vpn.ListServerResponse\"\x13\x82\xd3\xe4\x93\x02\r\x12\v/v1/servers\x12[\n" +
+	"\tGetServer\x12\x15.vpn.GetServerRequest\x1a\x16.vpn.GetServerResponse\"\x1f\x82\xd3\xe4\x93\x02\x19\x12\x17/v1/servers/{server_id}\x12g\n" +
+	"\fUpdateServer\x12\x18.vpn.UpdateServerRequest\x1a\x19.vpn.UpdateServerResponse\"\"\x82\xd3\xe4\x93\x02\x1c:\x01*\x1a\x17/v1/servers/{server_id}\x12?\n" +
+	"\n" +
+	"WatchPeers\x12\x16.vpn.WatchPeersRequest\x1a\x17.vpn.WatchPeersResponse0\x012\xaf\x04\n" +
+	"\rConfigService\x12t\n" +
+	"\x0eGenerateConfig\x12\x1a.vpn.GenerateConfigRequest\x1a\x1b.vpn.GenerateConfigResponse\")\x82\xd3\xe4\x93\x02#:\x01*\"\x1e/v1/servers/{server_id}/config\x12:\n" +
+	"\tGetConfig\x12\x15.vpn.GetConfigRequest\x1a\x16.vpn.GetConfigResponse\x12v\n" +
+	"\n" +
+	"RotateKeys\x12\x1a.vpn.GenerateConfigRequest\x1a\x16.vpn.GetConfigResponse\"4\x82\xd3\xe4\x93\x02.:\x01*\")/v1/servers/{server_id}/config:rotateKeys\x12\x8e\x01\n" +
+	"\x15CreateConfigShareLink\x12!.vpn.CreateConfigShareLinkRequest\x1a\".vpn.CreateConfigShareLinkResponse\".\x82\xd3\xe4\x93\x02(:\x01*\"#/v1/servers/{server_id}/share-links\x12c\n" +
 	"\n" +
-	"RotateKeys\x12\x1a.vpn.GenerateConfigRequest\x1a\x16.vpn.GetConfigResponseB.Z,github.com/shivamp1998/vpn_backend/proto/genb\x06proto3"
+	"RevokePeer\x12\x16.vpn.RevokePeerRequest\x1a\x17.vpn.RevokePeerResponse\"$\x82\xd3\xe4\x93\x02\x1e*\x1c/v1/servers/{server_id}/peer2v\n" +
+	"\fAuditService\x12f\n" +
+	"\x0fListAuditEvents\x12\x1b.vpn.ListAuditEventsRequest\x1a\x1c.vpn.ListAuditEventsResponse\"\x18\x82\xd3\xe4\x93\x02\x12\x12\x10/v1/audit-events2\xa2\x02\n" +
+	"\fEventService\x12{\n" +
+	"\x14ListDeadLetterEvents\x12 .vpn.ListDeadLetterEventsRequest\x1a!.vpn.ListDeadLetterEventsResponse\"\x1e\x82\xd3\xe4\x93\x02\x18\x12\x16/v1/dead-letter-events\x12\x94\x01\n" +
+	"\x16RequeueDeadLetterEvent\x12\".vpn.RequeueDeadLetterEventRequest\x1a#.vpn.RequeueDeadLetterEventResponse\"1\x82\xd3\xe4\x93\x02+\")/v1/dead-letter-events/{event_id}:requeue2\xb0\x04\n" +
+	"\x0eWebhookService\x12_\n" +
+	"\rCreateWebhook\x12\x19.vpn.CreateWebhookRequest\x1a\x1a.vpn.CreateWebhookResponse\"\x17\x82\xd3\xe4\x93\x02\x11:\x01*\"\f/v1/webhooks\x12Y\n" +
+	"\fListWebhooks\x12\x18.vpn.ListWebhooksRequest\x1a\x19.vpn.ListWebhooksResponse\"\x14\x82\xd3\xe4\x93\x02\x0e\x12\f/v1/webhooks\x12i\n" +
+	"\rDeleteWebhook\x12\x19.vpn.DeleteWebhookRequest\x1a\x1a.vpn.DeleteWebhookResponse\"!\x82\xd3\xe4\x93\x02\x1b*\x19/v1/webhooks/{webhook_id}\x12\x8c\x01\n" +
+	"\x15ListWebhookDeliveries\x12!.vpn.ListWebhookDeliveriesRequest\x1a\".vpn.ListWebhookDeliveriesResponse\",\x82\xd3\xe4\x93\x02&\x12$/v1/webhooks/{webhook_id}/deliveries\x12h\n" +
+	"\vTestWebhook\x12\x17.vpn.TestWebhookRequest\x1a\x18.vpn.TestWebhookResponse\"&\x82\xd3\xe4\x93\x02 \"\x1e/v1/webhooks/{webhook_id}:testB.Z,github.com/shivamp1998/vpn_backend/proto/genb\x06proto3"
 
 var (
 	file_vpn_proto_rawDescOnce sync.Once
@@ -985,51 +3671,131 @@ func file_vpn_proto_rawDescGZIP() []byte {
 	return file_vpn_proto_rawDescData
 }
 
-var file_vpn_proto_msgTypes = make([]protoimpl.MessageInfo, 15)
+var file_vpn_proto_enumTypes = make([]protoimpl.EnumInfo, 5)
+var file_vpn_proto_msgTypes = make([]protoimpl.MessageInfo, 47)
 var file_vpn_proto_goTypes = []any{
-	(*LoginRequest)(nil),           // 0: vpn.LoginRequest
-	(*RegisterRequest)(nil),        // 1: vpn.RegisterRequest
-	(*AuthenticationResponse)(nil), // 2: vpn.AuthenticationResponse
-	(*Server)(nil),                 // 3: vpn.Server
-	(*CreateServerRequest)(nil),    // 4: vpn.CreateServerRequest
-	(*CreateServerResponse)(nil),   // 5: vpn.CreateServerResponse
-	(*ListServerRequest)(nil),      // 6: vpn.ListServerRequest
-	(*ListServerResponse)(nil),     // 7: vpn.ListServerResponse
-	(*GetServerRequest)(nil),       // 8: vpn.GetServerRequest
-	(*GetServerResponse)(nil),      // 9: vpn.GetServerResponse
-	(*GenerateConfigRequest)(nil),  // 10: vpn.GenerateConfigRequest
-	(*GenerateConfigResponse)(nil), // 11: vpn.GenerateConfigResponse
-	(*ConfigData)(nil),             // 12: vpn.ConfigData
-	(*GetConfigRequest)(nil),       // 13: vpn.GetConfigRequest
-	(*GetConfigResponse)(nil),      // 14: vpn.GetConfigResponse
+	(ConfigFormat)(0),                      // 0: vpn.ConfigFormat
+	(QRCodeFormat)(0),                      // 1: vpn.QRCodeFormat
+	(QRRecoveryLevel)(0),                   // 2: vpn.QRRecoveryLevel
+	(ShareLinkKind)(0),                     // 3: vpn.ShareLinkKind
+	(PeerChange_Type)(0),                   // 4: vpn.PeerChange.Type
+	(*LoginRequest)(nil),                   // 5: vpn.LoginRequest
+	(*RegisterRequest)(nil),                // 6: vpn.RegisterRequest
+	(*AuthenticationResponse)(nil),         // 7: vpn.AuthenticationResponse
+	(*Server)(nil),                         // 8: vpn.Server
+	(*CreateServerRequest)(nil),            // 9: vpn.CreateServerRequest
+	(*CreateServerResponse)(nil),           // 10: vpn.CreateServerResponse
+	(*ListServerRequest)(nil),              // 11: vpn.ListServerRequest
+	(*ListServerResponse)(nil),             // 12: vpn.ListServerResponse
+	(*GetServerRequest)(nil),               // 13: vpn.GetServerRequest
+	(*GetServerResponse)(nil),              // 14: vpn.GetServerResponse
+	(*UpdateServerRequest)(nil),            // 15: vpn.UpdateServerRequest
+	(*UpdateServerResponse)(nil),           // 16: vpn.UpdateServerResponse
+	(*WatchPeersRequest)(nil),              // 17: vpn.WatchPeersRequest
+	(*Peer)(nil),                           // 18: vpn.Peer
+	(*PeerChange)(nil),                     // 19: vpn.PeerChange
+	(*WatchPeersResponse)(nil),             // 20: vpn.WatchPeersResponse
+	(*RevokePeerRequest)(nil),              // 21: vpn.RevokePeerRequest
+	(*RevokePeerResponse)(nil),             // 22: vpn.RevokePeerResponse
+	(*QRCodeOptions)(nil),                  // 23: vpn.QRCodeOptions
+	(*GenerateConfigRequest)(nil),          // 24: vpn.GenerateConfigRequest
+	(*GenerateConfigResponse)(nil),         // 25: vpn.GenerateConfigResponse
+	(*ConfigExport)(nil),                   // 26: vpn.ConfigExport
+	(*ConfigData)(nil),                     // 27: vpn.ConfigData
+	(*GetConfigRequest)(nil),               // 28: vpn.GetConfigRequest
+	(*GetConfigResponse)(nil),              // 29: vpn.GetConfigResponse
+	(*CreateConfigShareLinkRequest)(nil),   // 30: vpn.CreateConfigShareLinkRequest
+	(*CreateConfigShareLinkResponse)(nil),  // 31: vpn.CreateConfigShareLinkResponse
+	(*AuditEvent)(nil),                     // 32: vpn.AuditEvent
+	(*ListAuditEventsRequest)(nil),         // 33: vpn.ListAuditEventsRequest
+	(*ListAuditEventsResponse)(nil),        // 34: vpn.ListAuditEventsResponse
+	(*OutboxEvent)(nil),                    // 35: vpn.OutboxEvent
+	(*ListDeadLetterEventsRequest)(nil),    // 36: vpn.ListDeadLetterEventsRequest
+	(*ListDeadLetterEventsResponse)(nil),   // 37: vpn.ListDeadLetterEventsResponse
+	(*RequeueDeadLetterEventRequest)(nil),  // 38: vpn.RequeueDeadLetterEventRequest
+	(*RequeueDeadLetterEventResponse)(nil), // 39: vpn.RequeueDeadLetterEventResponse
+	(*Webhook)(nil),                        // 40: vpn.Webhook
+	(*WebhookDelivery)(nil),                // 41: vpn.WebhookDelivery
+	(*CreateWebhookRequest)(nil),           // 42: vpn.CreateWebhookRequest
+	(*CreateWebhookResponse)(nil),          // 43: vpn.CreateWebhookResponse
+	(*ListWebhooksRequest)(nil),            // 44: vpn.ListWebhooksRequest
+	(*ListWebhooksResponse)(nil),           // 45: vpn.ListWebhooksResponse
+	(*DeleteWebhookRequest)(nil),           // 46: vpn.DeleteWebhookRequest
+	(*DeleteWebhookResponse)(nil),          // 47: vpn.DeleteWebhookResponse
+	(*ListWebhookDeliveriesRequest)(nil),   // 48: vpn.ListWebhookDeliveriesRequest
+	(*ListWebhookDeliveriesResponse)(nil),  // 49: vpn.ListWebhookDeliveriesResponse
+	(*TestWebhookRequest)(nil),             // 50: vpn.TestWebhookRequest
+	(*TestWebhookResponse)(nil),            // 51: vpn.TestWebhookResponse
 }
 var file_vpn_proto_depIdxs = []int32{
-	3,  // 0: vpn.CreateServerResponse.server:type_name -> vpn.Server
-	3,  // 1: vpn.ListServerResponse.servers:type_name -> vpn.Server
-	3,  // 2: vpn.GetServerResponse.server:type_name -> vpn.Server
-	12, // 3: vpn.GenerateConfigResponse.config_data:type_name -> vpn.ConfigData
-	12, // 4: vpn.GetConfigResponse.config_data:type_name -> vpn.ConfigData
-	0,  // 5: vpn.UserService.Login:input_type -> vpn.LoginRequest
-	1,  // 6: vpn.UserService.Register:input_type -> vpn.RegisterRequest
-	4,  // 7: vpn.ServerService.CreateServer:input_type -> vpn.CreateServerRequest
-	6,  // 8: vpn.ServerService.ListServers:input_type -> vpn.ListServerRequest
-	8,  // 9: vpn.ServerService.GetServer:input_type -> vpn.GetServerRequest
-	10, // 10: vpn.ConfigService.GenerateConfig:input_type -> vpn.GenerateConfigRequest
-	13, // 11: vpn.ConfigService.GetConfig:input_type -> vpn.GetConfigRequest
-	10, // 12: vpn.ConfigService.RotateKeys:input_type -> vpn.GenerateConfigRequest
-	2,  // 13: vpn.UserService.Login:output_type -> vpn.AuthenticationResponse
-	2,  // 14: vpn.UserService.Register:output_type -> vpn.AuthenticationResponse
-	5,  // 15: vpn.ServerService.CreateServer:output_type -> vpn.CreateServerResponse
-	7,  // 16: vpn.ServerService.ListServers:output_type -> vpn.ListServerResponse
-	9,  // 17: vpn.ServerService.GetServer:output_type -> vpn.GetServerResponse
-	11, // 18: vpn.ConfigService.GenerateConfig:output_type -> vpn.GenerateConfigResponse
-	14, // 19: vpn.ConfigService.GetConfig:output_type -> vpn.GetConfigResponse
-	14, // 20: vpn.ConfigService.RotateKeys:output_type -> vpn.GetConfigResponse
-	13, // [13:21] is the sub-list for method output_type
-	5,  // [5:13] is the sub-list for method input_type
-	5,  // [5:5] is the sub-list for extension type_name
-	5,  // [5:5] is the sub-list for extension extendee
-	0,  // [0:5] is the sub-list for field type_name
+	8,  // 0: vpn.CreateServerResponse.server:type_name -> vpn.Server
+	8,  // 1: vpn.ListServerResponse.servers:type_name -> vpn.Server
+	8,  // 2: vpn.GetServerResponse.server:type_name -> vpn.Server
+	8,  // 3: vpn.UpdateServerResponse.server:type_name -> vpn.Server
+	4,  // 4: vpn.PeerChange.type:type_name -> vpn.PeerChange.Type
+	18, // 5: vpn.WatchPeersResponse.peers:type_name -> vpn.Peer
+	19, // 6: vpn.WatchPeersResponse.changes:type_name -> vpn.PeerChange
+	2,  // 7: vpn.QRCodeOptions.recovery_level:type_name -> vpn.QRRecoveryLevel
+	1,  // 8: vpn.QRCodeOptions.format:type_name -> vpn.QRCodeFormat
+	0,  // 9: vpn.GenerateConfigRequest.format:type_name -> vpn.ConfigFormat
+	23, // 10: vpn.GenerateConfigRequest.qr_code_options:type_name -> vpn.QRCodeOptions
+	27, // 11: vpn.GenerateConfigResponse.config_data:type_name -> vpn.ConfigData
+	26, // 12: vpn.GenerateConfigResponse.export:type_name -> vpn.ConfigExport
+	0,  // 13: vpn.ConfigExport.format:type_name -> vpn.ConfigFormat
+	27, // 14: vpn.GetConfigResponse.config_data:type_name -> vpn.ConfigData
+	3,  // 15: vpn.CreateConfigShareLinkRequest.kind:type_name -> vpn.ShareLinkKind
+	3,  // 16: vpn.CreateConfigShareLinkResponse.kind:type_name -> vpn.ShareLinkKind
+	32, // 17: vpn.ListAuditEventsResponse.events:type_name -> vpn.AuditEvent
+	35, // 18: vpn.ListDeadLetterEventsResponse.events:type_name -> vpn.OutboxEvent
+	40, // 19: vpn.CreateWebhookResponse.webhook:type_name -> vpn.Webhook
+	40, // 20: vpn.ListWebhooksResponse.webhooks:type_name -> vpn.Webhook
+	41, // 21: vpn.ListWebhookDeliveriesResponse.deliveries:type_name -> vpn.WebhookDelivery
+	41, // 22: vpn.TestWebhookResponse.delivery:type_name -> vpn.WebhookDelivery
+	5,  // 23: vpn.UserService.Login:input_type -> vpn.LoginRequest
+	6,  // 24: vpn.UserService.Register:input_type -> vpn.RegisterRequest
+	9,  // 25: vpn.ServerService.CreateServer:input_type -> vpn.CreateServerRequest
+	11, // 26: vpn.ServerService.ListServers:input_type -> vpn.ListServerRequest
+	13, // 27: vpn.ServerService.GetServer:input_type -> vpn.GetServerRequest
+	15, // 28: vpn.ServerService.UpdateServer:input_type -> vpn.UpdateServerRequest
+	17, // 29: vpn.ServerService.WatchPeers:input_type -> vpn.WatchPeersRequest
+	24, // 30: vpn.ConfigService.GenerateConfig:input_type -> vpn.GenerateConfigRequest
+	28, // 31: vpn.ConfigService.GetConfig:input_type -> vpn.GetConfigRequest
+	24, // 32: vpn.ConfigService.RotateKeys:input_type -> vpn.GenerateConfigRequest
+	30, // 33: vpn.ConfigService.CreateConfigShareLink:input_type -> vpn.CreateConfigShareLinkRequest
+	21, // 34: vpn.ConfigService.RevokePeer:input_type -> vpn.RevokePeerRequest
+	33, // 35: vpn.AuditService.ListAuditEvents:input_type -> vpn.ListAuditEventsRequest
+	36, // 36: vpn.EventService.ListDeadLetterEvents:input_type -> vpn.ListDeadLetterEventsRequest
+	38, // 37: vpn.EventService.RequeueDeadLetterEvent:input_type -> vpn.RequeueDeadLetterEventRequest
+	42, // 38: vpn.WebhookService.CreateWebhook:input_type -> vpn.CreateWebhookRequest
+	44, // 39: vpn.WebhookService.ListWebhooks:input_type -> vpn.ListWebhooksRequest
+	46, // 40: vpn.WebhookService.DeleteWebhook:input_type -> vpn.DeleteWebhookRequest
+	48, // 41: vpn.WebhookService.ListWebhookDeliveries:input_type -> vpn.ListWebhookDeliveriesRequest
+	50, // 42: vpn.WebhookService.TestWebhook:input_type -> vpn.TestWebhookRequest
+	7,  // 43: vpn.UserService.Login:output_type -> vpn.AuthenticationResponse
+	7,  // 44: vpn.UserService.Register:output_type -> vpn.AuthenticationResponse
+	10, // 45: vpn.ServerService.CreateServer:output_type -> vpn.CreateServerResponse
+	12, // 46: vpn.ServerService.ListServers:output_type -> vpn.ListServerResponse
+	14, // 47: vpn.ServerService.GetServer:output_type -> vpn.GetServerResponse
+	16, // 48: vpn.ServerService.UpdateServer:output_type -> vpn.UpdateServerResponse
+	20, // 49: vpn.ServerService.WatchPeers:output_type -> vpn.WatchPeersResponse
+	25, // 50: vpn.ConfigService.GenerateConfig:output_type -> vpn.GenerateConfigResponse
+	29, // 51: vpn.ConfigService.GetConfig:output_type -> vpn.GetConfigResponse
+	29, // 52: vpn.ConfigService.RotateKeys:output_type -> vpn.GetConfigResponse
+	31, // 53: vpn.ConfigService.CreateConfigShareLink:output_type -> vpn.CreateConfigShareLinkResponse
+	22, // 54: vpn.ConfigService.RevokePeer:output_type -> vpn.RevokePeerResponse
+	34, // 55: vpn.AuditService.ListAuditEvents:output_type -> vpn.ListAuditEventsResponse
+	37, // 56: vpn.EventService.ListDeadLetterEvents:output_type -> vpn.ListDeadLetterEventsResponse
+	39, // 57: vpn.EventService.RequeueDeadLetterEvent:output_type -> vpn.RequeueDeadLetterEventResponse
+	43, // 58: vpn.WebhookService.CreateWebhook:output_type -> vpn.CreateWebhookResponse
+	45, // 59: vpn.WebhookService.ListWebhooks:output_type -> vpn.ListWebhooksResponse
+	47, // 60: vpn.WebhookService.DeleteWebhook:output_type -> vpn.DeleteWebhookResponse
+	49, // 61: vpn.WebhookService.ListWebhookDeliveries:output_type -> vpn.ListWebhookDeliveriesResponse
+	51, // 62: vpn.WebhookService.TestWebhook:output_type -> vpn.TestWebhookResponse
+	43, // [43:63] is the sub-list for method output_type
+	23, // [23:43] is the sub-list for method input_type
+	23, // [23:23] is the sub-list for extension type_name
+	23, // [23:23] is the sub-list for extension extendee
+	0,  // [0:23] is the sub-list for field type_name
 }
 
 func init() { file_vpn_proto_init() }
@@ -1042,13 +3808,14 @@ func file_vpn_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_vpn_proto_rawDesc), len(file_vpn_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   15,
+			NumEnums:      5,
+			NumMessages:   47,
 			NumExtensions: 0,
-			NumServices:   3,
+			NumServices:   6,
 		},
 		GoTypes:           file_vpn_proto_goTypes,
 		DependencyIndexes: file_vpn_proto_depIdxs,
+		EnumInfos:         file_vpn_proto_enumTypes,
 		MessageInfos:      file_vpn_proto_msgTypes,
 	}.Build()
 	File_vpn_proto = out.File